@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/entity/diff"
+	opevent "github.com/shanluzhineng/fwpkg/opevents/pkg"
+	"go.uber.org/zap/zapcore"
+)
+
+// OPAction of the audit event logs
+const (
+	ActionCreate      = "create"
+	ActionUpdate      = "update"
+	ActionPatch       = "patch"
+	ActionDelete      = "delete"
+	ActionDeleteList  = "deleteList"
+	ActionRestore     = "restore"
+	ActionBatchCreate = "batchCreate"
+	ActionBatchUpdate = "batchUpdate"
+	ActionImport      = "import"
+)
+
+// key of iris ctx.Values and gin context,the value is *Record
+const RecordContextKey = "fwpkg.audit"
+
+// the changes of an entity
+type EntityChange struct {
+	Id      string              `json:"id"`
+	Changes []*diff.FieldChange `json:"changes,omitempty"`
+}
+
+// audit record of a mutating request,it is the TaskInfo of the written OpEventLog.
+// the middleware fills the request info,the handler adds the changed entities
+type Record struct {
+	Action        string          `json:"action"`
+	EntityType    string          `json:"entityType,omitempty"`
+	EntityList    []*EntityChange `json:"entityList,omitempty"`
+	PrincipalId   string          `json:"principalId,omitempty"`
+	PrincipalName string          `json:"principalName,omitempty"`
+	TenantId      string          `json:"tenantId,omitempty"`
+	Method        string          `json:"method"`
+	Path          string          `json:"path"`
+	Status        int             `json:"status"`
+	IPAddress     string          `json:"ipAddress,omitempty"`
+	CorrelationId string          `json:"correlationId,omitempty"`
+
+	// the record is not written,e.g. nothing is changed by the request
+	Skipped bool `json:"-"`
+}
+
+// add an entity and its field level changes
+func (r *Record) AddEntity(id string, changeList []*diff.FieldChange) {
+	r.EntityList = append(r.EntityList, &EntityChange{
+		Id:      id,
+		Changes: changeList,
+	})
+}
+
+func (r *Record) setPrincipal(principal *fwauth.Principal) {
+	if principal == nil {
+		return
+	}
+	r.PrincipalId = principal.Id
+	r.PrincipalName = principal.Name
+	if len(r.TenantId) <= 0 {
+		r.TenantId = principal.TenantId
+	}
+}
+
+func (r *Record) message() string {
+	idList := make([]string, 0, len(r.EntityList))
+	for _, eachEntity := range r.EntityList {
+		idList = append(idList, eachEntity.Id)
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s", r.Action, r.EntityType, strings.Join(idList, ",")))
+}
+
+type AuditOptions struct {
+	// OPAction of the event log,it is derived from the method if not set
+	Action     string
+	EntityType string
+	// the path param of entity id,it is added to the record if the handler adds no entity,
+	// DefaultIdParam is used if not set
+	IdParam string
+	// the service that saves the event logs,the composed IOpEventLogService of ioc container is used if not set
+	Service opevent.IOpEventLogService
+}
+
+const DefaultIdParam = "id"
+
+type AuditOption func(*AuditOptions)
+
+func WithAction(action string) AuditOption {
+	return func(o *AuditOptions) {
+		o.Action = action
+	}
+}
+
+func WithEntityType(entityType string) AuditOption {
+	return func(o *AuditOptions) {
+		o.EntityType = entityType
+	}
+}
+
+func WithIdParam(idParam string) AuditOption {
+	return func(o *AuditOptions) {
+		o.IdParam = idParam
+	}
+}
+
+func WithService(service opevent.IOpEventLogService) AuditOption {
+	return func(o *AuditOptions) {
+		o.Service = service
+	}
+}
+
+func newAuditOptions(opts []AuditOption) *AuditOptions {
+	options := &AuditOptions{}
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+	if len(options.IdParam) <= 0 {
+		options.IdParam = DefaultIdParam
+	}
+	return options
+}
+
+// POST is create,PUT and PATCH are update,DELETE is delete
+func actionOfMethod(method string) string {
+	switch method {
+	case http.MethodPost:
+		return ActionCreate
+	case http.MethodPut, http.MethodPatch:
+		return ActionUpdate
+	case http.MethodDelete:
+		return ActionDelete
+	}
+	return strings.ToLower(method)
+}
+
+// save a record with the service of opts,it is used for the changes that are written after the request,
+// e.g. the items of an async import
+func WriteRecord(ctx context.Context, record *Record, opts ...AuditOption) error {
+	return Write(ctx, newAuditOptions(opts).Service, record)
+}
+
+// save the record as an OpEventLog,service is the composed IOpEventLogService of ioc container if it is nil
+func Write(ctx context.Context, service opevent.IOpEventLogService, record *Record) error {
+	if service == nil {
+		service = opevent.GetOpEventLogService(ctx)
+	}
+	eventLog := opevent.NewOpEventLog(
+		opevent.WithOpAction(record.Action),
+		opevent.WithCorrelationId(record.CorrelationId),
+		opevent.WithContext(ctx))
+	eventLog.TenantId = record.TenantId
+	eventLog.CreatorId = record.PrincipalId
+	eventLog.IPAddress = record.IPAddress
+	eventLog.EventMessage = record.message()
+	eventLog.TaskInfo = record
+	eventLog.WithLogLvel(zapcore.InfoLevel).WithApiServerSource()
+	return service.Save(eventLog)
+}
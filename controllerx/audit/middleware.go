@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/system/correlation"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+// handler that writes an audit record of the request after it succeeds,the failed requests are not recorded.
+// the handler gets the record by Get to add the changed entities and their changes,
+// the path param of id is recorded if no entity is added.
+// it should run after the authentication so the principal is recorded
+func New(opts ...AuditOption) iris.Handler {
+	options := newAuditOptions(opts)
+	return func(ctx iris.Context) {
+		record := &Record{
+			Action:        options.Action,
+			EntityType:    options.EntityType,
+			Method:        ctx.Method(),
+			Path:          ctx.Path(),
+			IPAddress:     ctx.RemoteAddr(),
+			CorrelationId: correlation.RequestIdFromContext(ctx.Request().Context()),
+		}
+		if len(record.Action) <= 0 {
+			record.Action = actionOfMethod(record.Method)
+		}
+		ctx.Values().Set(RecordContextKey, record)
+		ctx.Next()
+
+		record.Status = ctx.GetStatusCode()
+		if record.Skipped || record.Status >= http.StatusBadRequest {
+			return
+		}
+		record.setPrincipal(fwauth.GetIrisPrincipal(ctx))
+		if id := ctx.Params().Get(options.IdParam); len(record.EntityList) <= 0 && len(id) > 0 {
+			record.AddEntity(id, nil)
+		}
+		write(ctx.Request(), options, record)
+	}
+}
+
+// audit record of current request,nil if the middleware is not used
+func Get(ctx iris.Context) *Record {
+	record, _ := ctx.Values().Get(RecordContextKey).(*Record)
+	return record
+}
+
+// gin version of New
+func NewGin(opts ...AuditOption) gin.HandlerFunc {
+	options := newAuditOptions(opts)
+	return func(c *gin.Context) {
+		record := &Record{
+			Action:        options.Action,
+			EntityType:    options.EntityType,
+			Method:        c.Request.Method,
+			Path:          c.Request.URL.Path,
+			IPAddress:     c.ClientIP(),
+			CorrelationId: correlation.RequestIdFromContext(c.Request.Context()),
+		}
+		if len(record.Action) <= 0 {
+			record.Action = actionOfMethod(record.Method)
+		}
+		c.Set(RecordContextKey, record)
+		c.Next()
+
+		record.Status = c.Writer.Status()
+		if record.Skipped || record.Status >= http.StatusBadRequest {
+			return
+		}
+		record.setPrincipal(fwauth.GetGinPrincipal(c))
+		if id := c.Param(options.IdParam); len(record.EntityList) <= 0 && len(id) > 0 {
+			record.AddEntity(id, nil)
+		}
+		write(c.Request, options, record)
+	}
+}
+
+// audit record of current gin request,nil if the middleware is not used
+func GetGin(c *gin.Context) *Record {
+	value, ok := c.Get(RecordContextKey)
+	if !ok {
+		return nil
+	}
+	record, _ := value.(*Record)
+	return record
+}
+
+// the response is written,a failure of the event log service is only logged
+func write(request *http.Request, options *AuditOptions, record *Record) {
+	if err := Write(request.Context(), options.Service, record); err != nil {
+		log.FromContext(request.Context()).Warn(fmt.Sprintf("audit write fail,action:%s,path:%s,err:%s", record.Action, record.Path, err.Error()))
+	}
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/entity/diff"
+	opevent "github.com/shanluzhineng/fwpkg/opevents/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+type testOpEventLogService struct {
+	opevent.IOpEventLogService
+	eventLogList []*opevent.OpEventLog
+}
+
+func (s *testOpEventLogService) Save(item *opevent.OpEventLog) error {
+	s.eventLogList = append(s.eventLogList, item)
+	return nil
+}
+
+func TestAudit(t *testing.T) {
+	service := &testOpEventLogService{}
+	app := iris.New()
+	app.Use(func(ctx iris.Context) {
+		fwauth.SetIrisPrincipal(ctx, &fwauth.Principal{Id: "u1", Name: "user", TenantId: "t1"})
+		ctx.Next()
+	})
+	app.Put("/orders/{id}", New(WithEntityType("Order"), WithService(service)), func(ctx iris.Context) {
+		Get(ctx).AddEntity(ctx.Params().Get("id"), []*diff.FieldChange{{Field: "name", Before: "a", After: "b"}})
+	})
+	app.Delete("/orders/{id}", New(WithService(service)), func(ctx iris.Context) {
+		ctx.StatusCode(http.StatusNotFound)
+	})
+	app.Post("/orders/{id}/close", New(WithAction("close"), WithService(service)), func(ctx iris.Context) {})
+	assert.Nil(t, app.Build())
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/orders/o1", nil))
+	assert.Len(t, service.eventLogList, 1)
+	eventLog := service.eventLogList[0]
+	assert.Equal(t, ActionUpdate, eventLog.OPAction)
+	assert.Equal(t, "t1", eventLog.TenantId)
+	assert.Equal(t, "u1", eventLog.CreatorId)
+	assert.Equal(t, "update Order o1", eventLog.EventMessage)
+	record := eventLog.TaskInfo.(*Record)
+	assert.Equal(t, http.StatusOK, record.Status)
+	assert.Equal(t, "user", record.PrincipalName)
+	assert.Len(t, record.EntityList, 1)
+	assert.Len(t, record.EntityList[0].Changes, 1)
+
+	// failed request is not recorded
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/orders/o1", nil))
+	assert.Len(t, service.eventLogList, 1)
+
+	// the id param is recorded for custom route
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders/o2/close", nil))
+	assert.Len(t, service.eventLogList, 2)
+	assert.Equal(t, "close", service.eventLogList[1].OPAction)
+	assert.Equal(t, "o2", service.eventLogList[1].TaskInfo.(*Record).EntityList[0].Id)
+}
+
+func TestGinAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := &testOpEventLogService{}
+	engine := gin.New()
+	engine.DELETE("/orders", NewGin(WithAction(ActionDeleteList), WithService(service)), func(c *gin.Context) {
+		record := GetGin(c)
+		record.Skipped = len(c.Query("ids")) <= 0
+		if !record.Skipped {
+			record.AddEntity(c.Query("ids"), nil)
+		}
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/orders", nil))
+	assert.Empty(t, service.eventLogList)
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/orders?ids=o1", nil))
+	assert.Len(t, service.eventLogList, 1)
+	assert.Equal(t, ActionDeleteList, service.eventLogList[0].OPAction)
+	assert.Equal(t, "o1", service.eventLogList[0].TaskInfo.(*Record).EntityList[0].Id)
+}
@@ -0,0 +1,161 @@
+package authz
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// cache time of policy if EnforcerWithCacheTTL is not used
+const DefaultPolicyCacheTTL = 5 * time.Minute
+
+// the user who asks for a permission
+type Subject struct {
+	UserId   string
+	TenantId string
+	RoleList []string
+}
+
+type Decision struct {
+	Allowed bool
+	// the permission is granted only for the items matched by one of the conditions,
+	// it is empty if the permission is granted without condition
+	ConditionList []bson.M
+}
+
+// filter of the items that the permission is granted for,nil if there is no condition
+func (d *Decision) Filter() bson.M {
+	switch len(d.ConditionList) {
+	case 0:
+		return nil
+	case 1:
+		return d.ConditionList[0]
+	}
+	conditionList := make(bson.A, 0, len(d.ConditionList))
+	for _, eachCondition := range d.ConditionList {
+		conditionList = append(conditionList, eachCondition)
+	}
+	return bson.M{"$or": conditionList}
+}
+
+type IAuthorizer interface {
+	Authorize(subject *Subject, permission string) (*Decision, error)
+}
+
+type Enforcer struct {
+	store    IPolicyStore
+	cacheTTL time.Duration
+
+	lock     sync.RWMutex
+	roleMap  map[string]*compiledRole
+	loadTime time.Time
+}
+
+var _ IAuthorizer = (*Enforcer)(nil)
+
+type EnforcerOption func(*Enforcer)
+
+// the policy is loaded again after ttl,it is never reloaded if ttl is not positive
+func EnforcerWithCacheTTL(ttl time.Duration) EnforcerOption {
+	return func(e *Enforcer) {
+		e.cacheTTL = ttl
+	}
+}
+
+// rbac authorizer,roles inherit the permissions of their parent roles
+func NewEnforcer(store IPolicyStore, opts ...EnforcerOption) *Enforcer {
+	e := &Enforcer{
+		store:    store,
+		cacheTTL: DefaultPolicyCacheTTL,
+	}
+	for _, eachOpt := range opts {
+		eachOpt(e)
+	}
+	return e
+}
+
+// the permission is allowed if any role of subject grants it,
+// a permission granted without condition overrides the conditional grants
+func (e *Enforcer) Authorize(subject *Subject, permission string) (*Decision, error) {
+	roleMap, err := e.getRoleMap()
+	if err != nil {
+		return nil, err
+	}
+	decision := &Decision{}
+	if subject == nil {
+		return decision, nil
+	}
+	for _, eachRoleName := range subject.RoleList {
+		role, ok := roleMap[eachRoleName]
+		if !ok {
+			continue
+		}
+		for _, eachPermission := range role.permissionList {
+			if MatchPermission(eachPermission, permission) {
+				return &Decision{Allowed: true}, nil
+			}
+		}
+		for _, eachGrant := range role.grantList {
+			if !MatchPermission(eachGrant.Permission, permission) {
+				continue
+			}
+			decision.Allowed = true
+			if len(eachGrant.Where) <= 0 {
+				return &Decision{Allowed: true}, nil
+			}
+			decision.ConditionList = append(decision.ConditionList, resolveWhere(eachGrant.Where, subject))
+		}
+	}
+	return decision, nil
+}
+
+// drop the cached policy,it is loaded again by the next Authorize
+func (e *Enforcer) Invalidate() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.roleMap = nil
+}
+
+func (e *Enforcer) getRoleMap() (map[string]*compiledRole, error) {
+	e.lock.RLock()
+	roleMap := e.roleMap
+	expired := e.cacheTTL > 0 && time.Since(e.loadTime) > e.cacheTTL
+	e.lock.RUnlock()
+	if roleMap != nil && !expired {
+		return roleMap, nil
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.roleMap != nil && (e.cacheTTL <= 0 || time.Since(e.loadTime) <= e.cacheTTL) {
+		return e.roleMap, nil
+	}
+	policy, err := e.store.LoadPolicy()
+	if err != nil {
+		if e.roleMap != nil {
+			// keep the stale policy if the store is unavailable,try again after ttl
+			e.loadTime = time.Now()
+			return e.roleMap, nil
+		}
+		return nil, err
+	}
+	e.roleMap = compilePolicy(policy)
+	e.loadTime = time.Now()
+	return e.roleMap, nil
+}
+
+// replace the values of subject in conditions
+func resolveWhere(where map[string]interface{}, subject *Subject) bson.M {
+	result := bson.M{}
+	for key, value := range where {
+		switch value {
+		case WhereValueUserId:
+			value = subject.UserId
+		case WhereValueTenantId:
+			value = subject.TenantId
+		}
+		result[key] = value
+	}
+	return result
+}
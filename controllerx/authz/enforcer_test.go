@@ -0,0 +1,60 @@
+package authz
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type countPolicyStore struct {
+	policy *Policy
+	count  int
+	err    error
+}
+
+func (s *countPolicyStore) LoadPolicy() (*Policy, error) {
+	s.count++
+	return s.policy, s.err
+}
+
+func TestEnforcer(t *testing.T) {
+	store := &countPolicyStore{policy: &Policy{RoleList: []Role{
+		{Name: "viewer", PermissionList: []string{"order:read"}},
+		{Name: "editor", InheritList: []string{"viewer", "editor"}, GrantList: []Grant{
+			{Permission: "order:update", Where: map[string]interface{}{"creatorId": WhereValueUserId}},
+			{Permission: "order:*", Where: map[string]interface{}{"tenantId": WhereValueTenantId}},
+		}},
+		{Name: "admin", PermissionList: []string{"*"}},
+	}}}
+	e := NewEnforcer(store)
+
+	decision, err := e.Authorize(&Subject{RoleList: []string{"editor"}}, "order:read")
+	assert.Nil(t, err)
+	assert.Equal(t, &Decision{Allowed: true}, decision)
+
+	decision, _ = e.Authorize(&Subject{UserId: "u1", TenantId: "t1", RoleList: []string{"editor"}}, "order:update")
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, bson.M{"$or": bson.A{bson.M{"creatorId": "u1"}, bson.M{"tenantId": "t1"}}}, decision.Filter())
+
+	decision, _ = e.Authorize(&Subject{RoleList: []string{"viewer", "unknown"}}, "order:delete")
+	assert.False(t, decision.Allowed)
+	decision, _ = e.Authorize(&Subject{RoleList: []string{"admin"}}, "user:delete")
+	assert.True(t, decision.Allowed)
+	assert.Nil(t, decision.Filter())
+	assert.Equal(t, 1, store.count)
+
+	// the policy is loaded again after invalidate
+	store.err = errors.New("unavailable")
+	e.Invalidate()
+	_, err = e.Authorize(nil, "order:read")
+	assert.NotNil(t, err)
+}
+
+func TestMatchPermission(t *testing.T) {
+	assert.True(t, MatchPermission("order:*", "order:read"))
+	assert.False(t, MatchPermission("order:*", "orderItem:read"))
+	assert.False(t, MatchPermission("order:read", "order:delete"))
+	assert.Equal(t, "order:read", Permission("order", PermissionActionRead))
+}
@@ -0,0 +1,98 @@
+package authz
+
+import (
+	"strings"
+)
+
+const (
+	// * matches all permissions,order:* matches all actions of order
+	PermissionWildcard = "*"
+	// separator of resource and action in permission,e.g. order:read
+	PermissionSeparator = ":"
+
+	// values of Grant.Where that are replaced with the current subject
+	WhereValueUserId   = "$user.id"
+	WhereValueTenantId = "$user.tenantId"
+)
+
+// actions of entity resource
+const (
+	PermissionActionRead   = "read"
+	PermissionActionCreate = "create"
+	PermissionActionUpdate = "update"
+	PermissionActionDelete = "delete"
+	PermissionActionExport = "export"
+	PermissionActionImport = "import"
+)
+
+// permission of the action on resource,e.g. order:read
+func Permission(resource string, action string) string {
+	return resource + PermissionSeparator + action
+}
+
+// a permission granted only for the items matched by the conditions
+type Grant struct {
+	Permission string `mapstructure:"permission" json:"permission" bson:"permission"`
+	// key is the field name of item,value is the expected value,
+	// e.g. {"creatorId":"$user.id"} means the owner of item is current user
+	Where map[string]interface{} `mapstructure:"where" json:"where" bson:"where"`
+}
+
+type Role struct {
+	Name string `mapstructure:"name" json:"name" bson:"name"`
+	// roles whose permissions are inherited
+	InheritList []string `mapstructure:"inherits" json:"inherits" bson:"inherits"`
+	// permissions granted without condition
+	PermissionList []string `mapstructure:"permissions" json:"permissions" bson:"permissions"`
+	GrantList      []Grant  `mapstructure:"grants" json:"grants" bson:"grants"`
+}
+
+type Policy struct {
+	RoleList []Role `mapstructure:"roles" json:"roles" bson:"roles"`
+}
+
+// granted matches required,granted can be * or end with :*
+func MatchPermission(granted string, required string) bool {
+	if granted == PermissionWildcard || granted == required {
+		return true
+	}
+	wildcardSuffix := PermissionSeparator + PermissionWildcard
+	if strings.HasSuffix(granted, wildcardSuffix) {
+		return strings.HasPrefix(required, strings.TrimSuffix(granted, PermissionWildcard))
+	}
+	return false
+}
+
+// effective permissions of a role,inherited roles are flattened
+type compiledRole struct {
+	permissionList []string
+	grantList      []Grant
+}
+
+// flatten the inherited roles,unknown roles and cycles are ignored
+func compilePolicy(policy *Policy) map[string]*compiledRole {
+	roleMap := make(map[string]*Role, len(policy.RoleList))
+	for i := range policy.RoleList {
+		roleMap[policy.RoleList[i].Name] = &policy.RoleList[i]
+	}
+	result := make(map[string]*compiledRole, len(roleMap))
+	for name := range roleMap {
+		compiled := &compiledRole{}
+		collectRole(roleMap, name, make(map[string]bool), compiled)
+		result[name] = compiled
+	}
+	return result
+}
+
+func collectRole(roleMap map[string]*Role, name string, visited map[string]bool, compiled *compiledRole) {
+	role, ok := roleMap[name]
+	if !ok || visited[name] {
+		return
+	}
+	visited[name] = true
+	compiled.permissionList = append(compiled.permissionList, role.PermissionList...)
+	compiled.grantList = append(compiled.grantList, role.GrantList...)
+	for _, eachInherit := range role.InheritList {
+		collectRole(roleMap, eachInherit, visited, compiled)
+	}
+}
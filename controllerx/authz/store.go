@@ -0,0 +1,96 @@
+package authz
+
+import (
+	"fmt"
+
+	"github.com/shanluzhineng/configurationx"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+)
+
+// configuration key of policy,see NewConfigPolicyStore
+const ConfigurationKey = "authz"
+
+// source of policy,the policy is loaded again when the cache of Enforcer expires
+type IPolicyStore interface {
+	LoadPolicy() (*Policy, error)
+}
+
+// #region memory store
+
+type memoryPolicyStore struct {
+	policy *Policy
+}
+
+func NewMemoryPolicyStore(policy *Policy) IPolicyStore {
+	return &memoryPolicyStore{
+		policy: policy,
+	}
+}
+
+func (s *memoryPolicyStore) LoadPolicy() (*Policy, error) {
+	if s.policy == nil {
+		return &Policy{}, nil
+	}
+	return s.policy, nil
+}
+
+// #endregion
+
+// #region configuration store
+
+type configPolicyStore struct {
+	key string
+}
+
+// policy from configurationx,ConfigurationKey is used if key is empty,e.g.
+//
+//	authz:
+//	  roles:
+//	    - name: viewer
+//	      permissions: ["order:read"]
+//	    - name: editor
+//	      inherits: ["viewer"]
+//	      grants:
+//	        - permission: order:update
+//	          where: {creatorId: $user.id}
+func NewConfigPolicyStore(key string) IPolicyStore {
+	if len(key) <= 0 {
+		key = ConfigurationKey
+	}
+	return &configPolicyStore{
+		key: key,
+	}
+}
+
+func (s *configPolicyStore) LoadPolicy() (*Policy, error) {
+	policy := &Policy{}
+	if ok := configurationx.GetInstance().UnmarshalPropertiesTo(s.key, policy); !ok {
+		return nil, fmt.Errorf("authz policy parser fail,key:%s", s.key)
+	}
+	return policy, nil
+}
+
+// #endregion
+
+// #region mongodb store
+
+type mongoPolicyStore struct {
+	repository mongodbr.IRepository
+}
+
+// each document of the collection is a Role
+func NewMongoPolicyStore(repository mongodbr.IRepository) IPolicyStore {
+	return &mongoPolicyStore{
+		repository: repository,
+	}
+}
+
+func (s *mongoPolicyStore) LoadPolicy() (*Policy, error) {
+	policy := &Policy{}
+	if err := s.repository.FindAll().All(&policy.RoleList); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// #endregion
@@ -1,23 +1,90 @@
 package controllerx
 
-import "github.com/kataras/iris/v12"
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/audit"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/controllerx/idempotency"
+	"github.com/shanluzhineng/fwpkg/controllerx/openapi"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsecache"
+	"github.com/shanluzhineng/fwpkg/entity/changefeed"
+)
 
 type BaseControllerOptions struct {
 	AuthenticatedDisabled bool
+	// authenticators of the routes,fwauth.GetDefaultAuthenticators is used if not set
+	AuthenticatorList []fwauth.Authenticator
 }
 
 type BaseEntityControllerOptions struct {
-	AllDisabled        bool
-	ListDisabled       bool
-	GetByIdDisabled    bool
-	CreateDisabled     bool
-	UpdateDisabled     bool
-	DeleteDisabled     bool
-	DeleteListDisabled bool
+	AllDisabled         bool
+	ListDisabled        bool
+	GetByIdDisabled     bool
+	CreateDisabled      bool
+	UpdateDisabled      bool
+	PatchDisabled       bool
+	DeleteDisabled      bool
+	DeleteListDisabled  bool
+	RestoreDisabled     bool
+	BatchCreateDisabled bool
+	BatchUpdateDisabled bool
+
+	// POST /export,GET /export/{id},GET /export/{id}/download are registered if enabled
+	ExportEnabled bool
+	// POST /import,GET /import/{id},GET /import/{id}/report are registered if enabled
+	ImportEnabled bool
+	// max size of uploaded import file,DefaultImportMaxFileSize is used if not set
+	ImportMaxFileSize int64
+
+	// max item count of POST /batch and PATCH /batch,DefaultBatchMaxSize is used if not set
+	BatchMaxSize int
+
+	// honour the Idempotency-Key header of POST / and POST /batch if enabled
+	IdempotencyEnabled    bool
+	IdempotencyOptionList []idempotency.IdempotencyOption
+
+	// cache the responses of GET /all,GET / and GET /{id} if enabled,the entries are evicted by the changes of routes,
+	// responsecache.GetDefaultCache is used if ResponseCache is not set
+	ResponseCacheEnabled    bool
+	ResponseCache           *responsecache.Cache
+	ResponseCacheOptionList []responsecache.CacheOption
+
+	// write an audit record of the create,update,patch,delete,restore,batch and import routes if enabled,
+	// the record has the field level changes of the items,see audit.New.
+	// the items of import are written after the request,each written batch is saved as a new record
+	AuditEnabled    bool
+	AuditOptionList []audit.AuditOption
+
+	// GET /stream(server-sent events) and GET /stream/ws(websocket) push the changes of items if enabled,
+	// the changes are from mongodb change streams,or from the writes of controller if change streams are not available.
+	// changefeed.GetDefaultFeed is used if StreamFeed is not set,DefaultStreamHeartbeat is used if StreamHeartbeat is not set
+	StreamEnabled   bool
+	StreamFeed      *changefeed.Feed
+	StreamHeartbeat time.Duration
+
+	// authorize DELETE /{id}?hard=true,only admin is allowed if not set
+	HardDeleteAuthorizeFunc func(ctx iris.Context) bool
+	// permissions of actions are resource:read,resource:create,resource:update,resource:delete,
+	// resource:export and resource:import,no permission is checked if it is empty
+	Resource string
+	// permission of action,it overrides the permission from Resource,empty value disables the check of action
+	PermissionMap map[openapi.EntityAction]string
+
+	// authorize the request with HeaderTenantBypass to access the items of all tenants,
+	// only super admin is allowed if not set
+	TenantBypassAuthorizeFunc func(ctx iris.Context) bool
 
 	ListFilterFunc                   func(entityType interface{}, filter map[string]interface{}, ctx iris.Context)
 	FilterCurrentUserForListDisabled bool
 
+	// the funcs of GinEntityController,they have the same meaning as the iris ones
+	GinHardDeleteAuthorizeFunc   func(c *gin.Context) bool
+	GinTenantBypassAuthorizeFunc func(c *gin.Context) bool
+	GinListFilterFunc            func(entityType interface{}, filter map[string]interface{}, c *gin.Context)
+
 	BaseControllerOptions
 }
 
@@ -30,8 +97,12 @@ func BaseEntityControllerWithAllEndpointDisabled(v bool) BaseEntityControllerOpt
 		rro.GetByIdDisabled = v
 		rro.CreateDisabled = v
 		rro.UpdateDisabled = v
+		rro.PatchDisabled = v
 		rro.DeleteDisabled = v
 		rro.DeleteListDisabled = v
+		rro.RestoreDisabled = v
+		rro.BatchCreateDisabled = v
+		rro.BatchUpdateDisabled = v
 	}
 }
 
@@ -65,6 +136,12 @@ func BaseEntityControllerWithUpdateDisabled(v bool) BaseEntityControllerOption {
 	}
 }
 
+func BaseEntityControllerWithPatchDisabled(v bool) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.PatchDisabled = v
+	}
+}
+
 func BaseEntityControllerWithDeleteDisabled(v bool) BaseEntityControllerOption {
 	return func(rro *BaseEntityControllerOptions) {
 		rro.DeleteDisabled = v
@@ -77,8 +154,131 @@ func BaseEntityControllerWithDeleteListDisabled(v bool) BaseEntityControllerOpti
 	}
 }
 
+func BaseEntityControllerWithAuthenticators(authenticatorList ...fwauth.Authenticator) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.AuthenticatorList = authenticatorList
+	}
+}
+
 func BaseEntityControllerWithAuthenticatedDisabled(v bool) BaseEntityControllerOption {
 	return func(rro *BaseEntityControllerOptions) {
 		rro.AuthenticatedDisabled = v
 	}
 }
+
+func BaseEntityControllerWithRestoreDisabled(v bool) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.RestoreDisabled = v
+	}
+}
+
+func BaseEntityControllerWithHardDeleteAuthorizeFunc(f func(ctx iris.Context) bool) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.HardDeleteAuthorizeFunc = f
+	}
+}
+
+func BaseEntityControllerWithGinHardDeleteAuthorizeFunc(f func(c *gin.Context) bool) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.GinHardDeleteAuthorizeFunc = f
+	}
+}
+
+func BaseEntityControllerWithResource(resource string) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.Resource = resource
+	}
+}
+
+func BaseEntityControllerWithPermission(action openapi.EntityAction, permission string) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		if rro.PermissionMap == nil {
+			rro.PermissionMap = make(map[openapi.EntityAction]string)
+		}
+		rro.PermissionMap[action] = permission
+	}
+}
+
+func BaseEntityControllerWithTenantBypassAuthorizeFunc(f func(ctx iris.Context) bool) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.TenantBypassAuthorizeFunc = f
+	}
+}
+
+func BaseEntityControllerWithGinTenantBypassAuthorizeFunc(f func(c *gin.Context) bool) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.GinTenantBypassAuthorizeFunc = f
+	}
+}
+
+func BaseEntityControllerWithGinListFilterFunc(f func(entityType interface{}, filter map[string]interface{}, c *gin.Context)) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.GinListFilterFunc = f
+	}
+}
+
+func BaseEntityControllerWithBatchCreateDisabled(v bool) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.BatchCreateDisabled = v
+	}
+}
+
+func BaseEntityControllerWithBatchUpdateDisabled(v bool) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.BatchUpdateDisabled = v
+	}
+}
+
+func BaseEntityControllerWithBatchMaxSize(v int) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.BatchMaxSize = v
+	}
+}
+
+func BaseEntityControllerWithExportEnabled(v bool) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.ExportEnabled = v
+	}
+}
+
+func BaseEntityControllerWithImportEnabled(v bool) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.ImportEnabled = v
+	}
+}
+
+func BaseEntityControllerWithImportMaxFileSize(v int64) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.ImportMaxFileSize = v
+	}
+}
+
+func BaseEntityControllerWithIdempotency(opts ...idempotency.IdempotencyOption) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.IdempotencyEnabled = true
+		rro.IdempotencyOptionList = opts
+	}
+}
+
+func BaseEntityControllerWithResponseCache(cache *responsecache.Cache, opts ...responsecache.CacheOption) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.ResponseCacheEnabled = true
+		rro.ResponseCache = cache
+		rro.ResponseCacheOptionList = opts
+	}
+}
+
+func BaseEntityControllerWithAudit(opts ...audit.AuditOption) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.AuditEnabled = true
+		rro.AuditOptionList = opts
+	}
+}
+
+func BaseEntityControllerWithStream(feed *changefeed.Feed, heartbeat time.Duration) BaseEntityControllerOption {
+	return func(rro *BaseEntityControllerOptions) {
+		rro.StreamEnabled = true
+		rro.StreamFeed = feed
+		rro.StreamHeartbeat = heartbeat
+	}
+}
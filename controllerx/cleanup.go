@@ -0,0 +1,34 @@
+package controllerx
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shanluzhineng/fwpkg/app"
+)
+
+// the cleanups of export and import stores,one cleanup runs for each store
+// and all of them are stopped when the application is shutdown
+var (
+	_cleanupCtx, _cleanupCancel = context.WithCancel(context.Background())
+	_cleanupStores              sync.Map
+)
+
+func init() {
+	app.RegisterOneShutdown(cleanupShutdown)
+}
+
+// run the cleanup in background with the interval,
+// it is not started again if the cleanup of store is running
+func startCleanup(store interface{}, runCleanup func(ctx context.Context, interval time.Duration), interval time.Duration) {
+	if _, loaded := _cleanupStores.LoadOrStore(store, struct{}{}); loaded {
+		return
+	}
+	go runCleanup(_cleanupCtx, interval)
+}
+
+// stop the running cleanups
+func cleanupShutdown() app.IShutdownAction {
+	return app.NewShutdownAction(_cleanupCancel)
+}
@@ -0,0 +1,36 @@
+package controllerx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartCleanupOncePerStore(t *testing.T) {
+	started := make(chan context.Context, 3)
+	runCleanup := func(ctx context.Context, interval time.Duration) {
+		started <- ctx
+	}
+	store := entity.NewMemoryExportStore(time.Minute)
+	startCleanup(store, runCleanup, time.Minute)
+	startCleanup(store, runCleanup, time.Minute)
+	startCleanup(entity.NewMemoryExportStore(time.Minute), runCleanup, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case ctx := <-started:
+			// the cleanups are stopped by the shutdown of application
+			assert.Equal(t, _cleanupCtx, ctx)
+		case <-time.After(time.Second):
+			t.Fatal("cleanup is not started")
+		}
+	}
+	select {
+	case <-started:
+		t.Fatal("cleanup of a store is started twice")
+	case <-time.After(time.Millisecond * 100):
+	}
+}
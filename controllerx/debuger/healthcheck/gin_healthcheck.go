@@ -0,0 +1,33 @@
+package healthcheck
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/app"
+	"github.com/shanluzhineng/fwpkg/controllerx"
+	"github.com/shanluzhineng/fwpkg/system/health"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+// the same route as healthcheckStartup for GinApplication
+func healthcheckGinStartup(webApp *controllerx.GinApplication) app.IStartupAction {
+	return app.NewStartupAction(func() {
+		if app.HostApplication.SystemConfig().App.IsRunInCli {
+			return
+		}
+		log.Logger.Debug("正在构建gin healthcheck路径组件,api/health/check...")
+		registerDiskSpaceChecker()
+		webApp.GET("/api/health/check", ginHealthcheck)
+		webApp.GET("/api/health/live", ginProbe(health.TagLiveness))
+		webApp.GET("/api/health/ready", ginProbe(health.TagReadiness))
+	})
+}
+
+func ginHealthcheck(c *gin.Context) {
+	c.JSON(newHealthcheckResponse(c.Request, ""))
+}
+
+func ginProbe(tag string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(newProbeResponse(c.Request.Context(), tag))
+	}
+}
@@ -1,27 +1,51 @@
 package healthcheck
 
 import (
+	"context"
+	"net/http"
+	"os"
 	"strings"
 
 	"github.com/shanluzhineng/fwpkg/app"
 	"github.com/shanluzhineng/fwpkg/app/host"
 	"github.com/shanluzhineng/fwpkg/controllerx"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
 	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/system/health"
 	"github.com/shanluzhineng/fwpkg/system/log"
 	"github.com/shanluzhineng/fwpkg/utils/str"
 
 	"github.com/kataras/iris/v12"
 )
 
+const (
+	redactedValue = "******"
+)
+
+var (
+	_authenticatorList []fwauth.Authenticator
+	// env keys that contain these words are redacted even if the request is authenticated
+	_sensitiveEnvKeyList = []string{"password", "secret", "token", "key", "credential"}
+)
+
+// authenticators that allow the request to read the env of /api/health/check,
+// the default authenticators of fwauth are used if they are not set
+func SetAuthenticators(authenticatorList ...fwauth.Authenticator) {
+	_authenticatorList = authenticatorList
+}
+
 func healthcheckStartup(webApp *controllerx.IrisApplication) app.IStartupAction {
 	return app.NewStartupAction(func() {
 		if app.HostApplication.SystemConfig().App.IsRunInCli {
 			return
 		}
 		log.Logger.Debug("正在构建healthcheck路径组件,api/health/check...")
+		registerDiskSpaceChecker()
 		healthRouterParty := webApp.Party("/api/health")
 		{
 			healthRouterParty.Get("/check", healthcheck)
+			healthRouterParty.Get("/live", irisProbe(health.TagLiveness))
+			healthRouterParty.Get("/ready", irisProbe(health.TagReadiness))
 		}
 
 		healthcheck := host.GetHostEnvironment().GetEnvString(host.ENV_Healthcheck)
@@ -36,23 +60,104 @@ func healthcheckStartup(webApp *controllerx.IrisApplication) app.IStartupAction
 	})
 }
 
+// all of the checks,env is included if the request is authenticated
 func healthcheck(ctx iris.Context) {
-	response := responsex.NewSuccessResponse(func(br *responsex.BaseResponse) {
+	status, response := newHealthcheckResponse(ctx.Request(), "")
+	ctx.StatusCode(status)
+	ctx.JSON(response)
+}
+
+func irisProbe(tag string) iris.Handler {
+	return func(ctx iris.Context) {
+		status, response := newProbeResponse(ctx.Request().Context(), tag)
+		ctx.StatusCode(status)
+		ctx.JSON(response)
+	}
+}
+
+// data of the response of /api/health/check
+type healthcheckData struct {
+	*health.Report
+	Env map[string]interface{} `json:"env,omitempty"`
+}
+
+func newHealthcheckResponse(r *http.Request, tag string) (int, *responsex.BaseResponse) {
+	status, response := newProbeResponse(r.Context(), tag)
+	if isAuthenticated(r) {
+		response.SetData(&healthcheckData{
+			Report: response.GetData().(*health.Report),
+			Env:    redactedEnv(),
+		})
+	}
+	return status, response
+}
+
+// 503 if the status is down,the probes of kubernetes and consul treat it as failed
+func newProbeResponse(ctx context.Context, tag string) (int, *responsex.BaseResponse) {
+	report := health.Check(ctx, tag)
+	if report.Status == health.StatusDown {
+		return http.StatusServiceUnavailable, responsex.NewErrorResponse(func(br *responsex.BaseResponse) {
+			br.SetMessage("some of the components are down")
+			br.SetData(report)
+		})
+	}
+	return http.StatusOK, responsex.NewSuccessResponse(func(br *responsex.BaseResponse) {
 		br.SetMessage("Hi,I am a OK ,and I am running")
+		br.SetData(report)
+	})
+}
 
-		envValue := make(map[string]interface{})
-		envKeyList := host.GetHostEnvironment().AllKey()
-		for _, eachKey := range envKeyList {
-			if !strings.HasPrefix(eachKey, "app.") {
-				continue
-			}
-			val := host.GetHostEnvironment().GetEnv(eachKey)
-			if val == nil {
-				continue
-			}
-			envValue[eachKey] = val
+func isAuthenticated(r *http.Request) bool {
+	if fwauth.PrincipalFromContext(r.Context()) != nil {
+		return true
+	}
+	authenticatorList := _authenticatorList
+	if len(authenticatorList) <= 0 {
+		authenticatorList = fwauth.GetDefaultAuthenticators()
+	}
+	if len(authenticatorList) <= 0 {
+		return false
+	}
+	principal, err := fwauth.NewAuthenticatorChain(authenticatorList...).Authenticate(r)
+	return err == nil && principal != nil
+}
+
+// env with prefix app.,the values of sensitive keys are redacted
+func redactedEnv() map[string]interface{} {
+	envValue := make(map[string]interface{})
+	envKeyList := host.GetHostEnvironment().AllKey()
+	for _, eachKey := range envKeyList {
+		if !strings.HasPrefix(eachKey, "app.") {
+			continue
 		}
-		br.SetData(envValue)
-	})
-	ctx.JSON(response)
+		val := host.GetHostEnvironment().GetEnv(eachKey)
+		if val == nil {
+			continue
+		}
+		if isSensitiveEnvKey(eachKey) {
+			val = redactedValue
+		}
+		envValue[eachKey] = val
+	}
+	return envValue
+}
+
+func isSensitiveEnvKey(key string) bool {
+	lowerKey := strings.ToLower(key)
+	for _, eachWord := range _sensitiveEnvKeyList {
+		if strings.Contains(lowerKey, eachWord) {
+			return true
+		}
+	}
+	return false
+}
+
+// free space of the working directory,a low disk only degrades the service
+func registerDiskSpaceChecker() {
+	path, err := os.Getwd()
+	if err != nil {
+		path = "."
+	}
+	health.Register(health.NewDiskSpaceChecker(path, health.DefaultMinFreeBytes),
+		health.WithTags(health.TagLiveness), health.WithNonCritical())
 }
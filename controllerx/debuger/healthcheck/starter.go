@@ -4,4 +4,5 @@ import "github.com/shanluzhineng/fwpkg/app"
 
 func init() {
 	app.RegisterStartupAction(healthcheckStartup)
+	app.RegisterStartupAction(healthcheckGinStartup)
 }
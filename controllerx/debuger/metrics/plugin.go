@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package metrics
+
+import (
+	"fmt"
+)
+
+func init() {
+	fmt.Printf("plugin metrics init function called\r\n")
+}
+
+type Bootstrap struct {
+}
+
+func newBootstrap() Bootstrap {
+	b := Bootstrap{}
+	return b
+}
+
+func (b Bootstrap) BootstrapPlugin() (err error) {
+	return nil
+}
+
+var PluginBootstrap = newBootstrap()
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shanluzhineng/fwpkg/app"
+	"github.com/shanluzhineng/fwpkg/controllerx"
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"github.com/shanluzhineng/fwpkg/system/metrics"
+	"go.uber.org/zap"
+)
+
+func init() {
+	app.RegisterStartupAction(metricsStartupAction, metricsGinStartupAction)
+}
+
+func metricsStartupAction(webApp *controllerx.IrisApplication) app.IStartupAction {
+	return app.NewStartupAction(func() {
+		if app.HostApplication.SystemConfig().App.IsRunInCli {
+			return
+		}
+		log.Logger.Debug("正在构建metrics路径组件,/metrics...")
+		registerContainerCollectors()
+		webApp.Get(metrics.Path, iris.FromStd(metrics.Handler()))
+	})
+}
+
+// the same route as metricsStartupAction for GinApplication
+func metricsGinStartupAction(webApp *controllerx.GinApplication) app.IStartupAction {
+	return app.NewStartupAction(func() {
+		if app.HostApplication.SystemConfig().App.IsRunInCli {
+			return
+		}
+		log.Logger.Debug("正在构建gin metrics路径组件,/metrics...")
+		registerContainerCollectors()
+		webApp.GET(metrics.Path, gin.WrapH(metrics.Handler()))
+	})
+}
+
+// the modules register their custom collectors into ioc by app.Context.RegistInstanceAs(collector, new(prometheus.Collector))
+func registerContainerCollectors() {
+	collectorList := app.Context.GetListByBaseInterface(new(prometheus.Collector))
+	for _, eachCollector := range collectorList {
+		currentCollector, ok := eachCollector.(prometheus.Collector)
+		if !ok {
+			continue
+		}
+		if err := metrics.Register(currentCollector); err != nil {
+			log.Logger.Warn(fmt.Sprintf("注册metrics collector时出现异常,collector:%T", currentCollector), zap.Error(err))
+		}
+	}
+}
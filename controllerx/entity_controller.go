@@ -3,26 +3,76 @@ package controllerx
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"reflect"
 	"sync"
 
 	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/context"
 	"github.com/kataras/iris/v12/core/router"
-	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/controllerx/audit"
+	"github.com/shanluzhineng/fwpkg/controllerx/authz"
+	"github.com/shanluzhineng/fwpkg/controllerx/idempotency"
+	"github.com/shanluzhineng/fwpkg/controllerx/openapi"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsecache"
 	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
 	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/entity/changefeed"
 	"github.com/shanluzhineng/fwpkg/entity/filter"
+	"github.com/shanluzhineng/fwpkg/entity/patch"
 	"github.com/shanluzhineng/fwpkg/mongodbr"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// fields that are kept from stored document when the document is replaced
+var creationAuditFieldList = []string{"creationTime", "creatorId"}
+
+// fields that are changed only by delete and restore,they are kept from stored document by replace
+var softDeleteFieldList = []string{mongodbr.SoftDeleteFieldIsDeleted, mongodbr.SoftDeleteFieldDeletionTime, mongodbr.SoftDeleteFieldDeleterId}
+
+// action of permission for BaseEntityControllerOptions.Resource
+var entityActionPermissionMapping = map[openapi.EntityAction]string{
+	openapi.EntityActionAll:            authz.PermissionActionRead,
+	openapi.EntityActionList:           authz.PermissionActionRead,
+	openapi.EntityActionGetById:        authz.PermissionActionRead,
+	openapi.EntityActionCreate:         authz.PermissionActionCreate,
+	openapi.EntityActionBatchCreate:    authz.PermissionActionCreate,
+	openapi.EntityActionUpdate:         authz.PermissionActionUpdate,
+	openapi.EntityActionPatch:          authz.PermissionActionUpdate,
+	openapi.EntityActionBatchUpdate:    authz.PermissionActionUpdate,
+	openapi.EntityActionRestore:        authz.PermissionActionUpdate,
+	openapi.EntityActionDelete:         authz.PermissionActionDelete,
+	openapi.EntityActionDeleteList:     authz.PermissionActionDelete,
+	openapi.EntityActionExport:         authz.PermissionActionExport,
+	openapi.EntityActionGetExport:      authz.PermissionActionExport,
+	openapi.EntityActionDownloadExport: authz.PermissionActionExport,
+	openapi.EntityActionImport:         authz.PermissionActionImport,
+	openapi.EntityActionGetImport:      authz.PermissionActionImport,
+	openapi.EntityActionImportReport:   authz.PermissionActionImport,
+	openapi.EntityActionStream:         authz.PermissionActionRead,
+	openapi.EntityActionStreamWs:       authz.PermissionActionRead,
+}
+
 type EntityController[T mongodbr.IEntity] struct {
 	RouterPath    string
 	EntityService entity.IEntityService[T]
+	// used by export routes,GetEntityExportService is used if not set
+	ExportService entity.IEntityExportService[T]
+	// used by import routes,GetEntityImportService is used if not set
+	ImportService entity.IEntityImportService[T]
 
-	Options BaseEntityControllerOptions
-	once    sync.Once
+	Options    BaseEntityControllerOptions
+	once       sync.Once
+	exportOnce sync.Once
+	importOnce sync.Once
+
+	// handler of Idempotency-Key,it is shared by the create routes
+	idempotencyHandler context.Handler
+	responseCache      *responsecache.Cache
+	// feed of the stream routes,the changes of items are published to it
+	changeFeed *changefeed.Feed
 }
 
 func (c *EntityController[T]) RegistRouter(webapp *IrisApplication, opts ...BaseEntityControllerOption) router.Party {
@@ -31,37 +81,181 @@ func (c *EntityController[T]) RegistRouter(webapp *IrisApplication, opts ...Base
 	}
 
 	routerParty := webapp.Party(c.RouterPath)
+	if c.Options.IdempotencyEnabled {
+		c.idempotencyHandler = idempotency.New(c.Options.IdempotencyOptionList...)
+	}
+	if c.Options.ResponseCacheEnabled {
+		c.responseCache = c.Options.ResponseCache
+		if c.responseCache == nil {
+			c.responseCache = responsecache.GetDefaultCache()
+		}
+	}
+	c.changeFeed = streamFeed(&c.Options)
 
 	if !c.Options.AllDisabled {
-		routerParty.Get("/all", c.MergeAuthenticatedContextIfNeed(c.Options.AuthenticatedDisabled, c.All)...)
+		c.handle(routerParty, http.MethodGet, "/all", openapi.EntityActionAll, c.All)
 	}
 	if !c.Options.ListDisabled {
-		routerParty.Get("/", c.MergeAuthenticatedContextIfNeed(c.Options.AuthenticatedDisabled, c.GetList)...)
+		c.handle(routerParty, http.MethodGet, "/", openapi.EntityActionList, c.GetList)
 	}
 	if !c.Options.GetByIdDisabled {
-		routerParty.Get("/{id}", c.MergeAuthenticatedContextIfNeed(c.Options.AuthenticatedDisabled, c.GetById)...)
+		c.handle(routerParty, http.MethodGet, "/{id}", openapi.EntityActionGetById, c.GetById)
 	}
 	if !c.Options.CreateDisabled {
-		routerParty.Post("/", c.MergeAuthenticatedContextIfNeed(c.Options.AuthenticatedDisabled, c.Create)...)
+		c.handle(routerParty, http.MethodPost, "/", openapi.EntityActionCreate, c.Create)
+	}
+	if !c.Options.BatchCreateDisabled {
+		c.handle(routerParty, http.MethodPost, "/batch", openapi.EntityActionBatchCreate, c.BatchCreate)
 	}
 	if !c.Options.UpdateDisabled {
-		routerParty.Put("/{id}", c.MergeAuthenticatedContextIfNeed(c.Options.AuthenticatedDisabled, c.Update)...)
+		c.handle(routerParty, http.MethodPut, "/{id}", openapi.EntityActionUpdate, c.Update)
+	}
+	if !c.Options.PatchDisabled {
+		c.handle(routerParty, http.MethodPatch, "/{id}", openapi.EntityActionPatch, c.Patch)
+	}
+	if !c.Options.BatchUpdateDisabled {
+		c.handle(routerParty, http.MethodPatch, "/batch", openapi.EntityActionBatchUpdate, c.BatchUpdate)
 	}
 	if !c.Options.DeleteDisabled {
-		routerParty.Delete("/{id}", c.MergeAuthenticatedContextIfNeed(c.Options.AuthenticatedDisabled, c.Delete)...)
+		c.handle(routerParty, http.MethodDelete, "/{id}", openapi.EntityActionDelete, c.Delete)
+	}
+	if !c.Options.RestoreDisabled && mongodbr.IsSoftDeleteEntity(new(T)) {
+		c.handle(routerParty, http.MethodPost, "/{id}/restore", openapi.EntityActionRestore, c.Restore)
 	}
 	if !c.Options.DeleteListDisabled {
-		routerParty.Delete("/", c.MergeAuthenticatedContextIfNeed(c.Options.AuthenticatedDisabled, c.DeleteList)...)
+		c.handle(routerParty, http.MethodDelete, "/", openapi.EntityActionDeleteList, c.DeleteList)
+	}
+	if c.Options.ExportEnabled {
+		// stale running exports of a stopped process are failed on startup
+		c.GetExportService()
+		c.handle(routerParty, http.MethodPost, "/export", openapi.EntityActionExport, c.Export)
+		c.handle(routerParty, http.MethodGet, "/export/{id}", openapi.EntityActionGetExport, c.GetExport)
+		c.handle(routerParty, http.MethodGet, "/export/{id}/download", openapi.EntityActionDownloadExport, c.DownloadExport)
+	}
+	if c.Options.ImportEnabled {
+		c.handle(routerParty, http.MethodPost, "/import", openapi.EntityActionImport, c.Import)
+		c.handle(routerParty, http.MethodGet, "/import/{id}", openapi.EntityActionGetImport, c.GetImport)
+		c.handle(routerParty, http.MethodGet, "/import/{id}/report", openapi.EntityActionImportReport, c.DownloadImportReport)
+	}
+	if c.Options.StreamEnabled {
+		c.handle(routerParty, http.MethodGet, "/stream", openapi.EntityActionStream, c.Stream)
+		c.handle(routerParty, http.MethodGet, "/stream/ws", openapi.EntityActionStreamWs, c.StreamWs)
 	}
 
 	return routerParty
 }
 
+// regist the route and describe it in OpenAPI document
+func (c *EntityController[T]) handle(routerParty router.Party, method string, relativePath string, action openapi.EntityAction, handler context.Handler) {
+	handlerList := []context.Handler{handler}
+	if auditOptList := auditOptionList[T](&c.Options, action); auditOptList != nil {
+		handlerList = []context.Handler{audit.New(auditOptList...), handler}
+	}
+	if c.idempotencyHandler != nil && (action == openapi.EntityActionCreate || action == openapi.EntityActionBatchCreate) {
+		handlerList = append([]context.Handler{c.idempotencyHandler}, handlerList...)
+	}
+	if cacheHandler := c.responseCacheHandler(action); cacheHandler != nil {
+		handlerList = append([]context.Handler{cacheHandler}, handlerList...)
+	}
+	permission := c.GetPermission(action)
+	if len(permission) > 0 {
+		handlerList = append([]context.Handler{RequirePermission(permission)}, handlerList...)
+	}
+	route := routerParty.Handle(method, relativePath, c.MergeAuthenticatedContextIfNeed(c.Options.AuthenticatedDisabled, handlerList...)...)
+	if route == nil {
+		return
+	}
+	openapi.RegistEntityOperation(method, route.Tmpl().Src, openapi.EntityOperation{
+		Action:        action,
+		EntityType:    reflect.TypeOf(new(T)).Elem(),
+		Authenticated: !c.Options.AuthenticatedDisabled,
+		Permission:    permission,
+	})
+}
+
+// #region response cache
+
+// name of entity in the tags of response cache
+func (c *EntityController[T]) EntityName() string {
+	return reflect.TypeOf(new(T)).Elem().Name()
+}
+
+// remove the cached list responses and the responses of items,
+// all cached responses of the entity are removed if idList is empty
+func (c *EntityController[T]) InvalidateResponseCache(idList ...string) error {
+	if c.responseCache == nil {
+		return nil
+	}
+	entityName := c.EntityName()
+	if len(idList) <= 0 {
+		return c.responseCache.Invalidate(responsecache.EntityTag(entityName))
+	}
+	tagList := []string{responsecache.EntityListTag(entityName)}
+	for _, eachId := range idList {
+		tagList = append(tagList, responsecache.EntityItemTag(entityName, eachId))
+	}
+	return c.responseCache.Invalidate(tagList...)
+}
+
+// cache handler for the read actions,evict handler for the actions that change the items
+func (c *EntityController[T]) responseCacheHandler(action openapi.EntityAction) context.Handler {
+	if c.responseCache == nil {
+		return nil
+	}
+	entityName := c.EntityName()
+	optList := append([]responsecache.CacheOption{responsecache.WithTenantFunc(cacheTenant)}, c.Options.ResponseCacheOptionList...)
+	switch action {
+	case openapi.EntityActionAll, openapi.EntityActionList:
+		return c.responseCache.Handler(append(optList,
+			responsecache.WithTags(responsecache.EntityTag(entityName), responsecache.EntityListTag(entityName)))...)
+	case openapi.EntityActionGetById:
+		return c.responseCache.Handler(append(optList,
+			responsecache.WithTags(responsecache.EntityTag(entityName)),
+			responsecache.WithTagFunc(func(ctx iris.Context) []string {
+				return []string{responsecache.EntityItemTag(entityName, ctx.Params().Get("id"))}
+			}))...)
+	case openapi.EntityActionCreate:
+		return c.responseCache.EvictHandler(func(ctx iris.Context) []string {
+			return []string{responsecache.EntityListTag(entityName)}
+		})
+	case openapi.EntityActionUpdate, openapi.EntityActionPatch, openapi.EntityActionDelete, openapi.EntityActionRestore:
+		return c.responseCache.EvictHandler(func(ctx iris.Context) []string {
+			return []string{responsecache.EntityListTag(entityName), responsecache.EntityItemTag(entityName, ctx.Params().Get("id"))}
+		})
+	case openapi.EntityActionBatchCreate, openapi.EntityActionBatchUpdate, openapi.EntityActionDeleteList, openapi.EntityActionImport:
+		return c.responseCache.EvictHandler(func(ctx iris.Context) []string {
+			return []string{responsecache.EntityTag(entityName)}
+		})
+	}
+	return nil
+}
+
+// tenant of the cache key,it is the tenant of GetRequestEntityService
+func cacheTenant(ctx iris.Context) (string, bool) {
+	if IsTenantBypassRequested(ctx) {
+		return "", true
+	}
+	return GetTenantId(ctx), false
+}
+
+// #endregion
+
+// permission required by action,empty if it is not checked
+func (c *EntityController[T]) GetPermission(action openapi.EntityAction) string {
+	if permission, ok := c.Options.PermissionMap[action]; ok {
+		return permission
+	}
+	if len(c.Options.Resource) <= 0 {
+		return ""
+	}
+	return authz.Permission(c.Options.Resource, entityActionPermissionMapping[action])
+}
+
 func (c *EntityController[T]) MergeAuthenticatedContextIfNeed(authenticatedDisabled bool, handlers ...context.Handler) []context.Handler {
 	handlerList := make([]context.Handler, 0)
 	if !authenticatedDisabled {
 		// handler auth
-		handlerList = append(handlerList, fwauth.GetCasdoorMiddleware().Serve)
+		handlerList = append(handlerList, AuthenticateHandler(c.Options.AuthenticatorList...))
 	}
 	handlerList = append(handlerList, handlers...)
 	return handlerList
@@ -77,7 +271,76 @@ func (c *EntityController[T]) GetEntityService() entity.IEntityService[T] {
 	return c.EntityService
 }
 
+// the entity service of current request,for tenant entity it only reads and writes the items of current tenant,
+// for conditional permission it only reads and writes the items matched by the conditions.
+// the commands run with the context of request,so they are canceled with the request and traced under its span.
+// a forbidden response is written if the tenant is not resolved or the bypass is not authorized
+func (c *EntityController[T]) GetRequestEntityService(ctx iris.Context) (entity.IEntityService[T], bool) {
+	tenantId, ok := c.getRequestTenantId(ctx)
+	if !ok {
+		return nil, false
+	}
+	service := c.GetEntityService().WithContext(ctx.Request().Context())
+	if len(tenantId) > 0 {
+		service = service.WithTenant(tenantId)
+	}
+	if scope := getPermissionScope(ctx); scope != nil {
+		service = service.WithScope(scope)
+	}
+	return service, true
+}
+
+// conditions of the permission of current request,nil if the permission is granted without condition
+func getPermissionScope(ctx iris.Context) bson.M {
+	decision := GetPermissionDecision(ctx)
+	if decision == nil {
+		return nil
+	}
+	return decision.Filter()
+}
+
+// empty if T is not a tenant entity or tenant isolation is bypassed
+func (c *EntityController[T]) getRequestTenantId(ctx iris.Context) (string, bool) {
+	if !entity.IsTenantEntity(new(T)) {
+		return "", true
+	}
+	if IsTenantBypassRequested(ctx) {
+		if !c.canBypassTenant(ctx) {
+			responsex.HandleAppError(ctx, ErrCodeTenantBypassDenied.New(""))
+			return "", false
+		}
+		return "", true
+	}
+	tenantId := GetTenantId(ctx)
+	if len(tenantId) <= 0 {
+		responsex.HandleAppError(ctx, ErrCodeTenantUnresolved.New(""))
+		return "", false
+	}
+	return tenantId, true
+}
+
+// a record of tenantId can be read by current request
+func (c *EntityController[T]) isTenantAccessible(ctx iris.Context, tenantId string) bool {
+	if len(tenantId) <= 0 || tenantId == GetTenantId(ctx) {
+		return true
+	}
+	return IsTenantBypassRequested(ctx) && c.canBypassTenant(ctx)
+}
+
+// bypass tenant isolation is allowed for super admin by default
+func (c *EntityController[T]) canBypassTenant(ctx iris.Context) bool {
+	if c.Options.TenantBypassAuthorizeFunc != nil {
+		return c.Options.TenantBypassAuthorizeFunc(ctx)
+	}
+	return IsSuperAdmin(ctx)
+}
+
 func (c *EntityController[T]) All(ctx iris.Context) {
+	projection, err := GetProjection(ctx, new(T))
+	if err != nil {
+		responsex.HandleErrorBadRequest(ctx, err)
+		return
+	}
 	filter := map[string]interface{}{}
 	if !c.Options.FilterCurrentUserForListDisabled {
 		// auto filter current userId
@@ -87,18 +350,26 @@ func (c *EntityController[T]) All(ctx iris.Context) {
 	if c.Options.ListFilterFunc != nil {
 		c.Options.ListFilterFunc(new(T), filter, ctx)
 	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
 	var list []T
-	var err error
-	if len(filter) > 0 {
-		list, err = c.GetEntityService().FindList(filter)
+	if len(filter) > 0 || !projection.IsEmpty() {
+		list, err = service.FindList(filter, mongodbr.FindOptionWithProjection(projection))
 	} else {
-		list, err = c.GetEntityService().FindAll()
+		list, err = service.FindAll()
 	}
 	if err != nil {
-		responsex.HandleErrorInternalServerError(ctx, err)
+		responsex.HandleAppError(ctx, err)
 		return
 	}
-	responsex.HandleSuccessWithListData(ctx, list, int64(len(list)))
+	data, err := ProjectList(list, projection, new(T))
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	responsex.HandleSuccessWithListData(ctx, data, int64(len(list)))
 }
 
 func (c *EntityController[T]) GetList(ctx iris.Context) {
@@ -109,7 +380,11 @@ func (c *EntityController[T]) GetList(ctx iris.Context) {
 	}
 
 	// params
-	pagination := MustGetPagination(ctx)
+	projection, err := GetProjection(ctx, new(T))
+	if err != nil {
+		responsex.HandleErrorBadRequest(ctx, err)
+		return
+	}
 	query := filter.MustGetFilterQuery(ctx.FormValue)
 	sort := filter.MustGetSortOption(ctx.FormValue)
 
@@ -117,27 +392,76 @@ func (c *EntityController[T]) GetList(ctx iris.Context) {
 		// auto filter current userId
 		AddUserIdFilterIfNeed(query, new(T), ctx)
 	}
-	service := c.GetEntityService()
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	if cursorPagination, ok := GetCursorPagination(ctx); ok {
+		c.getListByCursor(ctx, service, query, sort, projection, cursorPagination)
+		return
+	}
+
+	pagination := MustGetPagination(ctx)
 	list, err := service.FindList(query, mongodbr.FindOptionWithSort(sort),
-		mongodbr.FindOptionWithPage(int64(pagination.Page), int64(pagination.Size)))
+		mongodbr.FindOptionWithPage(int64(pagination.Page), int64(pagination.Size)),
+		mongodbr.FindOptionWithProjection(projection))
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+
+	var count int64 = responsex.ListTotalNotCounted
+	if MustGetListTotal(ctx, true) {
+		count, err = service.Count(query)
+		if err != nil {
+			responsex.HandleAppError(ctx, err)
+			return
+		}
+	}
+	data, err := ProjectList(list, projection, new(T))
 	if err != nil {
-		responsex.HandleErrorInternalServerError(ctx, err)
+		responsex.HandleAppError(ctx, err)
 		return
 	}
+	responsex.HandleSuccessWithListData(ctx, data, count)
+}
 
-	count, err := service.Count(query)
+// keyset pagination,total is not counted by default
+func (c *EntityController[T]) getListByCursor(ctx iris.Context, service entity.IEntityService[T], query map[string]interface{}, sort bson.D,
+	projection *mongodbr.Projection, pagination *entity.CursorPagination) {
+	page, err := service.FindPage(query, &mongodbr.KeysetPage{
+		Sort:   sort,
+		After:  pagination.After,
+		Before: pagination.Before,
+		Limit:  int64(pagination.Limit),
+	}, mongodbr.FindOptionWithProjection(projection))
 	if err != nil {
-		responsex.HandleErrorInternalServerError(ctx, err)
+		// invalid cursor is 400 by mapEntityError
+		responsex.HandleAppError(ctx, err)
 		return
 	}
-	responsex.HandleSuccessWithListData(ctx, list, count)
+
+	var count int64 = responsex.ListTotalNotCounted
+	if MustGetListTotal(ctx, false) {
+		count, err = service.Count(query)
+		if err != nil {
+			responsex.HandleAppError(ctx, err)
+			return
+		}
+	}
+	data, err := ProjectList(page.List, projection, new(T))
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	responsex.HandleSuccessWithCursorListData(ctx, data, count, page.NextCursor, page.PrevCursor)
 }
 
 // get by id
 func (c *EntityController[T]) GetById(ctx iris.Context) {
 	idValue := ctx.Params().Get("id")
 	if len(idValue) <= 0 {
-		responsex.HandleErrorBadRequest(ctx, errors.New("id must not be empty"))
+		responsex.HandleAppError(ctx, ErrCodeIdRequired.New(""))
 		return
 	}
 
@@ -146,13 +470,22 @@ func (c *EntityController[T]) GetById(ctx iris.Context) {
 		responsex.HandleErrorBadRequest(ctx, fmt.Errorf("invalid id,id must be bson id format,id:%s", idValue))
 		return
 	}
-	item, err := c.GetEntityService().FindById(id)
+	projection, err := GetProjection(ctx, new(T))
 	if err != nil {
-		responsex.HandleErrorInternalServerError(ctx, err)
+		responsex.HandleErrorBadRequest(ctx, err)
+		return
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	item, err := service.FindById(id, mongodbr.FindOneOptionWithProjection(projection))
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
 		return
 	}
 	if item == nil {
-		responsex.HandleErrorInternalServerError(ctx, fmt.Errorf("invalid id,id:%s", idValue))
+		responsex.HandleErrorNotFound(ctx, fmt.Errorf("not found item,id:%s", idValue))
 		return
 	}
 	// // filter user is current user
@@ -160,7 +493,17 @@ func (c *EntityController[T]) GetById(ctx iris.Context) {
 	// 	responsex.HandleErrorInternalServerError(ctx, fmt.Errorf("invalid id,id:%s", idValue))
 	// 	return
 	// }
-	responsex.HandleSuccessWithData(ctx, item)
+	if projection.IsEmpty() {
+		if etag, ok := entityETag(entityValue(item)); ok {
+			ctx.Header("ETag", etag)
+		}
+	}
+	data, err := ProjectItem(item, projection, new(T))
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	responsex.HandleSuccessWithData(ctx, data)
 }
 
 // create
@@ -177,57 +520,182 @@ func (c *EntityController[T]) Create(ctx iris.Context) {
 		return
 	}
 
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
 	// handler user info
 	c.SetUserInfo(ctx, input)
 
-	newItem, err := c.GetEntityService().Create(input)
+	newItem, err := service.Create(input)
 	if err != nil {
-		responsex.HandleErrorInternalServerError(ctx, err)
+		responsex.HandleAppError(ctx, err)
 		return
 	}
+	auditEntityChange[T](ctx.Request().Context(), audit.Get(ctx), GetTenantId(ctx), entityObjectId(entityValue(newItem)), nil, entityValue(newItem))
+	publishEntityChange(ctx.Request().Context(), c.changeFeed, service, changefeed.OperationCreate, entityObjectId(entityValue(newItem)), entityValue(newItem))
 	responsex.HandleSuccessWithData(ctx, newItem)
 }
 
-// update
+// update,replace the whole document
 func (c *EntityController[T]) Update(ctx iris.Context) {
-	idValue := ctx.Params().Get("id")
-	if len(idValue) <= 0 {
-		responsex.HandleErrorBadRequest(ctx, errors.New("id must not be empty"))
+	id, ok := getObjectIdParam(ctx)
+	if !ok {
 		return
 	}
-	id, err := primitive.ObjectIDFromHex(idValue)
-	if err != nil {
-		responsex.HandleErrorBadRequest(ctx, fmt.Errorf("invalid id,id must be bson id format,id:%s", idValue))
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
 		return
 	}
-	service := c.GetEntityService()
 	item, err := service.FindById(id)
 	if err != nil {
-		responsex.HandleErrorInternalServerError(ctx, err)
+		responsex.HandleAppError(ctx, err)
 		return
 	}
 	if item == nil {
-		responsex.HandleErrorBadRequest(ctx, fmt.Errorf("not found item,id:%s", idValue))
+		responsex.HandleErrorNotFound(ctx, fmt.Errorf("not found item,id:%s", id.Hex()))
+		return
+	}
+	if !checkIfMatch(ctx, entityValue(item)) {
 		return
 	}
-	// filter user is current user
-	// if !FilterMustIsCurrentUserId(item, ctx) {
-	// 	responsex.HandleErrorInternalServerError(ctx, fmt.Errorf("invalid id,id:%s", idValue))
-	// 	return
-	// }
 
-	input := make(map[string]interface{})
-	err = ctx.ReadJSON(&input)
+	input := new(T)
+	err = ctx.ReadJSON(input)
 	if err != nil {
 		responsex.HandleErrorBadRequest(ctx, err)
 		return
 	}
+	inputValue := entityValue(input)
+	err = mongodbr.Validate(inputValue)
+	if err != nil {
+		responsex.HandleErrorBadRequest(ctx, err)
+		return
+	}
+	if hookable, ok := inputValue.(mongodbr.IEntityBeforeUpdate); ok {
+		hookable.BeforeUpdate()
+	}
+	replacement, err := mongodbr.ToBsonMap(inputValue)
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	replacement["_id"] = id
+	if err = keepStoredFields[T](replacement, entityValue(item)); err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
 
-	err = service.UpdateFields(id, input)
+	if versioned, ok := entityValue(item).(mongodbr.IVersionedEntity); ok {
+		// expected version is from If-Match header or from the request body
+		expectedVersion := versioned.GetVersion()
+		if len(ctx.GetHeader(headerIfMatch)) <= 0 {
+			expectedVersion = inputValue.(mongodbr.IVersionedEntity).GetVersion()
+		}
+		err = service.ReplaceByIdWithVersion(id, expectedVersion, replacement)
+	} else {
+		err = service.ReplaceById(id, replacement)
+	}
 	if err != nil {
-		responsex.HandleErrorInternalServerError(ctx, err)
+		handleEntityWriteError(ctx, err)
 		return
 	}
+	auditEntityChange[T](ctx.Request().Context(), audit.Get(ctx), GetTenantId(ctx), id, entityValue(item), replacement)
+	publishEntityChange(ctx.Request().Context(), c.changeFeed, service, changefeed.OperationUpdate, id, replacement)
+	responsex.HandleSuccess(ctx)
+}
+
+// creation audit fields,soft delete fields and the tenant of stored item cannot be changed by replace
+func keepStoredFields[T mongodbr.IEntity](replacement bson.M, storedValue interface{}) error {
+	stored, err := mongodbr.ToBsonMap(storedValue)
+	if err != nil {
+		return err
+	}
+	for _, eachField := range creationAuditFieldList {
+		if v, ok := stored[eachField]; ok {
+			replacement[eachField] = v
+		}
+	}
+	for _, eachField := range softDeleteFieldList {
+		if v, ok := stored[eachField]; ok {
+			replacement[eachField] = v
+		} else {
+			delete(replacement, eachField)
+		}
+	}
+	if v, ok := stored[entity.TenantFieldName]; ok && entity.IsTenantEntity(new(T)) {
+		replacement[entity.TenantFieldName] = v
+	}
+	return nil
+}
+
+// patch,support json merge patch(RFC 7396) and json patch(RFC 6902)
+func (c *EntityController[T]) Patch(ctx iris.Context) {
+	id, ok := getObjectIdParam(ctx)
+	if !ok {
+		return
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	item, err := service.FindById(id)
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	if item == nil {
+		responsex.HandleErrorNotFound(ctx, fmt.Errorf("not found item,id:%s", id.Hex()))
+		return
+	}
+	if !checkIfMatch(ctx, entityValue(item)) {
+		return
+	}
+	body, err := ctx.GetBody()
+	if err != nil {
+		responsex.HandleErrorBadRequest(ctx, err)
+		return
+	}
+	current, err := mongodbr.ToBsonMap(entityValue(item))
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+
+	fieldSet := mongodbr.GetEntityFieldSet(new(T))
+	var update bson.M
+	switch ctx.GetContentTypeRequested() {
+	case patch.ContentTypeJsonPatch:
+		update, err = patch.JsonPatchToUpdate(body, fieldSet, current)
+	case patch.ContentTypeMergePatch, context.ContentJSONHeaderValue:
+		update, err = patch.MergePatchToUpdate(body, fieldSet, current)
+	default:
+		responsex.HandleError(http.StatusUnsupportedMediaType, ctx,
+			fmt.Errorf("unsupported content type,content type must be %s or %s", patch.ContentTypeMergePatch, patch.ContentTypeJsonPatch))
+		return
+	}
+	if err != nil {
+		// failed test is 409,invalid path or operation is 422 by mapEntityError,otherwise 400
+		responsex.HandleError(http.StatusBadRequest, ctx, err)
+		return
+	}
+	if len(update) <= 0 {
+		responsex.HandleSuccess(ctx)
+		return
+	}
+
+	// the patch is computed from the stored document,so it must be applied to the same version
+	if versioned, ok := entityValue(item).(mongodbr.IVersionedEntity); ok {
+		err = service.UpdateByIdWithVersion(id, versioned.GetVersion(), update)
+	} else {
+		err = service.UpdateById(id, update)
+	}
+	if err != nil {
+		handleEntityWriteError(ctx, err)
+		return
+	}
+	auditEntityUpdate[T](ctx.Request().Context(), audit.Get(ctx), GetTenantId(ctx), service, id, entityValue(item))
+	publishEntityChange(ctx.Request().Context(), c.changeFeed, service, changefeed.OperationUpdate, id, nil)
 	responsex.HandleSuccess(ctx)
 }
 
@@ -235,7 +703,7 @@ func (c *EntityController[T]) Update(ctx iris.Context) {
 func (c *EntityController[T]) Delete(ctx iris.Context) {
 	idValue := ctx.Params().Get("id")
 	if len(idValue) <= 0 {
-		responsex.HandleErrorBadRequest(ctx, errors.New("id must not be empty"))
+		responsex.HandleAppError(ctx, ErrCodeIdRequired.New(""))
 		return
 	}
 	oid, err := primitive.ObjectIDFromHex(idValue)
@@ -243,14 +711,27 @@ func (c *EntityController[T]) Delete(ctx iris.Context) {
 		responsex.HandleErrorBadRequest(ctx, fmt.Errorf("invalid id format,err:%s", err.Error()))
 		return
 	}
-	service := c.GetEntityService()
-	item, err := service.FindById(oid)
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	hard := ctx.URLParamBoolDefault("hard", false)
+	if hard && !c.canHardDelete(ctx) {
+		responsex.HandleAppError(ctx, ErrCodeHardDeleteDenied.New(""))
+		return
+	}
+	repository := service.GetRepository()
+	if hard {
+		// soft deleted item can be deleted permanently
+		repository = repository.WithDeleted()
+	}
+	item, err := mongodbr.FindTByObjectId[T](repository, oid)
 	if err != nil {
-		responsex.HandleErrorInternalServerError(ctx, err)
+		responsex.HandleAppError(ctx, err)
 		return
 	}
 	if item == nil {
-		responsex.HandleErrorBadRequest(ctx, fmt.Errorf("not found item,id:%s", idValue))
+		responsex.HandleErrorNotFound(ctx, fmt.Errorf("not found item,id:%s", idValue))
 		return
 	}
 	// filter user is current user
@@ -258,15 +739,73 @@ func (c *EntityController[T]) Delete(ctx iris.Context) {
 	// 	responsex.HandleErrorInternalServerError(ctx, fmt.Errorf("invalid id,id:%s", idValue))
 	// 	return
 	// }
+	if !checkIfMatch(ctx, entityValue(item)) {
+		return
+	}
 
-	err = c.GetEntityService().Delete(oid)
+	versioned, ok := entityValue(item).(mongodbr.IVersionedEntity)
+	ifMatch := ok && len(ctx.GetHeader(headerIfMatch)) > 0
+	switch {
+	case hard && ifMatch:
+		err = service.PurgeWithVersion(oid, versioned.GetVersion())
+	case hard:
+		err = service.Purge(oid)
+	case ifMatch:
+		err = service.SoftDeleteWithVersion(oid, versioned.GetVersion(), GetUserId(ctx))
+	default:
+		err = service.SoftDelete(oid, GetUserId(ctx))
+	}
 	if err != nil {
-		responsex.HandleErrorInternalServerError(ctx, err)
+		handleEntityWriteError(ctx, err)
 		return
 	}
+	auditEntityChange[T](ctx.Request().Context(), audit.Get(ctx), GetTenantId(ctx), oid, entityValue(item), nil)
+	publishEntityChange(ctx.Request().Context(), c.changeFeed, service, changefeed.OperationDelete, oid, entityValue(item))
 	responsex.HandleSuccess(ctx)
 }
 
+// restore a soft deleted item
+func (c *EntityController[T]) Restore(ctx iris.Context) {
+	id, ok := getObjectIdParam(ctx)
+	if !ok {
+		return
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	if !service.GetRepository().IsSoftDeleteEnabled() {
+		responsex.HandleAppError(ctx, ErrCodeSoftDeleteDisabled.New(""))
+		return
+	}
+	record := audit.Get(ctx)
+	before, err := restoreAuditSnapshot(record, service, id)
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	err = service.Restore(id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			responsex.HandleErrorNotFound(ctx, fmt.Errorf("not found deleted item,id:%s", id.Hex()))
+			return
+		}
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	auditEntityUpdate[T](ctx.Request().Context(), record, GetTenantId(ctx), service, id, before)
+	publishEntityChange(ctx.Request().Context(), c.changeFeed, service, changefeed.OperationUpdate, id, nil)
+	responsex.HandleSuccess(ctx)
+}
+
+// hard delete is allowed for admin by default
+func (c *EntityController[T]) canHardDelete(ctx iris.Context) bool {
+	if c.Options.HardDeleteAuthorizeFunc != nil {
+		return c.Options.HardDeleteAuthorizeFunc(ctx)
+	}
+	return IsAdmin(ctx)
+}
+
 // delete
 func (c *EntityController[T]) DeleteList(ctx iris.Context) {
 	payload, err := GetBatchRequestPayload(ctx)
@@ -274,7 +813,11 @@ func (c *EntityController[T]) DeleteList(ctx iris.Context) {
 		responsex.HandleErrorBadRequest(ctx, err)
 		return
 	}
+	record := audit.Get(ctx)
 	if len(payload.Ids) <= 0 {
+		if record != nil {
+			record.Skipped = true
+		}
 		responsex.HandleSuccess(ctx)
 		return
 	}
@@ -284,11 +827,32 @@ func (c *EntityController[T]) DeleteList(ctx iris.Context) {
 	// auto filter current userId
 	// AddUserIdFilterIfNeed(filter, new(T), ctx)
 
-	_, err = c.GetEntityService().DeleteMany(filter)
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	var deletedList []T
+	if record != nil || needPublishEntityChange(c.changeFeed, service) {
+		// the items are loaded before they are deleted so their fields are recorded and published
+		deletedList, err = service.FindList(filter)
+		if err != nil {
+			responsex.HandleAppError(ctx, err)
+			return
+		}
+	}
+	_, err = service.DeleteMany(filter)
 	if err != nil {
-		responsex.HandleErrorInternalServerError(ctx, err)
+		responsex.HandleAppError(ctx, err)
 		return
 	}
+	if record != nil && len(deletedList) <= 0 {
+		record.Skipped = true
+	}
+	for index := range deletedList {
+		item := entityValue(&deletedList[index])
+		auditEntityChange[T](ctx.Request().Context(), record, GetTenantId(ctx), entityObjectId(item), item, nil)
+		publishEntityChange(ctx.Request().Context(), c.changeFeed, service, changefeed.OperationDelete, entityObjectId(item), item)
+	}
 	responsex.HandleSuccess(ctx)
 }
 
@@ -0,0 +1,146 @@
+package controllerx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/shanluzhineng/fwpkg/controllerx/audit"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/controllerx/openapi"
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/entity/diff"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OPAction of the actions that are audited if BaseEntityControllerOptions.AuditEnabled is set
+var entityActionAuditMapping = map[openapi.EntityAction]string{
+	openapi.EntityActionCreate:      audit.ActionCreate,
+	openapi.EntityActionUpdate:      audit.ActionUpdate,
+	openapi.EntityActionPatch:       audit.ActionPatch,
+	openapi.EntityActionDelete:      audit.ActionDelete,
+	openapi.EntityActionDeleteList:  audit.ActionDeleteList,
+	openapi.EntityActionRestore:     audit.ActionRestore,
+	openapi.EntityActionBatchCreate: audit.ActionBatchCreate,
+	openapi.EntityActionBatchUpdate: audit.ActionBatchUpdate,
+	openapi.EntityActionImport:      audit.ActionImport,
+}
+
+// options of the audit handler of action,nil if the action is not audited
+func auditOptionList[T mongodbr.IEntity](options *BaseEntityControllerOptions, action openapi.EntityAction) []audit.AuditOption {
+	if !options.AuditEnabled {
+		return nil
+	}
+	opAction, ok := entityActionAuditMapping[action]
+	if !ok {
+		return nil
+	}
+	return append([]audit.AuditOption{audit.WithAction(opAction), audit.WithEntityType(auditEntityType[T]())}, options.AuditOptionList...)
+}
+
+// name of the entity struct,T is usually a pointer type
+func auditEntityType[T mongodbr.IEntity]() string {
+	t := reflect.TypeOf(new(T)).Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// add the field level changes of an entity to the audit record,
+// before is nil for the created entity and after is nil for the deleted entity.
+// nothing is done if record is nil
+func auditEntityChange[T mongodbr.IEntity](ctx context.Context, record *audit.Record, tenantId string, id primitive.ObjectID, before interface{}, after interface{}) {
+	if record == nil {
+		return
+	}
+	if len(tenantId) > 0 {
+		record.TenantId = tenantId
+	}
+	changeList, err := diff.Compare(mongodbr.GetEntityFieldSet(new(T)), before, after)
+	if err != nil {
+		// the entity is still recorded without the changes
+		log.FromContext(ctx).Warn(fmt.Sprintf("audit diff fail,id:%s,err:%s", id.Hex(), err.Error()))
+	}
+	record.AddEntity(id.Hex(), changeList)
+}
+
+// add the changes of an updated entity to the audit record,the after snapshot is loaded from service.
+// nothing is loaded if record is nil
+func auditEntityUpdate[T mongodbr.IEntity](ctx context.Context, record *audit.Record, tenantId string,
+	service entity.IEntityService[T], id primitive.ObjectID, before interface{}) {
+	auditEntityUpdateList[T](ctx, record, tenantId, service, []primitive.ObjectID{id}, map[primitive.ObjectID]interface{}{id: before})
+}
+
+// add the changes of updated entities to the audit record,beforeMap is the snapshots before the write.
+// the after snapshots are loaded from service,nothing is loaded if record is nil
+func auditEntityUpdateList[T mongodbr.IEntity](ctx context.Context, record *audit.Record, tenantId string,
+	service entity.IEntityService[T], idList []primitive.ObjectID, beforeMap map[primitive.ObjectID]interface{}) {
+	if record == nil || len(idList) <= 0 {
+		return
+	}
+	afterList, err := service.FindList(bson.M{"_id": bson.M{"$in": idList}})
+	if err != nil {
+		// the entities are still recorded without the changes
+		log.FromContext(ctx).Warn(fmt.Sprintf("audit load fail,err:%s", err.Error()))
+		for _, eachId := range idList {
+			record.AddEntity(eachId.Hex(), nil)
+		}
+		return
+	}
+	afterMap := make(map[primitive.ObjectID]interface{}, len(afterList))
+	for i := range afterList {
+		afterMap[afterList[i].GetObjectId()] = entityValue(&afterList[i])
+	}
+	for _, eachId := range idList {
+		auditEntityChange[T](ctx, record, tenantId, eachId, beforeMap[eachId], afterMap[eachId])
+	}
+}
+
+// the soft deleted item before restore,it is loaded only if record is not nil
+func restoreAuditSnapshot[T mongodbr.IEntity](record *audit.Record, service entity.IEntityService[T], id primitive.ObjectID) (interface{}, error) {
+	if record == nil {
+		return nil, nil
+	}
+	item, err := mongodbr.FindTByObjectId[T](service.GetRepository().WithDeleted(), id)
+	if err != nil {
+		return nil, err
+	}
+	return entityValue(item), nil
+}
+
+// ItemWrittenFunc of import,the items are written after the request so each batch is saved as a new audit record
+// with the request info of record
+func auditImportedItems[T mongodbr.IEntity](ctx context.Context, options *BaseEntityControllerOptions,
+	record *audit.Record, principal *fwauth.Principal, tenantId string) func(itemList []entity.ImportedItem) {
+	// the request is finished before the items are written
+	ctx = context.WithoutCancel(ctx)
+	request := *record
+	request.EntityList = nil
+	request.Status = http.StatusOK
+	if principal != nil {
+		request.PrincipalId = principal.Id
+		request.PrincipalName = principal.Name
+	}
+	return func(itemList []entity.ImportedItem) {
+		batchRecord := request
+		for _, eachItem := range itemList {
+			auditEntityChange[T](ctx, &batchRecord, tenantId, eachItem.Id, eachItem.Before, eachItem.After)
+		}
+		if err := audit.WriteRecord(ctx, &batchRecord, options.AuditOptionList...); err != nil {
+			log.FromContext(ctx).Warn(fmt.Sprintf("audit write fail,action:%s,path:%s,err:%s", batchRecord.Action, batchRecord.Path, err.Error()))
+		}
+	}
+}
+
+// object id of the entity value
+func entityObjectId(v interface{}) primitive.ObjectID {
+	if item, ok := v.(mongodbr.IEntity); ok {
+		return item.GetObjectId()
+	}
+	return primitive.NilObjectID
+}
@@ -0,0 +1,63 @@
+package controllerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/audit"
+	"github.com/shanluzhineng/fwpkg/controllerx/openapi"
+	"github.com/shanluzhineng/fwpkg/entity/diff"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	opevent "github.com/shanluzhineng/fwpkg/opevents/pkg"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type testOpEventLogService struct {
+	opevent.IOpEventLogService
+	eventLogList []*opevent.OpEventLog
+}
+
+func (s *testOpEventLogService) Save(item *opevent.OpEventLog) error {
+	s.eventLogList = append(s.eventLogList, item)
+	return nil
+}
+
+func TestEntityControllerAuditPatch(t *testing.T) {
+	useTestLogger()
+	eventLogService := &testOpEventLogService{}
+	service := &testOrderService{item: &testOrder{Entity: mongodbr.Entity{ObjectId: primitive.NewObjectID()}, Name: "old"}}
+	c := &EntityController[*testOrder]{EntityService: service}
+	c.Options.AuthenticatedDisabled = true
+	c.Options.AuditEnabled = true
+	c.Options.AuditOptionList = []audit.AuditOption{audit.WithService(eventLogService)}
+	app := iris.New()
+	c.handle(app.Party("/orders"), http.MethodPatch, "/{id}", openapi.EntityActionPatch, c.Patch)
+	assert.NoError(t, app.Build())
+
+	req := httptest.NewRequest(http.MethodPatch, "/orders/"+service.item.ObjectId.Hex(), strings.NewReader(`{"name":"new"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Len(t, eventLogService.eventLogList, 1)
+	eventLog := eventLogService.eventLogList[0]
+	assert.Equal(t, audit.ActionPatch, eventLog.OPAction)
+	record := eventLog.TaskInfo.(*audit.Record)
+	assert.Equal(t, "testOrder", record.EntityType)
+	assert.Len(t, record.EntityList, 1)
+	assert.Equal(t, service.item.ObjectId.Hex(), record.EntityList[0].Id)
+	changeMap := make(map[string]*diff.FieldChange)
+	for _, eachChange := range record.EntityList[0].Changes {
+		changeMap[eachChange.Field] = eachChange
+	}
+	// the version is increased by the update
+	assert.Len(t, changeMap, 2)
+	assert.Equal(t, "old", changeMap["name"].Before)
+	assert.Equal(t, "new", changeMap["name"].After)
+	assert.Contains(t, changeMap, "version")
+}
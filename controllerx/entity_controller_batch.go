@@ -0,0 +1,374 @@
+package controllerx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/audit"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/entity/changefeed"
+	"github.com/shanluzhineng/fwpkg/entity/patch"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// max item count of a batch request if BaseEntityControllerOptions.BatchMaxSize is not set
+const DefaultBatchMaxSize = 1000
+
+// batch create,the body is a json array of items.
+// query ordered=false means the items are written by an unordered bulk write,
+// otherwise nothing is written if any item is invalid and the write stops at the first failed item
+func (c *EntityController[T]) BatchCreate(ctx iris.Context) {
+	rawList, ok := c.readBatchBody(ctx)
+	if !ok {
+		return
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	ordered := isBatchOrdered(ctx)
+	resultList := newBatchResultList(len(rawList))
+
+	itemList := make([]interface{}, 0, len(rawList))
+	indexList := make([]int, 0, len(rawList))
+	for index, eachRaw := range rawList {
+		input := new(T)
+		if err := json.Unmarshal(eachRaw, input); err != nil {
+			failBatchItem(&resultList[index], http.StatusBadRequest, err)
+			continue
+		}
+		inputValue := entityValue(input)
+		if err := mongodbr.Validate(inputValue); err != nil {
+			failBatchItem(&resultList[index], http.StatusBadRequest, err)
+			continue
+		}
+		// handler user info
+		c.SetUserInfo(ctx, inputValue)
+		itemList = append(itemList, inputValue)
+		indexList = append(indexList, index)
+	}
+	if len(itemList) <= 0 || (ordered && len(itemList) < len(rawList)) {
+		skipBatchItems(resultList, indexList)
+		responsex.HandleSuccessWithData(ctx, resultList)
+		return
+	}
+
+	_, err := service.CreateMany(itemList, ordered)
+	applyBatchWriteResult(resultList, indexList, err, ordered, http.StatusCreated)
+	record := audit.Get(ctx)
+	for i, index := range indexList {
+		if isBatchItemFailed(&resultList[index]) {
+			continue
+		}
+		if e, ok := itemList[i].(mongodbr.IEntity); ok {
+			resultList[index].Id = e.GetObjectId().Hex()
+			auditEntityChange[T](ctx.Request().Context(), record, GetTenantId(ctx), e.GetObjectId(), nil, e)
+			publishEntityChange(ctx.Request().Context(), c.changeFeed, service, changefeed.OperationCreate, e.GetObjectId(), e)
+		}
+	}
+	responsex.HandleSuccessWithData(ctx, resultList)
+}
+
+// batch update,the body is a json array of json merge patch(RFC 7396),
+// each patch must contain the id of item. for versioned entity the version in patch is the expected version,
+// the stored version is used if it is not set.
+// query ordered has the same meaning as batch create
+func (c *EntityController[T]) BatchUpdate(ctx iris.Context) {
+	rawList, ok := c.readBatchBody(ctx)
+	if !ok {
+		return
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	ordered := isBatchOrdered(ctx)
+	resultList := newBatchResultList(len(rawList))
+
+	fieldSet := mongodbr.GetEntityFieldSet(new(T))
+	idKey := "objectId"
+	if f, ok := fieldSet.Field("_id"); ok && len(f.JsonName) > 0 {
+		idKey = f.JsonName
+	}
+	patchList := make([]map[string]json.RawMessage, len(rawList))
+	idList := make([]primitive.ObjectID, 0, len(rawList))
+	for index, eachRaw := range rawList {
+		patchObject := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(eachRaw, &patchObject); err != nil {
+			failBatchItem(&resultList[index], http.StatusBadRequest, fmt.Errorf("item must be a json object,%w", err))
+			continue
+		}
+		var idValue string
+		_ = json.Unmarshal(patchObject[idKey], &idValue)
+		id, err := primitive.ObjectIDFromHex(idValue)
+		if err != nil {
+			failBatchItem(&resultList[index], http.StatusBadRequest, fmt.Errorf("invalid id,%s must be bson id format", idKey))
+			continue
+		}
+		delete(patchObject, idKey)
+		resultList[index].Id = id.Hex()
+		patchList[index] = patchObject
+		idList = append(idList, id)
+	}
+
+	storedList, err := service.FindList(bson.M{"_id": bson.M{"$in": idList}})
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	storedMap := make(map[primitive.ObjectID]T, len(storedList))
+	for _, eachItem := range storedList {
+		storedMap[eachItem.GetObjectId()] = eachItem
+	}
+
+	updateList := make([]mongodbr.BulkUpdateItem, 0, len(rawList))
+	indexList := make([]int, 0, len(rawList))
+	for index, eachPatch := range patchList {
+		if isBatchItemFailed(&resultList[index]) {
+			continue
+		}
+		id, _ := primitive.ObjectIDFromHex(resultList[index].Id)
+		stored, ok := storedMap[id]
+		if !ok {
+			failBatchItem(&resultList[index], http.StatusNotFound, fmt.Errorf("not found item,id:%s", id.Hex()))
+			continue
+		}
+		updateItem, err := buildBatchUpdateItem[T](id, entityValue(&stored), eachPatch, fieldSet)
+		if err != nil {
+			failBatchItem(&resultList[index], batchPatchErrorStatus(err), err)
+			continue
+		}
+		if len(updateItem.Update) <= 0 {
+			resultList[index].Status = http.StatusOK
+			continue
+		}
+		updateList = append(updateList, *updateItem)
+		indexList = append(indexList, index)
+	}
+	if ordered && hasFailedBatchItem(resultList) {
+		skipBatchItems(resultList, indexList)
+		responsex.HandleSuccessWithData(ctx, resultList)
+		return
+	}
+	if len(updateList) <= 0 {
+		responsex.HandleSuccessWithData(ctx, resultList)
+		return
+	}
+
+	result, err := service.BulkUpdateById(updateList, ordered)
+	applyBatchWriteResult(resultList, indexList, err, ordered, http.StatusOK)
+	if err == nil && result != nil && result.MatchedCount < int64(len(updateList)) {
+		// some items are changed or deleted by other request between the read and the write
+		checkBatchUpdateMatched(service, resultList, updateList, indexList)
+	}
+	updatedList := updatedIdList(resultList, updateList, indexList)
+	auditEntityUpdateList[T](ctx.Request().Context(), audit.Get(ctx), GetTenantId(ctx), service, updatedList, batchAuditSnapshots(storedMap))
+	publishEntityUpdateList(ctx.Request().Context(), c.changeFeed, service, updatedList)
+	responsex.HandleSuccessWithData(ctx, resultList)
+}
+
+// translate the patch of an item to update,the patched item is validated
+func buildBatchUpdateItem[T mongodbr.IEntity](id primitive.ObjectID, stored interface{},
+	patchObject map[string]json.RawMessage, fieldSet *mongodbr.EntityFieldSet) (*mongodbr.BulkUpdateItem, error) {
+	updateItem := &mongodbr.BulkUpdateItem{Id: id}
+	if versioned, ok := stored.(mongodbr.IVersionedEntity); ok {
+		version := versioned.GetVersion()
+		if v, ok := patchObject[mongodbr.VersionFieldName]; ok {
+			if err := json.Unmarshal(v, &version); err != nil {
+				return nil, fmt.Errorf("invalid %s,%w", mongodbr.VersionFieldName, err)
+			}
+			delete(patchObject, mongodbr.VersionFieldName)
+		}
+		updateItem.Version = &version
+	}
+	// the other readonly fields are rejected by MergePatchToUpdate as the patch of single item
+	data, err := json.Marshal(patchObject)
+	if err != nil {
+		return nil, err
+	}
+
+	storedJson, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := patch.ApplyMergePatch(storedJson, data)
+	if err != nil {
+		return nil, err
+	}
+	patchedItem := new(T)
+	if err := json.Unmarshal(patched, patchedItem); err != nil {
+		return nil, err
+	}
+	if err := mongodbr.Validate(entityValue(patchedItem)); err != nil {
+		return nil, err
+	}
+
+	current, err := mongodbr.ToBsonMap(stored)
+	if err != nil {
+		return nil, err
+	}
+	updateItem.Update, err = patch.MergePatchToUpdate(data, fieldSet, current)
+	if err != nil {
+		return nil, err
+	}
+	return updateItem, nil
+}
+
+// snapshots of the stored items before the batch update
+func batchAuditSnapshots[T mongodbr.IEntity](storedMap map[primitive.ObjectID]T) map[primitive.ObjectID]interface{} {
+	snapshotMap := make(map[primitive.ObjectID]interface{}, len(storedMap))
+	for id, stored := range storedMap {
+		stored := stored
+		snapshotMap[id] = entityValue(&stored)
+	}
+	return snapshotMap
+}
+
+// find the items that are not matched by the bulk write
+func checkBatchUpdateMatched[T mongodbr.IEntity](service entity.IEntityService[T], resultList []entity.BatchItemResult, updateList []mongodbr.BulkUpdateItem, indexList []int) {
+	idList := make([]primitive.ObjectID, 0, len(updateList))
+	for _, eachItem := range updateList {
+		idList = append(idList, eachItem.Id)
+	}
+	storedList, err := service.FindList(bson.M{"_id": bson.M{"$in": idList}})
+	if err != nil {
+		return
+	}
+	storedMap := make(map[primitive.ObjectID]interface{}, len(storedList))
+	for i := range storedList {
+		storedMap[storedList[i].GetObjectId()] = entityValue(&storedList[i])
+	}
+	for i, eachItem := range updateList {
+		stored, ok := storedMap[eachItem.Id]
+		if !ok {
+			failBatchItem(&resultList[indexList[i]], http.StatusNotFound, fmt.Errorf("not found item,id:%s", eachItem.Id.Hex()))
+			continue
+		}
+		versioned, ok := stored.(mongodbr.IVersionedEntity)
+		if ok && eachItem.Version != nil && versioned.GetVersion() != *eachItem.Version+1 {
+			failBatchItem(&resultList[indexList[i]], http.StatusConflict, fmt.Errorf("%w,id:%s", mongodbr.ErrConcurrencyConflict, eachItem.Id.Hex()))
+		}
+	}
+}
+
+// id of the items that are updated by the bulk write
+func updatedIdList(resultList []entity.BatchItemResult, updateList []mongodbr.BulkUpdateItem, indexList []int) []primitive.ObjectID {
+	idList := make([]primitive.ObjectID, 0, len(updateList))
+	for i, eachItem := range updateList {
+		if !isBatchItemFailed(&resultList[indexList[i]]) {
+			idList = append(idList, eachItem.Id)
+		}
+	}
+	return idList
+}
+
+// read the json array body,write a bad request response if it is invalid
+func (c *EntityController[T]) readBatchBody(ctx iris.Context) ([]json.RawMessage, bool) {
+	rawList := make([]json.RawMessage, 0)
+	if err := ctx.ReadJSON(&rawList); err != nil {
+		responsex.HandleErrorBadRequest(ctx, fmt.Errorf("body must be a json array,%w", err))
+		return nil, false
+	}
+	if len(rawList) <= 0 {
+		responsex.HandleAppError(ctx, ErrCodeBodyRequired.New(""))
+		return nil, false
+	}
+	if err := checkBatchSize(len(rawList), c.Options.BatchMaxSize); err != nil {
+		responsex.HandleError(http.StatusRequestEntityTooLarge, ctx, err)
+		return nil, false
+	}
+	return rawList, true
+}
+
+// BatchMaxSize is DefaultBatchMaxSize if it is not set
+func checkBatchSize(count int, maxSize int) error {
+	if maxSize <= 0 {
+		maxSize = DefaultBatchMaxSize
+	}
+	if count > maxSize {
+		return fmt.Errorf("too many items,max item count is %d", maxSize)
+	}
+	return nil
+}
+
+// query ordered,default is true
+func isBatchOrdered(ctx iris.Context) bool {
+	return ctx.URLParamBoolDefault("ordered", true)
+}
+
+func newBatchResultList(count int) []entity.BatchItemResult {
+	resultList := make([]entity.BatchItemResult, count)
+	for index := range resultList {
+		resultList[index].Index = index
+	}
+	return resultList
+}
+
+func failBatchItem(result *entity.BatchItemResult, status int, err error) {
+	result.Status = status
+	result.Error = err.Error()
+}
+
+func isBatchItemFailed(result *entity.BatchItemResult) bool {
+	return result.Status >= http.StatusBadRequest
+}
+
+func hasFailedBatchItem(resultList []entity.BatchItemResult) bool {
+	for _, eachItem := range resultList {
+		if isBatchItemFailed(&eachItem) {
+			return true
+		}
+	}
+	return false
+}
+
+// mark the valid items as skipped,they are not written because of the invalid items
+func skipBatchItems(resultList []entity.BatchItemResult, indexList []int) {
+	for _, index := range indexList {
+		failBatchItem(&resultList[index], http.StatusFailedDependency, errors.New("skipped,the batch contains failed item"))
+	}
+}
+
+// set the status of written items,indexList is the position in request of each write model
+func applyBatchWriteResult(resultList []entity.BatchItemResult, indexList []int, err error, ordered bool, successStatus int) {
+	if err == nil {
+		for _, index := range indexList {
+			resultList[index].Status = successStatus
+		}
+		return
+	}
+	errorMap, ok := mongodbr.BulkWriteErrorMap(err)
+	if !ok || len(errorMap) <= 0 {
+		for _, index := range indexList {
+			failBatchItem(&resultList[index], http.StatusInternalServerError, err)
+		}
+		return
+	}
+	failed := false
+	for i, index := range indexList {
+		if writeErr, ok := errorMap[i]; ok {
+			failBatchItem(&resultList[index], batchWriteErrorStatus(writeErr), writeErr)
+			failed = true
+			continue
+		}
+		if ordered && failed {
+			failBatchItem(&resultList[index], http.StatusFailedDependency, errors.New("skipped,a previous item is failed"))
+			continue
+		}
+		resultList[index].Status = successStatus
+	}
+}
+
+func batchWriteErrorStatus(err error) int {
+	return responsex.ToAppError(http.StatusInternalServerError, err).Status
+}
+
+func batchPatchErrorStatus(err error) int {
+	return responsex.ToAppError(http.StatusBadRequest, err).Status
+}
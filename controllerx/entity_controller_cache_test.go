@@ -0,0 +1,157 @@
+package controllerx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/openapi"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsecache"
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type testOrder struct {
+	mongodbr.Entity          `bson:",inline"`
+	mongodbr.VersionedEntity `bson:",inline"`
+	Name                     string `json:"name" bson:"name"`
+}
+
+// service of one order,the version is increased by update
+type testOrderService struct {
+	entity.IEntityService[*testOrder]
+	item      *testOrder
+	findCount int
+}
+
+func (s *testOrderService) WithContext(ctx context.Context) entity.IEntityService[*testOrder] {
+	return s
+}
+
+func (s *testOrderService) FindById(id primitive.ObjectID, opts ...mongodbr.FindOneOption) (**testOrder, error) {
+	s.findCount++
+	if id != s.item.ObjectId {
+		return nil, nil
+	}
+	item := *s.item
+	value := &item
+	return &value, nil
+}
+
+func (s *testOrderService) FindList(filter interface{}, opts ...mongodbr.FindOption) ([]*testOrder, error) {
+	item := *s.item
+	return []*testOrder{&item}, nil
+}
+
+func (s *testOrderService) UpdateByIdWithVersion(id primitive.ObjectID, version int64, update interface{}) error {
+	if version != s.item.Version {
+		return mongodbr.ErrConcurrencyConflict
+	}
+	if name, ok := update.(bson.M)["$set"].(bson.M)["name"].(string); ok {
+		s.item.Name = name
+	}
+	s.item.Version++
+	return nil
+}
+
+func TestEntityControllerCacheKeepsVersionETag(t *testing.T) {
+	useTestLogger()
+	service := &testOrderService{item: &testOrder{Entity: mongodbr.Entity{ObjectId: primitive.NewObjectID()}, VersionedEntity: mongodbr.VersionedEntity{Version: 1}}}
+	c := &EntityController[*testOrder]{EntityService: service}
+	c.Options.AuthenticatedDisabled = true
+	c.responseCache = responsecache.NewCache(responsecache.WithStore(responsecache.NewMemoryStore(10)))
+	app := iris.New()
+	party := app.Party("/orders")
+	c.handle(party, http.MethodGet, "/{id}", openapi.EntityActionGetById, c.GetById)
+	c.handle(party, http.MethodPatch, "/{id}", openapi.EntityActionPatch, c.Patch)
+	assert.NoError(t, app.Build())
+
+	target := "/orders/" + service.item.ObjectId.Hex()
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+	patch := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, target, strings.NewReader(`{"name":"new"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set("If-Match", etag)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	// the version ETag is replayed by the cached response
+	w = get()
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	assert.Equal(t, 1, service.findCount)
+
+	// the ETag of cached response is accepted by If-Match
+	w = patch(w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "new", service.item.Name)
+
+	// the stale ETag is rejected
+	w = patch(`"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	// the patch evicts the cached response
+	w = get()
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+}
+
+func TestGinEntityControllerCacheKeepsVersionETag(t *testing.T) {
+	useTestLogger()
+	gin.SetMode(gin.TestMode)
+	service := &testOrderService{item: &testOrder{Entity: mongodbr.Entity{ObjectId: primitive.NewObjectID()}, VersionedEntity: mongodbr.VersionedEntity{Version: 1}}}
+	c := &GinEntityController[*testOrder]{EntityService: service}
+	c.Options.AuthenticatedDisabled = true
+	c.responseCache = responsecache.NewCache(responsecache.WithStore(responsecache.NewMemoryStore(10)))
+	engine := gin.New()
+	group := engine.Group("/gin/orders")
+	c.handle(group, http.MethodGet, "/:id", openapi.EntityActionGetById, c.GetById)
+	c.handle(group, http.MethodPatch, "/:id", openapi.EntityActionPatch, c.Patch)
+
+	target := "/gin/orders/" + service.item.ObjectId.Hex()
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+	w := get()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	w = get()
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+	assert.Equal(t, 1, service.findCount)
+
+	req := httptest.NewRequest(http.MethodPatch, target, strings.NewReader(`{"name":"new"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = get()
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	// the routes are described in the OpenAPI document
+	doc := openapi.GenerateGin(engine.Routes())
+	assert.Contains(t, doc.Paths, "/gin/orders/{id}")
+	assert.Equal(t, []string{"testOrder"}, (*doc.Paths["/gin/orders/{id}"])["patch"].Tags)
+}
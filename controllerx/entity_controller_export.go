@@ -0,0 +1,156 @@
+package controllerx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/entity/filter"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+// interval of removing expired export files and records
+const exportCleanupInterval = time.Minute * 10
+
+// body of POST /export,all fields are optional
+type ExportRequest struct {
+	// csv,xlsx,ndjson or parquet,query type is used if not set
+	Type                 string            `json:"type"`
+	FieldNameList        []string          `json:"fieldNameList"`
+	FieldNameTitleMap    map[string]string `json:"fieldNameTitleMap"`
+	ExcludeFieldNameList []string          `json:"excludeFieldNameList"`
+}
+
+func (c *EntityController[T]) GetExportService() entity.IEntityExportService[T] {
+	c.exportOnce.Do(func() {
+		if c.ExportService == nil {
+			c.ExportService = GetEntityExportService[T](c.GetEntityService().GetRepository())
+		}
+		startCleanup(c.ExportService.GetStore(), c.ExportService.RunCleanup, exportCleanupInterval)
+	})
+	return c.ExportService
+}
+
+// start an async export,conditions and sort are the same as GetList
+func (c *EntityController[T]) Export(ctx iris.Context) {
+	input := &ExportRequest{}
+	if ctx.GetContentLength() > 0 {
+		if err := ctx.ReadJSON(input); err != nil {
+			responsex.HandleErrorBadRequest(ctx, err)
+			return
+		}
+	}
+	query := filter.MustGetFilterQuery(ctx.FormValue)
+	sort := filter.MustGetSortOption(ctx.FormValue)
+	if !c.Options.FilterCurrentUserForListDisabled {
+		// auto filter current userId
+		AddUserIdFilterIfNeed(query, new(T), ctx)
+	}
+
+	tenantId, ok := c.getRequestTenantId(ctx)
+	if !ok {
+		return
+	}
+	if scope := getPermissionScope(ctx); scope != nil {
+		query = map[string]interface{}{"$and": []interface{}{query, scope}}
+	}
+
+	exportType := input.Type
+	if len(exportType) <= 0 {
+		exportType = ctx.URLParamDefault("type", entity.ExportType_CSV)
+	}
+	service := c.GetExportService()
+	exportId, err := service.Export(entity.ExportOptions{
+		Type:                 exportType,
+		Filter:               query,
+		Sort:                 sort,
+		Async:                true,
+		CreatorId:            GetUserId(ctx),
+		TenantId:             tenantId,
+		FieldNameList:        input.FieldNameList,
+		FieldNameTitleMap:    input.FieldNameTitleMap,
+		ExcludeFieldNameList: input.ExcludeFieldNameList,
+	})
+	if err != nil {
+		// invalid type or options are 400 by mapEntityError,count and store errors are 500
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	export, err := service.GetExport(exportId)
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	responsex.HandleSuccessWithData(ctx, export)
+}
+
+// status and rows written of an export
+func (c *EntityController[T]) GetExport(ctx iris.Context) {
+	export, ok := c.findExport(ctx)
+	if !ok {
+		return
+	}
+	responsex.HandleSuccessWithData(ctx, export)
+}
+
+// download the file of a finished export
+func (c *EntityController[T]) DownloadExport(ctx iris.Context) {
+	export, ok := c.findExport(ctx)
+	if !ok {
+		return
+	}
+	if export.Status != entity.ExportStatus_Finished {
+		responsex.HandleError(http.StatusConflict, ctx, fmt.Errorf("export is not finished,status:%s", export.Status))
+		return
+	}
+	file, err := c.GetExportService().OpenFile(export)
+	if err != nil {
+		if errors.Is(err, entity.ErrExportFileNotFound) {
+			responsex.HandleErrorNotFound(ctx, fmt.Errorf("export file not found,id:%s", export.Id))
+			return
+		}
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	defer file.Close()
+	ctx.ContentType(exportContentType(export.FileName))
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": export.FileName}))
+	if _, err := io.Copy(ctx.ResponseWriter(), file); err != nil {
+		log.Logger.Warn(fmt.Sprintf("download export error (id: %s),err: %s", export.Id, err.Error()))
+	}
+}
+
+// content type by the extension of export file
+func exportContentType(fileName string) string {
+	if contentType := mime.TypeByExtension(path.Ext(fileName)); len(contentType) > 0 {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// only the user who starts the export and admin can read it
+func (c *EntityController[T]) findExport(ctx iris.Context) (*entity.EntityExport, bool) {
+	exportId := ctx.Params().Get("id")
+	export, err := c.GetExportService().GetExport(exportId)
+	if err != nil {
+		if errors.Is(err, entity.ErrExportNotFound) {
+			responsex.HandleErrorNotFound(ctx, fmt.Errorf("export not found,id:%s", exportId))
+			return nil, false
+		}
+		responsex.HandleAppError(ctx, err)
+		return nil, false
+	}
+	if (len(export.CreatorId) > 0 && export.CreatorId != GetUserId(ctx) && !IsAdmin(ctx)) ||
+		!c.isTenantAccessible(ctx, export.TenantId) {
+		responsex.HandleErrorNotFound(ctx, fmt.Errorf("export not found,id:%s", exportId))
+		return nil, false
+	}
+	return export, true
+}
@@ -0,0 +1,152 @@
+package controllerx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/audit"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/entity"
+)
+
+// max size of uploaded import file if BaseEntityControllerOptions.ImportMaxFileSize is not set
+const DefaultImportMaxFileSize int64 = 32 << 20
+
+func (c *EntityController[T]) GetImportService() entity.IEntityImportService[T] {
+	c.importOnce.Do(func() {
+		if c.ImportService == nil {
+			c.ImportService = GetEntityImportService[T](c.GetEntityService().GetRepository())
+		}
+		startCleanup(c.ImportService.GetStore(), c.ImportService.RunCleanup, exportCleanupInterval)
+	})
+	return c.ImportService
+}
+
+// start an async import,the body is a multipart form,
+// file is the uploaded file,the other fields are the same as entity.ImportOptions.
+// keyFieldNameList is separated by comma and columnFieldMap is a json object
+func (c *EntityController[T]) Import(ctx iris.Context) {
+	tenantId, ok := c.getRequestTenantId(ctx)
+	if !ok {
+		return
+	}
+	// rows are matched by key fields,the conditions of permission cannot be checked
+	if getPermissionScope(ctx) != nil {
+		responsex.HandleAppError(ctx, ErrCodeImportConditionalPermission.New(""))
+		return
+	}
+	maxFileSize := c.Options.ImportMaxFileSize
+	if maxFileSize <= 0 {
+		maxFileSize = DefaultImportMaxFileSize
+	}
+	ctx.SetMaxRequestBodySize(maxFileSize)
+	file, header, err := ctx.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			responsex.HandleError(http.StatusRequestEntityTooLarge, ctx, err)
+			return
+		}
+		responsex.HandleErrorBadRequest(ctx, err)
+		return
+	}
+	defer file.Close()
+
+	options := entity.ImportOptions{
+		Type:      ctx.PostValueDefault("type", ""),
+		Mode:      ctx.PostValueDefault("mode", entity.ImportMode_Insert),
+		BatchSize: ctx.PostValueIntDefault("batchSize", 0),
+		Async:     true,
+		CreatorId: GetUserId(ctx),
+		TenantId:  tenantId,
+		FileName:  header.Filename,
+	}
+	if dryRun, err := ctx.PostValueBool("dryRun"); err == nil {
+		options.DryRun = dryRun
+	}
+	if keyFieldNameList := ctx.PostValueDefault("keyFieldNameList", ""); len(keyFieldNameList) > 0 {
+		options.KeyFieldNameList = strings.Split(keyFieldNameList, ",")
+	}
+	if columnFieldMap := ctx.PostValueDefault("columnFieldMap", ""); len(columnFieldMap) > 0 {
+		if err := json.Unmarshal([]byte(columnFieldMap), &options.ColumnFieldMap); err != nil {
+			responsex.HandleErrorBadRequest(ctx, fmt.Errorf("invalid columnFieldMap,err:%s", err.Error()))
+			return
+		}
+	}
+
+	if record := audit.Get(ctx); record != nil {
+		options.ItemWrittenFunc = auditImportedItems[T](ctx.Request().Context(), &c.Options, record, fwauth.GetIrisPrincipal(ctx), tenantId)
+	}
+
+	service := c.GetImportService()
+	importId, err := service.Import(file, options)
+	if err != nil {
+		// invalid type or options are 400 by mapEntityError,file and store errors are 500
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	entityImport, err := service.GetImport(importId)
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	responsex.HandleSuccessWithData(ctx, entityImport)
+}
+
+// status,counts and the first errors of an import
+func (c *EntityController[T]) GetImport(ctx iris.Context) {
+	entityImport, ok := c.findImport(ctx)
+	if !ok {
+		return
+	}
+	responsex.HandleSuccessWithData(ctx, entityImport)
+}
+
+// download the error report of a finished import
+func (c *EntityController[T]) DownloadImportReport(ctx iris.Context) {
+	entityImport, ok := c.findImport(ctx)
+	if !ok {
+		return
+	}
+	if entityImport.Status == entity.ImportStatus_Running {
+		responsex.HandleError(http.StatusConflict, ctx, fmt.Errorf("import is not finished,status:%s", entityImport.Status))
+		return
+	}
+	if len(entityImport.ReportPath) <= 0 {
+		responsex.HandleErrorNotFound(ctx, fmt.Errorf("import has no error,id:%s", entityImport.Id))
+		return
+	}
+	if _, err := os.Stat(entityImport.ReportPath); err != nil {
+		responsex.HandleErrorNotFound(ctx, fmt.Errorf("import report not found,id:%s", entityImport.Id))
+		return
+	}
+	if err := ctx.SendFile(entityImport.ReportPath, entityImport.ReportFileName); err != nil {
+		responsex.HandleAppError(ctx, err)
+	}
+}
+
+// only the user who starts the import and admin can read it
+func (c *EntityController[T]) findImport(ctx iris.Context) (*entity.EntityImport, bool) {
+	importId := ctx.Params().Get("id")
+	entityImport, err := c.GetImportService().GetImport(importId)
+	if err != nil {
+		if errors.Is(err, entity.ErrImportNotFound) {
+			responsex.HandleErrorNotFound(ctx, fmt.Errorf("import not found,id:%s", importId))
+			return nil, false
+		}
+		responsex.HandleAppError(ctx, err)
+		return nil, false
+	}
+	if (len(entityImport.CreatorId) > 0 && entityImport.CreatorId != GetUserId(ctx) && !IsAdmin(ctx)) ||
+		!c.isTenantAccessible(ctx, entityImport.TenantId) {
+		responsex.HandleErrorNotFound(ctx, fmt.Errorf("import not found,id:%s", importId))
+		return nil, false
+	}
+	return entityImport, true
+}
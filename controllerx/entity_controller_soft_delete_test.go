@@ -0,0 +1,124 @@
+package controllerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/openapi"
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.uber.org/zap"
+)
+
+type testNote struct {
+	mongodbr.Entity           `bson:",inline"`
+	mongodbr.SoftDeleteEntity `bson:",inline"`
+	Name                      string `json:"name" bson:"name"`
+}
+
+type testVersionedNote struct {
+	mongodbr.Entity           `bson:",inline"`
+	mongodbr.SoftDeleteEntity `bson:",inline"`
+	mongodbr.VersionedEntity  `bson:",inline"`
+	Name                      string `json:"name" bson:"name"`
+}
+
+// log to stdout,the default logger writes rotating files to the log directory of package
+func useTestLogger() {
+	log.Logger = zap.NewExample()
+}
+
+func TestEntityControllerWriteSoftDeleted(t *testing.T) {
+	useTestLogger()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("write", func(mt *mtest.T) {
+		repository, err := mongodbr.NewRepositoryBase(func() *mongo.Collection { return mt.Coll }, mongodbr.WithSoftDelete())
+		assert.NoError(mt, err)
+		c := &EntityController[*testNote]{EntityService: entity.NewEntityService[*testNote](repository)}
+		c.Options.AuthenticatedDisabled = true
+		app := iris.New()
+		party := app.Party("/notes")
+		c.handle(party, http.MethodPut, "/{id}", openapi.EntityActionUpdate, c.Update)
+		c.handle(party, http.MethodPatch, "/{id}", openapi.EntityActionPatch, c.Patch)
+		assert.NoError(mt, app.Build())
+
+		id := primitive.NewObjectID()
+		target := "/notes/" + id.Hex()
+		send := func(method string, body string, contentType string) int {
+			req := httptest.NewRequest(method, target, strings.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			return w.Code
+		}
+		// the item is soft deleted after it is read,so nothing is matched by the write
+		stored := bson.D{{Key: "_id", Value: id}, {Key: "name", Value: "a"}}
+		notDeleted := bson.M{mongodbr.SoftDeleteFieldIsDeleted: bson.M{"$ne": true}}
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.notes", mtest.FirstBatch, stored),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+		assert.Equal(mt, http.StatusNotFound, send(http.MethodPut, `{"name":"b","isDeleted":true}`, "application/json"))
+		mt.GetStartedEvent()
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(mt, notDeleted[mongodbr.SoftDeleteFieldIsDeleted], toBsonM(mt, update.Lookup("q").Document())[mongodbr.SoftDeleteFieldIsDeleted])
+		// the soft delete fields of body are replaced by the stored fields
+		assert.Equal(mt, false, toBsonM(mt, update.Lookup("u").Document())[mongodbr.SoftDeleteFieldIsDeleted])
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.notes", mtest.FirstBatch, stored),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}))
+		assert.Equal(mt, http.StatusNotFound, send(http.MethodPatch, `{"name":"b"}`, "application/merge-patch+json"))
+		mt.GetStartedEvent()
+		query := mt.GetStartedEvent().Command.Lookup("query").Document()
+		assert.Equal(mt, notDeleted[mongodbr.SoftDeleteFieldIsDeleted], toBsonM(mt, query)[mongodbr.SoftDeleteFieldIsDeleted])
+	})
+}
+
+func TestEntityControllerPurgeWithVersion(t *testing.T) {
+	useTestLogger()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("purge", func(mt *mtest.T) {
+		repository, err := mongodbr.NewRepositoryBase(func() *mongo.Collection { return mt.Coll }, mongodbr.WithSoftDelete())
+		assert.NoError(mt, err)
+		c := &EntityController[*testVersionedNote]{EntityService: entity.NewEntityService[*testVersionedNote](repository)}
+		c.Options.AuthenticatedDisabled = true
+		c.Options.HardDeleteAuthorizeFunc = func(ctx iris.Context) bool { return true }
+		app := iris.New()
+		party := app.Party("/notes")
+		c.handle(party, http.MethodDelete, "/{id}", openapi.EntityActionDelete, c.Delete)
+		assert.NoError(mt, app.Build())
+
+		id := primitive.NewObjectID()
+		stored := bson.D{{Key: "_id", Value: id}, {Key: "name", Value: "a"}, {Key: mongodbr.VersionFieldName, Value: int64(3)}}
+		// the version is changed after the item is read
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.notes", mtest.FirstBatch, stored),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+			mtest.CreateCursorResponse(0, "db.notes", mtest.FirstBatch, bson.D{{Key: "n", Value: int32(1)}}))
+		req := httptest.NewRequest(http.MethodDelete, "/notes/"+id.Hex()+"?hard=true", nil)
+		req.Header.Set(headerIfMatch, `"3"`)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		assert.Equal(mt, http.StatusPreconditionFailed, w.Code)
+
+		mt.GetStartedEvent()
+		deletion := mt.GetStartedEvent().Command.Lookup("deletes").Array().Index(0).Value().Document()
+		assert.Equal(mt, int64(3), toBsonM(mt, deletion.Lookup("q").Document())[mongodbr.VersionFieldName])
+	})
+}
+
+func toBsonM(t assert.TestingT, raw bson.Raw) bson.M {
+	m := bson.M{}
+	assert.NoError(t, bson.Unmarshal(raw, &m))
+	return m
+}
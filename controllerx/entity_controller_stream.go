@@ -0,0 +1,359 @@
+package controllerx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/entity/changefeed"
+	"github.com/shanluzhineng/fwpkg/entity/filter"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// interval of the heartbeat of change stream if BaseEntityControllerOptions.StreamHeartbeat is not set
+const DefaultStreamHeartbeat = time.Second * 15
+
+const (
+	headerLastEventId = "Last-Event-ID"
+	// used by websocket and the clients that cannot set the header
+	queryLastEventId = "lastEventId"
+
+	// timeout of writing a websocket message
+	streamWriteTimeout = time.Second * 10
+)
+
+// the origin of websocket request must be the same as the host
+var streamUpgrader = websocket.Upgrader{}
+
+// a change of item,it is the data of server-sent event and the message of websocket
+type StreamMessage struct {
+	Id string `json:"id"`
+	// create,update or delete
+	Event      string    `json:"event"`
+	DocumentId string    `json:"documentId"`
+	Time       time.Time `json:"time"`
+	// the item after the change,it is the item before deletion for delete event.
+	// nil if it is not available,e.g. the item is deleted permanently and the pre-image of change stream is not enabled
+	Item interface{} `json:"item,omitempty"`
+}
+
+type streamWriter interface {
+	WriteMessage(message *StreamMessage) error
+	WriteHeartbeat() error
+}
+
+// #region stream handlers
+
+// push the changes of items as server-sent events,the items are filtered by conditions
+// and by the tenant,user and permission of current request as GetList does.
+// the event name is create,update or delete and the data is the json of StreamMessage,
+// the subscription resumes after Last-Event-ID header or query lastEventId
+func (c *EntityController[T]) Stream(ctx iris.Context) {
+	requestCtx := ctx.Request().Context()
+	query, eventChan, ok := c.subscribeChanges(ctx, requestCtx)
+	if !ok {
+		return
+	}
+	writer, err := newSSEStreamWriter(ctx.ResponseWriter())
+	if err != nil {
+		responsex.HandleAppError(ctx, err)
+		return
+	}
+	pushChangeEvents[T](requestCtx, eventChan, query, streamHeartbeat(&c.Options), writer)
+}
+
+// websocket version of Stream,each message is the json of StreamMessage
+// and the subscription resumes after query lastEventId
+func (c *EntityController[T]) StreamWs(ctx iris.Context) {
+	streamCtx, cancel := context.WithCancel(ctx.Request().Context())
+	defer cancel()
+	query, eventChan, ok := c.subscribeChanges(ctx, streamCtx)
+	if !ok {
+		return
+	}
+	// the error response is written by upgrader
+	conn, err := streamUpgrader.Upgrade(ctx.ResponseWriter(), ctx.Request(), nil)
+	if err != nil {
+		return
+	}
+	serveStreamWebSocket[T](streamCtx, cancel, conn, eventChan, query, streamHeartbeat(&c.Options))
+}
+
+// subscribe the changes of items visible to current request,
+// an error response is written if the conditions or Last-Event-ID is invalid
+func (c *EntityController[T]) subscribeChanges(ctx iris.Context, streamCtx context.Context) (map[string]interface{}, <-chan *changefeed.ChangeEvent, bool) {
+	query, err := filter.GetFilterQuery(ctx.URLParam)
+	if err != nil {
+		responsex.HandleErrorBadRequest(ctx, err)
+		return nil, nil, false
+	}
+	if query == nil {
+		query = map[string]interface{}{}
+	}
+	if !c.Options.FilterCurrentUserForListDisabled {
+		// auto filter current userId
+		AddUserIdFilterIfNeed(query, new(T), ctx)
+	}
+	if c.Options.ListFilterFunc != nil {
+		c.Options.ListFilterFunc(new(T), query, ctx)
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return nil, nil, false
+	}
+	repository := service.GetRepository()
+	eventChan, err := c.changeFeed.Subscribe(streamCtx, repository.GetCollection(), service.GetTenantId(), getLastEventId(ctx.GetHeader, ctx.URLParam))
+	if err != nil {
+		if errors.Is(err, changefeed.ErrInvalidEventId) {
+			responsex.HandleErrorBadRequest(ctx, err)
+			return nil, nil, false
+		}
+		responsex.HandleAppError(ctx, err)
+		return nil, nil, false
+	}
+	return streamQuery(repository.GetScope(), query), eventChan, true
+}
+
+// #endregion
+
+// the feed of stream routes,nil if they are not enabled
+func streamFeed(options *BaseEntityControllerOptions) *changefeed.Feed {
+	if !options.StreamEnabled {
+		return nil
+	}
+	if options.StreamFeed != nil {
+		return options.StreamFeed
+	}
+	return changefeed.GetDefaultFeed()
+}
+
+func streamHeartbeat(options *BaseEntityControllerOptions) time.Duration {
+	if options.StreamHeartbeat > 0 {
+		return options.StreamHeartbeat
+	}
+	return DefaultStreamHeartbeat
+}
+
+// Last-Event-ID header is sent by the browser when EventSource reconnects
+func getLastEventId(getHeader func(key string) string, getQuery func(key string) string) string {
+	if lastEventId := getHeader(headerLastEventId); len(lastEventId) > 0 {
+		return lastEventId
+	}
+	return getQuery(queryLastEventId)
+}
+
+// the scope of repository is the tenant and permission conditions of current request
+func streamQuery(scope bson.M, query map[string]interface{}) map[string]interface{} {
+	if len(scope) <= 0 {
+		return query
+	}
+	if len(query) <= 0 {
+		return scope
+	}
+	return map[string]interface{}{"$and": []interface{}{map[string]interface{}(scope), query}}
+}
+
+// write the events matched by query until ctx is done,the event channel is closed or the write fails
+func pushChangeEvents[T mongodbr.IEntity](ctx context.Context, eventChan <-chan *changefeed.ChangeEvent, query map[string]interface{},
+	heartbeat time.Duration, writer streamWriter) {
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := writer.WriteHeartbeat(); err != nil {
+				return
+			}
+		case event, ok := <-eventChan:
+			if !ok {
+				// the subscriber is too slow,the client resumes from the last received event
+				return
+			}
+			message, err := toStreamMessage[T](event, query)
+			if err != nil {
+				log.FromContext(ctx).Warn(fmt.Sprintf("change event decode fail,id:%s,err:%s", event.Id, err.Error()))
+				continue
+			}
+			if message == nil {
+				continue
+			}
+			if err := writer.WriteMessage(message); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// nil if the event is not matched by query
+func toStreamMessage[T mongodbr.IEntity](event *changefeed.ChangeEvent, query map[string]interface{}) (*StreamMessage, error) {
+	message := &StreamMessage{
+		Id:         event.Id,
+		Event:      event.Operation,
+		DocumentId: event.DocumentId,
+		Time:       event.Time,
+	}
+	if event.Document == nil {
+		// the item cannot be checked,only the subscriber of all items receives it
+		if len(query) > 0 {
+			return nil, nil
+		}
+		return message, nil
+	}
+	if !changefeed.Match(query, event.Document) {
+		return nil, nil
+	}
+	data, err := bson.Marshal(event.Document)
+	if err != nil {
+		return nil, err
+	}
+	item := new(T)
+	if err := bson.Unmarshal(data, item); err != nil {
+		return nil, err
+	}
+	message.Item = entityValue(item)
+	return message, nil
+}
+
+// #region stream writers
+
+type sseStreamWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// write the headers of event stream
+func newSSEStreamWriter(w http.ResponseWriter) (*sseStreamWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("streaming is not supported by the response writer")
+	}
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// disable the buffering of nginx
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &sseStreamWriter{w: w, flusher: flusher}, nil
+}
+
+func (s *sseStreamWriter) WriteMessage(message *StreamMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "id: %s\nevent: %s\ndata: %s\n\n", message.Id, message.Event, data); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// comment line,it keeps the connection alive through the proxies
+func (s *sseStreamWriter) WriteHeartbeat() error {
+	if _, err := io.WriteString(s.w, ": heartbeat\n\n"); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+type wsStreamWriter struct {
+	conn *websocket.Conn
+}
+
+func (s *wsStreamWriter) WriteMessage(message *StreamMessage) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		return err
+	}
+	return s.conn.WriteJSON(message)
+}
+
+func (s *wsStreamWriter) WriteHeartbeat() error {
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout))
+}
+
+// push the events to websocket connection until the client closes it,
+// the messages from client are discarded
+func serveStreamWebSocket[T mongodbr.IEntity](ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn,
+	eventChan <-chan *changefeed.ChangeEvent, query map[string]interface{}, heartbeat time.Duration) {
+	defer conn.Close()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+	pushChangeEvents[T](ctx, eventChan, query, heartbeat, &wsStreamWriter{conn: conn})
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(streamWriteTimeout))
+}
+
+// #endregion
+
+// #region publish
+
+// the changes are published by the controller if the stream is enabled and change streams are not available
+func needPublishEntityChange[T mongodbr.IEntity](feed *changefeed.Feed, service entity.IEntityService[T]) bool {
+	return feed != nil && !feed.UsesChangeStream(service.GetRepository().GetCollection())
+}
+
+// publish a change of item to the in-process bus of feed,the item is loaded by id if it is nil
+func publishEntityChange[T mongodbr.IEntity](ctx context.Context, feed *changefeed.Feed, service entity.IEntityService[T],
+	operation string, id primitive.ObjectID, item interface{}) {
+	if !needPublishEntityChange(feed, service) {
+		return
+	}
+	repository := service.GetRepository()
+	if item == nil {
+		stored, err := mongodbr.FindTByObjectId[T](repository.WithDeleted(), id)
+		if err != nil {
+			log.FromContext(ctx).Warn(fmt.Sprintf("change publish fail,id:%s,err:%s", id.Hex(), err.Error()))
+			return
+		}
+		if stored == nil {
+			return
+		}
+		item = entityValue(stored)
+	}
+	document, err := mongodbr.ToBsonMap(item)
+	if err != nil {
+		log.FromContext(ctx).Warn(fmt.Sprintf("change publish fail,id:%s,err:%s", id.Hex(), err.Error()))
+		return
+	}
+	feed.Publish(repository.GetCollection(), operation, id.Hex(), document)
+}
+
+// publish the updates of items,the items are loaded by one query
+func publishEntityUpdateList[T mongodbr.IEntity](ctx context.Context, feed *changefeed.Feed, service entity.IEntityService[T], idList []primitive.ObjectID) {
+	if len(idList) <= 0 || !needPublishEntityChange(feed, service) {
+		return
+	}
+	list, err := service.FindList(bson.M{"_id": bson.M{"$in": idList}})
+	if err != nil {
+		log.FromContext(ctx).Warn(fmt.Sprintf("change publish fail,err:%s", err.Error()))
+		return
+	}
+	for index := range list {
+		item := entityValue(&list[index])
+		publishEntityChange(ctx, feed, service, changefeed.OperationUpdate, entityObjectId(item), item)
+	}
+}
+
+// #endregion
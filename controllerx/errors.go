@@ -0,0 +1,48 @@
+package controllerx
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/entity/patch"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+)
+
+const ErrorModuleController = "controllerx"
+
+// error codes of EntityController,messages are localized by the message keys
+var (
+	ErrCodeIdRequired                  = responsex.RegistErrorCode(ErrorModuleController, 10001, http.StatusBadRequest, "error.idRequired", "id must not be empty")
+	ErrCodeBodyRequired                = responsex.RegistErrorCode(ErrorModuleController, 10002, http.StatusBadRequest, "error.bodyRequired", "body must not be empty")
+	ErrCodeSoftDeleteDisabled          = responsex.RegistErrorCode(ErrorModuleController, 10003, http.StatusBadRequest, "error.softDeleteDisabled", "soft delete is not enabled")
+	ErrCodePermissionDenied            = responsex.RegistErrorCode(ErrorModuleController, 10004, http.StatusForbidden, "error.permissionDenied", "permission denied")
+	ErrCodeTenantUnresolved            = responsex.RegistErrorCode(ErrorModuleController, 10005, http.StatusForbidden, "error.tenantUnresolved", "tenant of current user is not resolved")
+	ErrCodeTenantBypassDenied          = responsex.RegistErrorCode(ErrorModuleController, 10006, http.StatusForbidden, "error.tenantBypassDenied", "no permission to access the items of all tenants")
+	ErrCodeHardDeleteDenied            = responsex.RegistErrorCode(ErrorModuleController, 10007, http.StatusForbidden, "error.hardDeleteDenied", "no permission to delete item permanently")
+	ErrCodeImportConditionalPermission = responsex.RegistErrorCode(ErrorModuleController, 10008, http.StatusForbidden, "error.importConditionalPermission", "import is not allowed with a conditional permission")
+)
+
+func init() {
+	responsex.RegistErrorMapper(mapEntityError)
+}
+
+// errors of entity and mongodbr
+func mapEntityError(err error) *responsex.AppError {
+	switch {
+	case errors.Is(err, mongodbr.ErrConcurrencyConflict):
+		return responsex.ErrCodePreconditionFailed.Wrap(err)
+	case errors.Is(err, entity.ErrExportNotFound), errors.Is(err, entity.ErrImportNotFound):
+		return responsex.ErrCodeNotFound.Wrap(err)
+	case errors.Is(err, mongodbr.ErrInvalidCursor),
+		errors.Is(err, entity.ErrUnsupportedExportType), errors.Is(err, entity.ErrInvalidExportOptions),
+		errors.Is(err, entity.ErrUnsupportedImportType), errors.Is(err, entity.ErrInvalidImportOptions):
+		return responsex.ErrCodeBadRequest.Wrap(err)
+	case errors.Is(err, patch.ErrTestFailed):
+		return responsex.ErrCodeConflict.Wrap(err)
+	case errors.Is(err, patch.ErrInvalidPath), errors.Is(err, patch.ErrUnsupportedOperation):
+		return responsex.ErrCodeUnprocessableEntity.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package fwauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+)
+
+func init() {
+	responsex.RegistErrorMapper(mapAuthError)
+}
+
+// errors of authentication are 401
+func mapAuthError(err error) *responsex.AppError {
+	var validationError *jwt.ValidationError
+	switch {
+	case errors.Is(err, ErrNoCredentials), errors.Is(err, ErrTokenMissing), errors.Is(err, ErrInvalidApiKey),
+		errors.As(err, &validationError):
+		return responsex.ErrCodeUnauthorized.Wrap(err)
+	}
+	return nil
+}
+
+// the request has no credentials of the authenticator,the next authenticator of chain is tried
+var ErrNoCredentials = errors.New("no credentials")
+
+// key of iris ctx.Values and gin context,the value is *Principal
+const PrincipalContextKey = "fwpkg.principal"
+
+// the authenticated user of request,it is the same for iris and gin handlers
+type Principal struct {
+	Id       string
+	Name     string
+	TenantId string
+	RoleList []string
+	// preferred locale of user,e.g. zh-CN
+	Locale string
+	// name of the authenticator that produces the principal
+	Provider string
+	// *casdoorsdk.Claims for casdoor,jwt.MapClaims for jwt,*ApiKey for api key,*x509.Certificate for mtls
+	Claims interface{}
+}
+
+// principal has the role
+func (p *Principal) HasRole(role string) bool {
+	for _, eachRole := range p.RoleList {
+		if eachRole == role {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate the request with one kind of credentials,
+// return ErrNoCredentials if the request does not carry the credentials
+type Authenticator interface {
+	Name() string
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+type authenticatorChain struct {
+	authenticatorList []Authenticator
+}
+
+// the authenticators are tried in order until one of them succeeds,several authenticators may accept
+// the same kind of credentials such as bearer token. if all of them fail,the first error is returned,
+// ErrTokenMissing is returned if no credentials are found
+func NewAuthenticatorChain(authenticatorList ...Authenticator) Authenticator {
+	return &authenticatorChain{
+		authenticatorList: authenticatorList,
+	}
+}
+
+func (c *authenticatorChain) Name() string {
+	nameList := make([]string, 0, len(c.authenticatorList))
+	for _, eachAuthenticator := range c.authenticatorList {
+		nameList = append(nameList, eachAuthenticator.Name())
+	}
+	return strings.Join(nameList, ",")
+}
+
+func (c *authenticatorChain) Authenticate(r *http.Request) (*Principal, error) {
+	var firstErr error
+	for _, eachAuthenticator := range c.authenticatorList {
+		principal, err := eachAuthenticator.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if len(principal.Provider) <= 0 {
+			principal.Provider = eachAuthenticator.Name()
+		}
+		return principal, nil
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, ErrTokenMissing
+}
+
+// #region principal context
+
+type principalContextKey struct{}
+
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// nil if the request is not authenticated by an Authenticator
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey{}).(*Principal)
+	return principal
+}
+
+// #endregion
+
+// token of the Authorization: Bearer {token} header,ErrNoCredentials if the header is not set
+func BearerToken(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", ErrNoCredentials
+	}
+	authHeaderParts := strings.SplitN(authHeader, " ", 2)
+	if len(authHeaderParts) != 2 || !strings.EqualFold(authHeaderParts[0], "bearer") {
+		return "", ErrNoCredentials
+	}
+	return strings.TrimSpace(authHeaderParts[1]), nil
+}
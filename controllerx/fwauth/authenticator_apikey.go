@@ -0,0 +1,114 @@
+package fwauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/shanluzhineng/fwpkg/utils/crypto"
+)
+
+const (
+	AuthenticatorNameApiKey = "apikey"
+
+	// header of api key if ApiKeyOptions.HeaderName is not set
+	HeaderApiKey = "X-Api-Key"
+)
+
+var ErrInvalidApiKey = errors.New("invalid api key")
+
+// an api key and the principal it authenticates
+type ApiKey struct {
+	Id       string   `mapstructure:"id" json:"id" yaml:"id"`
+	Name     string   `mapstructure:"name" json:"name" yaml:"name"`
+	TenantId string   `mapstructure:"tenantId" json:"tenantId" yaml:"tenantId"`
+	RoleList []string `mapstructure:"roles" json:"roles" yaml:"roles"`
+	// plain key,prefer KeyHash so the key is not kept in configuration
+	Key string `mapstructure:"key" json:"-" yaml:"key"`
+	// sha256 hex of key(see HashApiKey) or bcrypt hash of key
+	KeyHash string `mapstructure:"keyHash" json:"-" yaml:"keyHash"`
+}
+
+type ApiKeyOptions struct {
+	// HeaderApiKey is used if not set
+	HeaderName string   `mapstructure:"headerName" json:"headerName" yaml:"headerName"`
+	KeyList    []ApiKey `mapstructure:"keys" json:"keys" yaml:"keys"`
+}
+
+type apiKeyAuthenticator struct {
+	headerName string
+	// key is the sha256 hex of api key
+	hashMap    map[string]*ApiKey
+	bcryptList []*ApiKey
+}
+
+// static keys and hashed keys,the key is read from the header
+func NewApiKeyAuthenticator(options ApiKeyOptions) Authenticator {
+	a := &apiKeyAuthenticator{
+		headerName: options.HeaderName,
+		hashMap:    make(map[string]*ApiKey),
+		bcryptList: make([]*ApiKey, 0),
+	}
+	if len(a.headerName) <= 0 {
+		a.headerName = HeaderApiKey
+	}
+	for i := range options.KeyList {
+		apiKey := &options.KeyList[i]
+		switch {
+		case len(apiKey.Key) > 0:
+			a.hashMap[HashApiKey(apiKey.Key)] = apiKey
+		case strings.HasPrefix(apiKey.KeyHash, "$2"):
+			a.bcryptList = append(a.bcryptList, apiKey)
+		case len(apiKey.KeyHash) > 0:
+			a.hashMap[strings.ToLower(apiKey.KeyHash)] = apiKey
+		}
+	}
+	return a
+}
+
+// sha256 hex of api key,it is the value of ApiKey.KeyHash
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *apiKeyAuthenticator) Name() string {
+	return AuthenticatorNameApiKey
+}
+
+func (a *apiKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := strings.TrimSpace(r.Header.Get(a.headerName))
+	if len(key) <= 0 {
+		return nil, ErrNoCredentials
+	}
+	apiKey := a.find(key)
+	if apiKey == nil {
+		return nil, ErrInvalidApiKey
+	}
+	return &Principal{
+		Id:       apiKey.Id,
+		Name:     apiKey.Name,
+		TenantId: apiKey.TenantId,
+		RoleList: apiKey.RoleList,
+		Provider: AuthenticatorNameApiKey,
+		Claims:   apiKey,
+	}, nil
+}
+
+func (a *apiKeyAuthenticator) find(key string) *ApiKey {
+	hash := HashApiKey(key)
+	for eachHash, eachKey := range a.hashMap {
+		if subtle.ConstantTimeCompare([]byte(eachHash), []byte(hash)) == 1 {
+			return eachKey
+		}
+	}
+	for _, eachKey := range a.bcryptList {
+		if crypto.BcryptCheck(key, eachKey.KeyHash) {
+			return eachKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package fwauth
+
+import (
+	"net/http"
+
+	"github.com/casdoor/casdoor-go-sdk/casdoorsdk"
+)
+
+const (
+	AuthenticatorNameCasdoor = "casdoor"
+
+	// property of casdoor user that overrides the tenant of user
+	CasdoorPropertyTenantId = "tenantId"
+)
+
+type casdoorAuthenticator struct {
+}
+
+// bearer token issued by casdoor,the sdk is initialized by GetCasdoorMiddleware
+func NewCasdoorAuthenticator() Authenticator {
+	return &casdoorAuthenticator{}
+}
+
+func (a *casdoorAuthenticator) Name() string {
+	return AuthenticatorNameCasdoor
+}
+
+func (a *casdoorAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, err := BearerToken(r)
+	if err != nil {
+		return nil, err
+	}
+	GetCasdoorMiddleware()
+	claims, err := casdoorsdk.ParseJwtToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return PrincipalFromCasdoorClaims(claims), nil
+}
+
+// tenant is the tenantId property of user or the organization of user
+func PrincipalFromCasdoorClaims(claims *casdoorsdk.Claims) *Principal {
+	principal := &Principal{
+		Id:       claims.Id,
+		Name:     claims.Name,
+		TenantId: claims.Owner,
+		Locale:   claims.Language,
+		RoleList: make([]string, 0, len(claims.Roles)),
+		Provider: AuthenticatorNameCasdoor,
+		Claims:   claims,
+	}
+	if tenantId := claims.Properties[CasdoorPropertyTenantId]; len(tenantId) > 0 {
+		principal.TenantId = tenantId
+	}
+	for _, eachRole := range claims.Roles {
+		if eachRole != nil {
+			principal.RoleList = append(principal.RoleList, eachRole.Name)
+		}
+	}
+	return principal
+}
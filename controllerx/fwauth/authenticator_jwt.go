@@ -0,0 +1,225 @@
+package fwauth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	rsax "github.com/shanluzhineng/fwpkg/utils/crypto/rsa"
+)
+
+const (
+	AuthenticatorNameJwt = "jwt"
+
+	DefaultJwtTenantClaim = "tenantId"
+	DefaultJwtRoleClaim   = "roles"
+	DefaultJwtNameClaim   = "name"
+	DefaultJwtLocaleClaim = "locale"
+)
+
+// options of self-issued jwt,HS256 is accepted if Secret is set,
+// RS256 is accepted if PublicKey,PublicKeyFile or JwksUrl is set
+type JwtOptions struct {
+	Secret string `mapstructure:"secret" json:"secret" yaml:"secret"`
+	// pem file of rsa public key,it is loaded by NewJwtAuthenticator
+	PublicKeyFile string         `mapstructure:"publicKeyFile" json:"publicKeyFile" yaml:"publicKeyFile"`
+	PublicKey     *rsa.PublicKey `mapstructure:"-" json:"-" yaml:"-"`
+	// keys are selected by the kid header of token
+	JwksUrl string `mapstructure:"jwksUrl" json:"jwksUrl" yaml:"jwksUrl"`
+	// keys of jwks are fetched again after the interval,DefaultJwksRefreshInterval is used if not set
+	JwksRefreshInterval time.Duration `mapstructure:"jwksRefreshInterval" json:"jwksRefreshInterval" yaml:"jwksRefreshInterval"`
+	// iss and aud are checked if they are set
+	Issuer   string `mapstructure:"issuer" json:"issuer" yaml:"issuer"`
+	Audience string `mapstructure:"audience" json:"audience" yaml:"audience"`
+	// tokens without exp are rejected unless it is true
+	ExpirationOptional bool `mapstructure:"expirationOptional" json:"expirationOptional" yaml:"expirationOptional"`
+	// claim names of principal,sub is the id of principal
+	TenantClaim string `mapstructure:"tenantClaim" json:"tenantClaim" yaml:"tenantClaim"`
+	RoleClaim   string `mapstructure:"roleClaim" json:"roleClaim" yaml:"roleClaim"`
+	NameClaim   string `mapstructure:"nameClaim" json:"nameClaim" yaml:"nameClaim"`
+	LocaleClaim string `mapstructure:"localeClaim" json:"localeClaim" yaml:"localeClaim"`
+	// BearerToken is used if not set
+	Extractor func(r *http.Request) (string, error) `mapstructure:"-" json:"-" yaml:"-"`
+}
+
+func (o *JwtOptions) normalize() {
+	if len(o.TenantClaim) <= 0 {
+		o.TenantClaim = DefaultJwtTenantClaim
+	}
+	if len(o.RoleClaim) <= 0 {
+		o.RoleClaim = DefaultJwtRoleClaim
+	}
+	if len(o.NameClaim) <= 0 {
+		o.NameClaim = DefaultJwtNameClaim
+	}
+	if len(o.LocaleClaim) <= 0 {
+		o.LocaleClaim = DefaultJwtLocaleClaim
+	}
+	if o.Extractor == nil {
+		o.Extractor = BearerToken
+	}
+}
+
+type jwtAuthenticator struct {
+	options      JwtOptions
+	jwks         *jwksKeySet
+	validMethods []string
+}
+
+func NewJwtAuthenticator(options JwtOptions) (Authenticator, error) {
+	options.normalize()
+	if options.PublicKey == nil && len(options.PublicKeyFile) > 0 {
+		publicKey, err := rsax.LoadPublicKey(options.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load jwt public key fail,file:%s,err:%w", options.PublicKeyFile, err)
+		}
+		options.PublicKey = publicKey
+	}
+	a := &jwtAuthenticator{
+		options:      options,
+		validMethods: make([]string, 0),
+	}
+	if len(options.Secret) > 0 {
+		a.validMethods = append(a.validMethods, jwt.SigningMethodHS256.Alg())
+	}
+	if options.PublicKey != nil || len(options.JwksUrl) > 0 {
+		a.validMethods = append(a.validMethods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(a.validMethods) <= 0 {
+		return nil, errors.New("jwt secret,public key or jwks url must be set")
+	}
+	if len(options.JwksUrl) > 0 {
+		a.jwks = newJwksKeySet(options.JwksUrl, options.JwksRefreshInterval)
+	}
+	return a, nil
+}
+
+func (a *jwtAuthenticator) Name() string {
+	return AuthenticatorNameJwt
+}
+
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	tokenString, err := a.options.Extractor(r)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, a.keyFunc, jwt.WithValidMethods(a.validMethods)); err != nil {
+		return nil, err
+	}
+	// exp is only checked by the parser if it is present
+	if !claims.VerifyExpiresAt(time.Now().Unix(), !a.options.ExpirationOptional) {
+		return nil, errors.New("token has no expiration")
+	}
+	if len(a.options.Issuer) > 0 && !claims.VerifyIssuer(a.options.Issuer, true) {
+		return nil, errors.New("invalid token issuer")
+	}
+	if len(a.options.Audience) > 0 && !claims.VerifyAudience(a.options.Audience, true) {
+		return nil, errors.New("invalid token audience")
+	}
+	principal := &Principal{
+		Id:       claimString(claims, "sub"),
+		Name:     claimString(claims, a.options.NameClaim),
+		TenantId: claimString(claims, a.options.TenantClaim),
+		RoleList: claimStringList(claims, a.options.RoleClaim),
+		Locale:   claimString(claims, a.options.LocaleClaim),
+		Provider: AuthenticatorNameJwt,
+		Claims:   claims,
+	}
+	if len(principal.Id) <= 0 {
+		return nil, errors.New("token has no subject")
+	}
+	return principal, nil
+}
+
+func (a *jwtAuthenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+		return []byte(a.options.Secret), nil
+	}
+	if kid, ok := token.Header["kid"].(string); ok && a.jwks != nil {
+		return a.jwks.getKey(kid)
+	}
+	if a.options.PublicKey != nil {
+		return a.options.PublicKey, nil
+	}
+	return nil, errors.New("token has no kid")
+}
+
+func claimString(claims jwt.MapClaims, name string) string {
+	v, _ := claims[name].(string)
+	return v
+}
+
+// a claim of json array or a string separated by space or comma
+func claimStringList(claims jwt.MapClaims, name string) []string {
+	result := make([]string, 0)
+	switch v := claims[name].(type) {
+	case []interface{}:
+		for _, eachItem := range v {
+			if s, ok := eachItem.(string); ok {
+				result = append(result, s)
+			}
+		}
+	case string:
+		result = append(result, strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' })...)
+	}
+	return result
+}
+
+// #region issue
+
+// issue tokens that are accepted by the jwt authenticator of the same options
+type JwtIssuer struct {
+	options    JwtOptions
+	privateKey *rsa.PrivateKey
+	ttl        time.Duration
+}
+
+// RS256 is used if privateKey is not nil,otherwise HS256 with options.Secret
+func NewJwtIssuer(options JwtOptions, privateKey *rsa.PrivateKey, ttl time.Duration) *JwtIssuer {
+	options.normalize()
+	return &JwtIssuer{
+		options:    options,
+		privateKey: privateKey,
+		ttl:        ttl,
+	}
+}
+
+// kid is set to the header if it is not empty
+func (i *JwtIssuer) Issue(principal *Principal, kid string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":                 principal.Id,
+		"iat":                 now.Unix(),
+		"exp":                 now.Add(i.ttl).Unix(),
+		i.options.NameClaim:   principal.Name,
+		i.options.TenantClaim: principal.TenantId,
+		i.options.RoleClaim:   principal.RoleList,
+	}
+	if len(principal.Locale) > 0 {
+		claims[i.options.LocaleClaim] = principal.Locale
+	}
+	if len(i.options.Issuer) > 0 {
+		claims["iss"] = i.options.Issuer
+	}
+	if len(i.options.Audience) > 0 {
+		claims["aud"] = i.options.Audience
+	}
+	var token *jwt.Token
+	var key interface{}
+	if i.privateKey != nil {
+		token, key = jwt.NewWithClaims(jwt.SigningMethodRS256, claims), i.privateKey
+	} else {
+		token, key = jwt.NewWithClaims(jwt.SigningMethodHS256, claims), []byte(i.options.Secret)
+	}
+	if len(kid) > 0 {
+		token.Header["kid"] = kid
+	}
+	return token.SignedString(key)
+}
+
+// #endregion
@@ -0,0 +1,68 @@
+package fwauth
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+)
+
+const AuthenticatorNameMtls = "mtls"
+
+type MtlsOptions struct {
+	// common names of the accepted client certificates,all verified certificates are accepted if it is empty
+	AllowedSubjectList []string `mapstructure:"allowedSubjects" json:"allowedSubjects" yaml:"allowedSubjects"`
+	// build the principal from the client certificate,
+	// default is common name as id and name,the first organization as tenant and organizational units as roles
+	PrincipalFunc func(cert *x509.Certificate) (*Principal, error) `mapstructure:"-" json:"-" yaml:"-"`
+}
+
+type mtlsAuthenticator struct {
+	options    MtlsOptions
+	allowedSet map[string]bool
+}
+
+// client certificate verified by the tls server,the server must set tls.Config.ClientAuth and ClientCAs.
+// if tls is terminated by a proxy,the certificate is not available
+func NewMtlsAuthenticator(options MtlsOptions) Authenticator {
+	a := &mtlsAuthenticator{
+		options:    options,
+		allowedSet: make(map[string]bool, len(options.AllowedSubjectList)),
+	}
+	for _, eachSubject := range options.AllowedSubjectList {
+		a.allowedSet[eachSubject] = true
+	}
+	if a.options.PrincipalFunc == nil {
+		a.options.PrincipalFunc = principalFromCertificate
+	}
+	return a
+}
+
+func (a *mtlsAuthenticator) Name() string {
+	return AuthenticatorNameMtls
+}
+
+func (a *mtlsAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	// only verified chains are trusted,PeerCertificates may be unverified
+	if r.TLS == nil || len(r.TLS.VerifiedChains) <= 0 || len(r.TLS.VerifiedChains[0]) <= 0 {
+		return nil, ErrNoCredentials
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	if len(a.allowedSet) > 0 && !a.allowedSet[cert.Subject.CommonName] {
+		return nil, fmt.Errorf("client certificate is not allowed,subject:%s", cert.Subject.CommonName)
+	}
+	return a.options.PrincipalFunc(cert)
+}
+
+func principalFromCertificate(cert *x509.Certificate) (*Principal, error) {
+	principal := &Principal{
+		Id:       cert.Subject.CommonName,
+		Name:     cert.Subject.CommonName,
+		RoleList: cert.Subject.OrganizationalUnit,
+		Provider: AuthenticatorNameMtls,
+		Claims:   cert,
+	}
+	if len(cert.Subject.Organization) > 0 {
+		principal.TenantId = cert.Subject.Organization[0]
+	}
+	return principal, nil
+}
@@ -0,0 +1,102 @@
+package fwauth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticatorChain(t *testing.T) {
+	options := JwtOptions{Secret: "secret", Issuer: "fwpkg"}
+	jwtAuthenticator, err := NewJwtAuthenticator(options)
+	assert.Nil(t, err)
+	apiKeyAuthenticator := NewApiKeyAuthenticator(ApiKeyOptions{
+		KeyList: []ApiKey{
+			{Id: "job", TenantId: "t1", Key: "plain-key"},
+			{Id: "sync", TenantId: "t2", KeyHash: HashApiKey("hashed-key"), RoleList: []string{"reader"}},
+		},
+	})
+	chain := NewAuthenticatorChain(jwtAuthenticator, apiKeyAuthenticator, NewMtlsAuthenticator(MtlsOptions{}))
+
+	// no credentials
+	_, err = chain.Authenticate(httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, ErrTokenMissing, err)
+
+	// jwt
+	token, err := NewJwtIssuer(options, nil, time.Minute).Issue(&Principal{Id: "u1", Name: "user", TenantId: "t1", RoleList: []string{"admin"}}, "")
+	assert.Nil(t, err)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	principal, err := chain.Authenticate(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "u1", principal.Id)
+	assert.Equal(t, "t1", principal.TenantId)
+	assert.Equal(t, AuthenticatorNameJwt, principal.Provider)
+	assert.True(t, principal.HasRole("admin"))
+
+	// a token of other issuer is rejected
+	token, _ = NewJwtIssuer(JwtOptions{Secret: "secret", Issuer: "other"}, nil, time.Minute).Issue(&Principal{Id: "u1"}, "")
+	r.Header.Set("Authorization", "Bearer "+token)
+	_, err = chain.Authenticate(r)
+	assert.NotNil(t, err)
+
+	// api key
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(HeaderApiKey, "plain-key")
+	principal, err = chain.Authenticate(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "job", principal.Id)
+	r.Header.Set(HeaderApiKey, "hashed-key")
+	principal, err = chain.Authenticate(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "sync", principal.Id)
+	assert.True(t, principal.HasRole("reader"))
+	r.Header.Set(HeaderApiKey, "unknown")
+	_, err = chain.Authenticate(r)
+	assert.Equal(t, ErrInvalidApiKey, err)
+
+	// mtls
+	r = httptest.NewRequest("GET", "/", nil)
+	r.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{
+			Subject: pkix.Name{CommonName: "device-1", Organization: []string{"t3"}, OrganizationalUnit: []string{"device"}},
+		}}},
+	}
+	principal, err = chain.Authenticate(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "device-1", principal.Id)
+	assert.Equal(t, "t3", principal.TenantId)
+	assert.Equal(t, AuthenticatorNameMtls, principal.Provider)
+}
+
+func TestJwtExpirationRequired(t *testing.T) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "u1"}).SignedString([]byte("secret"))
+	assert.Nil(t, err)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+
+	// a token without exp is rejected by default
+	authenticator, err := NewJwtAuthenticator(JwtOptions{Secret: "secret"})
+	assert.Nil(t, err)
+	_, err = authenticator.Authenticate(r)
+	assert.NotNil(t, err)
+
+	authenticator, err = NewJwtAuthenticator(JwtOptions{Secret: "secret", ExpirationOptional: true})
+	assert.Nil(t, err)
+	principal, err := authenticator.Authenticate(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "u1", principal.Id)
+
+	// an expired token is rejected
+	token, err = NewJwtIssuer(JwtOptions{Secret: "secret"}, nil, -time.Minute).Issue(&Principal{Id: "u1"}, "")
+	assert.Nil(t, err)
+	r.Header.Set("Authorization", "Bearer "+token)
+	_, err = authenticator.Authenticate(r)
+	assert.NotNil(t, err)
+}
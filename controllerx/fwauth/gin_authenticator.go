@@ -0,0 +1,49 @@
+package fwauth
+
+import (
+	"net/http"
+
+	"github.com/casdoor/casdoor-go-sdk/casdoorsdk"
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+)
+
+// gin version of IrisAuthenticate,casdoor claims are also kept with AuthKey
+func GinAuthenticate(authenticatorList ...Authenticator) gin.HandlerFunc {
+	chain := NewAuthenticatorChain(authenticatorList...)
+	return func(c *gin.Context) {
+		principal, err := chain.Authenticate(c.Request)
+		if err != nil {
+			responsex.FailWithStatus(http.StatusUnauthorized, err, c)
+			return
+		}
+		SetGinPrincipal(c, principal)
+		c.Next()
+	}
+}
+
+func SetGinPrincipal(c *gin.Context, principal *Principal) {
+	c.Set(PrincipalContextKey, principal)
+	if claims, ok := principal.Claims.(*casdoorsdk.Claims); ok {
+		c.Set(AuthKey, claims)
+	}
+	c.Request = c.Request.WithContext(ContextWithPrincipal(c.Request.Context(), principal))
+}
+
+// principal of current request,it is built from casdoor claims if the request is authenticated by GinCasdoorHandler.
+// nil if the request is not authenticated
+func GetGinPrincipal(c *gin.Context) *Principal {
+	if v, ok := c.Get(PrincipalContextKey); ok {
+		if principal, ok := v.(*Principal); ok {
+			return principal
+		}
+	}
+	if v, ok := c.Get(AuthKey); ok {
+		if claims, ok := v.(*casdoorsdk.Claims); ok {
+			principal := PrincipalFromCasdoorClaims(claims)
+			c.Set(PrincipalContextKey, principal)
+			return principal
+		}
+	}
+	return nil
+}
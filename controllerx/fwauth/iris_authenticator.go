@@ -0,0 +1,58 @@
+package fwauth
+
+import (
+	"github.com/casdoor/casdoor-go-sdk/casdoorsdk"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+)
+
+var _defaultAuthenticatorList []Authenticator
+
+// authenticators of the routes that do not set their own,
+// the casdoor middleware is used if they are not set
+func SetDefaultAuthenticators(authenticatorList ...Authenticator) {
+	_defaultAuthenticatorList = authenticatorList
+}
+
+func GetDefaultAuthenticators() []Authenticator {
+	return _defaultAuthenticatorList
+}
+
+// authenticate the request with the authenticators in order,an unauthorized response is written if all of them fail.
+// the principal is kept in ctx.Values and the request context,
+// casdoor claims are also kept for Middleware.GetUserClaims
+func IrisAuthenticate(authenticatorList ...Authenticator) iris.Handler {
+	chain := NewAuthenticatorChain(authenticatorList...)
+	return func(ctx iris.Context) {
+		principal, err := chain.Authenticate(ctx.Request())
+		if err != nil {
+			responsex.HandleErrorUnauthorized(ctx, err)
+			return
+		}
+		SetIrisPrincipal(ctx, principal)
+		ctx.Next()
+	}
+}
+
+func SetIrisPrincipal(ctx iris.Context, principal *Principal) {
+	ctx.Values().Set(PrincipalContextKey, principal)
+	if claims, ok := principal.Claims.(*casdoorsdk.Claims); ok {
+		ctx.Values().Set(GetCasdoorMiddleware().Options.Jwt.ContextKey, claims)
+	}
+	request := ctx.Request()
+	ctx.ResetRequest(request.WithContext(ContextWithPrincipal(request.Context(), principal)))
+}
+
+// principal of current request,it is built from casdoor claims if the request is authenticated by Middleware.Serve.
+// nil if the request is not authenticated
+func GetIrisPrincipal(ctx iris.Context) *Principal {
+	if principal, ok := ctx.Values().Get(PrincipalContextKey).(*Principal); ok {
+		return principal
+	}
+	if claims := GetCasdoorMiddleware().GetUserClaims(ctx); claims != nil {
+		principal := PrincipalFromCasdoorClaims(claims)
+		ctx.Values().Set(PrincipalContextKey, principal)
+		return principal
+	}
+	return nil
+}
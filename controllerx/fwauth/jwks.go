@@ -0,0 +1,125 @@
+package fwauth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// keys of jwks are fetched again after the interval if JwtOptions.JwksRefreshInterval is not set
+	DefaultJwksRefreshInterval = time.Hour
+	// a token with unknown kid fetches the keys at most once in the interval
+	jwksMinRefreshInterval = time.Minute
+)
+
+type jwksKeySet struct {
+	url             string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	lock      sync.Mutex
+	keyMap    map[string]*rsa.PublicKey
+	fetchTime time.Time
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func newJwksKeySet(url string, refreshInterval time.Duration) *jwksKeySet {
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultJwksRefreshInterval
+	}
+	return &jwksKeySet{
+		url:             url,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// keys are fetched when they expire or kid is unknown
+func (s *jwksKeySet) getKey(kid string) (*rsa.PublicKey, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key, ok := s.keyMap[kid]
+	elapsed := time.Since(s.fetchTime)
+	if ok && elapsed < s.refreshInterval {
+		return key, nil
+	}
+	if s.keyMap == nil || elapsed >= jwksMinRefreshInterval {
+		keyMap, err := s.fetch()
+		if err != nil {
+			if ok {
+				// keep the stale key if the endpoint is unavailable
+				return key, nil
+			}
+			return nil, err
+		}
+		s.keyMap = keyMap
+		s.fetchTime = time.Now()
+		key, ok = keyMap[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown jwks key,kid:%s", kid)
+	}
+	return key, nil
+}
+
+func (s *jwksKeySet) fetch() (map[string]*rsa.PublicKey, error) {
+	res, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks fail,url:%s,status:%d", s.url, res.StatusCode)
+	}
+	keySet := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&keySet); err != nil {
+		return nil, err
+	}
+	keyMap := make(map[string]*rsa.PublicKey)
+	for _, eachKey := range keySet.Keys {
+		if eachKey.Kty != "RSA" || (len(eachKey.Use) > 0 && eachKey.Use != "sig") {
+			continue
+		}
+		publicKey, err := eachKey.rsaPublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwks key,kid:%s,err:%w", eachKey.Kid, err)
+		}
+		keyMap[eachKey.Kid] = publicKey
+	}
+	return keyMap, nil
+}
+
+func (k *jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
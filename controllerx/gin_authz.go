@@ -0,0 +1,49 @@
+package controllerx
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/controllerx/authz"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+)
+
+// subject of current gin request,roles are the roles of principal
+func GetGinSubject(c *gin.Context) *authz.Subject {
+	subject := &authz.Subject{
+		TenantId: GetGinTenantId(c),
+		RoleList: make([]string, 0),
+	}
+	principal := GetGinPrincipal(c)
+	if principal == nil {
+		return subject
+	}
+	subject.UserId = principal.Id
+	subject.RoleList = append(subject.RoleList, principal.RoleList...)
+	return subject
+}
+
+// gin version of RequirePermission,a conditional permission is kept in the context,see GetGinPermissionDecision
+func GinRequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		decision, err := GetAuthorizer().Authorize(GetGinSubject(c), permission)
+		if err != nil {
+			responsex.FailWithError(err, c)
+			return
+		}
+		if !decision.Allowed {
+			responsex.FailWithError(ErrCodePermissionDenied.New(fmt.Sprintf("permission denied,permission:%s", permission)).
+				WithArgs(map[string]interface{}{"permission": permission}), c)
+			return
+		}
+		c.Set(PermissionDecisionContextKey, decision)
+		c.Next()
+	}
+}
+
+// decision of the permission checked by GinRequirePermission,nil if the route does not require permission
+func GetGinPermissionDecision(c *gin.Context) *authz.Decision {
+	v, _ := c.Get(PermissionDecisionContextKey)
+	decision, _ := v.(*authz.Decision)
+	return decision
+}
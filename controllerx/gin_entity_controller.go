@@ -0,0 +1,777 @@
+package controllerx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/shanluzhineng/fwpkg/controllerx/audit"
+	"github.com/shanluzhineng/fwpkg/controllerx/authz"
+	"github.com/shanluzhineng/fwpkg/controllerx/idempotency"
+	"github.com/shanluzhineng/fwpkg/controllerx/openapi"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsecache"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/entity/changefeed"
+	"github.com/shanluzhineng/fwpkg/entity/filter"
+	"github.com/shanluzhineng/fwpkg/entity/patch"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// gin version of EntityController,it registers the same routes with the same options and responses.
+// the funcs of options for iris are replaced by GinHardDeleteAuthorizeFunc,GinTenantBypassAuthorizeFunc and GinListFilterFunc.
+type GinEntityController[T mongodbr.IEntity] struct {
+	RouterPath    string
+	EntityService entity.IEntityService[T]
+	// used by export routes,GetEntityExportService is used if not set
+	ExportService entity.IEntityExportService[T]
+	// used by import routes,GetEntityImportService is used if not set
+	ImportService entity.IEntityImportService[T]
+
+	Options    BaseEntityControllerOptions
+	once       sync.Once
+	exportOnce sync.Once
+	importOnce sync.Once
+
+	// handler of Idempotency-Key,it is shared by the create routes
+	idempotencyHandler gin.HandlerFunc
+	responseCache      *responsecache.Cache
+	// feed of the stream routes,the changes of items are published to it
+	changeFeed *changefeed.Feed
+}
+
+func (c *GinEntityController[T]) RegistRouter(webapp *GinApplication, opts ...BaseEntityControllerOption) *gin.RouterGroup {
+	for _, eachOpt := range opts {
+		eachOpt(&(c.Options))
+	}
+
+	routerGroup := webapp.Group(c.RouterPath)
+	if c.Options.IdempotencyEnabled {
+		c.idempotencyHandler = idempotency.NewGin(c.Options.IdempotencyOptionList...)
+	}
+	if c.Options.ResponseCacheEnabled {
+		c.responseCache = c.Options.ResponseCache
+		if c.responseCache == nil {
+			c.responseCache = responsecache.GetDefaultCache()
+		}
+	}
+	c.changeFeed = streamFeed(&c.Options)
+
+	if !c.Options.AllDisabled {
+		c.handle(routerGroup, http.MethodGet, "/all", openapi.EntityActionAll, c.All)
+	}
+	if !c.Options.ListDisabled {
+		c.handle(routerGroup, http.MethodGet, "/", openapi.EntityActionList, c.GetList)
+	}
+	if !c.Options.GetByIdDisabled {
+		c.handle(routerGroup, http.MethodGet, "/:id", openapi.EntityActionGetById, c.GetById)
+	}
+	if !c.Options.CreateDisabled {
+		c.handle(routerGroup, http.MethodPost, "/", openapi.EntityActionCreate, c.Create)
+	}
+	if !c.Options.BatchCreateDisabled {
+		c.handle(routerGroup, http.MethodPost, "/batch", openapi.EntityActionBatchCreate, c.BatchCreate)
+	}
+	if !c.Options.UpdateDisabled {
+		c.handle(routerGroup, http.MethodPut, "/:id", openapi.EntityActionUpdate, c.Update)
+	}
+	if !c.Options.PatchDisabled {
+		c.handle(routerGroup, http.MethodPatch, "/:id", openapi.EntityActionPatch, c.Patch)
+	}
+	if !c.Options.BatchUpdateDisabled {
+		c.handle(routerGroup, http.MethodPatch, "/batch", openapi.EntityActionBatchUpdate, c.BatchUpdate)
+	}
+	if !c.Options.DeleteDisabled {
+		c.handle(routerGroup, http.MethodDelete, "/:id", openapi.EntityActionDelete, c.Delete)
+	}
+	if !c.Options.RestoreDisabled && mongodbr.IsSoftDeleteEntity(new(T)) {
+		c.handle(routerGroup, http.MethodPost, "/:id/restore", openapi.EntityActionRestore, c.Restore)
+	}
+	if !c.Options.DeleteListDisabled {
+		c.handle(routerGroup, http.MethodDelete, "/", openapi.EntityActionDeleteList, c.DeleteList)
+	}
+	if c.Options.ExportEnabled {
+		// stale running exports of a stopped process are failed on startup
+		c.GetExportService()
+		c.handle(routerGroup, http.MethodPost, "/export", openapi.EntityActionExport, c.Export)
+		c.handle(routerGroup, http.MethodGet, "/export/:id", openapi.EntityActionGetExport, c.GetExport)
+		c.handle(routerGroup, http.MethodGet, "/export/:id/download", openapi.EntityActionDownloadExport, c.DownloadExport)
+	}
+	if c.Options.ImportEnabled {
+		c.handle(routerGroup, http.MethodPost, "/import", openapi.EntityActionImport, c.Import)
+		c.handle(routerGroup, http.MethodGet, "/import/:id", openapi.EntityActionGetImport, c.GetImport)
+		c.handle(routerGroup, http.MethodGet, "/import/:id/report", openapi.EntityActionImportReport, c.DownloadImportReport)
+	}
+	if c.Options.StreamEnabled {
+		c.handle(routerGroup, http.MethodGet, "/stream", openapi.EntityActionStream, c.Stream)
+		c.handle(routerGroup, http.MethodGet, "/stream/ws", openapi.EntityActionStreamWs, c.StreamWs)
+	}
+
+	return routerGroup
+}
+
+func (c *GinEntityController[T]) handle(routerGroup *gin.RouterGroup, method string, relativePath string, action openapi.EntityAction, handler gin.HandlerFunc) {
+	handlerList := []gin.HandlerFunc{handler}
+	if auditOptList := auditOptionList[T](&c.Options, action); auditOptList != nil {
+		handlerList = []gin.HandlerFunc{audit.NewGin(auditOptList...), handler}
+	}
+	if c.idempotencyHandler != nil && (action == openapi.EntityActionCreate || action == openapi.EntityActionBatchCreate) {
+		handlerList = append([]gin.HandlerFunc{c.idempotencyHandler}, handlerList...)
+	}
+	if cacheHandler := c.responseCacheHandler(action); cacheHandler != nil {
+		handlerList = append([]gin.HandlerFunc{cacheHandler}, handlerList...)
+	}
+	permission := c.GetPermission(action)
+	if len(permission) > 0 {
+		handlerList = append([]gin.HandlerFunc{GinRequirePermission(permission)}, handlerList...)
+	}
+	if !c.Options.AuthenticatedDisabled {
+		handlerList = append([]gin.HandlerFunc{GinAuthenticateHandler(c.Options.AuthenticatorList...)}, handlerList...)
+	}
+	routerGroup.Handle(method, relativePath, handlerList...)
+	openapi.RegistEntityOperation(method, path.Join(routerGroup.BasePath(), relativePath), openapi.EntityOperation{
+		Action:        action,
+		EntityType:    reflect.TypeOf(new(T)).Elem(),
+		Authenticated: !c.Options.AuthenticatedDisabled,
+		Permission:    permission,
+	})
+}
+
+// #region response cache
+
+// see EntityController.EntityName
+func (c *GinEntityController[T]) EntityName() string {
+	return reflect.TypeOf(new(T)).Elem().Name()
+}
+
+// see EntityController.InvalidateResponseCache
+func (c *GinEntityController[T]) InvalidateResponseCache(idList ...string) error {
+	if c.responseCache == nil {
+		return nil
+	}
+	entityName := c.EntityName()
+	if len(idList) <= 0 {
+		return c.responseCache.Invalidate(responsecache.EntityTag(entityName))
+	}
+	tagList := []string{responsecache.EntityListTag(entityName)}
+	for _, eachId := range idList {
+		tagList = append(tagList, responsecache.EntityItemTag(entityName, eachId))
+	}
+	return c.responseCache.Invalidate(tagList...)
+}
+
+// see EntityController.responseCacheHandler
+func (c *GinEntityController[T]) responseCacheHandler(action openapi.EntityAction) gin.HandlerFunc {
+	if c.responseCache == nil {
+		return nil
+	}
+	entityName := c.EntityName()
+	optList := append([]responsecache.CacheOption{responsecache.WithGinTenantFunc(ginCacheTenant)}, c.Options.ResponseCacheOptionList...)
+	switch action {
+	case openapi.EntityActionAll, openapi.EntityActionList:
+		return c.responseCache.GinHandler(append(optList,
+			responsecache.WithTags(responsecache.EntityTag(entityName), responsecache.EntityListTag(entityName)))...)
+	case openapi.EntityActionGetById:
+		return c.responseCache.GinHandler(append(optList,
+			responsecache.WithTags(responsecache.EntityTag(entityName)),
+			responsecache.WithGinTagFunc(func(ctx *gin.Context) []string {
+				return []string{responsecache.EntityItemTag(entityName, ctx.Param("id"))}
+			}))...)
+	case openapi.EntityActionCreate:
+		return c.responseCache.GinEvictHandler(func(ctx *gin.Context) []string {
+			return []string{responsecache.EntityListTag(entityName)}
+		})
+	case openapi.EntityActionUpdate, openapi.EntityActionPatch, openapi.EntityActionDelete, openapi.EntityActionRestore:
+		return c.responseCache.GinEvictHandler(func(ctx *gin.Context) []string {
+			return []string{responsecache.EntityListTag(entityName), responsecache.EntityItemTag(entityName, ctx.Param("id"))}
+		})
+	case openapi.EntityActionBatchCreate, openapi.EntityActionBatchUpdate, openapi.EntityActionDeleteList, openapi.EntityActionImport:
+		return c.responseCache.GinEvictHandler(func(ctx *gin.Context) []string {
+			return []string{responsecache.EntityTag(entityName)}
+		})
+	}
+	return nil
+}
+
+// see cacheTenant
+func ginCacheTenant(c *gin.Context) (string, bool) {
+	if IsGinTenantBypassRequested(c) {
+		return "", true
+	}
+	return GetGinTenantId(c), false
+}
+
+// #endregion
+
+// permission required by action,empty if it is not checked
+func (c *GinEntityController[T]) GetPermission(action openapi.EntityAction) string {
+	if permission, ok := c.Options.PermissionMap[action]; ok {
+		return permission
+	}
+	if len(c.Options.Resource) <= 0 {
+		return ""
+	}
+	return authz.Permission(c.Options.Resource, entityActionPermissionMapping[action])
+}
+
+func (c *GinEntityController[T]) GetEntityService() entity.IEntityService[T] {
+	c.once.Do(func() {
+		if c.EntityService != nil {
+			return
+		}
+		c.EntityService = GetEntityService[T]()
+	})
+	return c.EntityService
+}
+
+// see EntityController.GetRequestEntityService
+func (c *GinEntityController[T]) GetRequestEntityService(ctx *gin.Context) (entity.IEntityService[T], bool) {
+	tenantId, ok := c.getRequestTenantId(ctx)
+	if !ok {
+		return nil, false
+	}
+	service := c.GetEntityService().WithContext(ctx.Request.Context())
+	if len(tenantId) > 0 {
+		service = service.WithTenant(tenantId)
+	}
+	if scope := getGinPermissionScope(ctx); scope != nil {
+		service = service.WithScope(scope)
+	}
+	return service, true
+}
+
+// conditions of the permission of current request,nil if the permission is granted without condition
+func getGinPermissionScope(ctx *gin.Context) bson.M {
+	decision := GetGinPermissionDecision(ctx)
+	if decision == nil {
+		return nil
+	}
+	return decision.Filter()
+}
+
+// empty if T is not a tenant entity or tenant isolation is bypassed
+func (c *GinEntityController[T]) getRequestTenantId(ctx *gin.Context) (string, bool) {
+	if !entity.IsTenantEntity(new(T)) {
+		return "", true
+	}
+	if IsGinTenantBypassRequested(ctx) {
+		if !c.canBypassTenant(ctx) {
+			responsex.FailWithError(ErrCodeTenantBypassDenied.New(""), ctx)
+			return "", false
+		}
+		return "", true
+	}
+	tenantId := GetGinTenantId(ctx)
+	if len(tenantId) <= 0 {
+		responsex.FailWithError(ErrCodeTenantUnresolved.New(""), ctx)
+		return "", false
+	}
+	return tenantId, true
+}
+
+// a record of tenantId can be read by current request
+func (c *GinEntityController[T]) isTenantAccessible(ctx *gin.Context, tenantId string) bool {
+	if len(tenantId) <= 0 || tenantId == GetGinTenantId(ctx) {
+		return true
+	}
+	return IsGinTenantBypassRequested(ctx) && c.canBypassTenant(ctx)
+}
+
+// bypass tenant isolation is allowed for super admin by default
+func (c *GinEntityController[T]) canBypassTenant(ctx *gin.Context) bool {
+	if c.Options.GinTenantBypassAuthorizeFunc != nil {
+		return c.Options.GinTenantBypassAuthorizeFunc(ctx)
+	}
+	return IsGinSuperAdmin(ctx)
+}
+
+func (c *GinEntityController[T]) All(ctx *gin.Context) {
+	projection, err := GetGinProjection(ctx, new(T))
+	if err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return
+	}
+	filter := map[string]interface{}{}
+	if !c.Options.FilterCurrentUserForListDisabled {
+		// auto filter current userId
+		AddGinUserIdFilterIfNeed(filter, new(T), ctx)
+	}
+
+	if c.Options.GinListFilterFunc != nil {
+		c.Options.GinListFilterFunc(new(T), filter, ctx)
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	var list []T
+	if len(filter) > 0 || !projection.IsEmpty() {
+		list, err = service.FindList(filter, mongodbr.FindOptionWithProjection(projection))
+	} else {
+		list, err = service.FindAll()
+	}
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	data, err := ProjectList(list, projection, new(T))
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	responsex.GinHandleSuccessWithListData(ctx, data, int64(len(list)))
+}
+
+func (c *GinEntityController[T]) GetList(ctx *gin.Context) {
+	all := filter.MustGetFilterAll(ctx.Query)
+	if all {
+		c.All(ctx)
+		return
+	}
+
+	// params
+	projection, err := GetGinProjection(ctx, new(T))
+	if err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return
+	}
+	query := filter.MustGetFilterQuery(ctx.Query)
+	sort := filter.MustGetSortOption(ctx.Query)
+
+	if !c.Options.FilterCurrentUserForListDisabled {
+		// auto filter current userId
+		AddGinUserIdFilterIfNeed(query, new(T), ctx)
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	if cursorPagination, ok := GetGinCursorPagination(ctx); ok {
+		c.getListByCursor(ctx, service, query, sort, projection, cursorPagination)
+		return
+	}
+
+	pagination := MustGetGinPagination(ctx)
+	list, err := service.FindList(query, mongodbr.FindOptionWithSort(sort),
+		mongodbr.FindOptionWithPage(int64(pagination.Page), int64(pagination.Size)),
+		mongodbr.FindOptionWithProjection(projection))
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+
+	var count int64 = responsex.ListTotalNotCounted
+	if MustGetGinListTotal(ctx, true) {
+		count, err = service.Count(query)
+		if err != nil {
+			responsex.FailWithError(err, ctx)
+			return
+		}
+	}
+	data, err := ProjectList(list, projection, new(T))
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	responsex.GinHandleSuccessWithListData(ctx, data, count)
+}
+
+// keyset pagination,total is not counted by default
+func (c *GinEntityController[T]) getListByCursor(ctx *gin.Context, service entity.IEntityService[T], query map[string]interface{}, sort bson.D,
+	projection *mongodbr.Projection, pagination *entity.CursorPagination) {
+	page, err := service.FindPage(query, &mongodbr.KeysetPage{
+		Sort:   sort,
+		After:  pagination.After,
+		Before: pagination.Before,
+		Limit:  int64(pagination.Limit),
+	}, mongodbr.FindOptionWithProjection(projection))
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+
+	var count int64 = responsex.ListTotalNotCounted
+	if MustGetGinListTotal(ctx, false) {
+		count, err = service.Count(query)
+		if err != nil {
+			responsex.FailWithError(err, ctx)
+			return
+		}
+	}
+	data, err := ProjectList(page.List, projection, new(T))
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	responsex.GinHandleSuccessWithCursorListData(ctx, data, count, page.NextCursor, page.PrevCursor)
+}
+
+// get by id
+func (c *GinEntityController[T]) GetById(ctx *gin.Context) {
+	id, ok := getGinObjectIdParam(ctx)
+	if !ok {
+		return
+	}
+	projection, err := GetGinProjection(ctx, new(T))
+	if err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	item, err := service.FindById(id, mongodbr.FindOneOptionWithProjection(projection))
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	if item == nil {
+		responsex.FailWithStatus(http.StatusNotFound, fmt.Errorf("not found item,id:%s", id.Hex()), ctx)
+		return
+	}
+	if projection.IsEmpty() {
+		if etag, ok := entityETag(entityValue(item)); ok {
+			ctx.Header("ETag", etag)
+		}
+	}
+	data, err := ProjectItem(item, projection, new(T))
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	responsex.GinHandleSuccessWithData(ctx, data)
+}
+
+// create
+func (c *GinEntityController[T]) Create(ctx *gin.Context) {
+	input := new(T)
+	err := ctx.ShouldBindJSON(input)
+	if err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return
+	}
+	err = mongodbr.Validate(input)
+	if err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return
+	}
+
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	// handler user info
+	c.SetUserInfo(ctx, input)
+
+	newItem, err := service.Create(input)
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	auditEntityChange[T](ctx.Request.Context(), audit.GetGin(ctx), GetGinTenantId(ctx), entityObjectId(entityValue(newItem)), nil, entityValue(newItem))
+	publishEntityChange(ctx.Request.Context(), c.changeFeed, service, changefeed.OperationCreate, entityObjectId(entityValue(newItem)), entityValue(newItem))
+	responsex.GinHandleSuccessWithData(ctx, newItem)
+}
+
+// update,replace the whole document
+func (c *GinEntityController[T]) Update(ctx *gin.Context) {
+	id, ok := getGinObjectIdParam(ctx)
+	if !ok {
+		return
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	item, err := service.FindById(id)
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	if item == nil {
+		responsex.FailWithStatus(http.StatusNotFound, fmt.Errorf("not found item,id:%s", id.Hex()), ctx)
+		return
+	}
+	if !ginCheckIfMatch(ctx, entityValue(item)) {
+		return
+	}
+
+	input := new(T)
+	err = ctx.ShouldBindJSON(input)
+	if err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return
+	}
+	inputValue := entityValue(input)
+	err = mongodbr.Validate(inputValue)
+	if err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return
+	}
+	if hookable, ok := inputValue.(mongodbr.IEntityBeforeUpdate); ok {
+		hookable.BeforeUpdate()
+	}
+	replacement, err := mongodbr.ToBsonMap(inputValue)
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	replacement["_id"] = id
+	if err = keepStoredFields[T](replacement, entityValue(item)); err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+
+	if versioned, ok := entityValue(item).(mongodbr.IVersionedEntity); ok {
+		// expected version is from If-Match header or from the request body
+		expectedVersion := versioned.GetVersion()
+		if len(ctx.GetHeader(headerIfMatch)) <= 0 {
+			expectedVersion = inputValue.(mongodbr.IVersionedEntity).GetVersion()
+		}
+		err = service.ReplaceByIdWithVersion(id, expectedVersion, replacement)
+	} else {
+		err = service.ReplaceById(id, replacement)
+	}
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	auditEntityChange[T](ctx.Request.Context(), audit.GetGin(ctx), GetGinTenantId(ctx), id, entityValue(item), replacement)
+	publishEntityChange(ctx.Request.Context(), c.changeFeed, service, changefeed.OperationUpdate, id, replacement)
+	responsex.GinHandleSuccess(ctx)
+}
+
+// patch,support json merge patch(RFC 7396) and json patch(RFC 6902)
+func (c *GinEntityController[T]) Patch(ctx *gin.Context) {
+	id, ok := getGinObjectIdParam(ctx)
+	if !ok {
+		return
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	item, err := service.FindById(id)
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	if item == nil {
+		responsex.FailWithStatus(http.StatusNotFound, fmt.Errorf("not found item,id:%s", id.Hex()), ctx)
+		return
+	}
+	if !ginCheckIfMatch(ctx, entityValue(item)) {
+		return
+	}
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return
+	}
+	current, err := mongodbr.ToBsonMap(entityValue(item))
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+
+	fieldSet := mongodbr.GetEntityFieldSet(new(T))
+	var update bson.M
+	switch ctx.ContentType() {
+	case patch.ContentTypeJsonPatch:
+		update, err = patch.JsonPatchToUpdate(body, fieldSet, current)
+	case patch.ContentTypeMergePatch, binding.MIMEJSON:
+		update, err = patch.MergePatchToUpdate(body, fieldSet, current)
+	default:
+		responsex.FailWithStatus(http.StatusUnsupportedMediaType,
+			fmt.Errorf("unsupported content type,content type must be %s or %s", patch.ContentTypeMergePatch, patch.ContentTypeJsonPatch), ctx)
+		return
+	}
+	if err != nil {
+		// failed test is 409,invalid path or operation is 422 by mapEntityError,otherwise 400
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return
+	}
+	if len(update) <= 0 {
+		responsex.GinHandleSuccess(ctx)
+		return
+	}
+
+	// the patch is computed from the stored document,so it must be applied to the same version
+	if versioned, ok := entityValue(item).(mongodbr.IVersionedEntity); ok {
+		err = service.UpdateByIdWithVersion(id, versioned.GetVersion(), update)
+	} else {
+		err = service.UpdateById(id, update)
+	}
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	auditEntityUpdate[T](ctx.Request.Context(), audit.GetGin(ctx), GetGinTenantId(ctx), service, id, entityValue(item))
+	publishEntityChange(ctx.Request.Context(), c.changeFeed, service, changefeed.OperationUpdate, id, nil)
+	responsex.GinHandleSuccess(ctx)
+}
+
+// delete
+func (c *GinEntityController[T]) Delete(ctx *gin.Context) {
+	oid, ok := getGinObjectIdParam(ctx)
+	if !ok {
+		return
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	hard, _ := strconv.ParseBool(ctx.Query("hard"))
+	if hard && !c.canHardDelete(ctx) {
+		responsex.FailWithError(ErrCodeHardDeleteDenied.New(""), ctx)
+		return
+	}
+	repository := service.GetRepository()
+	if hard {
+		// soft deleted item can be deleted permanently
+		repository = repository.WithDeleted()
+	}
+	item, err := mongodbr.FindTByObjectId[T](repository, oid)
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	if item == nil {
+		responsex.FailWithStatus(http.StatusNotFound, fmt.Errorf("not found item,id:%s", oid.Hex()), ctx)
+		return
+	}
+	if !ginCheckIfMatch(ctx, entityValue(item)) {
+		return
+	}
+
+	versioned, ok := entityValue(item).(mongodbr.IVersionedEntity)
+	ifMatch := ok && len(ctx.GetHeader(headerIfMatch)) > 0
+	switch {
+	case hard && ifMatch:
+		err = service.PurgeWithVersion(oid, versioned.GetVersion())
+	case hard:
+		err = service.Purge(oid)
+	case ifMatch:
+		err = service.SoftDeleteWithVersion(oid, versioned.GetVersion(), GetGinUserId(ctx))
+	default:
+		err = service.SoftDelete(oid, GetGinUserId(ctx))
+	}
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	auditEntityChange[T](ctx.Request.Context(), audit.GetGin(ctx), GetGinTenantId(ctx), oid, entityValue(item), nil)
+	publishEntityChange(ctx.Request.Context(), c.changeFeed, service, changefeed.OperationDelete, oid, entityValue(item))
+	responsex.GinHandleSuccess(ctx)
+}
+
+// restore a soft deleted item
+func (c *GinEntityController[T]) Restore(ctx *gin.Context) {
+	id, ok := getGinObjectIdParam(ctx)
+	if !ok {
+		return
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	if !service.GetRepository().IsSoftDeleteEnabled() {
+		responsex.FailWithError(ErrCodeSoftDeleteDisabled.New(""), ctx)
+		return
+	}
+	record := audit.GetGin(ctx)
+	before, err := restoreAuditSnapshot(record, service, id)
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	err = service.Restore(id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			responsex.FailWithStatus(http.StatusNotFound, fmt.Errorf("not found deleted item,id:%s", id.Hex()), ctx)
+			return
+		}
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	auditEntityUpdate[T](ctx.Request.Context(), record, GetGinTenantId(ctx), service, id, before)
+	publishEntityChange(ctx.Request.Context(), c.changeFeed, service, changefeed.OperationUpdate, id, nil)
+	responsex.GinHandleSuccess(ctx)
+}
+
+// hard delete is allowed for admin by default
+func (c *GinEntityController[T]) canHardDelete(ctx *gin.Context) bool {
+	if c.Options.GinHardDeleteAuthorizeFunc != nil {
+		return c.Options.GinHardDeleteAuthorizeFunc(ctx)
+	}
+	return IsGinAdmin(ctx)
+}
+
+// delete
+func (c *GinEntityController[T]) DeleteList(ctx *gin.Context) {
+	payload, err := GetGinBatchRequestPayload(ctx)
+	if err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return
+	}
+	record := audit.GetGin(ctx)
+	if len(payload.Ids) <= 0 {
+		if record != nil {
+			record.Skipped = true
+		}
+		responsex.GinHandleSuccess(ctx)
+		return
+	}
+	filter := bson.M{
+		"_id": bson.M{"$in": payload.Ids},
+	}
+
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	var deletedList []T
+	if record != nil || needPublishEntityChange(c.changeFeed, service) {
+		// the items are loaded before they are deleted so their fields are recorded and published
+		deletedList, err = service.FindList(filter)
+		if err != nil {
+			responsex.FailWithError(err, ctx)
+			return
+		}
+	}
+	_, err = service.DeleteMany(filter)
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	if record != nil && len(deletedList) <= 0 {
+		record.Skipped = true
+	}
+	for index := range deletedList {
+		item := entityValue(&deletedList[index])
+		auditEntityChange[T](ctx.Request.Context(), record, GetGinTenantId(ctx), entityObjectId(item), item, nil)
+		publishEntityChange(ctx.Request.Context(), c.changeFeed, service, changefeed.OperationDelete, entityObjectId(item), item)
+	}
+	responsex.GinHandleSuccess(ctx)
+}
+
+func (c *GinEntityController[T]) SetUserInfo(ctx *gin.Context, entityValue interface{}) {
+	userinfoProvider, ok := entityValue.(entity.IEntityWithUser)
+	if !ok {
+		return
+	}
+	userId := GetGinUserId(ctx)
+	if userId != "" {
+		userinfoProvider.SetUserCreator(userId)
+	}
+}
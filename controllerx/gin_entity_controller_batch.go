@@ -0,0 +1,191 @@
+package controllerx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/controllerx/audit"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/entity/changefeed"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// see EntityController.BatchCreate
+func (c *GinEntityController[T]) BatchCreate(ctx *gin.Context) {
+	rawList, ok := c.readBatchBody(ctx)
+	if !ok {
+		return
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	ordered := isGinBatchOrdered(ctx)
+	resultList := newBatchResultList(len(rawList))
+
+	itemList := make([]interface{}, 0, len(rawList))
+	indexList := make([]int, 0, len(rawList))
+	for index, eachRaw := range rawList {
+		input := new(T)
+		if err := json.Unmarshal(eachRaw, input); err != nil {
+			failBatchItem(&resultList[index], http.StatusBadRequest, err)
+			continue
+		}
+		inputValue := entityValue(input)
+		if err := mongodbr.Validate(inputValue); err != nil {
+			failBatchItem(&resultList[index], http.StatusBadRequest, err)
+			continue
+		}
+		// handler user info
+		c.SetUserInfo(ctx, inputValue)
+		itemList = append(itemList, inputValue)
+		indexList = append(indexList, index)
+	}
+	if len(itemList) <= 0 || (ordered && len(itemList) < len(rawList)) {
+		skipBatchItems(resultList, indexList)
+		responsex.GinHandleSuccessWithData(ctx, resultList)
+		return
+	}
+
+	_, err := service.CreateMany(itemList, ordered)
+	applyBatchWriteResult(resultList, indexList, err, ordered, http.StatusCreated)
+	record := audit.GetGin(ctx)
+	for i, index := range indexList {
+		if isBatchItemFailed(&resultList[index]) {
+			continue
+		}
+		if e, ok := itemList[i].(mongodbr.IEntity); ok {
+			resultList[index].Id = e.GetObjectId().Hex()
+			auditEntityChange[T](ctx.Request.Context(), record, GetGinTenantId(ctx), e.GetObjectId(), nil, e)
+			publishEntityChange(ctx.Request.Context(), c.changeFeed, service, changefeed.OperationCreate, e.GetObjectId(), e)
+		}
+	}
+	responsex.GinHandleSuccessWithData(ctx, resultList)
+}
+
+// see EntityController.BatchUpdate
+func (c *GinEntityController[T]) BatchUpdate(ctx *gin.Context) {
+	rawList, ok := c.readBatchBody(ctx)
+	if !ok {
+		return
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return
+	}
+	ordered := isGinBatchOrdered(ctx)
+	resultList := newBatchResultList(len(rawList))
+
+	fieldSet := mongodbr.GetEntityFieldSet(new(T))
+	idKey := "objectId"
+	if f, ok := fieldSet.Field("_id"); ok && len(f.JsonName) > 0 {
+		idKey = f.JsonName
+	}
+	patchList := make([]map[string]json.RawMessage, len(rawList))
+	idList := make([]primitive.ObjectID, 0, len(rawList))
+	for index, eachRaw := range rawList {
+		patchObject := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(eachRaw, &patchObject); err != nil {
+			failBatchItem(&resultList[index], http.StatusBadRequest, fmt.Errorf("item must be a json object,%w", err))
+			continue
+		}
+		var idValue string
+		_ = json.Unmarshal(patchObject[idKey], &idValue)
+		id, err := primitive.ObjectIDFromHex(idValue)
+		if err != nil {
+			failBatchItem(&resultList[index], http.StatusBadRequest, fmt.Errorf("invalid id,%s must be bson id format", idKey))
+			continue
+		}
+		delete(patchObject, idKey)
+		resultList[index].Id = id.Hex()
+		patchList[index] = patchObject
+		idList = append(idList, id)
+	}
+
+	storedList, err := service.FindList(bson.M{"_id": bson.M{"$in": idList}})
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	storedMap := make(map[primitive.ObjectID]T, len(storedList))
+	for _, eachItem := range storedList {
+		storedMap[eachItem.GetObjectId()] = eachItem
+	}
+
+	updateList := make([]mongodbr.BulkUpdateItem, 0, len(rawList))
+	indexList := make([]int, 0, len(rawList))
+	for index, eachPatch := range patchList {
+		if isBatchItemFailed(&resultList[index]) {
+			continue
+		}
+		id, _ := primitive.ObjectIDFromHex(resultList[index].Id)
+		stored, ok := storedMap[id]
+		if !ok {
+			failBatchItem(&resultList[index], http.StatusNotFound, fmt.Errorf("not found item,id:%s", id.Hex()))
+			continue
+		}
+		updateItem, err := buildBatchUpdateItem[T](id, entityValue(&stored), eachPatch, fieldSet)
+		if err != nil {
+			failBatchItem(&resultList[index], batchPatchErrorStatus(err), err)
+			continue
+		}
+		if len(updateItem.Update) <= 0 {
+			resultList[index].Status = http.StatusOK
+			continue
+		}
+		updateList = append(updateList, *updateItem)
+		indexList = append(indexList, index)
+	}
+	if ordered && hasFailedBatchItem(resultList) {
+		skipBatchItems(resultList, indexList)
+		responsex.GinHandleSuccessWithData(ctx, resultList)
+		return
+	}
+	if len(updateList) <= 0 {
+		responsex.GinHandleSuccessWithData(ctx, resultList)
+		return
+	}
+
+	result, err := service.BulkUpdateById(updateList, ordered)
+	applyBatchWriteResult(resultList, indexList, err, ordered, http.StatusOK)
+	if err == nil && result != nil && result.MatchedCount < int64(len(updateList)) {
+		// some items are changed or deleted by other request between the read and the write
+		checkBatchUpdateMatched(service, resultList, updateList, indexList)
+	}
+	updatedList := updatedIdList(resultList, updateList, indexList)
+	auditEntityUpdateList[T](ctx.Request.Context(), audit.GetGin(ctx), GetGinTenantId(ctx), service, updatedList, batchAuditSnapshots(storedMap))
+	publishEntityUpdateList(ctx.Request.Context(), c.changeFeed, service, updatedList)
+	responsex.GinHandleSuccessWithData(ctx, resultList)
+}
+
+// read the json array body,write a bad request response if it is invalid
+func (c *GinEntityController[T]) readBatchBody(ctx *gin.Context) ([]json.RawMessage, bool) {
+	rawList := make([]json.RawMessage, 0)
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&rawList); err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, fmt.Errorf("body must be a json array,%w", err), ctx)
+		return nil, false
+	}
+	if len(rawList) <= 0 {
+		responsex.FailWithError(ErrCodeBodyRequired.New(""), ctx)
+		return nil, false
+	}
+	if err := checkBatchSize(len(rawList), c.Options.BatchMaxSize); err != nil {
+		responsex.FailWithStatus(http.StatusRequestEntityTooLarge, err, ctx)
+		return nil, false
+	}
+	return rawList, true
+}
+
+// query ordered,default is true
+func isGinBatchOrdered(ctx *gin.Context) bool {
+	ordered, err := strconv.ParseBool(ctx.Query("ordered"))
+	if err != nil {
+		return true
+	}
+	return ordered
+}
@@ -0,0 +1,129 @@
+package controllerx
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/entity/filter"
+)
+
+func (c *GinEntityController[T]) GetExportService() entity.IEntityExportService[T] {
+	c.exportOnce.Do(func() {
+		if c.ExportService == nil {
+			c.ExportService = GetEntityExportService[T](c.GetEntityService().GetRepository())
+		}
+		startCleanup(c.ExportService.GetStore(), c.ExportService.RunCleanup, exportCleanupInterval)
+	})
+	return c.ExportService
+}
+
+// see EntityController.Export
+func (c *GinEntityController[T]) Export(ctx *gin.Context) {
+	input := &ExportRequest{}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(input); err != nil {
+			responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+			return
+		}
+	}
+	query := filter.MustGetFilterQuery(ctx.Query)
+	sort := filter.MustGetSortOption(ctx.Query)
+	if !c.Options.FilterCurrentUserForListDisabled {
+		// auto filter current userId
+		AddGinUserIdFilterIfNeed(query, new(T), ctx)
+	}
+
+	tenantId, ok := c.getRequestTenantId(ctx)
+	if !ok {
+		return
+	}
+	if scope := getGinPermissionScope(ctx); scope != nil {
+		query = map[string]interface{}{"$and": []interface{}{query, scope}}
+	}
+
+	exportType := input.Type
+	if len(exportType) <= 0 {
+		exportType = ctx.DefaultQuery("type", entity.ExportType_CSV)
+	}
+	service := c.GetExportService()
+	exportId, err := service.Export(entity.ExportOptions{
+		Type:                 exportType,
+		Filter:               query,
+		Sort:                 sort,
+		Async:                true,
+		CreatorId:            GetGinUserId(ctx),
+		TenantId:             tenantId,
+		FieldNameList:        input.FieldNameList,
+		FieldNameTitleMap:    input.FieldNameTitleMap,
+		ExcludeFieldNameList: input.ExcludeFieldNameList,
+	})
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	export, err := service.GetExport(exportId)
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	responsex.GinHandleSuccessWithData(ctx, export)
+}
+
+// status and rows written of an export
+func (c *GinEntityController[T]) GetExport(ctx *gin.Context) {
+	export, ok := c.findExport(ctx)
+	if !ok {
+		return
+	}
+	responsex.GinHandleSuccessWithData(ctx, export)
+}
+
+// download the file of a finished export
+func (c *GinEntityController[T]) DownloadExport(ctx *gin.Context) {
+	export, ok := c.findExport(ctx)
+	if !ok {
+		return
+	}
+	if export.Status != entity.ExportStatus_Finished {
+		responsex.FailWithStatus(http.StatusConflict, fmt.Errorf("export is not finished,status:%s", export.Status), ctx)
+		return
+	}
+	file, err := c.GetExportService().OpenFile(export)
+	if err != nil {
+		if errors.Is(err, entity.ErrExportFileNotFound) {
+			responsex.FailWithStatus(http.StatusNotFound, fmt.Errorf("export file not found,id:%s", export.Id), ctx)
+			return
+		}
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	defer file.Close()
+	ctx.DataFromReader(http.StatusOK, -1, exportContentType(export.FileName), file, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": export.FileName}),
+	})
+}
+
+// only the user who starts the export and admin can read it
+func (c *GinEntityController[T]) findExport(ctx *gin.Context) (*entity.EntityExport, bool) {
+	exportId := ctx.Param("id")
+	export, err := c.GetExportService().GetExport(exportId)
+	if err != nil {
+		if errors.Is(err, entity.ErrExportNotFound) {
+			responsex.FailWithStatus(http.StatusNotFound, fmt.Errorf("export not found,id:%s", exportId), ctx)
+			return nil, false
+		}
+		responsex.FailWithError(err, ctx)
+		return nil, false
+	}
+	if (len(export.CreatorId) > 0 && export.CreatorId != GetGinUserId(ctx) && !IsGinAdmin(ctx)) ||
+		!c.isTenantAccessible(ctx, export.TenantId) {
+		responsex.FailWithStatus(http.StatusNotFound, fmt.Errorf("export not found,id:%s", exportId), ctx)
+		return nil, false
+	}
+	return export, true
+}
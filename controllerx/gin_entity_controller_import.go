@@ -0,0 +1,151 @@
+package controllerx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/controllerx/audit"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/entity"
+)
+
+func (c *GinEntityController[T]) GetImportService() entity.IEntityImportService[T] {
+	c.importOnce.Do(func() {
+		if c.ImportService == nil {
+			c.ImportService = GetEntityImportService[T](c.GetEntityService().GetRepository())
+		}
+		startCleanup(c.ImportService.GetStore(), c.ImportService.RunCleanup, exportCleanupInterval)
+	})
+	return c.ImportService
+}
+
+// see EntityController.Import
+func (c *GinEntityController[T]) Import(ctx *gin.Context) {
+	tenantId, ok := c.getRequestTenantId(ctx)
+	if !ok {
+		return
+	}
+	// rows are matched by key fields,the conditions of permission cannot be checked
+	if getGinPermissionScope(ctx) != nil {
+		responsex.FailWithError(ErrCodeImportConditionalPermission.New(""), ctx)
+		return
+	}
+	maxFileSize := c.Options.ImportMaxFileSize
+	if maxFileSize <= 0 {
+		maxFileSize = DefaultImportMaxFileSize
+	}
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxFileSize)
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			responsex.FailWithStatus(http.StatusRequestEntityTooLarge, err, ctx)
+			return
+		}
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return
+	}
+	defer file.Close()
+
+	batchSize, _ := strconv.Atoi(ctx.PostForm("batchSize"))
+	options := entity.ImportOptions{
+		Type:      ctx.PostForm("type"),
+		Mode:      ctx.DefaultPostForm("mode", entity.ImportMode_Insert),
+		BatchSize: batchSize,
+		Async:     true,
+		CreatorId: GetGinUserId(ctx),
+		TenantId:  tenantId,
+		FileName:  header.Filename,
+	}
+	if dryRun, err := strconv.ParseBool(ctx.PostForm("dryRun")); err == nil {
+		options.DryRun = dryRun
+	}
+	if keyFieldNameList := ctx.PostForm("keyFieldNameList"); len(keyFieldNameList) > 0 {
+		options.KeyFieldNameList = strings.Split(keyFieldNameList, ",")
+	}
+	if columnFieldMap := ctx.PostForm("columnFieldMap"); len(columnFieldMap) > 0 {
+		if err := json.Unmarshal([]byte(columnFieldMap), &options.ColumnFieldMap); err != nil {
+			responsex.FailWithStatus(http.StatusBadRequest, fmt.Errorf("invalid columnFieldMap,err:%s", err.Error()), ctx)
+			return
+		}
+	}
+
+	if record := audit.GetGin(ctx); record != nil {
+		options.ItemWrittenFunc = auditImportedItems[T](ctx.Request.Context(), &c.Options, record, fwauth.GetGinPrincipal(ctx), tenantId)
+	}
+
+	service := c.GetImportService()
+	importId, err := service.Import(file, options)
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	entityImport, err := service.GetImport(importId)
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	responsex.GinHandleSuccessWithData(ctx, entityImport)
+}
+
+// status,counts and the first errors of an import
+func (c *GinEntityController[T]) GetImport(ctx *gin.Context) {
+	entityImport, ok := c.findImport(ctx)
+	if !ok {
+		return
+	}
+	responsex.GinHandleSuccessWithData(ctx, entityImport)
+}
+
+// download the error report of a finished import
+func (c *GinEntityController[T]) DownloadImportReport(ctx *gin.Context) {
+	entityImport, ok := c.findImport(ctx)
+	if !ok {
+		return
+	}
+	if entityImport.Status == entity.ImportStatus_Running {
+		responsex.FailWithStatus(http.StatusConflict, fmt.Errorf("import is not finished,status:%s", entityImport.Status), ctx)
+		return
+	}
+	if len(entityImport.ReportPath) <= 0 {
+		responsex.FailWithStatus(http.StatusNotFound, fmt.Errorf("import has no error,id:%s", entityImport.Id), ctx)
+		return
+	}
+	if _, err := os.Stat(entityImport.ReportPath); err != nil {
+		responsex.FailWithStatus(http.StatusNotFound, fmt.Errorf("import report not found,id:%s", entityImport.Id), ctx)
+		return
+	}
+	ctx.FileAttachment(entityImport.ReportPath, entityImport.ReportFileName)
+}
+
+// only the user who starts the import and admin can read it
+func (c *GinEntityController[T]) findImport(ctx *gin.Context) (*entity.EntityImport, bool) {
+	importId := ctx.Param("id")
+	entityImport, err := c.GetImportService().GetImport(importId)
+	if err != nil {
+		if errors.Is(err, entity.ErrImportNotFound) {
+			responsex.FailWithStatus(http.StatusNotFound, fmt.Errorf("import not found,id:%s", importId), ctx)
+			return nil, false
+		}
+		responsex.FailWithError(err, ctx)
+		return nil, false
+	}
+	if (len(entityImport.CreatorId) > 0 && entityImport.CreatorId != GetGinUserId(ctx) && !IsGinAdmin(ctx)) ||
+		!c.isTenantAccessible(ctx, entityImport.TenantId) {
+		responsex.FailWithStatus(http.StatusNotFound, fmt.Errorf("import not found,id:%s", importId), ctx)
+		return nil, false
+	}
+	return entityImport, true
+}
@@ -0,0 +1,77 @@
+package controllerx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/entity/changefeed"
+	"github.com/shanluzhineng/fwpkg/entity/filter"
+)
+
+// see EntityController.Stream
+func (c *GinEntityController[T]) Stream(ctx *gin.Context) {
+	requestCtx := ctx.Request.Context()
+	query, eventChan, ok := c.subscribeChanges(ctx, requestCtx)
+	if !ok {
+		return
+	}
+	writer, err := newSSEStreamWriter(ctx.Writer)
+	if err != nil {
+		responsex.FailWithError(err, ctx)
+		return
+	}
+	pushChangeEvents[T](requestCtx, eventChan, query, streamHeartbeat(&c.Options), writer)
+}
+
+// see EntityController.StreamWs
+func (c *GinEntityController[T]) StreamWs(ctx *gin.Context) {
+	streamCtx, cancel := context.WithCancel(ctx.Request.Context())
+	defer cancel()
+	query, eventChan, ok := c.subscribeChanges(ctx, streamCtx)
+	if !ok {
+		return
+	}
+	// the error response is written by upgrader
+	conn, err := streamUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return
+	}
+	serveStreamWebSocket[T](streamCtx, cancel, conn, eventChan, query, streamHeartbeat(&c.Options))
+}
+
+// see EntityController.subscribeChanges
+func (c *GinEntityController[T]) subscribeChanges(ctx *gin.Context, streamCtx context.Context) (map[string]interface{}, <-chan *changefeed.ChangeEvent, bool) {
+	query, err := filter.GetFilterQuery(ctx.Query)
+	if err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+		return nil, nil, false
+	}
+	if query == nil {
+		query = map[string]interface{}{}
+	}
+	if !c.Options.FilterCurrentUserForListDisabled {
+		// auto filter current userId
+		AddGinUserIdFilterIfNeed(query, new(T), ctx)
+	}
+	if c.Options.GinListFilterFunc != nil {
+		c.Options.GinListFilterFunc(new(T), query, ctx)
+	}
+	service, ok := c.GetRequestEntityService(ctx)
+	if !ok {
+		return nil, nil, false
+	}
+	repository := service.GetRepository()
+	eventChan, err := c.changeFeed.Subscribe(streamCtx, repository.GetCollection(), service.GetTenantId(), getLastEventId(ctx.GetHeader, ctx.Query))
+	if err != nil {
+		if errors.Is(err, changefeed.ErrInvalidEventId) {
+			responsex.FailWithStatus(http.StatusBadRequest, err, ctx)
+			return nil, nil, false
+		}
+		responsex.FailWithError(err, ctx)
+		return nil, nil, false
+	}
+	return streamQuery(repository.GetScope(), query), eventChan, true
+}
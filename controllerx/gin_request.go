@@ -0,0 +1,39 @@
+package controllerx
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/controllerx/middleware/requestid"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/system/i18n"
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"go.uber.org/zap"
+)
+
+func init() {
+	responsex.SetGinLocaleResolver(GetGinLocale)
+}
+
+// request id of current gin request,see requestid.NewGin
+func GetGinRequestId(c *gin.Context) string {
+	return requestid.GetGin(c)
+}
+
+// logger with the request id of current gin request,log.Logger if the request has no request id
+func GetGinLogger(c *gin.Context) *zap.Logger {
+	return log.FromContext(c.Request.Context())
+}
+
+// gin version of GetLocale
+func GetGinLocale(c *gin.Context) string {
+	localeList := make([]string, 0, 2)
+	if principal := GetGinPrincipal(c); principal != nil && len(principal.Locale) > 0 {
+		localeList = append(localeList, principal.Locale)
+	}
+	localeList = append(localeList, c.GetHeader("Accept-Language"))
+	return i18n.Default().Match(localeList...)
+}
+
+// gin version of T
+func GinT(c *gin.Context, key string, args ...interface{}) string {
+	return i18n.T(GetGinLocale(c), key, args...)
+}
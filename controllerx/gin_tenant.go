@@ -0,0 +1,68 @@
+package controllerx
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/entity"
+)
+
+// gin version of TenantResolver
+type GinTenantResolver func(c *gin.Context) string
+
+var _ginTenantResolverList = []GinTenantResolver{GinTenantFromClaims()}
+
+// set the resolvers of GetGinTenantId,the first non-empty tenant is used,default is GinTenantFromClaims
+func SetGinTenantResolver(resolverList ...GinTenantResolver) {
+	_ginTenantResolverList = resolverList
+}
+
+// tenant of the principal,see TenantFromClaims
+func GinTenantFromClaims() GinTenantResolver {
+	return func(c *gin.Context) string {
+		principal := GetGinPrincipal(c)
+		if principal == nil {
+			return ""
+		}
+		return principal.TenantId
+	}
+}
+
+// tenant from the request header,HeaderTenantId is used if headerName is empty
+func GinTenantFromHeader(headerName string) GinTenantResolver {
+	if len(headerName) <= 0 {
+		headerName = HeaderTenantId
+	}
+	return func(c *gin.Context) string {
+		return strings.TrimSpace(c.GetHeader(headerName))
+	}
+}
+
+// tenant of current gin request,it is resolved once and kept in the request context
+func GetGinTenantId(c *gin.Context) string {
+	if tenantId := entity.TenantIdFromContext(c.Request.Context()); len(tenantId) > 0 {
+		return tenantId
+	}
+	for _, eachResolver := range _ginTenantResolverList {
+		if tenantId := eachResolver(c); len(tenantId) > 0 {
+			SetGinTenantId(c, tenantId)
+			return tenantId
+		}
+	}
+	return ""
+}
+
+// put the tenant into the request context,so the following handlers use it
+func SetGinTenantId(c *gin.Context, tenantId string) {
+	c.Request = c.Request.WithContext(entity.ContextWithTenantId(c.Request.Context(), tenantId))
+}
+
+// see IsSuperAdmin
+func IsGinSuperAdmin(c *gin.Context) bool {
+	return isSuperAdminPrincipal(GetGinPrincipal(c))
+}
+
+// the request asks to bypass tenant isolation with HeaderTenantBypass,it must be authorized
+func IsGinTenantBypassRequested(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader(HeaderTenantBypass), "true")
+}
@@ -0,0 +1,57 @@
+package controllerx
+
+import (
+	"github.com/casdoor/casdoor-go-sdk/casdoorsdk"
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+)
+
+// gin version of AuthenticateHandler,the casdoor authenticator is used if there is no default authenticator
+func GinAuthenticateHandler(authenticatorList ...fwauth.Authenticator) gin.HandlerFunc {
+	if len(authenticatorList) <= 0 {
+		authenticatorList = fwauth.GetDefaultAuthenticators()
+	}
+	if len(authenticatorList) <= 0 {
+		authenticatorList = []fwauth.Authenticator{fwauth.NewCasdoorAuthenticator()}
+	}
+	return fwauth.GinAuthenticate(authenticatorList...)
+}
+
+// authenticated user of current gin request,nil if the request is not authenticated
+func GetGinPrincipal(c *gin.Context) *fwauth.Principal {
+	return fwauth.GetGinPrincipal(c)
+}
+
+func GetGinUserId(c *gin.Context) string {
+	principal := GetGinPrincipal(c)
+	if principal != nil {
+		return principal.Id
+	}
+	return ""
+}
+
+// gin version of IsAdmin
+func IsGinAdmin(c *gin.Context) bool {
+	principal := GetGinPrincipal(c)
+	if principal == nil {
+		return false
+	}
+	if claims, ok := principal.Claims.(*casdoorsdk.Claims); ok && claims.IsAdmin {
+		return true
+	}
+	return principal.HasRole(RoleAdmin)
+}
+
+func AddGinUserIdFilterIfNeed(filter map[string]interface{}, entity interface{}, c *gin.Context) {
+	if filter == nil {
+		return
+	}
+	if checkEntityIsIEntityWithUser(entity) == nil {
+		return
+	}
+	currentUserId := GetGinUserId(c)
+	if currentUserId == "" {
+		return
+	}
+	filter["creatorId"] = currentUserId
+}
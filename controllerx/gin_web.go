@@ -0,0 +1,157 @@
+package controllerx
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/configurationx"
+	"github.com/shanluzhineng/fwpkg/app"
+	"github.com/shanluzhineng/fwpkg/app/host"
+	"github.com/shanluzhineng/fwpkg/app/web"
+	"github.com/shanluzhineng/fwpkg/controllerx/middleware/cors"
+	errHandler "github.com/shanluzhineng/fwpkg/controllerx/middleware/err"
+	"github.com/shanluzhineng/fwpkg/controllerx/middleware/metrics"
+	"github.com/shanluzhineng/fwpkg/controllerx/middleware/requestid"
+	"github.com/shanluzhineng/fwpkg/controllerx/middleware/tracing"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+func init() {
+	app.Register(NewGinApplication)
+}
+
+// gin version of IrisApplication,it has the same lifecycle,middlewares and startup actions
+type GinApplication struct {
+	*gin.Engine
+	Address string
+
+	isBuilded       bool
+	ginConfigurator []GinConfigurator
+	Err             error
+}
+
+type GinConfigurator func(*GinApplication)
+
+func NewGinApplication() *GinApplication {
+	engine := gin.New()
+	//请求id,在日志,事件及调用其他服务时传递
+	engine.Use(requestid.NewGin())
+	//请求的server span,日志中记录traceId
+	engine.Use(tracing.NewGin())
+	//请求数,耗时及大小的指标
+	engine.Use(metrics.NewGin())
+	//错误封装
+	engine.Use(errHandler.NewGin())
+	engine.Use(gin.CustomRecovery(func(c *gin.Context, err any) {
+		responsex.FailWithStatus(http.StatusInternalServerError, fmt.Errorf("%v", err), c)
+	}))
+	engine.Use(gin.LoggerWithConfig(gin.LoggerConfig{
+		SkipPaths: []string{"/api/health/check"},
+	}))
+	if configurationx.GetInstance().Web != nil {
+		cors.UseGinCors(engine, configurationx.GetInstance().Web.Cors)
+	}
+	ginApp := &GinApplication{
+		Engine:          engine,
+		ginConfigurator: make([]GinConfigurator, 0),
+		isBuilded:       false,
+	}
+	return ginApp
+}
+
+// build GinApplication environments
+func (a *GinApplication) Build(configurators ...GinConfigurator) *GinApplication {
+	if a.isBuilded {
+		return a
+	}
+	if a.Err != nil {
+		return a
+	}
+	defer func() {
+		a.isBuilded = true
+	}()
+	envHttp := host.GetHostEnvironment().GetEnvString(host.ENV_HTTP)
+	if len(envHttp) > 0 {
+		a.Address = envHttp
+	} else {
+		host.GetHostEnvironment().SetHttp(a.Address)
+	}
+	if len(a.Address) <= 0 {
+		msg := "没有配置好app.http参数"
+		log.Error(msg)
+		panic(msg)
+	}
+
+	//配置web应用中间件
+	web.SetWebApplication(web.NewWebApplication())
+	web.Application.ConfigureService()
+
+	a.pprofStartupAction()
+	//运行启动项
+	app.HostApplication.RunStartup()
+
+	//构建配置
+	for _, eachConfigurator := range configurators {
+		if eachConfigurator == nil {
+			continue
+		}
+		a.ginConfigurator = append(a.ginConfigurator, eachConfigurator)
+	}
+
+	//设置启动消耗的时间
+	startTime := host.GetHostEnvironment().GetEnv(host.ENV_StartTime).(time.Time)
+	interval := time.Since(startTime)
+	host.GetHostEnvironment().SetEnv(host.ENV_StartInterval, interval)
+
+	return a
+}
+
+func (a *GinApplication) Run(configurators ...GinConfigurator) *GinApplication {
+	a.Build(configurators...)
+
+	for _, eachConfigurator := range a.ginConfigurator {
+		eachConfigurator(a)
+	}
+	err := a.Engine.Run(a.Address)
+	a.Err = err
+	return a
+}
+
+func (a *GinApplication) pprofStartupAction() {
+	if app.HostApplication.SystemConfig().App.IsRunInCli {
+		return
+	}
+
+	log.Logger.Debug("正在构建pprof路径组件,/debug/pprof...")
+	// gin does not allow a catch-all route with static routes of the same path,so the action is dispatched here
+	a.Any("/debug/pprof/*action", func(c *gin.Context) {
+		switch strings.TrimPrefix(c.Param("action"), "/") {
+		case "cmdline":
+			pprof.Cmdline(c.Writer, c.Request)
+		case "profile":
+			pprof.Profile(c.Writer, c.Request)
+		case "symbol":
+			pprof.Symbol(c.Writer, c.Request)
+		case "trace":
+			pprof.Trace(c.Writer, c.Request)
+		default:
+			pprof.Index(c.Writer, c.Request)
+		}
+	})
+
+	httpValue := os.Getenv("app.http")
+	advertiseHostValue := os.Getenv("app.advertisehost")
+	if len(httpValue) > 0 {
+		pprofPath := httpValue
+		if len(advertiseHostValue) > 0 {
+			pprofPath = strings.Replace(httpValue, "0.0.0.0", advertiseHostValue, 1)
+		}
+		log.Logger.Debug(fmt.Sprintf("已经构建好pprof路径组件,你可以通过 %s/debug/pprof 来访问pprof", pprofPath))
+	}
+}
@@ -0,0 +1,84 @@
+package idempotency
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+// gin version of New
+func NewGin(opts ...IdempotencyOption) gin.HandlerFunc {
+	options := newOptions(opts...)
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(HeaderIdempotencyKey)
+		if len(idempotencyKey) <= 0 {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > MaxKeyLength {
+			responsex.FailWithError(ErrCodeKeyInvalid.New(fmt.Sprintf("the length of idempotency key must not be greater than %d", MaxKeyLength)), c)
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			responsex.FailWithError(responsex.ErrCodeBadRequest.Wrap(err), c)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := options.KeyPrefix + storeKey(fwauth.GetGinPrincipal(c), c.Request.Method, c.Request.URL.Path, idempotencyKey)
+		fingerprint := requestFingerprint(c.Request, body)
+		store := options.Store
+
+		token, record, err := waitLock(c.Request.Context(), options, key)
+		if err != nil {
+			responsex.FailWithError(err, c)
+			return
+		}
+		if record != nil {
+			replayGin(c, record, fingerprint)
+			return
+		}
+		if len(token) <= 0 {
+			// the store fails,the request is not rejected
+			c.Next()
+			return
+		}
+		defer func() {
+			if err := store.Unlock(key, token); err != nil {
+				log.Logger.Warn(fmt.Sprintf("idempotency unlock fail,key:%s,err:%s", key, err.Error()))
+			}
+		}()
+
+		recorder := responsex.RecordGin(c)
+		c.Next()
+
+		save(options, key, &Record{
+			Fingerprint: fingerprint,
+			Status:      recorder.Status(),
+			Header:      responseHeader(recorder.Header()),
+			Body:        append([]byte(nil), recorder.Body()...),
+		})
+		recorder.Send(c)
+	}
+}
+
+func replayGin(c *gin.Context, record *Record, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		responsex.FailWithError(ErrCodeKeyMismatch.New(""), c)
+		return
+	}
+	header := c.Writer.Header()
+	for name, valueList := range record.Header {
+		header[name] = valueList
+	}
+	c.Header(HeaderIdempotentReplayed, "true")
+	c.Status(record.Status)
+	_, _ = c.Writer.Write(record.Body)
+	c.Abort()
+}
@@ -0,0 +1,49 @@
+package idempotency
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGinIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	count := 0
+	engine := gin.New()
+	engine.POST("/orders", NewGin(WithStore(NewMemoryStore())), func(c *gin.Context) {
+		count++
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Header("X-Order", string(body))
+		c.JSON(http.StatusCreated, gin.H{"n": count})
+	})
+	post := func(key string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		req.Header.Set(HeaderIdempotencyKey, key)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("k1", "a")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "a", w.Header().Get("X-Order"))
+	body := w.Body.String()
+
+	// replayed
+	w = post("k1", "a")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, body, w.Body.String())
+	assert.Equal(t, "a", w.Header().Get("X-Order"))
+	assert.Equal(t, "true", w.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, 1, count)
+
+	// same key with different payload
+	w = post("k1", "b")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, 1, count)
+}
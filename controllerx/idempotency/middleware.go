@@ -0,0 +1,266 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/system/correlation"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// set to true in the replayed response
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	DefaultTtl         = 24 * time.Hour
+	DefaultLockTtl     = 30 * time.Second
+	DefaultWaitTimeout = 10 * time.Second
+	DefaultKeyPrefix   = "idempotency:"
+	MaxKeyLength       = 255
+
+	ErrorModuleIdempotency = "idempotency"
+)
+
+var (
+	ErrCodeKeyInvalid    = responsex.RegistErrorCode(ErrorModuleIdempotency, 11001, http.StatusBadRequest, "error.idempotencyKeyInvalid", "idempotency key is invalid")
+	ErrCodeKeyMismatch   = responsex.RegistErrorCode(ErrorModuleIdempotency, 11002, http.StatusConflict, "error.idempotencyKeyMismatch", "idempotency key is used by a request with different payload")
+	ErrCodeKeyInProgress = responsex.RegistErrorCode(ErrorModuleIdempotency, 11003, http.StatusConflict, "error.idempotencyKeyInProgress", "request with the same idempotency key is in progress")
+)
+
+// headers of the response that are not replayed
+var excludedHeaderList = []string{"Date", "Content-Length", correlation.HeaderRequestId}
+
+type IdempotencyOptions struct {
+	// redis store is used if not set,memory store is used if redisx.IRedisService is not registered
+	Store IStore
+	// how long the response is replayed,DefaultTtl is used if not set
+	Ttl time.Duration
+	// the lock is released after it if the request is not completed,DefaultLockTtl is used if not set
+	LockTtl time.Duration
+	// how long a duplicate waits for the request in progress,409 is returned after it,
+	// DefaultWaitTimeout is used if not set
+	WaitTimeout time.Duration
+	KeyPrefix   string
+}
+
+type IdempotencyOption func(*IdempotencyOptions)
+
+func WithStore(store IStore) IdempotencyOption {
+	return func(o *IdempotencyOptions) {
+		o.Store = store
+	}
+}
+
+func WithTtl(ttl time.Duration) IdempotencyOption {
+	return func(o *IdempotencyOptions) {
+		o.Ttl = ttl
+	}
+}
+
+func WithLockTtl(ttl time.Duration) IdempotencyOption {
+	return func(o *IdempotencyOptions) {
+		o.LockTtl = ttl
+	}
+}
+
+func WithWaitTimeout(timeout time.Duration) IdempotencyOption {
+	return func(o *IdempotencyOptions) {
+		o.WaitTimeout = timeout
+	}
+}
+
+func WithKeyPrefix(keyPrefix string) IdempotencyOption {
+	return func(o *IdempotencyOptions) {
+		o.KeyPrefix = keyPrefix
+	}
+}
+
+// handler that honours the Idempotency-Key header,the response of the first request is stored
+// and replayed for the requests with the same key.
+// the key is scoped by the user,method and path,a request with the same key and different payload gets 409.
+// concurrent duplicates are serialized by a lock,5xx responses are not stored so the request can be retried.
+// it should run after the authentication so the key is scoped by the user
+func New(opts ...IdempotencyOption) iris.Handler {
+	options := newOptions(opts...)
+	return func(ctx iris.Context) {
+		idempotencyKey := ctx.GetHeader(HeaderIdempotencyKey)
+		if len(idempotencyKey) <= 0 {
+			ctx.Next()
+			return
+		}
+		if len(idempotencyKey) > MaxKeyLength {
+			responsex.HandleAppError(ctx, ErrCodeKeyInvalid.New(fmt.Sprintf("the length of idempotency key must not be greater than %d", MaxKeyLength)))
+			return
+		}
+		body, err := io.ReadAll(ctx.Request().Body)
+		if err != nil {
+			responsex.HandleAppError(ctx, responsex.ErrCodeBadRequest.Wrap(err))
+			return
+		}
+		ctx.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+		key := options.KeyPrefix + storeKey(fwauth.GetIrisPrincipal(ctx), ctx.Method(), ctx.Path(), idempotencyKey)
+		fingerprint := requestFingerprint(ctx.Request(), body)
+		store := options.Store
+
+		token, record, err := waitLock(ctx.Request().Context(), options, key)
+		if err != nil {
+			responsex.HandleAppError(ctx, err)
+			return
+		}
+		if record != nil {
+			replay(ctx, record, fingerprint)
+			return
+		}
+		if len(token) <= 0 {
+			// the store fails,the request is not rejected
+			ctx.Next()
+			return
+		}
+		defer func() {
+			if err := store.Unlock(key, token); err != nil {
+				log.Logger.Warn(fmt.Sprintf("idempotency unlock fail,key:%s,err:%s", key, err.Error()))
+			}
+		}()
+
+		ctx.Record()
+		ctx.Next()
+
+		save(options, key, &Record{
+			Fingerprint: fingerprint,
+			Status:      ctx.GetStatusCode(),
+			Header:      responseHeader(ctx.ResponseWriter().Header()),
+			Body:        ctx.Recorder().Body(),
+		})
+	}
+}
+
+func newOptions(opts ...IdempotencyOption) *IdempotencyOptions {
+	options := &IdempotencyOptions{}
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+	if options.Store == nil {
+		options.Store = newLazyRedisStore()
+	}
+	if options.Ttl <= 0 {
+		options.Ttl = DefaultTtl
+	}
+	if options.LockTtl <= 0 {
+		options.LockTtl = DefaultLockTtl
+	}
+	if options.WaitTimeout <= 0 {
+		options.WaitTimeout = DefaultWaitTimeout
+	}
+	if len(options.KeyPrefix) <= 0 {
+		options.KeyPrefix = DefaultKeyPrefix
+	}
+	return options
+}
+
+// acquire the lock of key,record is not nil if the key is completed and the response should be replayed.
+// token is empty if the store fails,err is not nil if the request is rejected
+func waitLock(requestCtx context.Context, options *IdempotencyOptions, key string) (token string, record *Record, err error) {
+	store := options.Store
+	deadline := time.Now().Add(options.WaitTimeout)
+	for {
+		record, err := store.Get(key)
+		if err != nil {
+			log.Logger.Warn(fmt.Sprintf("idempotency get fail,key:%s,err:%s", key, err.Error()))
+			return "", nil, nil
+		}
+		if record != nil {
+			return "", record, nil
+		}
+		token, err = store.Lock(key, options.LockTtl)
+		if err != nil {
+			log.Logger.Warn(fmt.Sprintf("idempotency lock fail,key:%s,err:%s", key, err.Error()))
+			return "", nil, nil
+		}
+		if len(token) > 0 {
+			// the request may be completed between get and lock
+			record, err = store.Get(key)
+			if err == nil && record != nil {
+				_ = store.Unlock(key, token)
+				return "", record, nil
+			}
+			return token, nil, nil
+		}
+		if !time.Now().Before(deadline) {
+			return "", nil, ErrCodeKeyInProgress.New("")
+		}
+		select {
+		case <-requestCtx.Done():
+			return "", nil, requestCtx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// store the response,5xx responses are not stored
+func save(options *IdempotencyOptions, key string, record *Record) {
+	if record.Status >= http.StatusInternalServerError {
+		return
+	}
+	record.CreationTime = time.Now()
+	if err := options.Store.Save(key, record, options.Ttl); err != nil {
+		log.Logger.Warn(fmt.Sprintf("idempotency save fail,key:%s,err:%s", key, err.Error()))
+	}
+}
+
+func replay(ctx iris.Context, record *Record, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		responsex.HandleAppError(ctx, ErrCodeKeyMismatch.New(""))
+		return
+	}
+	header := ctx.ResponseWriter().Header()
+	for name, valueList := range record.Header {
+		header[name] = valueList
+	}
+	ctx.Header(HeaderIdempotentReplayed, "true")
+	ctx.StatusCode(record.Status)
+	_, _ = ctx.Write(record.Body)
+	ctx.StopExecution()
+}
+
+// the key is hashed with the user,method and path so the same key of different users or endpoints does not conflict
+func storeKey(principal *fwauth.Principal, method string, path string, idempotencyKey string) string {
+	scope := ""
+	if principal != nil {
+		scope = principal.TenantId + ":" + principal.Id
+	}
+	h := sha256.New()
+	for _, each := range []string{scope, method, path, idempotencyKey} {
+		h.Write([]byte(each))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func requestFingerprint(request *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(request.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(request.URL.RequestURI()))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func responseHeader(header http.Header) http.Header {
+	result := header.Clone()
+	for _, eachName := range excludedHeaderList {
+		result.Del(eachName)
+	}
+	return result
+}
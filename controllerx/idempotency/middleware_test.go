@@ -0,0 +1,101 @@
+package idempotency
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestApp(handler iris.Handler) *iris.Application {
+	app := iris.New()
+	app.Post("/orders", New(WithStore(NewMemoryStore())), handler)
+	if err := app.Build(); err != nil {
+		panic(err)
+	}
+	return app
+}
+
+func post(app *iris.Application, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	if len(key) > 0 {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	var count int32
+	app := newTestApp(func(ctx iris.Context) {
+		n := atomic.AddInt32(&count, 1)
+		body, _ := io.ReadAll(ctx.Request().Body)
+		ctx.Header("X-Order", string(body))
+		ctx.StatusCode(http.StatusCreated)
+		ctx.JSON(iris.Map{"n": n})
+	})
+
+	w := post(app, "k1", "a")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "a", w.Header().Get("X-Order"))
+	body := w.Body.String()
+
+	// replayed
+	w = post(app, "k1", "a")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, body, w.Body.String())
+	assert.Equal(t, "a", w.Header().Get("X-Order"))
+	assert.Equal(t, "true", w.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	// same key with different payload
+	w = post(app, "k1", "b")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	// no key
+	post(app, "", "a")
+	post(app, "", "a")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+}
+
+func TestIdempotencyConcurrent(t *testing.T) {
+	var count int32
+	app := newTestApp(func(ctx iris.Context) {
+		atomic.AddInt32(&count, 1)
+		time.Sleep(200 * time.Millisecond)
+		ctx.JSON(iris.Map{})
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := post(app, "k1", "a")
+			assert.Equal(t, http.StatusOK, w.Code)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestIdempotencyServerError(t *testing.T) {
+	var count int32
+	app := newTestApp(func(ctx iris.Context) {
+		atomic.AddInt32(&count, 1)
+		ctx.StatusCode(http.StatusInternalServerError)
+	})
+
+	post(app, "k1", "a")
+	post(app, "k1", "a")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+}
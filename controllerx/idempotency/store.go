@@ -0,0 +1,132 @@
+package idempotency
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// response of the first request with the idempotency key
+type Record struct {
+	// sha256 of method,path and body of the request
+	Fingerprint  string      `json:"fingerprint"`
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         []byte      `json:"body,omitempty"`
+	CreationTime time.Time   `json:"creationTime"`
+}
+
+type IStore interface {
+	// stored record of the key,nil if it is not found or expired
+	Get(key string) (*Record, error)
+	// store the record,it is expired after ttl
+	Save(key string, record *Record, ttl time.Duration) error
+	// lock the key so the duplicates are serialized,
+	// empty token is returned if the key is locked by others,the lock is released after ttl
+	Lock(key string, ttl time.Duration) (string, error)
+	// release the lock acquired with the token
+	Unlock(key string, token string) error
+}
+
+func newLockToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// #region memory store
+
+type memoryItem struct {
+	record     *Record
+	expireTime time.Time
+}
+
+type memoryLock struct {
+	token      string
+	expireTime time.Time
+}
+
+type memoryStore struct {
+	lock      sync.Mutex
+	itemMap   map[string]*memoryItem
+	lockMap   map[string]*memoryLock
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// records in memory of current instance,it is used in test or single instance deployment
+func NewMemoryStore() IStore {
+	return &memoryStore{
+		itemMap: make(map[string]*memoryItem),
+		lockMap: make(map[string]*memoryLock),
+		now:     time.Now,
+	}
+}
+
+func (s *memoryStore) Get(key string) (*Record, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := s.now()
+	s.sweep(now)
+	item, ok := s.itemMap[key]
+	if !ok || !now.Before(item.expireTime) {
+		return nil, nil
+	}
+	return item.record, nil
+}
+
+func (s *memoryStore) Save(key string, record *Record, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.itemMap[key] = &memoryItem{
+		record:     record,
+		expireTime: s.now().Add(ttl),
+	}
+	return nil
+}
+
+func (s *memoryStore) Lock(key string, ttl time.Duration) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := s.now()
+	if l, ok := s.lockMap[key]; ok && now.Before(l.expireTime) {
+		return "", nil
+	}
+	token := newLockToken()
+	s.lockMap[key] = &memoryLock{
+		token:      token,
+		expireTime: now.Add(ttl),
+	}
+	return token, nil
+}
+
+func (s *memoryStore) Unlock(key string, token string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if l, ok := s.lockMap[key]; ok && l.token == token {
+		delete(s.lockMap, key)
+	}
+	return nil
+}
+
+// remove the expired items,at most once a minute
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, item := range s.itemMap {
+		if !now.Before(item.expireTime) {
+			delete(s.itemMap, key)
+		}
+	}
+	for key, l := range s.lockMap {
+		if !now.Before(l.expireTime) {
+			delete(s.lockMap, key)
+		}
+	}
+}
+
+// #endregion
@@ -0,0 +1,119 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/shanluzhineng/fwpkg/app"
+	"github.com/shanluzhineng/fwpkg/redisx"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+var (
+	lockScript = redis.NewScript(`return redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2])`)
+	// the lock is deleted only by the owner
+	unlockScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+const lockKeySuffix = ":lock"
+
+type redisStore struct {
+	redisService redisx.IRedisService
+}
+
+// records in redis,the keys are shared by the instances of cluster.
+// keys are prefixed with the key prefix of redisService
+func NewRedisStore(redisService redisx.IRedisService) IStore {
+	return &redisStore{
+		redisService: redisService,
+	}
+}
+
+func (s *redisStore) Get(key string) (*Record, error) {
+	value := s.redisService.StringGet(key)
+	if err := value.Err(); err != nil {
+		return nil, err
+	}
+	if !value.Exist() {
+		return nil, nil
+	}
+	record := &Record{}
+	if err := json.Unmarshal(value.Bytes(), record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s *redisStore) Save(key string, record *Record, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.redisService.StringSet(key, data, redisx.WithTTL(ttl))
+}
+
+func (s *redisStore) Lock(key string, ttl time.Duration) (string, error) {
+	token := newLockToken()
+	v, err := s.redisService.RunScript(lockScript, []string{key + lockKeySuffix}, []interface{}{token, ttl.Milliseconds()})
+	if err != nil {
+		return "", err
+	}
+	if v == nil {
+		return "", nil
+	}
+	return token, nil
+}
+
+func (s *redisStore) Unlock(key string, token string) error {
+	_, err := s.redisService.RunScript(unlockScript, []string{key + lockKeySuffix}, []interface{}{token})
+	return err
+}
+
+// #region lazy redis store
+
+// the redis service is registered when the web application is built,so it is resolved at the first request
+type lazyRedisStore struct {
+	once  sync.Once
+	store IStore
+}
+
+func newLazyRedisStore() IStore {
+	return &lazyRedisStore{}
+}
+
+func (s *lazyRedisStore) getStore() IStore {
+	s.once.Do(func() {
+		if app.Context != nil {
+			if redisService, ok := app.Context.GetInstance(new(redisx.IRedisService)).(redisx.IRedisService); ok {
+				s.store = NewRedisStore(redisService)
+				return
+			}
+		}
+		log.Logger.Warn("redisx.IRedisService is not registered,the memory store of idempotency is used")
+		s.store = NewMemoryStore()
+	})
+	return s.store
+}
+
+func (s *lazyRedisStore) Get(key string) (*Record, error) {
+	return s.getStore().Get(key)
+}
+
+func (s *lazyRedisStore) Save(key string, record *Record, ttl time.Duration) error {
+	return s.getStore().Save(key, record, ttl)
+}
+
+func (s *lazyRedisStore) Lock(key string, ttl time.Duration) (string, error) {
+	return s.getStore().Lock(key, ttl)
+}
+
+func (s *lazyRedisStore) Unlock(key string, token string) error {
+	return s.getStore().Unlock(key, token)
+}
+
+// #endregion
@@ -0,0 +1,80 @@
+package controllerx
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/app"
+	"github.com/shanluzhineng/fwpkg/controllerx/authz"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+)
+
+// key of ctx.Values,the value is *authz.Decision of the permission of route
+const PermissionDecisionContextKey = "fwpkg.authz.decision"
+
+var (
+	_authorizer     authz.IAuthorizer
+	_authorizerOnce sync.Once
+)
+
+// the authorizer registered in ioc container,
+// if it is not registered,an enforcer with the policy from configuration key authz is used
+func GetAuthorizer() authz.IAuthorizer {
+	_authorizerOnce.Do(func() {
+		if app.Context != nil {
+			if authorizer, ok := app.Context.GetInstance(new(authz.IAuthorizer)).(authz.IAuthorizer); ok {
+				_authorizer = authorizer
+				return
+			}
+		}
+		_authorizer = authz.NewEnforcer(authz.NewConfigPolicyStore(authz.ConfigurationKey))
+	})
+	return _authorizer
+}
+
+// replace the authorizer used by RequirePermission
+func SetAuthorizer(authorizer authz.IAuthorizer) {
+	_authorizerOnce.Do(func() {})
+	_authorizer = authorizer
+}
+
+// subject of current request,roles are the roles of principal
+func GetSubject(ctx iris.Context) *authz.Subject {
+	subject := &authz.Subject{
+		TenantId: GetTenantId(ctx),
+		RoleList: make([]string, 0),
+	}
+	principal := GetPrincipal(ctx)
+	if principal == nil {
+		return subject
+	}
+	subject.UserId = principal.Id
+	subject.RoleList = append(subject.RoleList, principal.RoleList...)
+	return subject
+}
+
+// handler that checks current user has the permission,a forbidden response is written if it is denied.
+// a conditional permission is kept in ctx.Values,see GetPermissionDecision
+func RequirePermission(permission string) iris.Handler {
+	return func(ctx iris.Context) {
+		decision, err := GetAuthorizer().Authorize(GetSubject(ctx), permission)
+		if err != nil {
+			responsex.HandleAppError(ctx, err)
+			return
+		}
+		if !decision.Allowed {
+			responsex.HandleAppError(ctx, ErrCodePermissionDenied.New(fmt.Sprintf("permission denied,permission:%s", permission)).
+				WithArgs(map[string]interface{}{"permission": permission}))
+			return
+		}
+		ctx.Values().Set(PermissionDecisionContextKey, decision)
+		ctx.Next()
+	}
+}
+
+// decision of the permission checked by RequirePermission,nil if the route does not require permission
+func GetPermissionDecision(ctx iris.Context) *authz.Decision {
+	decision, _ := ctx.Values().Get(PermissionDecisionContextKey).(*authz.Decision)
+	return decision
+}
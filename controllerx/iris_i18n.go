@@ -0,0 +1,28 @@
+package controllerx
+
+import (
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/system/i18n"
+)
+
+func init() {
+	responsex.SetLocaleResolver(GetLocale)
+}
+
+// locale of current request,the locale of user claims is preferred to the Accept-Language header,
+// the default locale of i18n.Default is used if both of them are not supported
+func GetLocale(ctx iris.Context) string {
+	localeList := make([]string, 0, 2)
+	if principal := GetPrincipal(ctx); principal != nil && len(principal.Locale) > 0 {
+		localeList = append(localeList, principal.Locale)
+	}
+	localeList = append(localeList, ctx.GetHeader("Accept-Language"))
+	return i18n.Default().Match(localeList...)
+}
+
+// localized message of key in the locale of current request,
+// args is a map for {name} placeholders or the arguments of fmt.Sprintf
+func T(ctx iris.Context, key string, args ...interface{}) string {
+	return i18n.T(GetLocale(ctx), key, args...)
+}
@@ -0,0 +1,18 @@
+package controllerx
+
+import (
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/middleware/requestid"
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"go.uber.org/zap"
+)
+
+// request id of current request,see requestid.New
+func GetRequestId(ctx iris.Context) string {
+	return requestid.Get(ctx)
+}
+
+// logger with the request id of current request,log.Logger if the request has no request id
+func GetLogger(ctx iris.Context) *zap.Logger {
+	return log.FromContext(ctx.Request().Context())
+}
@@ -0,0 +1,108 @@
+package controllerx
+
+import (
+	"strings"
+
+	"github.com/casdoor/casdoor-go-sdk/casdoorsdk"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/entity"
+)
+
+const (
+	// header of tenant id,see TenantFromHeader
+	HeaderTenantId = "X-Tenant-Id"
+	// a super admin sends "X-Tenant-Bypass: true" to access the items of all tenants
+	HeaderTenantBypass = "X-Tenant-Bypass"
+
+	// property of casdoor user that overrides the tenant of user
+	ClaimsPropertyTenantId = fwauth.CasdoorPropertyTenantId
+	// role of principal that is treated as super admin by IsSuperAdmin
+	RoleSuperAdmin = "superAdmin"
+	// organization of casdoor global admins
+	casdoorBuiltInOrganization = "built-in"
+)
+
+// resolve the tenant of request,return empty if it cannot be resolved
+type TenantResolver func(ctx iris.Context) string
+
+var _tenantResolverList = []TenantResolver{TenantFromClaims()}
+
+// set the resolvers of GetTenantId,the first non-empty tenant is used,default is TenantFromClaims.
+// header and subdomain are sent by client,use them only behind a gateway that verifies them
+func SetTenantResolver(resolverList ...TenantResolver) {
+	_tenantResolverList = resolverList
+}
+
+// tenant of the principal,for casdoor it is the tenantId property of user or the organization of user
+func TenantFromClaims() TenantResolver {
+	return func(ctx iris.Context) string {
+		principal := GetPrincipal(ctx)
+		if principal == nil {
+			return ""
+		}
+		return principal.TenantId
+	}
+}
+
+// tenant from the request header,HeaderTenantId is used if headerName is empty
+func TenantFromHeader(headerName string) TenantResolver {
+	if len(headerName) <= 0 {
+		headerName = HeaderTenantId
+	}
+	return func(ctx iris.Context) string {
+		return strings.TrimSpace(ctx.GetHeader(headerName))
+	}
+}
+
+// the first label of subdomain,e.g. acme for acme.example.com
+func TenantFromSubdomain() TenantResolver {
+	return func(ctx iris.Context) string {
+		subdomain := ctx.Subdomain()
+		if index := strings.Index(subdomain, "."); index >= 0 {
+			subdomain = subdomain[:index]
+		}
+		return subdomain
+	}
+}
+
+// tenant of current request,it is resolved once and kept in the request context
+func GetTenantId(ctx iris.Context) string {
+	if tenantId := entity.TenantIdFromContext(ctx.Request().Context()); len(tenantId) > 0 {
+		return tenantId
+	}
+	for _, eachResolver := range _tenantResolverList {
+		if tenantId := eachResolver(ctx); len(tenantId) > 0 {
+			SetTenantId(ctx, tenantId)
+			return tenantId
+		}
+	}
+	return ""
+}
+
+// put the tenant into the request context,so the following handlers use it
+func SetTenantId(ctx iris.Context, tenantId string) {
+	request := ctx.Request()
+	ctx.ResetRequest(request.WithContext(entity.ContextWithTenantId(request.Context(), tenantId)))
+}
+
+// current user is a global admin of casdoor or has RoleSuperAdmin,
+// the principal of any authenticator is checked
+func IsSuperAdmin(ctx iris.Context) bool {
+	return isSuperAdminPrincipal(GetPrincipal(ctx))
+}
+
+func isSuperAdminPrincipal(principal *fwauth.Principal) bool {
+	if principal == nil {
+		return false
+	}
+	if claims, ok := principal.Claims.(*casdoorsdk.Claims); ok && claims.IsAdmin && claims.Owner == casdoorBuiltInOrganization {
+		return true
+	}
+	return principal.HasRole(RoleSuperAdmin)
+}
+
+// the request asks to bypass tenant isolation with HeaderTenantBypass,it must be authorized
+func IsTenantBypassRequested(ctx iris.Context) bool {
+	return strings.EqualFold(ctx.GetHeader(HeaderTenantBypass), "true")
+}
@@ -0,0 +1,55 @@
+package controllerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/casdoor/casdoor-go-sdk/casdoorsdk"
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSuperAdmin(t *testing.T) {
+	var principal *fwauth.Principal
+	var superAdmin bool
+	app := iris.New()
+	app.Get("/", func(ctx iris.Context) {
+		if principal != nil {
+			fwauth.SetIrisPrincipal(ctx, principal)
+		}
+		superAdmin = IsSuperAdmin(ctx)
+	})
+	assert.NoError(t, app.Build())
+	check := func(p *fwauth.Principal) bool {
+		principal = p
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		return superAdmin
+	}
+
+	assert.False(t, check(nil))
+	// principal of jwt or api key authenticator
+	assert.True(t, check(&fwauth.Principal{Id: "u1", RoleList: []string{RoleSuperAdmin}}))
+	assert.False(t, check(&fwauth.Principal{Id: "u1", RoleList: []string{RoleAdmin}}))
+}
+
+func TestIsGinSuperAdmin(t *testing.T) {
+	check := func(principal *fwauth.Principal) bool {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		if principal != nil {
+			fwauth.SetGinPrincipal(c, principal)
+		}
+		return IsGinSuperAdmin(c)
+	}
+
+	assert.False(t, check(nil))
+	assert.True(t, check(&fwauth.Principal{Id: "u1", RoleList: []string{RoleSuperAdmin}}))
+	// global admin of casdoor
+	builtInAdmin := &casdoorsdk.Claims{User: casdoorsdk.User{Owner: casdoorBuiltInOrganization, IsAdmin: true}}
+	assert.True(t, check(&fwauth.Principal{Id: "u1", Claims: builtInAdmin}))
+	orgAdmin := &casdoorsdk.Claims{User: casdoorsdk.User{Owner: "org", IsAdmin: true}}
+	assert.False(t, check(&fwauth.Principal{Id: "u1", Claims: orgAdmin}))
+}
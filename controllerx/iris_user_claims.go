@@ -14,6 +14,9 @@ import (
 	"github.com/shanluzhineng/fwpkg/system/reflector"
 )
 
+// role of principal that is treated as admin by IsAdmin
+const RoleAdmin = "admin"
+
 // user Custom claims struct
 type BaseClaims struct {
 	UUID        uuid.UUID
@@ -39,12 +42,18 @@ type BaseServiceContext struct {
 
 // Gin operations
 func GetClaims(c *gin.Context) (*CustomClaims, error) {
-	var cusClaim *CustomClaims
-	if claimAny, ok := c.Get(fwauth.AuthKey); ok {
-		claim := claimAny.(*casdoorsdk.Claims)
-		cusClaim = &CustomClaims{}
+	principal := fwauth.GetGinPrincipal(c)
+	if principal == nil {
+		return nil, nil
+	}
+	cusClaim := &CustomClaims{}
+	cusClaim.Id = principal.Id
+	cusClaim.Username = principal.Name
+	if tenantId, err := uuid.FromString(principal.TenantId); err == nil {
+		cusClaim.TenantId = tenantId
+	}
+	if claim, ok := principal.Claims.(*casdoorsdk.Claims); ok {
 		cusClaim.CasClaim = claim
-		cusClaim.Id = claim.Id
 		cusClaim.Username = claim.DisplayName
 	}
 	return cusClaim, nil
@@ -62,14 +71,44 @@ func SetBaseServiceContext(context *BaseServiceContext, c *gin.Context) {
 }
 
 // Iris operations
+
+// authenticate with the authenticators,fwauth.GetDefaultAuthenticators is used if they are not set
+// and the casdoor middleware is used if there is no default authenticator
+func AuthenticateHandler(authenticatorList ...fwauth.Authenticator) iris.Handler {
+	if len(authenticatorList) <= 0 {
+		authenticatorList = fwauth.GetDefaultAuthenticators()
+	}
+	if len(authenticatorList) <= 0 {
+		return fwauth.GetCasdoorMiddleware().Serve
+	}
+	return fwauth.IrisAuthenticate(authenticatorList...)
+}
+
+// authenticated user of current request,nil if the request is not authenticated
+func GetPrincipal(ctx iris.Context) *fwauth.Principal {
+	return fwauth.GetIrisPrincipal(ctx)
+}
+
 func GetUserId(ctx iris.Context) string {
-	claims := fwauth.GetCasdoorMiddleware().GetUserClaims(ctx)
-	if claims != nil {
-		return claims.Id
+	principal := GetPrincipal(ctx)
+	if principal != nil {
+		return principal.Id
 	}
 	return ""
 }
 
+// current user is admin of casdoor or has the admin role
+func IsAdmin(ctx iris.Context) bool {
+	principal := GetPrincipal(ctx)
+	if principal == nil {
+		return false
+	}
+	if claims, ok := principal.Claims.(*casdoorsdk.Claims); ok && claims.IsAdmin {
+		return true
+	}
+	return principal.HasRole(RoleAdmin)
+}
+
 func checkEntityIsIEntityWithUser(entityValue interface{}) entity.IEntityWithUser {
 	v, ok := entityValue.(entity.IEntityWithUser)
 	if !ok {
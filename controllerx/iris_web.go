@@ -19,6 +19,10 @@ import (
 	"github.com/shanluzhineng/configurationx"
 	"github.com/shanluzhineng/fwpkg/controllerx/middleware/cors"
 	errHandler "github.com/shanluzhineng/fwpkg/controllerx/middleware/err"
+	"github.com/shanluzhineng/fwpkg/controllerx/middleware/metrics"
+	"github.com/shanluzhineng/fwpkg/controllerx/middleware/requestid"
+	"github.com/shanluzhineng/fwpkg/controllerx/middleware/tracing"
+	"github.com/shanluzhineng/fwpkg/controllerx/ratelimit"
 
 	"net/http/pprof"
 
@@ -51,6 +55,12 @@ type Configurator func(*IrisApplication)
 
 func NewIrisApplication() *IrisApplication {
 	irisNew := iris.New()
+	//请求id,在日志,事件及调用其他服务时传递
+	irisNew.Use(requestid.New())
+	//请求的server span,日志中记录traceId
+	irisNew.Use(tracing.New())
+	//请求数,耗时及大小的指标
+	irisNew.Use(metrics.New())
 	//错误封装
 	irisNew.Use(errHandler.New())
 	irisNew.Use(recover.New())
@@ -72,6 +82,15 @@ func (a *IrisApplication) Configure(configurators ...Configurator) *IrisApplicat
 	return a
 }
 
+// limit the requests of all routes,the limiter of configuration key ratelimit is used if limiter is nil
+func (a *IrisApplication) UseRateLimit(limiter *ratelimit.Limiter) *IrisApplication {
+	if limiter == nil {
+		limiter = ratelimit.NewConfigLimiter(ratelimit.ConfigurationKey)
+	}
+	a.UseGlobal(ratelimit.New(limiter))
+	return a
+}
+
 // build IrisApplication environments
 func (a *IrisApplication) Build(configurators ...Configurator) *IrisApplication {
 	if a.isBuilded {
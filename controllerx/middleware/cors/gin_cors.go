@@ -0,0 +1,73 @@
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iris-contrib/middleware/cors"
+	"github.com/shanluzhineng/configurationx/options/web"
+)
+
+// gin version of UseCors,the same origins,methods and headers are allowed
+func UseGinCors(engine *gin.Engine, opts web.CORS) {
+	options := allowedAllOptions()
+	if opts.Mode == web.CorsMode_Whitelist {
+		options.AllowedOrigins = opts.GetAllowedOrigins()
+	}
+	engine.Use(newGinCors(options))
+}
+
+func newGinCors(options cors.Options) gin.HandlerFunc {
+	allowAll := false
+	for _, eachOrigin := range options.AllowedOrigins {
+		if eachOrigin == "*" {
+			allowAll = true
+		}
+	}
+	allowedMethods := strings.Join(options.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(options.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(options.ExposedHeaders, ", ")
+	isOriginAllowed := func(origin string) bool {
+		if allowAll {
+			return true
+		}
+		for _, eachOrigin := range options.AllowedOrigins {
+			if strings.EqualFold(eachOrigin, origin) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if len(origin) <= 0 {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+		if !isOriginAllowed(origin) {
+			c.Next()
+			return
+		}
+		c.Header("Access-Control-Allow-Origin", origin)
+		if options.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		// preflight request
+		if c.Request.Method == http.MethodOptions && len(c.GetHeader("Access-Control-Request-Method")) > 0 {
+			c.Header("Access-Control-Allow-Methods", allowedMethods)
+			c.Header("Access-Control-Allow-Headers", allowedHeaders)
+			if options.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.Itoa(options.MaxAge))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		if len(exposedHeaders) > 0 {
+			c.Header("Access-Control-Expose-Headers", exposedHeaders)
+		}
+		c.Next()
+	}
+}
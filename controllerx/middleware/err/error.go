@@ -2,6 +2,7 @@ package err
 
 import (
 	"encoding/json"
+	"errors"
 	"strings"
 
 	"github.com/kataras/iris/v12/context"
@@ -26,13 +27,12 @@ func (v *errWrapperMiddleware) ServeHTTP(ctx *context.Context) {
 	if context.StatusCodeNotSuccessful(statusCode) && !v.responseIsIgnore(responseData) {
 		ctx.Recorder().ResetBody()
 		err := ctx.GetErr()
-		ctx.StopWithJSON(statusCode, responsex.NewErrorResponse(func(br *responsex.BaseResponse) {
-			if err != nil {
-				br.SetMessage(err.Error())
-			} else {
-				br.SetMessage(string(responseData))
-			}
-		}))
+		if err == nil {
+			err = errors.New(string(responseData))
+		}
+		// the same mapping as responsex.HandleError
+		status, r := responsex.NewLocalizedAppErrorResponse(responsex.GetLocale(ctx), statusCode, err)
+		ctx.StopWithJSON(status, r)
 	}
 	respHeader := ctx.ResponseWriter().Header()
 	ctype := respHeader.Get("Content-Type")
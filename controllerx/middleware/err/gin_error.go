@@ -0,0 +1,36 @@
+package err
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+)
+
+// gin version of New,the last error of c.Errors is written as the error response
+// if the handlers do not write the response,e.g. c.Error(err) or c.Status(400)
+func NewGin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Written() {
+			return
+		}
+		statusCode := c.Writer.Status()
+		lastErr := c.Errors.Last()
+		if lastErr == nil && statusCode < http.StatusBadRequest {
+			return
+		}
+		if statusCode < http.StatusBadRequest {
+			statusCode = http.StatusInternalServerError
+		}
+		err := errors.New(http.StatusText(statusCode))
+		if lastErr != nil {
+			err = lastErr.Err
+		}
+		// the same mapping as responsex.FailWithStatus,the error is not added to c.Errors again
+		status, r := responsex.NewLocalizedAppErrorResponse(responsex.GetGinLocale(c), statusCode, err)
+		c.AbortWithStatusJSON(status, r)
+	}
+}
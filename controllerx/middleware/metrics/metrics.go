@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/system/metrics"
+)
+
+// record the count,latency and sizes of the requests by method,route template and status
+func New() iris.Handler {
+	return func(ctx iris.Context) {
+		startTime := time.Now()
+		ctx.Next()
+
+		route := ""
+		if currentRoute := ctx.GetCurrentRoute(); currentRoute != nil {
+			route = currentRoute.Path()
+		}
+		metrics.ObserveHttpRequest(ctx.Method(), route, ctx.GetStatusCode(), time.Since(startTime),
+			ctx.GetContentLength(), int64(ctx.ResponseWriter().Written()))
+	}
+}
+
+// gin version of New
+func NewGin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startTime := time.Now()
+		c.Next()
+
+		metrics.ObserveHttpRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(startTime),
+			c.Request.ContentLength, int64(c.Writer.Size()))
+	}
+}
@@ -0,0 +1,50 @@
+package requestid
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/system/correlation"
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"go.uber.org/zap"
+)
+
+// accept X-Request-Id or traceparent of the request,or generate a request id.
+// the correlation and a logger with the request id are kept in the request context,
+// the request id is echoed in X-Request-Id of response
+func New() iris.Handler {
+	return func(ctx iris.Context) {
+		requestCorrelation := correlation.FromHeader(ctx.Request().Header)
+		ctx.Header(correlation.HeaderRequestId, requestCorrelation.RequestId)
+
+		request := ctx.Request()
+		requestCtx := correlation.ContextWithCorrelation(request.Context(), requestCorrelation)
+		logger := log.FromContext(requestCtx).With(zap.String(correlation.LogFieldRequestId, requestCorrelation.RequestId))
+		requestCtx = log.ContextWithLogger(requestCtx, logger)
+		ctx.ResetRequest(request.WithContext(requestCtx))
+		ctx.Next()
+	}
+}
+
+// request id of current request,empty if the middleware is not used
+func Get(ctx iris.Context) string {
+	return correlation.RequestIdFromContext(ctx.Request().Context())
+}
+
+// gin version of New
+func NewGin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestCorrelation := correlation.FromHeader(c.Request.Header)
+		c.Header(correlation.HeaderRequestId, requestCorrelation.RequestId)
+
+		requestCtx := correlation.ContextWithCorrelation(c.Request.Context(), requestCorrelation)
+		logger := log.FromContext(requestCtx).With(zap.String(correlation.LogFieldRequestId, requestCorrelation.RequestId))
+		requestCtx = log.ContextWithLogger(requestCtx, logger)
+		c.Request = c.Request.WithContext(requestCtx)
+		c.Next()
+	}
+}
+
+// request id of current gin request,empty if the middleware is not used
+func GetGin(c *gin.Context) string {
+	return correlation.RequestIdFromContext(c.Request.Context())
+}
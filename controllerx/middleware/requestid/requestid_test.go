@@ -0,0 +1,60 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	opevent "github.com/shanluzhineng/fwpkg/opevents/pkg"
+	"github.com/shanluzhineng/fwpkg/system/correlation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestId(t *testing.T) {
+	var requestId, correlationId string
+	app := iris.New()
+	app.Use(New())
+	app.Get("/", func(ctx iris.Context) {
+		requestId = Get(ctx)
+		eventLog := opevent.NewOpEventLog(opevent.WithContext(ctx.Request().Context()))
+		correlationId = eventLog.CorrelationId
+	})
+	assert.Nil(t, app.Build())
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(correlation.HeaderRequestId, "req-1")
+	app.ServeHTTP(w, r)
+	assert.Equal(t, "req-1", requestId)
+	assert.Equal(t, "req-1", correlationId)
+	assert.Equal(t, "req-1", w.Header().Get(correlation.HeaderRequestId))
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, requestId, 32)
+	assert.Equal(t, requestId, w.Header().Get(correlation.HeaderRequestId))
+}
+
+func TestGinRequestId(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var requestId string
+	engine := gin.New()
+	engine.Use(NewGin())
+	engine.GET("/", func(c *gin.Context) {
+		requestId = GetGin(c)
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(correlation.HeaderRequestId, "req-1")
+	engine.ServeHTTP(w, r)
+	assert.Equal(t, "req-1", requestId)
+	assert.Equal(t, "req-1", w.Header().Get(correlation.HeaderRequestId))
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, requestId, 32)
+	assert.Equal(t, requestId, w.Header().Get(correlation.HeaderRequestId))
+}
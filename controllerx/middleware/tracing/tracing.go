@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/system/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// create a server span for each request,the remote span context of traceparent header is the parent.
+// the span is named by the route template,the logger of request context writes the trace id and span id
+func New() iris.Handler {
+	return func(ctx iris.Context) {
+		request := ctx.Request()
+		route := ""
+		if currentRoute := ctx.GetCurrentRoute(); currentRoute != nil {
+			route = currentRoute.Path()
+		}
+		spanCtx, span := startServerSpan(request.Context(), request.Method, route, request.Header)
+		defer span.End()
+
+		ctx.ResetRequest(request.WithContext(tracing.ContextWithTraceLogger(spanCtx)))
+		ctx.Next()
+		tracing.SetHttpStatus(span, ctx.GetStatusCode())
+	}
+}
+
+// gin version of New
+func NewGin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		spanCtx, span := startServerSpan(c.Request.Context(), c.Request.Method, c.FullPath(), c.Request.Header)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(tracing.ContextWithTraceLogger(spanCtx))
+		c.Next()
+		tracing.SetHttpStatus(span, c.Writer.Status())
+	}
+}
+
+func startServerSpan(ctx context.Context, method string, route string, header http.Header) (context.Context, trace.Span) {
+	spanName := method
+	if len(route) > 0 {
+		spanName = method + " " + route
+	}
+	return tracing.Tracer().Start(tracing.Extract(ctx, header), spanName,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("http.route", route),
+		))
+}
@@ -0,0 +1,370 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/entity/filter"
+	"github.com/shanluzhineng/fwpkg/entity/patch"
+)
+
+var (
+	_batchRequestPayloadType = reflect.TypeOf(entity.BatchRequestPayload{})
+	_batchItemResultType     = reflect.TypeOf(entity.BatchItemResult{})
+	_entityExportType        = reflect.TypeOf(entity.EntityExport{})
+	_exportRequestSchema     = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"type": {Type: "string", Default: entity.ExportType_CSV, Enum: []interface{}{
+				entity.ExportType_CSV, entity.ExportType_XLSX, entity.ExportType_NDJSON, entity.ExportType_Parquet}},
+			"fieldNameList":        {Type: "array", Items: &Schema{Type: "string"}},
+			"fieldNameTitleMap":    {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+			"excludeFieldNameList": {Type: "array", Items: &Schema{Type: "string"}},
+		},
+	}
+	_entityImportType    = reflect.TypeOf(entity.EntityImport{})
+	_importRequestSchema = &Schema{
+		Type:     "object",
+		Required: []string{"file"},
+		Properties: map[string]*Schema{
+			"file": {Type: "string", Format: "binary"},
+			"type": {Type: "string", Description: "detected from the extension of file if it is not set",
+				Enum: []interface{}{entity.ExportType_CSV, entity.ExportType_XLSX, entity.ExportType_NDJSON}},
+			"mode":             {Type: "string", Default: entity.ImportMode_Insert, Enum: []interface{}{entity.ImportMode_Insert, entity.ImportMode_Upsert}},
+			"dryRun":           {Type: "boolean", Description: "validate rows only"},
+			"keyFieldNameList": {Type: "string", Description: "key fields of upsert mode,separated by comma"},
+			"columnFieldMap":   {Type: "string", Description: "json object,key is the column of file and value is the field of entity"},
+			"batchSize":        {Type: "integer"},
+		},
+	}
+	_conditionType = reflect.TypeOf(filter.Condition{})
+	_sortType      = reflect.TypeOf(entity.Sort{})
+)
+
+// build the operation of an entity controller route
+func buildEntityOperation(g *SchemaGenerator, operation EntityOperation, path string) *Operation {
+	entityName := g.SchemaName(operation.EntityType)
+	entitySchema := RefSchema(entityName)
+
+	result := &Operation{
+		Tags:       []string{entityName},
+		Parameters: pathParameters(path),
+		Responses:  make(map[string]*Response),
+	}
+	switch operation.Action {
+	case EntityActionAll:
+		result.Summary = fmt.Sprintf("get all %s", entityName)
+		result.Parameters = append(result.Parameters, projectionQueryParameters()...)
+		result.Responses["200"] = jsonResponse("successful response", ListResponseSchema(g, entitySchema))
+	case EntityActionList:
+		result.Summary = fmt.Sprintf("get %s list", entityName)
+		result.Parameters = append(result.Parameters, listQueryParameters(g)...)
+		result.Parameters = append(result.Parameters, projectionQueryParameters()...)
+		result.Responses["200"] = jsonResponse("successful response", ListResponseSchema(g, entitySchema))
+	case EntityActionGetById:
+		result.Summary = fmt.Sprintf("get %s by id", entityName)
+		result.Parameters = append(result.Parameters, projectionQueryParameters()...)
+		response := jsonResponse("successful response", ResponseSchema(g, entitySchema))
+		response.Headers = map[string]*Header{
+			"ETag": {
+				Description: "version of entity,only for versioned entity",
+				Schema:      &Schema{Type: "string"},
+			},
+		}
+		result.Responses["200"] = response
+		appendErrorResponses(g, result.Responses, http.StatusNotFound)
+	case EntityActionCreate:
+		result.Summary = fmt.Sprintf("create %s", entityName)
+		result.RequestBody = jsonRequestBody(entitySchema)
+		result.Responses["200"] = jsonResponse("successful response", ResponseSchema(g, entitySchema))
+		appendErrorResponses(g, result.Responses, http.StatusBadRequest)
+	case EntityActionUpdate:
+		result.Summary = fmt.Sprintf("replace %s", entityName)
+		result.Parameters = append(result.Parameters, ifMatchParameter())
+		result.RequestBody = jsonRequestBody(entitySchema)
+		result.Responses["200"] = jsonResponse("successful response", ResponseSchema(g, nil))
+		appendErrorResponses(g, result.Responses, http.StatusBadRequest, http.StatusPreconditionFailed)
+	case EntityActionPatch:
+		result.Summary = fmt.Sprintf("patch %s", entityName)
+		result.Parameters = append(result.Parameters, ifMatchParameter())
+		result.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				patch.ContentTypeMergePatch: {Schema: entitySchema},
+				patch.ContentTypeJsonPatch:  {Schema: jsonPatchSchema()},
+			},
+		}
+		result.Responses["200"] = jsonResponse("successful response", ResponseSchema(g, nil))
+		appendErrorResponses(g, result.Responses, http.StatusBadRequest, http.StatusConflict,
+			http.StatusPreconditionFailed, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity)
+	case EntityActionDelete:
+		result.Summary = fmt.Sprintf("delete %s", entityName)
+		result.Parameters = append(result.Parameters, ifMatchParameter(), &Parameter{
+			Name:        "hard",
+			In:          "query",
+			Description: "delete permanently even if soft delete is enabled,only for admin",
+			Schema:      &Schema{Type: "boolean"},
+		})
+		result.Responses["200"] = jsonResponse("successful response", ResponseSchema(g, nil))
+		appendErrorResponses(g, result.Responses, http.StatusBadRequest, http.StatusForbidden, http.StatusPreconditionFailed)
+	case EntityActionRestore:
+		result.Summary = fmt.Sprintf("restore deleted %s", entityName)
+		result.Responses["200"] = jsonResponse("successful response", ResponseSchema(g, nil))
+		appendErrorResponses(g, result.Responses, http.StatusBadRequest, http.StatusNotFound)
+	case EntityActionBatchCreate:
+		result.Summary = fmt.Sprintf("create %s list with one bulk write", entityName)
+		result.Parameters = append(result.Parameters, batchOrderedParameter())
+		result.RequestBody = jsonRequestBody(&Schema{Type: "array", Items: entitySchema})
+		result.Responses["200"] = jsonResponse("result of each item", ResponseSchema(g, batchResultSchema(g)))
+		appendErrorResponses(g, result.Responses, http.StatusBadRequest, http.StatusRequestEntityTooLarge)
+	case EntityActionBatchUpdate:
+		result.Summary = fmt.Sprintf("patch %s list with one bulk write", entityName)
+		result.Parameters = append(result.Parameters, batchOrderedParameter())
+		result.RequestBody = &RequestBody{
+			Description: "json merge patch list,each patch must contain the id of item",
+			Required:    true,
+			Content: map[string]*MediaType{
+				"application/json": {Schema: &Schema{Type: "array", Items: entitySchema}},
+			},
+		}
+		result.Responses["200"] = jsonResponse("result of each item", ResponseSchema(g, batchResultSchema(g)))
+		appendErrorResponses(g, result.Responses, http.StatusBadRequest, http.StatusRequestEntityTooLarge)
+	case EntityActionExport:
+		result.Summary = fmt.Sprintf("export %s list to file", entityName)
+		// conditions and sort
+		result.Parameters = append(result.Parameters, listQueryParameters(g)[:2]...)
+		result.RequestBody = jsonRequestBody(_exportRequestSchema)
+		result.RequestBody.Required = false
+		result.Responses["200"] = jsonResponse("export record", ResponseSchema(g, RefSchema(g.SchemaName(_entityExportType))))
+		appendErrorResponses(g, result.Responses, http.StatusBadRequest)
+	case EntityActionGetExport:
+		result.Summary = fmt.Sprintf("get %s export status", entityName)
+		result.Responses["200"] = jsonResponse("export record", ResponseSchema(g, RefSchema(g.SchemaName(_entityExportType))))
+		appendErrorResponses(g, result.Responses, http.StatusNotFound)
+	case EntityActionDownloadExport:
+		result.Summary = fmt.Sprintf("download %s export file", entityName)
+		result.Responses["200"] = &Response{
+			Description: "export file",
+			Content: map[string]*MediaType{
+				"application/octet-stream": {Schema: &Schema{Type: "string", Format: "binary"}},
+			},
+		}
+		appendErrorResponses(g, result.Responses, http.StatusNotFound, http.StatusConflict)
+	case EntityActionImport:
+		result.Summary = fmt.Sprintf("import %s list from file", entityName)
+		result.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"multipart/form-data": {Schema: _importRequestSchema},
+			},
+		}
+		result.Responses["200"] = jsonResponse("import record", ResponseSchema(g, RefSchema(g.SchemaName(_entityImportType))))
+		appendErrorResponses(g, result.Responses, http.StatusBadRequest, http.StatusRequestEntityTooLarge)
+	case EntityActionGetImport:
+		result.Summary = fmt.Sprintf("get %s import status", entityName)
+		result.Responses["200"] = jsonResponse("import record", ResponseSchema(g, RefSchema(g.SchemaName(_entityImportType))))
+		appendErrorResponses(g, result.Responses, http.StatusNotFound)
+	case EntityActionImportReport:
+		result.Summary = fmt.Sprintf("download %s import error report", entityName)
+		result.Responses["200"] = &Response{
+			Description: "csv file of row errors",
+			Content: map[string]*MediaType{
+				"text/csv": {Schema: &Schema{Type: "string", Format: "binary"}},
+			},
+		}
+		appendErrorResponses(g, result.Responses, http.StatusNotFound, http.StatusConflict)
+	case EntityActionStream:
+		result.Summary = fmt.Sprintf("subscribe %s changes with server-sent events", entityName)
+		result.Parameters = append(result.Parameters, listQueryParameters(g)[:1]...)
+		result.Parameters = append(result.Parameters, lastEventIdParameters()...)
+		result.Responses["200"] = &Response{
+			Description: "event stream,event is create,update or delete and data is the json of changed item",
+			Content: map[string]*MediaType{
+				"text/event-stream": {Schema: &Schema{Type: "string"}},
+			},
+		}
+		appendErrorResponses(g, result.Responses, http.StatusBadRequest)
+	case EntityActionStreamWs:
+		result.Summary = fmt.Sprintf("subscribe %s changes with websocket", entityName)
+		result.Parameters = append(result.Parameters, listQueryParameters(g)[:1]...)
+		result.Parameters = append(result.Parameters, lastEventIdParameters()[1:]...)
+		result.Responses["101"] = &Response{
+			Description: "switching to websocket,each message is the json of change event",
+		}
+		appendErrorResponses(g, result.Responses, http.StatusBadRequest)
+	case EntityActionDeleteList:
+		result.Summary = fmt.Sprintf("delete %s list", entityName)
+		result.RequestBody = jsonRequestBody(RefSchema(g.SchemaName(_batchRequestPayloadType)))
+		result.Responses["200"] = jsonResponse("successful response", ResponseSchema(g, nil))
+		appendErrorResponses(g, result.Responses, http.StatusBadRequest)
+	}
+	result.OperationId = string(operation.Action) + entityName
+	if operation.Authenticated {
+		result.Security = []map[string][]string{{SecuritySchemeBearer: {}}}
+		appendErrorResponses(g, result.Responses, http.StatusUnauthorized)
+	}
+	if len(operation.Permission) > 0 {
+		result.Description = fmt.Sprintf("required permission:%s", operation.Permission)
+		appendErrorResponses(g, result.Responses, http.StatusForbidden)
+	}
+	appendErrorResponses(g, result.Responses, http.StatusInternalServerError)
+	return result
+}
+
+// query parameters of list api
+func listQueryParameters(g *SchemaGenerator) []*Parameter {
+	opList := []string{
+		filter.FilterOpNotSet, filter.FilterOpContains, filter.FilterOpNotContains, filter.FilterOpRegex,
+		filter.FilterOpEqual, filter.FilterOpNotEqual, filter.FilterOpIn, filter.FilterOpNotIn,
+		filter.FilterOpGreaterThan, filter.FilterOpLessThan, filter.FilterOpGreaterThanEqual,
+		filter.FilterOpLessThanEqual, filter.FilterOpSearch,
+	}
+	return []*Parameter{
+		{
+			Name: filter.FilterQueryFieldConditions,
+			In:   "query",
+			Description: fmt.Sprintf(`json encoded condition list,e.g. [{"key":"name","op":"eq","value":"abc"}],op can be one of %s`,
+				strings.Join(opList, ",")),
+			Schema: &Schema{Type: "string", Description: fmt.Sprintf("json of %s list", g.SchemaName(_conditionType))},
+		},
+		{
+			Name: filter.SortQueryField,
+			In:   "query",
+			Description: fmt.Sprintf(`json encoded sort list,e.g. [{"key":"name","d":"%s"}],d can be %s or %s`,
+				filter.ASCENDING, filter.ASCENDING, filter.DESCENDING),
+			Schema: &Schema{Type: "string", Description: fmt.Sprintf("json of %s list", g.SchemaName(_sortType))},
+		},
+		{
+			Name:        "page",
+			In:          "query",
+			Description: "page index,start from 1",
+			Schema:      &Schema{Type: "integer", Default: entity.PaginationDefaultPage},
+		},
+		{
+			Name:        "size",
+			In:          "query",
+			Description: "page size",
+			Schema:      &Schema{Type: "integer", Default: entity.PaginationDefaultSize},
+		},
+		{
+			Name:        "after",
+			In:          "query",
+			Description: "keyset pagination,return items after the cursor,nextCursor of the previous response",
+			Schema:      &Schema{Type: "string"},
+		},
+		{
+			Name:        "before",
+			In:          "query",
+			Description: "keyset pagination,return items before the cursor,prevCursor of the previous response",
+			Schema:      &Schema{Type: "string"},
+		},
+		{
+			Name:        "limit",
+			In:          "query",
+			Description: "keyset pagination page size,page and size are ignored when after,before or limit is set",
+			Schema:      &Schema{Type: "integer", Default: entity.PaginationDefaultSize},
+		},
+		{
+			Name:        "total",
+			In:          "query",
+			Description: "count total or not,total is -1 if not counted.default is true for page/size and false for keyset pagination",
+			Schema:      &Schema{Type: "boolean"},
+		},
+		{
+			Name:        filter.FilterQueryFieldAll,
+			In:          "query",
+			Description: "1 to return all items without paging",
+			Schema:      &Schema{Type: "string", Enum: []interface{}{"0", "1"}},
+		},
+	}
+}
+
+// sparse fieldsets,fields and exclude cannot be used together
+func projectionQueryParameters() []*Parameter {
+	return []*Parameter{
+		{
+			Name:        "fields",
+			In:          "query",
+			Description: "comma separated field paths to return,e.g. name,status,owner.id",
+			Schema:      &Schema{Type: "string"},
+		},
+		{
+			Name:        "exclude",
+			In:          "query",
+			Description: "comma separated field paths not to return",
+			Schema:      &Schema{Type: "string"},
+		},
+	}
+}
+
+// resume the change stream after the event
+func lastEventIdParameters() []*Parameter {
+	return []*Parameter{
+		{
+			Name:        "Last-Event-ID",
+			In:          "header",
+			Description: "id of the last received event,sent by the browser when it reconnects",
+			Schema:      &Schema{Type: "string"},
+		},
+		{
+			Name:        "lastEventId",
+			In:          "query",
+			Description: "id of the last received event,used when the header cannot be set",
+			Schema:      &Schema{Type: "string"},
+		},
+	}
+}
+
+func batchOrderedParameter() *Parameter {
+	return &Parameter{
+		Name:        "ordered",
+		In:          "query",
+		Description: "ordered bulk write stops at the first failed item and nothing is written if any item is invalid",
+		Schema:      &Schema{Type: "boolean", Default: true},
+	}
+}
+
+func batchResultSchema(g *SchemaGenerator) *Schema {
+	return &Schema{Type: "array", Items: RefSchema(g.SchemaName(_batchItemResultType))}
+}
+
+func ifMatchParameter() *Parameter {
+	return &Parameter{
+		Name:        "If-Match",
+		In:          "header",
+		Description: "ETag returned by get api,only for versioned entity",
+		Schema:      &Schema{Type: "string"},
+	}
+}
+
+func jsonRequestBody(schema *Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content: map[string]*MediaType{
+			"application/json": {Schema: schema},
+		},
+	}
+}
+
+// RFC 6902 document
+func jsonPatchSchema() *Schema {
+	return &Schema{
+		Type: "array",
+		Items: &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"op": {
+					Type: "string",
+					Enum: []interface{}{patch.OpAdd, patch.OpRemove, patch.OpReplace, patch.OpMove, patch.OpCopy, patch.OpTest},
+				},
+				"path":  {Type: "string"},
+				"from":  {Type: "string"},
+				"value": {},
+			},
+			Required: []string{"op", "path"},
+		},
+	}
+}
@@ -0,0 +1,283 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12/core/router"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+)
+
+// name of the bearer security scheme
+const SecuritySchemeBearer = "bearerAuth"
+
+var (
+	_baseResponseType = reflect.TypeOf(responsex.BaseResponse{})
+	_listResponseType = reflect.TypeOf(responsex.ListResponse{})
+)
+
+type GeneratorOptions struct {
+	Info Info
+	// routes that starts with these prefix are not documented
+	ExcludePathPrefixList []string
+}
+
+type GeneratorOption func(*GeneratorOptions)
+
+func GeneratorWithInfo(info Info) GeneratorOption {
+	return func(o *GeneratorOptions) {
+		o.Info = info
+	}
+}
+
+func GeneratorWithExcludePathPrefix(prefixList ...string) GeneratorOption {
+	return func(o *GeneratorOptions) {
+		o.ExcludePathPrefixList = append(o.ExcludePathPrefixList, prefixList...)
+	}
+}
+
+// a route to describe,path is the full path of route
+type RouteInfo struct {
+	Method      string
+	Path        string
+	Title       string
+	Description string
+}
+
+// build the OpenAPI document of the iris routes
+func Generate(routes []*router.Route, opts ...GeneratorOption) *Document {
+	routeList := make([]RouteInfo, 0, len(routes))
+	for _, eachRoute := range routes {
+		routeList = append(routeList, RouteInfo{
+			Method:      eachRoute.Method,
+			Path:        eachRoute.Tmpl().Src,
+			Title:       eachRoute.Title,
+			Description: eachRoute.Description,
+		})
+	}
+	return GenerateRoutes(routeList, opts...)
+}
+
+// build the OpenAPI document of the gin routes
+func GenerateGin(routes gin.RoutesInfo, opts ...GeneratorOption) *Document {
+	routeList := make([]RouteInfo, 0, len(routes))
+	for _, eachRoute := range routes {
+		routeList = append(routeList, RouteInfo{
+			Method: eachRoute.Method,
+			Path:   eachRoute.Path,
+		})
+	}
+	return GenerateRoutes(routeList, opts...)
+}
+
+func GenerateRoutes(routes []RouteInfo, opts ...GeneratorOption) *Document {
+	options := &GeneratorOptions{
+		Info: Info{Title: "API", Version: "v1"},
+	}
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+
+	g := NewSchemaGenerator()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    options.Info,
+		Paths:   make(map[string]*PathItem),
+	}
+	tagSet := make(map[string]bool)
+	secured := false
+	for _, eachRoute := range routes {
+		if !isDocumentedMethod(eachRoute.Method) {
+			continue
+		}
+		path := NormalizePath(eachRoute.Path)
+		if isExcludedPath(path, options.ExcludePathPrefixList) {
+			continue
+		}
+		operation := buildOperation(g, eachRoute, path)
+		if operation == nil {
+			continue
+		}
+		if len(operation.OperationId) <= 0 {
+			operation.OperationId = operationId(eachRoute.Method, path)
+		}
+		if len(operation.Security) > 0 {
+			secured = true
+		}
+		for _, eachTag := range operation.Tags {
+			tagSet[eachTag] = true
+		}
+		pathItem, ok := doc.Paths[path]
+		if !ok {
+			pathItem = &PathItem{}
+			doc.Paths[path] = pathItem
+		}
+		(*pathItem)[strings.ToLower(eachRoute.Method)] = operation
+	}
+
+	tagList := make([]string, 0, len(tagSet))
+	for eachTag := range tagSet {
+		tagList = append(tagList, eachTag)
+	}
+	sort.Strings(tagList)
+	for _, eachTag := range tagList {
+		doc.Tags = append(doc.Tags, &Tag{Name: eachTag})
+	}
+
+	doc.Components = &Components{Schemas: g.Schemas()}
+	if secured {
+		doc.Components.SecuritySchemes = map[string]*SecurityScheme{
+			SecuritySchemeBearer: {
+				Type:         "http",
+				Scheme:       "bearer",
+				BearerFormat: "JWT",
+			},
+		}
+	}
+	return doc
+}
+
+func buildOperation(g *SchemaGenerator, route RouteInfo, path string) *Operation {
+	registered, ok := findOperation(route.Method, path)
+	if !ok {
+		return defaultOperation(g, route, path)
+	}
+	switch v := registered.(type) {
+	case EntityOperation:
+		return buildEntityOperation(g, v, path)
+	case OperationBuilder:
+		operation := v(g)
+		if operation != nil && operation.Responses == nil {
+			operation.Responses = map[string]*Response{}
+		}
+		return operation
+	}
+	return defaultOperation(g, route, path)
+}
+
+// operation of route that is not registered,only path parameters can be described
+func defaultOperation(g *SchemaGenerator, route RouteInfo, path string) *Operation {
+	operation := &Operation{
+		Summary:     route.Title,
+		Description: route.Description,
+		Parameters:  pathParameters(path),
+		Responses: map[string]*Response{
+			"200": jsonResponse("successful response", RefSchema(g.SchemaName(_baseResponseType))),
+		},
+	}
+	if segments := strings.Split(strings.Trim(path, "/"), "/"); len(segments) > 0 {
+		operation.Tags = []string{tagOfPath(segments)}
+	}
+	return operation
+}
+
+// #region envelope
+
+// BaseResponse with data
+func ResponseSchema(g *SchemaGenerator, data *Schema) *Schema {
+	return envelopeSchema(g.SchemaName(_baseResponseType), data)
+}
+
+// ListResponse with data list
+func ListResponseSchema(g *SchemaGenerator, item *Schema) *Schema {
+	return envelopeSchema(g.SchemaName(_listResponseType), &Schema{Type: "array", Items: item})
+}
+
+func envelopeSchema(envelopeName string, data *Schema) *Schema {
+	if data == nil {
+		return RefSchema(envelopeName)
+	}
+	return &Schema{
+		AllOf: []*Schema{
+			RefSchema(envelopeName),
+			{
+				Type:       "object",
+				Properties: map[string]*Schema{"data": data},
+			},
+		},
+	}
+}
+
+func jsonResponse(description string, schema *Schema) *Response {
+	return &Response{
+		Description: description,
+		Content: map[string]*MediaType{
+			"application/json": {Schema: schema},
+		},
+	}
+}
+
+// error responses,the body is wrapped by err middleware
+func appendErrorResponses(g *SchemaGenerator, responses map[string]*Response, statusCodeList ...int) {
+	for _, eachStatusCode := range statusCodeList {
+		responses[strconv.Itoa(eachStatusCode)] = jsonResponse(http.StatusText(eachStatusCode), ResponseSchema(g, nil))
+	}
+}
+
+// #endregion
+
+func pathParameters(path string) []*Parameter {
+	parameterList := make([]*Parameter, 0)
+	for _, eachName := range pathParameterNames(path) {
+		parameterList = append(parameterList, &Parameter{
+			Name:     eachName,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	return parameterList
+}
+
+func isExcludedPath(path string, prefixList []string) bool {
+	for _, eachPrefix := range prefixList {
+		if strings.HasPrefix(path, eachPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GET /api/user/{id} => getApiUserById
+func operationId(method string, path string) string {
+	builder := strings.Builder{}
+	builder.WriteString(strings.ToLower(method))
+	for _, eachSegment := range strings.Split(path, "/") {
+		if len(eachSegment) <= 0 {
+			continue
+		}
+		if strings.HasPrefix(eachSegment, "{") {
+			builder.WriteString("By")
+			eachSegment = strings.Trim(eachSegment, "{}")
+		}
+		builder.WriteString(upperFirst(eachSegment))
+	}
+	return builder.String()
+}
+
+// /api/user/{id} => user
+func tagOfPath(segments []string) string {
+	tag := ""
+	for _, eachSegment := range segments {
+		if strings.HasPrefix(eachSegment, "{") {
+			break
+		}
+		tag = eachSegment
+	}
+	if len(tag) <= 0 {
+		return "default"
+	}
+	return tag
+}
+
+func upperFirst(v string) string {
+	v = strings.NewReplacer("-", "", "_", "", ".", "").Replace(v)
+	if len(v) <= 0 {
+		return v
+	}
+	return strings.ToUpper(v[:1]) + v[1:]
+}
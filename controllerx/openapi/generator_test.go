@@ -0,0 +1,76 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/stretchr/testify/assert"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+}
+
+type testUser struct {
+	Name    string       `json:"name" validate:"required,max=20" schema:"user name"`
+	Age     int          `json:"age,omitempty" validate:"gte=0,lte=150"`
+	Role    string       `json:"role" validate:"oneof=admin user"`
+	Address *testAddress `json:"address,omitempty"`
+	Secret  string       `json:"-"`
+}
+
+func TestGenerate(t *testing.T) {
+	irisApp := iris.New()
+	party := irisApp.Party("/api/user")
+	route := party.Get("/{id:string}", func(ctx iris.Context) {})
+	RegistEntityOperation(http.MethodGet, route.Tmpl().Src, EntityOperation{
+		Action:        EntityActionGetById,
+		EntityType:    reflect.TypeOf(&testUser{}),
+		Authenticated: true,
+	})
+	irisApp.Post("/api/custom", func(ctx iris.Context) {})
+
+	doc := Generate(irisApp.GetRoutes())
+	assert.Equal(t, Version, doc.OpenAPI)
+
+	pathItem, ok := doc.Paths["/api/user/{id}"]
+	assert.True(t, ok)
+	operation := (*pathItem)["get"]
+	assert.Equal(t, []string{"testUser"}, operation.Tags)
+	assert.Equal(t, "id", operation.Parameters[0].Name)
+	assert.NotEmpty(t, operation.Security)
+	assert.NotNil(t, doc.Components.SecuritySchemes[SecuritySchemeBearer])
+
+	userSchema := doc.Components.Schemas["testUser"]
+	assert.Equal(t, []string{"name"}, userSchema.Required)
+	assert.Equal(t, "user name", userSchema.Properties["name"].Description)
+	assert.Equal(t, 20, *userSchema.Properties["name"].MaxLength)
+	assert.Equal(t, float64(150), *userSchema.Properties["age"].Maximum)
+	assert.Equal(t, []interface{}{"admin", "user"}, userSchema.Properties["role"].Enum)
+	assert.Equal(t, "#/components/schemas/testAddress", userSchema.Properties["address"].Ref)
+	assert.NotContains(t, userSchema.Properties, "Secret")
+
+	_, ok = doc.Paths["/api/custom"]
+	assert.True(t, ok)
+	assert.NotNil(t, doc.Components.Schemas["BaseResponse"])
+}
+
+func TestGenerateGin(t *testing.T) {
+	engine := gin.New()
+	engine.GET("/api/order/:id", func(c *gin.Context) {})
+	RegistEntityOperation(http.MethodGet, "/api/order/:id", EntityOperation{
+		Action:     EntityActionGetById,
+		EntityType: reflect.TypeOf(&testUser{}),
+	})
+
+	doc := GenerateGin(engine.Routes())
+	pathItem, ok := doc.Paths["/api/order/{id}"]
+	assert.True(t, ok)
+	operation := (*pathItem)["get"]
+	assert.Equal(t, []string{"testUser"}, operation.Tags)
+	assert.Equal(t, "id", operation.Parameters[0].Name)
+	assert.Empty(t, operation.Security)
+}
@@ -0,0 +1,114 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// action of entity controller
+type EntityAction string
+
+const (
+	EntityActionAll            EntityAction = "all"
+	EntityActionList           EntityAction = "list"
+	EntityActionGetById        EntityAction = "getById"
+	EntityActionCreate         EntityAction = "create"
+	EntityActionUpdate         EntityAction = "update"
+	EntityActionPatch          EntityAction = "patch"
+	EntityActionDelete         EntityAction = "delete"
+	EntityActionDeleteList     EntityAction = "deleteList"
+	EntityActionRestore        EntityAction = "restore"
+	EntityActionBatchCreate    EntityAction = "batchCreate"
+	EntityActionBatchUpdate    EntityAction = "batchUpdate"
+	EntityActionExport         EntityAction = "export"
+	EntityActionGetExport      EntityAction = "getExport"
+	EntityActionDownloadExport EntityAction = "downloadExport"
+	EntityActionImport         EntityAction = "import"
+	EntityActionGetImport      EntityAction = "getImport"
+	EntityActionImportReport   EntityAction = "importReport"
+	EntityActionStream         EntityAction = "stream"
+	EntityActionStreamWs       EntityAction = "streamWs"
+)
+
+// describe a route registered by entity controller
+type EntityOperation struct {
+	Action EntityAction
+	// entity type,the pointer is removed
+	EntityType    reflect.Type
+	Authenticated bool
+	// permission required by the route,empty if it is not checked
+	Permission string
+}
+
+// describe a custom route
+type OperationBuilder func(g *SchemaGenerator) *Operation
+
+var (
+	_operationMapping = make(map[string]interface{})
+	_operationLock    sync.RWMutex
+
+	_pathParamRegexp    = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+	_ginPathParamRegexp = regexp.MustCompile(`/[:*]([^/]+)`)
+)
+
+// register an entity controller route,path is the full path of route
+func RegistEntityOperation(method string, path string, operation EntityOperation) {
+	operation.EntityType = indirectType(operation.EntityType)
+	registOperation(method, path, operation)
+}
+
+// register a custom route description,path is the full path of route
+func RegistOperation(method string, path string, builder OperationBuilder) {
+	registOperation(method, path, builder)
+}
+
+func registOperation(method string, path string, operation interface{}) {
+	_operationLock.Lock()
+	defer _operationLock.Unlock()
+	_operationMapping[operationKey(method, path)] = operation
+}
+
+func findOperation(method string, path string) (interface{}, bool) {
+	_operationLock.RLock()
+	defer _operationLock.RUnlock()
+	operation, ok := _operationMapping[operationKey(method, path)]
+	return operation, ok
+}
+
+func operationKey(method string, path string) string {
+	return strings.ToUpper(method) + " " + NormalizePath(path)
+}
+
+// convert iris or gin path to OpenAPI path,/api/user/{id:uint64} or /api/user/:id => /api/user/{id}
+func NormalizePath(path string) string {
+	path = _pathParamRegexp.ReplaceAllString(path, "{$1}")
+	path = _ginPathParamRegexp.ReplaceAllString(path, "/{$1}")
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// the parameter names of path
+func pathParameterNames(path string) []string {
+	matches := _pathParamRegexp.FindAllStringSubmatch(path, -1)
+	names := make([]string, 0, len(matches))
+	for _, eachMatch := range matches {
+		names = append(names, eachMatch[1])
+	}
+	return names
+}
+
+func isDocumentedMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
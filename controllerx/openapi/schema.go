@@ -0,0 +1,278 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	_timeType          = reflect.TypeOf(time.Time{})
+	_objectIdType      = reflect.TypeOf(primitive.ObjectID{})
+	_jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	_invalidSchemaNameChar = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// generate json schemas from go types,struct types are stored as components
+type SchemaGenerator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func NewSchemaGenerator() *SchemaGenerator {
+	return &SchemaGenerator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// all component schemas
+func (g *SchemaGenerator) Schemas() map[string]*Schema {
+	return g.schemas
+}
+
+// get the schema of v,v can be a value or a reflect.Type
+func (g *SchemaGenerator) SchemaOf(v interface{}) *Schema {
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+	if t == nil {
+		return &Schema{}
+	}
+	return g.schemaOfType(t)
+}
+
+// get the component name of struct type,the schema is generated if needed
+func (g *SchemaGenerator) SchemaName(t reflect.Type) string {
+	t = indirectType(t)
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := _invalidSchemaNameChar.ReplaceAllString(t.Name(), "_")
+	if len(name) <= 0 {
+		name = "Object"
+	}
+	if _, exist := g.schemas[name]; exist {
+		//same name in different package
+		pkgName := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = pkgName + "." + name
+		for index := 2; g.schemas[name] != nil; index++ {
+			name = pkgName + "." + t.Name() + strconv.Itoa(index)
+		}
+	}
+	g.names[t] = name
+	//reserve the name before walking so that recursive types can be resolved
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.structSchema(t)
+	return name
+}
+
+func (g *SchemaGenerator) schemaOfType(t reflect.Type) *Schema {
+	t = indirectType(t)
+	switch t {
+	case _timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case _objectIdType:
+		return &Schema{Type: "string", Pattern: "^[0-9a-fA-F]{24}$"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOfType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOfType(t.Elem())}
+	case reflect.Struct:
+		if t.Implements(_jsonMarshalerType) || reflect.PtrTo(t).Implements(_jsonMarshalerType) {
+			//custom json format,nothing can be described
+			return &Schema{}
+		}
+		return RefSchema(g.SchemaName(t))
+	}
+	//interface{} and others,any value
+	return &Schema{}
+}
+
+func (g *SchemaGenerator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	g.appendStructFields(s, t)
+	return s
+}
+
+func (g *SchemaGenerator) appendStructFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		name, skip := parseJsonTag(structField)
+		if skip {
+			continue
+		}
+		fieldType := indirectType(structField.Type)
+		if structField.Anonymous && !hasJsonName(structField) && fieldType.Kind() == reflect.Struct {
+			//promoted fields,the same as encoding/json
+			g.appendStructFields(s, fieldType)
+			continue
+		}
+		if !structField.IsExported() {
+			continue
+		}
+
+		fieldSchema := g.schemaOfType(structField.Type)
+		if description := structField.Tag.Get("schema"); len(description) > 0 {
+			if len(fieldSchema.Ref) > 0 {
+				//siblings of $ref are allowed in OpenAPI 3.1
+				fieldSchema = &Schema{Ref: fieldSchema.Ref}
+			}
+			fieldSchema.Description = description
+		}
+		if applyValidateTag(fieldSchema, structField.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fieldSchema
+	}
+}
+
+// apply validator rules to schema,return true if field is required
+func applyValidateTag(s *Schema, tag string) (required bool) {
+	if len(tag) <= 0 || tag == "-" {
+		return false
+	}
+	for _, eachRule := range strings.Split(tag, ",") {
+		if eachRule == "dive" {
+			//rules after dive are for elements
+			break
+		}
+		name, param, _ := strings.Cut(eachRule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "ip", "ipv4":
+			s.Format = "ipv4"
+		case "ipv6":
+			s.Format = "ipv6"
+		case "oneof":
+			for _, eachValue := range strings.Fields(param) {
+				s.Enum = append(s.Enum, enumValue(s, eachValue))
+			}
+		case "len":
+			applyLimit(s, param, true, true, false)
+		case "min", "gte":
+			applyLimit(s, param, true, false, false)
+		case "max", "lte":
+			applyLimit(s, param, false, true, false)
+		case "gt":
+			applyLimit(s, param, true, false, true)
+		case "lt":
+			applyLimit(s, param, false, true, true)
+		}
+	}
+	return required
+}
+
+// min/max is length for string,count for array,value for number
+func applyLimit(s *Schema, param string, isMin bool, isMax bool, exclusive bool) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "string":
+		if isMin {
+			s.MinLength = intPtr(int(value))
+		}
+		if isMax {
+			s.MaxLength = intPtr(int(value))
+		}
+	case "array":
+		if isMin {
+			s.MinItems = intPtr(int(value))
+		}
+		if isMax {
+			s.MaxItems = intPtr(int(value))
+		}
+	case "integer", "number":
+		switch {
+		case isMin && exclusive:
+			s.ExclusiveMinimum = &value
+		case isMax && exclusive:
+			s.ExclusiveMaximum = &value
+		default:
+			if isMin {
+				s.Minimum = &value
+			}
+			if isMax {
+				s.Maximum = &value
+			}
+		}
+	}
+}
+
+func enumValue(s *Schema, value string) interface{} {
+	switch s.Type {
+	case "integer":
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case "number":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+func parseJsonTag(structField reflect.StructField) (name string, skip bool) {
+	tag := structField.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	name = strings.Split(tag, ",")[0]
+	if len(name) <= 0 {
+		name = structField.Name
+	}
+	return name, false
+}
+
+func hasJsonName(structField reflect.StructField) bool {
+	tag := structField.Tag.Get("json")
+	return len(tag) > 0 && !strings.HasPrefix(tag, ",")
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func intPtr(v int) *int {
+	return &v
+}
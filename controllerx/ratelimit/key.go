@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"sync"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+)
+
+const (
+	KeyByIp     = "ip"
+	KeyByUser   = "user"
+	KeyByApiKey = "apikey"
+	KeyByRoute  = "route"
+	KeyByTenant = "tenant"
+)
+
+// value of a part of the counter key,an empty value means the key is not available for the request
+type KeyFunc func(ctx iris.Context) string
+
+var (
+	_keyFuncMap  = map[string]KeyFunc{}
+	_keyFuncLock sync.RWMutex
+)
+
+func init() {
+	RegistKeyFunc(KeyByIp, func(ctx iris.Context) string {
+		return ctx.RemoteAddr()
+	})
+	RegistKeyFunc(KeyByUser, func(ctx iris.Context) string {
+		if principal := GetPrincipal(ctx); principal != nil {
+			return principal.Id
+		}
+		return ""
+	})
+	RegistKeyFunc(KeyByTenant, func(ctx iris.Context) string {
+		if principal := GetPrincipal(ctx); principal != nil {
+			return principal.TenantId
+		}
+		return ""
+	})
+	RegistKeyFunc(KeyByApiKey, func(ctx iris.Context) string {
+		// the hash is used so the key is not kept in the store
+		if key := ctx.GetHeader(fwauth.HeaderApiKey); len(key) > 0 {
+			return fwauth.HashApiKey(key)
+		}
+		return ""
+	})
+	RegistKeyFunc(KeyByRoute, func(ctx iris.Context) string {
+		if route := ctx.GetCurrentRoute(); route != nil {
+			return route.Name()
+		}
+		return ctx.Method() + ctx.Path()
+	})
+}
+
+// regist a key that can be used in Rule.KeyByList
+func RegistKeyFunc(name string, keyFunc KeyFunc) {
+	_keyFuncLock.Lock()
+	defer _keyFuncLock.Unlock()
+	_keyFuncMap[name] = keyFunc
+}
+
+func getKeyFunc(name string) KeyFunc {
+	_keyFuncLock.RLock()
+	defer _keyFuncLock.RUnlock()
+	return _keyFuncMap[name]
+}
+
+// #region principal
+
+// key of ctx.Values,the principal resolved by the limiter
+const principalContextKey = "fwpkg.ratelimit.principal"
+
+// the limiter runs before the authentication of routes,so the principal is resolved with the default authenticators
+// of fwauth(casdoor if they are not set),the request is not rejected if the authentication fails
+func GetPrincipal(ctx iris.Context) *fwauth.Principal {
+	if principal := fwauth.GetIrisPrincipal(ctx); principal != nil {
+		return principal
+	}
+	if v := ctx.Values().Get(principalContextKey); v != nil {
+		principal, _ := v.(*fwauth.Principal)
+		return principal
+	}
+	authenticatorList := fwauth.GetDefaultAuthenticators()
+	if len(authenticatorList) <= 0 {
+		authenticatorList = []fwauth.Authenticator{fwauth.NewCasdoorAuthenticator()}
+	}
+	principal, err := fwauth.NewAuthenticatorChain(authenticatorList...).Authenticate(ctx.Request())
+	if err != nil {
+		principal = nil
+	}
+	// the failure is also kept so the request is authenticated once
+	ctx.Values().Set(principalContextKey, principal)
+	return principal
+}
+
+// #endregion
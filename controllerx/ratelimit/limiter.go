@@ -0,0 +1,291 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/configurationx"
+	"github.com/shanluzhineng/fwpkg/app"
+	"github.com/shanluzhineng/fwpkg/redisx"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+const (
+	// configuration key of rate limit,see NewConfigLimiter
+	ConfigurationKey = "ratelimit"
+
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+
+	// rules are loaded again after the interval if LimiterWithReloadInterval is not used
+	DefaultReloadInterval = time.Minute
+	DefaultKeyPrefix      = "ratelimit:"
+)
+
+type RateLimitOptions struct {
+	// memory or redis,the store can not be changed by reload
+	Store     string `mapstructure:"store" json:"store" yaml:"store"`
+	KeyPrefix string `mapstructure:"keyPrefix" json:"keyPrefix" yaml:"keyPrefix"`
+	// DefaultReloadInterval is used if it is not set
+	ReloadInterval time.Duration `mapstructure:"reloadInterval" json:"reloadInterval" yaml:"reloadInterval"`
+	// all matched rules are applied in order
+	RuleList []Rule `mapstructure:"rules" json:"rules" yaml:"rules"`
+}
+
+// source of rules,the rules are loaded again when the reload interval of Limiter expires
+type IRuleSource interface {
+	LoadRules() ([]Rule, error)
+}
+
+// #region rule source
+
+type memoryRuleSource struct {
+	ruleList []Rule
+}
+
+func NewMemoryRuleSource(ruleList ...Rule) IRuleSource {
+	return &memoryRuleSource{
+		ruleList: ruleList,
+	}
+}
+
+func (s *memoryRuleSource) LoadRules() ([]Rule, error) {
+	return s.ruleList, nil
+}
+
+type configRuleSource struct {
+	key string
+}
+
+// rules of RateLimitOptions from configurationx,ConfigurationKey is used if key is empty
+func NewConfigRuleSource(key string) IRuleSource {
+	if len(key) <= 0 {
+		key = ConfigurationKey
+	}
+	return &configRuleSource{
+		key: key,
+	}
+}
+
+func (s *configRuleSource) LoadRules() ([]Rule, error) {
+	options, err := loadOptions(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return options.RuleList, nil
+}
+
+func loadOptions(key string) (*RateLimitOptions, error) {
+	options := &RateLimitOptions{}
+	if ok := configurationx.GetInstance().UnmarshalPropertiesTo(key, options); !ok {
+		return nil, fmt.Errorf("rate limit options parser fail,key:%s", key)
+	}
+	return options, nil
+}
+
+// #endregion
+
+type Limiter struct {
+	store          IStore
+	source         IRuleSource
+	keyPrefix      string
+	reloadInterval time.Duration
+
+	lock     sync.RWMutex
+	ruleList []*Rule
+	loadTime time.Time
+}
+
+type LimiterOption func(*Limiter)
+
+// the rules are loaded again after the interval,they are never reloaded if the interval is not positive
+func LimiterWithReloadInterval(interval time.Duration) LimiterOption {
+	return func(l *Limiter) {
+		l.reloadInterval = interval
+	}
+}
+
+// prefix of the counter keys,DefaultKeyPrefix is used if it is not set
+func LimiterWithKeyPrefix(keyPrefix string) LimiterOption {
+	return func(l *Limiter) {
+		l.keyPrefix = keyPrefix
+	}
+}
+
+func NewLimiter(store IStore, source IRuleSource, opts ...LimiterOption) *Limiter {
+	l := &Limiter{
+		store:          store,
+		source:         source,
+		keyPrefix:      DefaultKeyPrefix,
+		reloadInterval: DefaultReloadInterval,
+	}
+	for _, eachOpt := range opts {
+		eachOpt(l)
+	}
+	return l
+}
+
+// limiter of the options from configurationx,ConfigurationKey is used if key is empty,e.g.
+//
+//	ratelimit:
+//	  store: redis
+//	  reloadInterval: 30s
+//	  rules:
+//	    - name: api
+//	      path: /api/**
+//	      keyBy: [tenant,user]
+//	      limit: 100
+//	      period: 1s
+//	      burst: 200
+//
+// the redis store uses redisx.IRedisService registered in ioc container,
+// the memory store is used if it is not registered
+func NewConfigLimiter(key string) *Limiter {
+	if len(key) <= 0 {
+		key = ConfigurationKey
+	}
+	options, err := loadOptions(key)
+	if err != nil {
+		log.Logger.Warn(err.Error())
+		options = &RateLimitOptions{}
+	}
+	opts := make([]LimiterOption, 0)
+	if len(options.KeyPrefix) > 0 {
+		opts = append(opts, LimiterWithKeyPrefix(options.KeyPrefix))
+	}
+	if options.ReloadInterval > 0 {
+		opts = append(opts, LimiterWithReloadInterval(options.ReloadInterval))
+	}
+	var store IStore
+	if options.Store == StoreRedis {
+		store = newLazyRedisStore()
+	} else {
+		store = NewMemoryStore()
+	}
+	return NewLimiter(store, NewConfigRuleSource(key), opts...)
+}
+
+// load the rules from source now,the current rules are kept if the new rules are invalid
+func (l *Limiter) Reload() error {
+	sourceRuleList, err := l.source.LoadRules()
+	if err != nil {
+		return err
+	}
+	ruleList := make([]*Rule, 0, len(sourceRuleList))
+	for i := range sourceRuleList {
+		rule := sourceRuleList[i]
+		if err := rule.normalize(); err != nil {
+			return err
+		}
+		ruleList = append(ruleList, &rule)
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.ruleList = ruleList
+	l.loadTime = time.Now()
+	return nil
+}
+
+func (l *Limiter) getRuleList() []*Rule {
+	l.lock.RLock()
+	ruleList, loadTime := l.ruleList, l.loadTime
+	l.lock.RUnlock()
+	if !loadTime.IsZero() && (l.reloadInterval <= 0 || time.Since(loadTime) < l.reloadInterval) {
+		return ruleList
+	}
+	if err := l.Reload(); err != nil {
+		log.Logger.Warn(fmt.Sprintf("reload rate limit rules fail,err:%s", err.Error()))
+		// try again after the interval
+		l.lock.Lock()
+		l.loadTime = time.Now()
+		l.lock.Unlock()
+		return ruleList
+	}
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.ruleList
+}
+
+// take the request from the counters of all matched rules,it is not taken from any counter if a rule denies it.
+// the result of the denied rule or the allowed rule with least remaining is returned,nil if no rule matches
+func (l *Limiter) Allow(ctx iris.Context) (*Result, error) {
+	keyList := make([]string, 0)
+	ruleList := make([]*Rule, 0)
+	for _, eachRule := range l.getRuleList() {
+		if !eachRule.Match(ctx.Method(), ctx.Path()) {
+			continue
+		}
+		keyList = append(keyList, l.buildKey(ctx, eachRule))
+		ruleList = append(ruleList, eachRule)
+	}
+	if len(ruleList) <= 0 {
+		return nil, nil
+	}
+	resultList, err := l.store.TakeAll(keyList, ruleList)
+	if err != nil {
+		return nil, err
+	}
+	var result *Result
+	for _, eachResult := range resultList {
+		if !eachResult.Allowed {
+			return eachResult, nil
+		}
+		if result == nil || eachResult.Remaining < result.Remaining {
+			result = eachResult
+		}
+	}
+	return result, nil
+}
+
+// prefix+rule name+values of keys,the ip is used if a value is not available,e.g. an anonymous user
+func (l *Limiter) buildKey(ctx iris.Context, rule *Rule) string {
+	partList := make([]string, 0, len(rule.KeyByList)+1)
+	partList = append(partList, rule.Name)
+	for _, eachKeyBy := range rule.KeyByList {
+		value := getKeyFunc(eachKeyBy)(ctx)
+		if len(value) <= 0 {
+			value = KeyByIp + "=" + ctx.RemoteAddr()
+		}
+		partList = append(partList, value)
+	}
+	return l.keyPrefix + strings.Join(partList, ":")
+}
+
+// #region lazy redis store
+
+// the redis service is registered when the web application is built,so it is resolved at the first request
+type lazyRedisStore struct {
+	once  sync.Once
+	store IStore
+}
+
+func newLazyRedisStore() IStore {
+	return &lazyRedisStore{}
+}
+
+func (s *lazyRedisStore) Take(key string, rule *Rule) (*Result, error) {
+	return s.getStore().Take(key, rule)
+}
+
+func (s *lazyRedisStore) TakeAll(keyList []string, ruleList []*Rule) ([]*Result, error) {
+	return s.getStore().TakeAll(keyList, ruleList)
+}
+
+func (s *lazyRedisStore) getStore() IStore {
+	s.once.Do(func() {
+		if app.Context != nil {
+			if redisService, ok := app.Context.GetInstance(new(redisx.IRedisService)).(redisx.IRedisService); ok {
+				s.store = NewRedisStore(redisService)
+				return
+			}
+		}
+		log.Logger.Warn("redisx.IRedisService is not registered,the memory store of rate limit is used")
+		s.store = NewMemoryStore()
+	})
+	return s.store
+}
+
+// #endregion
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleMatch(t *testing.T) {
+	rule := &Rule{Path: "/api/orders/{id}/*", MethodList: []string{"post"}, Limit: 1, Period: time.Second}
+	assert.Nil(t, rule.normalize())
+	assert.True(t, rule.Match("POST", "/api/orders/1/items"))
+	assert.False(t, rule.Match("GET", "/api/orders/1/items"))
+	assert.False(t, rule.Match("POST", "/api/orders/1"))
+	assert.False(t, rule.Match("POST", "/api/orders/1/items/2"))
+
+	rule = &Rule{Path: "/api/**", Limit: 1, Period: time.Second}
+	assert.Nil(t, rule.normalize())
+	assert.True(t, rule.Match("GET", "/api/orders/1"))
+	assert.False(t, rule.Match("GET", "/health"))
+
+	assert.NotNil(t, (&Rule{Limit: 1, Period: time.Second, KeyByList: []string{"unknown"}}).normalize())
+	assert.NotNil(t, (&Rule{Limit: 1}).normalize())
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore().(*memoryStore)
+	store.now = func() time.Time { return now }
+
+	tokenBucket := &Rule{Algorithm: AlgorithmTokenBucket, Limit: 1, Period: time.Second, Burst: 2}
+	assert.Nil(t, tokenBucket.normalize())
+	result, _ := store.Take("tb", tokenBucket)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+	result, _ = store.Take("tb", tokenBucket)
+	assert.True(t, result.Allowed)
+	result, _ = store.Take("tb", tokenBucket)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	now = now.Add(time.Second)
+	result, _ = store.Take("tb", tokenBucket)
+	assert.True(t, result.Allowed)
+
+	slidingWindow := &Rule{Algorithm: AlgorithmSlidingWindow, Limit: 2, Period: time.Minute}
+	assert.Nil(t, slidingWindow.normalize())
+	now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		result, _ = store.Take("sw", slidingWindow)
+		assert.True(t, result.Allowed)
+	}
+	result, _ = store.Take("sw", slidingWindow)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Minute, result.RetryAfter)
+	// the previous window weights 0.75,0.75*2 + 1 > 2
+	now = now.Add(75 * time.Second)
+	result, _ = store.Take("sw", slidingWindow)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 15*time.Second, result.RetryAfter)
+	now = now.Add(15 * time.Second)
+	result, _ = store.Take("sw", slidingWindow)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryStoreTakeAll(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore().(*memoryStore)
+	store.now = func() time.Time { return now }
+
+	user := &Rule{Name: "user", Limit: 1, Period: time.Minute}
+	assert.Nil(t, user.normalize())
+	tenant := &Rule{Name: "tenant", Limit: 2, Period: time.Minute}
+	assert.Nil(t, tenant.normalize())
+	keyList := []string{"user", "tenant"}
+	resultList, err := store.TakeAll(keyList, []*Rule{user, tenant})
+	assert.Nil(t, err)
+	assert.True(t, resultList[0].Allowed)
+	assert.True(t, resultList[1].Allowed)
+	assert.Equal(t, 1, resultList[1].Remaining)
+
+	// the request is denied by user,it is not taken from the counter of tenant
+	resultList, err = store.TakeAll(keyList, []*Rule{user, tenant})
+	assert.Nil(t, err)
+	assert.False(t, resultList[0].Allowed)
+	result, _ := store.Take("tenant", tenant)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
+
+func TestMiddleware(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), NewMemoryRuleSource(
+		Rule{Name: "orders", Path: "/api/orders", Limit: 1, Period: time.Minute, KeyByList: []string{KeyByIp, KeyByRoute}},
+	))
+	app := iris.New()
+	app.UseGlobal(New(limiter))
+	app.Get("/api/orders", func(ctx iris.Context) {})
+	app.Get("/api/users", func(ctx iris.Context) {})
+	assert.Nil(t, app.Build())
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	w := serve("/api/orders")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get(HeaderRateLimitLimit))
+	assert.Equal(t, "0", w.Header().Get(HeaderRateLimitRemaining))
+	w = serve("/api/orders")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get(HeaderRetryAfter))
+	w = serve("/api/users")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(HeaderRateLimitLimit))
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// handler that limits the requests with the rules of limiter,
+// a 429 response with Retry-After is written if the request is denied.
+// the request is not limited if the store fails
+func New(limiter *Limiter) iris.Handler {
+	return func(ctx iris.Context) {
+		result, err := limiter.Allow(ctx)
+		if err != nil {
+			log.Logger.Warn(fmt.Sprintf("rate limit fail,path:%s,err:%s", ctx.Path(), err.Error()))
+			ctx.Next()
+			return
+		}
+		if result == nil {
+			ctx.Next()
+			return
+		}
+		ctx.Header(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+		ctx.Header(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+		ctx.Header(HeaderRateLimitReset, strconv.FormatInt(ceilSeconds(result.ResetAfter), 10))
+		if !result.Allowed {
+			ctx.Header(HeaderRetryAfter, strconv.FormatInt(ceilSeconds(result.RetryAfter), 10))
+			responsex.HandleError(http.StatusTooManyRequests, ctx, fmt.Errorf("too many requests,retry after %s", result.RetryAfter))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
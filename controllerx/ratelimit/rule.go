@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	AlgorithmTokenBucket   = "tokenBucket"
+	AlgorithmSlidingWindow = "slidingWindow"
+)
+
+// a limit of the requests that match the path and methods,e.g.
+//
+//	rules:
+//	  - name: login
+//	    path: /api/auth/**
+//	    methods: [POST]
+//	    keyBy: [ip]
+//	    algorithm: slidingWindow
+//	    limit: 10
+//	    period: 1m
+type Rule struct {
+	// name is a part of the key of counters,path is used if it is empty
+	Name string `mapstructure:"name" json:"name" yaml:"name"`
+	// * matches one segment,** matches the rest segments,{param} is the same as *
+	Path string `mapstructure:"path" json:"path" yaml:"path"`
+	// all methods are matched if it is empty
+	MethodList []string `mapstructure:"methods" json:"methods" yaml:"methods"`
+	// ip,user,apikey,route,tenant or the name of RegistKeyFunc,
+	// the counter is shared by the requests with the same values. ip is used if it is empty
+	KeyByList []string `mapstructure:"keyBy" json:"keyBy" yaml:"keyBy"`
+	// AlgorithmTokenBucket is used if it is empty
+	Algorithm string `mapstructure:"algorithm" json:"algorithm" yaml:"algorithm"`
+	// limit requests in period,for token bucket it is the refill rate
+	Limit  int           `mapstructure:"limit" json:"limit" yaml:"limit"`
+	Period time.Duration `mapstructure:"period" json:"period" yaml:"period"`
+	// capacity of token bucket,Limit is used if it is not set
+	Burst int `mapstructure:"burst" json:"burst" yaml:"burst"`
+
+	segmentList []string
+	methodSet   map[string]bool
+}
+
+func (r *Rule) normalize() error {
+	if len(r.Path) <= 0 {
+		r.Path = "/**"
+	}
+	if len(r.Name) <= 0 {
+		r.Name = r.Path
+	}
+	if len(r.KeyByList) <= 0 {
+		r.KeyByList = []string{KeyByIp}
+	}
+	if len(r.Algorithm) <= 0 {
+		r.Algorithm = AlgorithmTokenBucket
+	}
+	if r.Algorithm != AlgorithmTokenBucket && r.Algorithm != AlgorithmSlidingWindow {
+		return fmt.Errorf("invalid rate limit algorithm,rule:%s,algorithm:%s", r.Name, r.Algorithm)
+	}
+	if r.Limit <= 0 || r.Period <= 0 {
+		return fmt.Errorf("limit and period of rate limit rule must be positive,rule:%s", r.Name)
+	}
+	if r.Burst <= 0 {
+		r.Burst = r.Limit
+	}
+	for _, eachKeyBy := range r.KeyByList {
+		if getKeyFunc(eachKeyBy) == nil {
+			return fmt.Errorf("invalid rate limit key,rule:%s,keyBy:%s", r.Name, eachKeyBy)
+		}
+	}
+	r.segmentList = splitPath(r.Path)
+	r.methodSet = make(map[string]bool, len(r.MethodList))
+	for _, eachMethod := range r.MethodList {
+		r.methodSet[strings.ToUpper(eachMethod)] = true
+	}
+	return nil
+}
+
+// the rule applies to the request
+func (r *Rule) Match(method string, path string) bool {
+	if len(r.methodSet) > 0 && !r.methodSet[strings.ToUpper(method)] {
+		return false
+	}
+	return matchSegmentList(r.segmentList, splitPath(path))
+}
+
+func splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+}
+
+func matchSegmentList(patternList []string, segmentList []string) bool {
+	for i, eachPattern := range patternList {
+		if eachPattern == "**" {
+			return true
+		}
+		if i >= len(segmentList) {
+			return false
+		}
+		if eachPattern == "*" || (strings.HasPrefix(eachPattern, "{") && strings.HasSuffix(eachPattern, "}")) {
+			continue
+		}
+		if eachPattern != segmentList[i] {
+			return false
+		}
+	}
+	return len(patternList) == len(segmentList)
+}
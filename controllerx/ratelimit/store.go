@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// result of taking one request from the counter of a rule
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// wait time before the request is allowed,zero if it is allowed
+	RetryAfter time.Duration
+	// time before the counter is full again
+	ResetAfter time.Duration
+}
+
+// counters of the rules,the counter of key is created when it is first used
+type IStore interface {
+	Take(key string, rule *Rule) (*Result, error)
+	// take the request from the counter of each key with the rule of the same index,
+	// the counters are changed only if all rules allow the request
+	TakeAll(keyList []string, ruleList []*Rule) ([]*Result, error)
+}
+
+// #region memory store
+
+const memoryStoreSweepInterval = time.Minute
+
+type memoryCounter struct {
+	// tokens of token bucket,count of current window of sliding window
+	value float64
+	// count of previous window of sliding window
+	previous float64
+	// refill time of token bucket,start of current window of sliding window
+	time time.Time
+	// counters that are not used in the period are removed
+	expireTime time.Time
+}
+
+type memoryStore struct {
+	now func() time.Time
+
+	lock       sync.Mutex
+	counterMap map[string]*memoryCounter
+	sweepTime  time.Time
+}
+
+// counters of current process,the limits are not shared by the instances of cluster
+func NewMemoryStore() IStore {
+	return &memoryStore{
+		now:        time.Now,
+		counterMap: make(map[string]*memoryCounter),
+	}
+}
+
+func (s *memoryStore) Take(key string, rule *Rule) (*Result, error) {
+	resultList, err := s.TakeAll([]string{key}, []*Rule{rule})
+	if err != nil {
+		return nil, err
+	}
+	return resultList[0], nil
+}
+
+// the request is taken from the copies of counters,they replace the counters if all rules allow it
+func (s *memoryStore) TakeAll(keyList []string, ruleList []*Rule) ([]*Result, error) {
+	if len(keyList) != len(ruleList) {
+		return nil, fmt.Errorf("count of keys and rules must be the same,keys:%d,rules:%d", len(keyList), len(ruleList))
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+	allowed := true
+	resultList := make([]*Result, 0, len(keyList))
+	pendingMap := make(map[string]*memoryCounter)
+	for i, eachKey := range keyList {
+		rule := ruleList[i]
+		counter, ok := pendingMap[eachKey]
+		if !ok {
+			counter = &memoryCounter{time: now}
+			if stored, ok := s.counterMap[eachKey]; ok {
+				*counter = *stored
+			} else if rule.Algorithm == AlgorithmTokenBucket {
+				counter.value = float64(rule.Burst)
+			} else {
+				counter.time = now.Truncate(rule.Period)
+			}
+			pendingMap[eachKey] = counter
+		}
+		var result *Result
+		if rule.Algorithm == AlgorithmTokenBucket {
+			result = takeTokenBucket(counter, rule, now)
+		} else {
+			result = takeSlidingWindow(counter, rule, now)
+		}
+		expireTime := now.Add(2 * rule.Period)
+		if result.ResetAfter > rule.Period {
+			expireTime = now.Add(result.ResetAfter + rule.Period)
+		}
+		if expireTime.After(counter.expireTime) {
+			counter.expireTime = expireTime
+		}
+		allowed = allowed && result.Allowed
+		resultList = append(resultList, result)
+	}
+	if allowed {
+		for eachKey, eachCounter := range pendingMap {
+			s.counterMap[eachKey] = eachCounter
+		}
+	}
+	return resultList, nil
+}
+
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweepTime) < memoryStoreSweepInterval {
+		return
+	}
+	s.sweepTime = now
+	for eachKey, eachCounter := range s.counterMap {
+		if now.After(eachCounter.expireTime) {
+			delete(s.counterMap, eachKey)
+		}
+	}
+}
+
+// tokens are refilled at Limit/Period,at most Burst tokens are kept
+func takeTokenBucket(counter *memoryCounter, rule *Rule, now time.Time) *Result {
+	// tokens per nanosecond
+	rate := float64(rule.Limit) / float64(rule.Period)
+	if elapsed := now.Sub(counter.time); elapsed > 0 {
+		counter.value = math.Min(float64(rule.Burst), counter.value+float64(elapsed)*rate)
+		counter.time = now
+	}
+	result := &Result{Limit: rule.Burst}
+	if counter.value >= 1 {
+		counter.value--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - counter.value) / rate))
+	}
+	result.Remaining = int(counter.value)
+	result.ResetAfter = time.Duration(math.Ceil((float64(rule.Burst) - counter.value) / rate))
+	return result
+}
+
+// the count of previous window is weighted by the rest of it in the sliding window
+func takeSlidingWindow(counter *memoryCounter, rule *Rule, now time.Time) *Result {
+	windowStart := now.Truncate(rule.Period)
+	if !windowStart.Equal(counter.time) {
+		if windowStart.Sub(counter.time) == rule.Period {
+			counter.previous = counter.value
+		} else {
+			counter.previous = 0
+		}
+		counter.value = 0
+		counter.time = windowStart
+	}
+	elapsed := now.Sub(windowStart)
+	weight := 1 - float64(elapsed)/float64(rule.Period)
+	count := counter.previous*weight + counter.value
+	limit := float64(rule.Limit)
+	result := &Result{Limit: rule.Limit}
+	if count+1 <= limit {
+		counter.value++
+		count++
+		result.Allowed = true
+	} else if counter.value+1 > limit || counter.previous <= 0 {
+		result.RetryAfter = rule.Period - elapsed
+	} else {
+		// the weight of previous window decreases until the request is allowed
+		allowedWeight := (limit - counter.value - 1) / counter.previous
+		result.RetryAfter = time.Duration(math.Ceil((1-allowedWeight)*float64(rule.Period))) - elapsed
+	}
+	result.Remaining = int(math.Max(0, limit-count))
+	result.ResetAfter = rule.Period - elapsed
+	if counter.value > 0 {
+		result.ResetAfter += rule.Period
+	}
+	return result
+}
+
+// #endregion
@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/shanluzhineng/fwpkg/redisx"
+)
+
+// redis time is used so the instances of cluster share the same clock.
+// the arguments of each key are {algorithm,limit,period(ms),burst},the counters are changed only if
+// all rules allow the request,the script returns {allowed,remaining,retryAfter(ms),resetAfter(ms)} of each key
+var takeScript = redis.NewScript(`local now = redis.call("TIME")
+now = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+local allAllowed = true
+local pendingMap = {}
+local result = {}
+for i, key in ipairs(KEYS) do
+	local algorithm = ARGV[(i - 1) * 4 + 1]
+	local limit = tonumber(ARGV[(i - 1) * 4 + 2])
+	local period = tonumber(ARGV[(i - 1) * 4 + 3])
+	local burst = tonumber(ARGV[(i - 1) * 4 + 4])
+	local pending = pendingMap[key]
+	local allowed = 0
+	local remaining = 0
+	local retryAfter = 0
+	local resetAfter = 0
+	if algorithm == "` + AlgorithmTokenBucket + `" then
+		local tokens, last
+		if pending then
+			tokens, last = pending.tokens, pending.time
+		else
+			local state = redis.call("HMGET", key, "tokens", "time")
+			tokens = tonumber(state[1]) or burst
+			last = tonumber(state[2]) or now
+		end
+		local rate = limit / period
+		if now > last then
+			tokens = math.min(burst, tokens + (now - last) * rate)
+			last = now
+		end
+		if tokens >= 1 then
+			tokens = tokens - 1
+			allowed = 1
+		else
+			retryAfter = math.ceil((1 - tokens) / rate)
+		end
+		remaining = math.floor(tokens)
+		resetAfter = math.ceil((burst - tokens) / rate)
+		pending = {tokens = tokens, time = last, fields = {"tokens", tostring(tokens), "time", last}, expire = resetAfter + period}
+	else
+		local window = math.floor(now / period)
+		local elapsed = now - window * period
+		local last, current, previous
+		if pending then
+			last, current, previous = pending.window, pending.current, pending.previous
+		else
+			local state = redis.call("HMGET", key, "window", "current", "previous")
+			last = tonumber(state[1])
+			current = tonumber(state[2]) or 0
+			previous = tonumber(state[3]) or 0
+		end
+		if last ~= window then
+			if last == window - 1 then
+				previous = current
+			else
+				previous = 0
+			end
+			current = 0
+		end
+		local count = previous * (1 - elapsed / period) + current
+		if count + 1 <= limit then
+			current = current + 1
+			count = count + 1
+			allowed = 1
+		elseif current + 1 > limit or previous <= 0 then
+			retryAfter = period - elapsed
+		else
+			retryAfter = math.ceil((1 - (limit - current - 1) / previous) * period) - elapsed
+		end
+		remaining = math.floor(math.max(0, limit - count))
+		resetAfter = period - elapsed
+		if current > 0 then
+			resetAfter = resetAfter + period
+		end
+		pending = {window = window, current = current, previous = previous, fields = {"window", window, "current", current, "previous", previous}, expire = 2 * period}
+	end
+	pendingMap[key] = pending
+	if allowed == 0 then
+		allAllowed = false
+	end
+	table.insert(result, allowed)
+	table.insert(result, remaining)
+	table.insert(result, retryAfter)
+	table.insert(result, resetAfter)
+end
+if allAllowed then
+	for key, pending in pairs(pendingMap) do
+		redis.call("HSET", key, unpack(pending.fields))
+		redis.call("PEXPIRE", key, pending.expire)
+	end
+end
+return result`)
+
+type redisStore struct {
+	redisService redisx.IRedisService
+}
+
+// counters in redis,the limits are shared by the instances of cluster.
+// keys are prefixed with the key prefix of redisService
+func NewRedisStore(redisService redisx.IRedisService) IStore {
+	return &redisStore{
+		redisService: redisService,
+	}
+}
+
+func (s *redisStore) Take(key string, rule *Rule) (*Result, error) {
+	resultList, err := s.TakeAll([]string{key}, []*Rule{rule})
+	if err != nil {
+		return nil, err
+	}
+	return resultList[0], nil
+}
+
+// all counters are checked by one script,so the request is taken from all of them or none
+func (s *redisStore) TakeAll(keyList []string, ruleList []*Rule) ([]*Result, error) {
+	if len(keyList) != len(ruleList) {
+		return nil, fmt.Errorf("count of keys and rules must be the same,keys:%d,rules:%d", len(keyList), len(ruleList))
+	}
+	args := make([]interface{}, 0, len(ruleList)*4)
+	for _, eachRule := range ruleList {
+		args = append(args, eachRule.Algorithm, eachRule.Limit, eachRule.Period.Milliseconds(), eachRule.Burst)
+	}
+	v, err := s.redisService.RunScript(takeScript, keyList, args)
+	if err != nil {
+		return nil, err
+	}
+	valueList, ok := v.([]interface{})
+	if !ok || len(valueList) != len(keyList)*4 {
+		return nil, fmt.Errorf("invalid result of rate limit script,keys:%v,result:%v", keyList, v)
+	}
+	resultList := make([]*Result, 0, len(keyList))
+	for i, eachRule := range ruleList {
+		intList := make([]int64, 4)
+		for j := range intList {
+			intList[j], _ = valueList[i*4+j].(int64)
+		}
+		limit := eachRule.Burst
+		if eachRule.Algorithm == AlgorithmSlidingWindow {
+			limit = eachRule.Limit
+		}
+		resultList = append(resultList, &Result{
+			Allowed:    intList[0] == 1,
+			Limit:      limit,
+			Remaining:  int(intList[1]),
+			RetryAfter: time.Duration(intList[2]) * time.Millisecond,
+			ResetAfter: time.Duration(intList[3]) * time.Millisecond,
+		})
+	}
+	return resultList, nil
+}
@@ -0,0 +1,397 @@
+package responsecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/system/correlation"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+const (
+	// HIT if the response is from cache,otherwise MISS
+	HeaderCache = "X-Cache"
+
+	DefaultTtl       = time.Minute
+	DefaultKeyPrefix = "responsecache:"
+)
+
+// headers of the response that are not cached
+var excludedHeaderList = []string{"Date", "Content-Length", HeaderCache, correlation.HeaderRequestId}
+
+// tags of the request,the cached entry is invalidated by any of them
+type TagFunc func(ctx iris.Context) []string
+
+// tenant that the request resolves to,bypass is true if the request reads the items of all tenants
+type TenantFunc func(ctx iris.Context) (tenantId string, bypass bool)
+
+type CacheOptions struct {
+	// redis store is used if not set,memory store is used if redisx.IRedisService is not registered
+	Store     IStore
+	KeyPrefix string
+
+	// how long the response is cached,DefaultTtl is used if not set
+	Ttl time.Duration
+	// the request headers that are part of the cache key,e.g. Accept-Language
+	VaryHeaderList []string
+	// the user and tenant of principal are part of the cache key,
+	// it should be enabled if the response depends on the current user
+	VaryByUser   bool
+	VaryByTenant bool
+	// tenant of the cache key,the tenant in request context or the tenant of principal is used if not set
+	TenantFunc    TenantFunc
+	GinTenantFunc GinTenantFunc
+	// value of Cache-Control,private or public max-age of Ttl is used if not set
+	CacheControl   string
+	TagList        []string
+	TagFuncList    []TagFunc
+	GinTagFuncList []GinTagFunc
+}
+
+type CacheOption func(*CacheOptions)
+
+func WithStore(store IStore) CacheOption {
+	return func(o *CacheOptions) {
+		o.Store = store
+	}
+}
+
+func WithKeyPrefix(keyPrefix string) CacheOption {
+	return func(o *CacheOptions) {
+		o.KeyPrefix = keyPrefix
+	}
+}
+
+func WithTtl(ttl time.Duration) CacheOption {
+	return func(o *CacheOptions) {
+		o.Ttl = ttl
+	}
+}
+
+func WithVaryHeaders(headerList ...string) CacheOption {
+	return func(o *CacheOptions) {
+		o.VaryHeaderList = append(o.VaryHeaderList, headerList...)
+	}
+}
+
+func WithVaryByUser(v bool) CacheOption {
+	return func(o *CacheOptions) {
+		o.VaryByUser = v
+	}
+}
+
+func WithVaryByTenant(v bool) CacheOption {
+	return func(o *CacheOptions) {
+		o.VaryByTenant = v
+	}
+}
+
+func WithTenantFunc(tenantFunc TenantFunc) CacheOption {
+	return func(o *CacheOptions) {
+		o.TenantFunc = tenantFunc
+	}
+}
+
+func WithCacheControl(cacheControl string) CacheOption {
+	return func(o *CacheOptions) {
+		o.CacheControl = cacheControl
+	}
+}
+
+// the tags of entry,they are used by Cache.Invalidate
+func WithTags(tagList ...string) CacheOption {
+	return func(o *CacheOptions) {
+		o.TagList = append(o.TagList, tagList...)
+	}
+}
+
+func WithTagFunc(tagFunc TagFunc) CacheOption {
+	return func(o *CacheOptions) {
+		o.TagFuncList = append(o.TagFuncList, tagFunc)
+	}
+}
+
+// the store of responses,the handlers of routes share the store and options of the cache
+type Cache struct {
+	options CacheOptions
+}
+
+var (
+	_defaultCache     *Cache
+	_defaultCacheOnce sync.Once
+)
+
+// cache that varies by user and tenant,it is used by EntityController if cache is not set
+func GetDefaultCache() *Cache {
+	_defaultCacheOnce.Do(func() {
+		_defaultCache = NewCache(WithVaryByUser(true), WithVaryByTenant(true))
+	})
+	return _defaultCache
+}
+
+// remove the entries of default cache that have any of the tags
+func Invalidate(tagList ...string) error {
+	return GetDefaultCache().Invalidate(tagList...)
+}
+
+func NewCache(opts ...CacheOption) *Cache {
+	c := &Cache{}
+	for _, eachOpt := range opts {
+		eachOpt(&c.options)
+	}
+	if c.options.Store == nil {
+		c.options.Store = newLazyRedisStore()
+	}
+	if len(c.options.KeyPrefix) <= 0 {
+		c.options.KeyPrefix = DefaultKeyPrefix
+	}
+	if c.options.Ttl <= 0 {
+		c.options.Ttl = DefaultTtl
+	}
+	return c
+}
+
+// handler that caches the 200 response of GET route with a new cache
+func New(opts ...CacheOption) iris.Handler {
+	return NewCache(opts...).Handler()
+}
+
+// remove the entries that have any of the tags
+func (c *Cache) Invalidate(tagList ...string) error {
+	return c.options.Store.InvalidateTags(tagList...)
+}
+
+// handler that caches the 200 response of GET route,opts override the options of cache for the route.
+// the cached response is returned with ETag and Cache-Control,304 is returned if If-None-Match matches the ETag.
+// it should run after the authentication if it varies by user or tenant
+func (c *Cache) Handler(opts ...CacheOption) iris.Handler {
+	options, cacheControl := c.routeOptions(opts...)
+	return func(ctx iris.Context) {
+		if ctx.Method() != http.MethodGet {
+			ctx.Next()
+			return
+		}
+		key := options.KeyPrefix + cacheKey(ctx.Path(), ctx.Request(), fwauth.GetIrisPrincipal(ctx), func() (string, bool) {
+			if options.TenantFunc != nil {
+				return options.TenantFunc(ctx)
+			}
+			return requestTenant(ctx.Request(), fwauth.GetIrisPrincipal(ctx))
+		}, &options)
+		if !strings.Contains(ctx.GetHeader("Cache-Control"), "no-cache") {
+			entry, err := options.Store.Get(key)
+			if err != nil {
+				log.Logger.Warn(fmt.Sprintf("response cache get fail,key:%s,err:%s", key, err.Error()))
+			} else if entry != nil {
+				writeEntry(ctx, entry, cacheControl, &options)
+				return
+			}
+		}
+
+		ctx.Record()
+		ctx.Next()
+		if ctx.GetStatusCode() != http.StatusOK {
+			return
+		}
+		// the body of recorder is reused by the next request
+		body := append([]byte(nil), ctx.Recorder().Body()...)
+		// the ETag of handler is kept,e.g. the version of entity that is checked by If-Match
+		etag := ctx.ResponseWriter().Header().Get("ETag")
+		if len(etag) <= 0 {
+			etag = newETag(body)
+		}
+		tagList := append([]string{}, options.TagList...)
+		for _, eachTagFunc := range options.TagFuncList {
+			tagList = append(tagList, eachTagFunc(ctx)...)
+		}
+		setEntry(&options, key, &Entry{
+			Status:  http.StatusOK,
+			Header:  responseHeader(ctx.ResponseWriter().Header()),
+			Body:    body,
+			ETag:    etag,
+			TagList: tagList,
+		})
+		setCacheHeader(ctx.ResponseWriter().Header(), etag, cacheControl, &options)
+		ctx.Header(HeaderCache, "MISS")
+		if etagMatch(ctx.GetHeader("If-None-Match"), etag) {
+			ctx.Recorder().ResetBody()
+			ctx.StatusCode(http.StatusNotModified)
+		}
+	}
+}
+
+// handler that removes the entries of tags after the route succeeds,e.g. for the routes that change the data
+func (c *Cache) EvictHandler(tagFuncList ...TagFunc) iris.Handler {
+	return func(ctx iris.Context) {
+		ctx.Next()
+		if ctx.GetStatusCode() >= http.StatusBadRequest {
+			return
+		}
+		tagList := make([]string, 0)
+		for _, eachTagFunc := range tagFuncList {
+			tagList = append(tagList, eachTagFunc(ctx)...)
+		}
+		c.evict(tagList)
+	}
+}
+
+func (c *Cache) evict(tagList []string) {
+	if err := c.Invalidate(tagList...); err != nil {
+		log.Logger.Warn(fmt.Sprintf("response cache invalidate fail,tags:%v,err:%s", tagList, err.Error()))
+	}
+}
+
+// copy of cache options for a route and the value of Cache-Control
+func (c *Cache) routeOptions(opts ...CacheOption) (CacheOptions, string) {
+	options := c.options
+	options.VaryHeaderList = append([]string{}, c.options.VaryHeaderList...)
+	options.TagList = append([]string{}, c.options.TagList...)
+	options.TagFuncList = append([]TagFunc{}, c.options.TagFuncList...)
+	options.GinTagFuncList = append([]GinTagFunc{}, c.options.GinTagFuncList...)
+	for _, eachOpt := range opts {
+		eachOpt(&options)
+	}
+	cacheControl := options.CacheControl
+	if len(cacheControl) <= 0 {
+		visibility := "public"
+		if options.VaryByUser || options.VaryByTenant {
+			visibility = "private"
+		}
+		cacheControl = fmt.Sprintf("%s, max-age=%d", visibility, int64(options.Ttl.Seconds()))
+	}
+	return options, cacheControl
+}
+
+func setEntry(options *CacheOptions, key string, entry *Entry) {
+	entry.CreationTime = time.Now()
+	if err := options.Store.Set(key, entry, options.Ttl); err != nil {
+		log.Logger.Warn(fmt.Sprintf("response cache set fail,key:%s,err:%s", key, err.Error()))
+	}
+}
+
+func writeEntry(ctx iris.Context, entry *Entry, cacheControl string, options *CacheOptions) {
+	header := ctx.ResponseWriter().Header()
+	for name, valueList := range entry.Header {
+		header[name] = valueList
+	}
+	setCacheHeader(header, entry.ETag, cacheControl, options)
+	ctx.Header(HeaderCache, "HIT")
+	if etagMatch(ctx.GetHeader("If-None-Match"), entry.ETag) {
+		ctx.StatusCode(http.StatusNotModified)
+		ctx.StopExecution()
+		return
+	}
+	ctx.StatusCode(entry.Status)
+	_, _ = ctx.Write(entry.Body)
+	ctx.StopExecution()
+}
+
+func setCacheHeader(header http.Header, etag string, cacheControl string, options *CacheOptions) {
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", cacheControl)
+	if len(options.VaryHeaderList) > 0 {
+		header.Set("Vary", strings.Join(options.VaryHeaderList, ", "))
+	}
+}
+
+// path,sorted query,vary headers,user and tenant
+func cacheKey(path string, request *http.Request, principal *fwauth.Principal, tenantFunc func() (string, bool), options *CacheOptions) string {
+	h := sha256.New()
+	write := func(v string) {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	write(path)
+	query := request.URL.Query()
+	nameList := make([]string, 0, len(query))
+	for name := range query {
+		nameList = append(nameList, name)
+	}
+	sort.Strings(nameList)
+	for _, eachName := range nameList {
+		valueList := append([]string{}, query[eachName]...)
+		sort.Strings(valueList)
+		for _, eachValue := range valueList {
+			write(url.QueryEscape(eachName) + "=" + url.QueryEscape(eachValue))
+		}
+	}
+	for _, eachHeader := range options.VaryHeaderList {
+		write(eachHeader + ":" + request.Header.Get(eachHeader))
+	}
+	if options.VaryByUser && principal != nil {
+		write("user:" + principal.Id)
+	}
+	if options.VaryByTenant {
+		tenantId, bypass := tenantFunc()
+		write("tenant:" + tenantId)
+		write("bypass:" + strconv.FormatBool(bypass))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// the tenant resolved into request context,the tenant of principal if it is not resolved yet
+func requestTenant(request *http.Request, principal *fwauth.Principal) (string, bool) {
+	if tenantId := entity.TenantIdFromContext(request.Context()); len(tenantId) > 0 {
+		return tenantId, false
+	}
+	if principal != nil {
+		return principal.TenantId, false
+	}
+	return "", false
+}
+
+func newETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return strconv.Quote(hex.EncodeToString(sum[:16]))
+}
+
+// weak comparison of If-None-Match,https://www.rfc-editor.org/rfc/rfc9110#field.if-none-match
+func etagMatch(ifNoneMatch string, etag string) bool {
+	if len(ifNoneMatch) <= 0 {
+		return false
+	}
+	for _, each := range strings.Split(ifNoneMatch, ",") {
+		each = strings.TrimSpace(each)
+		if each == "*" || strings.TrimPrefix(each, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func responseHeader(header http.Header) http.Header {
+	result := header.Clone()
+	for _, eachName := range excludedHeaderList {
+		result.Del(eachName)
+	}
+	return result
+}
+
+// #region tags of entity
+
+// tag of all entries of the entity
+func EntityTag(entityName string) string {
+	return "entity:" + entityName
+}
+
+// tag of list entries of the entity
+func EntityListTag(entityName string) string {
+	return "entity:" + entityName + ":list"
+}
+
+// tag of the entries of an item
+func EntityItemTag(entityName string, id string) string {
+	return "entity:" + entityName + ":item:" + id
+}
+
+// #endregion
@@ -0,0 +1,150 @@
+package responsecache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func get(app *iris.Application, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func TestCache(t *testing.T) {
+	count := 0
+	cache := NewCache(WithStore(NewMemoryStore(10)))
+	app := iris.New()
+	app.Get("/orders", cache.Handler(WithTags("orders"), WithVaryHeaders("Accept-Language")), func(ctx iris.Context) {
+		count++
+		ctx.JSON(iris.Map{"count": count})
+	})
+	app.Post("/orders", cache.EvictHandler(func(ctx iris.Context) []string { return []string{"orders"} }), func(ctx iris.Context) {
+		ctx.StatusCode(http.StatusCreated)
+	})
+	assert.Nil(t, app.Build())
+
+	w := get(app, "/orders?b=2&a=1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get(HeaderCache))
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+	body := w.Body.String()
+
+	// the order of query does not matter
+	w = get(app, "/orders?a=1&b=2", nil)
+	assert.Equal(t, "HIT", w.Header().Get(HeaderCache))
+	assert.Equal(t, body, w.Body.String())
+	assert.Equal(t, 1, count)
+
+	w = get(app, "/orders?a=1&b=2", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// vary header
+	get(app, "/orders?a=1&b=2", map[string]string{"Accept-Language": "zh"})
+	assert.Equal(t, 2, count)
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	app.ServeHTTP(httptest.NewRecorder(), req)
+	w = get(app, "/orders?a=1&b=2", nil)
+	assert.Equal(t, "MISS", w.Header().Get(HeaderCache))
+	assert.Equal(t, 3, count)
+}
+
+// the version ETag of GetById is kept,so it can be sent back with If-Match
+func TestCacheKeepsHandlerETag(t *testing.T) {
+	version := 1
+	cache := NewCache(WithStore(NewMemoryStore(10)))
+	app := iris.New()
+	app.Get("/orders/{id}", cache.Handler(WithTags("orders")), func(ctx iris.Context) {
+		ctx.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+		ctx.JSON(iris.Map{"version": version})
+	})
+	app.Patch("/orders/{id}", cache.EvictHandler(func(ctx iris.Context) []string { return []string{"orders"} }), func(ctx iris.Context) {
+		if ctx.GetHeader("If-Match") != strconv.Quote(strconv.Itoa(version)) {
+			ctx.StatusCode(http.StatusPreconditionFailed)
+			return
+		}
+		version++
+	})
+	assert.Nil(t, app.Build())
+
+	w := get(app, "/orders/1", nil)
+	assert.Equal(t, "MISS", w.Header().Get(HeaderCache))
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	w = get(app, "/orders/1", nil)
+	assert.Equal(t, "HIT", w.Header().Get(HeaderCache))
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	req := httptest.NewRequest(http.MethodPatch, "/orders/1", strings.NewReader(`{}`))
+	req.Header.Set("If-Match", etag)
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = get(app, "/orders/1", nil)
+	assert.Equal(t, "MISS", w.Header().Get(HeaderCache))
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+}
+
+func TestCacheVaryByResolvedTenant(t *testing.T) {
+	count := 0
+	cache := NewCache(WithStore(NewMemoryStore(10)), WithVaryByTenant(true))
+	app := iris.New()
+	// the tenant is resolved from header as TenantFromHeader does
+	resolveTenant := func(ctx iris.Context) {
+		request := ctx.Request()
+		ctx.ResetRequest(request.WithContext(entity.ContextWithTenantId(request.Context(), ctx.GetHeader("X-Tenant-Id"))))
+		ctx.Next()
+	}
+	handler := func(ctx iris.Context) {
+		count++
+		ctx.JSON(iris.Map{"tenantId": entity.TenantIdFromContext(ctx.Request().Context()), "count": count})
+	}
+	app.Get("/orders", resolveTenant, cache.Handler(), handler)
+	app.Get("/bypass/orders", resolveTenant, cache.Handler(WithTenantFunc(func(ctx iris.Context) (string, bool) {
+		if ctx.GetHeader("X-Tenant-Bypass") == "true" {
+			return "", true
+		}
+		return entity.TenantIdFromContext(ctx.Request().Context()), false
+	})), handler)
+	assert.Nil(t, app.Build())
+
+	w := get(app, "/orders", map[string]string{"X-Tenant-Id": "a"})
+	assert.Equal(t, "MISS", w.Header().Get(HeaderCache))
+	w = get(app, "/orders", map[string]string{"X-Tenant-Id": "b"})
+	assert.Equal(t, "MISS", w.Header().Get(HeaderCache))
+	assert.Contains(t, w.Body.String(), `"tenantId":"b"`)
+	w = get(app, "/orders", map[string]string{"X-Tenant-Id": "a"})
+	assert.Equal(t, "HIT", w.Header().Get(HeaderCache))
+	assert.Contains(t, w.Body.String(), `"tenantId":"a"`)
+	assert.Equal(t, 2, count)
+
+	get(app, "/bypass/orders", map[string]string{"X-Tenant-Id": "a"})
+	w = get(app, "/bypass/orders", map[string]string{"X-Tenant-Id": "a", "X-Tenant-Bypass": "true"})
+	assert.Equal(t, "MISS", w.Header().Get(HeaderCache))
+	w = get(app, "/bypass/orders", map[string]string{"X-Tenant-Id": "a", "X-Tenant-Bypass": "true"})
+	assert.Equal(t, "HIT", w.Header().Get(HeaderCache))
+	assert.Equal(t, 4, count)
+}
+
+func TestEtagMatch(t *testing.T) {
+	assert.True(t, etagMatch(`"a", W/"b"`, `"b"`))
+	assert.True(t, etagMatch("*", `"b"`))
+	assert.False(t, etagMatch(`"a"`, `"b"`))
+	assert.False(t, etagMatch("", `"b"`))
+}
@@ -0,0 +1,117 @@
+package responsecache
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/controllerx/fwauth"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+// gin version of TagFunc
+type GinTagFunc func(c *gin.Context) []string
+
+// gin version of TenantFunc
+type GinTenantFunc func(c *gin.Context) (tenantId string, bypass bool)
+
+func WithGinTenantFunc(tenantFunc GinTenantFunc) CacheOption {
+	return func(o *CacheOptions) {
+		o.GinTenantFunc = tenantFunc
+	}
+}
+
+func WithGinTagFunc(tagFunc GinTagFunc) CacheOption {
+	return func(o *CacheOptions) {
+		o.GinTagFuncList = append(o.GinTagFuncList, tagFunc)
+	}
+}
+
+// gin version of Handler,the tags of TagFuncList and TenantFunc are replaced by GinTagFuncList and GinTenantFunc
+func (c *Cache) GinHandler(opts ...CacheOption) gin.HandlerFunc {
+	options, cacheControl := c.routeOptions(opts...)
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method != http.MethodGet {
+			ctx.Next()
+			return
+		}
+		key := options.KeyPrefix + cacheKey(ctx.Request.URL.Path, ctx.Request, fwauth.GetGinPrincipal(ctx), func() (string, bool) {
+			if options.GinTenantFunc != nil {
+				return options.GinTenantFunc(ctx)
+			}
+			return requestTenant(ctx.Request, fwauth.GetGinPrincipal(ctx))
+		}, &options)
+		if !strings.Contains(ctx.GetHeader("Cache-Control"), "no-cache") {
+			entry, err := options.Store.Get(key)
+			if err != nil {
+				log.Logger.Warn(fmt.Sprintf("response cache get fail,key:%s,err:%s", key, err.Error()))
+			} else if entry != nil {
+				writeGinEntry(ctx, entry, cacheControl, &options)
+				return
+			}
+		}
+
+		recorder := responsex.RecordGin(ctx)
+		ctx.Next()
+		if recorder.Status() != http.StatusOK {
+			recorder.Send(ctx)
+			return
+		}
+		body := append([]byte(nil), recorder.Body()...)
+		etag := recorder.Header().Get("ETag")
+		if len(etag) <= 0 {
+			etag = newETag(body)
+		}
+		tagList := append([]string{}, options.TagList...)
+		for _, eachTagFunc := range options.GinTagFuncList {
+			tagList = append(tagList, eachTagFunc(ctx)...)
+		}
+		setEntry(&options, key, &Entry{
+			Status:  http.StatusOK,
+			Header:  responseHeader(recorder.Header()),
+			Body:    body,
+			ETag:    etag,
+			TagList: tagList,
+		})
+		setCacheHeader(recorder.Header(), etag, cacheControl, &options)
+		recorder.Header().Set(HeaderCache, "MISS")
+		if etagMatch(ctx.GetHeader("If-None-Match"), etag) {
+			recorder.ResetBody()
+			recorder.WriteHeader(http.StatusNotModified)
+		}
+		recorder.Send(ctx)
+	}
+}
+
+// gin version of EvictHandler
+func (c *Cache) GinEvictHandler(tagFuncList ...GinTagFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+		if ctx.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		tagList := make([]string, 0)
+		for _, eachTagFunc := range tagFuncList {
+			tagList = append(tagList, eachTagFunc(ctx)...)
+		}
+		c.evict(tagList)
+	}
+}
+
+func writeGinEntry(ctx *gin.Context, entry *Entry, cacheControl string, options *CacheOptions) {
+	header := ctx.Writer.Header()
+	for name, valueList := range entry.Header {
+		header[name] = valueList
+	}
+	setCacheHeader(header, entry.ETag, cacheControl, options)
+	header.Set(HeaderCache, "HIT")
+	if etagMatch(ctx.GetHeader("If-None-Match"), entry.ETag) {
+		ctx.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+	ctx.Status(entry.Status)
+	_, _ = ctx.Writer.Write(entry.Body)
+	ctx.Abort()
+}
@@ -0,0 +1,73 @@
+package responsecache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGinCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	version := 1
+	count := 0
+	cache := NewCache(WithStore(NewMemoryStore(10)))
+	engine := gin.New()
+	engine.GET("/orders/:id", cache.GinHandler(WithTags("orders"), WithGinTagFunc(func(c *gin.Context) []string {
+		return []string{"order:" + c.Param("id")}
+	})), func(c *gin.Context) {
+		count++
+		c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+		c.JSON(http.StatusOK, gin.H{"version": version})
+	})
+	engine.PATCH("/orders/:id", cache.GinEvictHandler(func(c *gin.Context) []string {
+		return []string{"order:" + c.Param("id")}
+	}), func(c *gin.Context) {
+		version++
+		c.Status(http.StatusOK)
+	})
+	serve := func(method string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/orders/1", nil)
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get(HeaderCache))
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+	body := w.Body.String()
+
+	w = serve(http.MethodGet, nil)
+	assert.Equal(t, "HIT", w.Header().Get(HeaderCache))
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	assert.Equal(t, body, w.Body.String())
+	assert.Equal(t, 1, count)
+
+	w = serve(http.MethodGet, map[string]string{"If-None-Match": `"1"`})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// the patch evicts the item
+	serve(http.MethodPatch, nil)
+	w = serve(http.MethodGet, map[string]string{"If-None-Match": `"1"`})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get(HeaderCache))
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Equal(t, 2, count)
+
+	// 304 of the response that is not cached yet
+	serve(http.MethodPatch, nil)
+	w = serve(http.MethodGet, map[string]string{"If-None-Match": `"3"`})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get(HeaderCache))
+	assert.Empty(t, w.Body.String())
+}
@@ -0,0 +1,116 @@
+package responsecache
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shanluzhineng/fwpkg/system/cache"
+	"github.com/shanluzhineng/fwpkg/system/metrics"
+)
+
+// cached response of GET request
+type Entry struct {
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         []byte      `json:"body,omitempty"`
+	ETag         string      `json:"etag"`
+	TagList      []string    `json:"tags,omitempty"`
+	CreationTime time.Time   `json:"creationTime"`
+}
+
+type IStore interface {
+	// cached entry of the key,nil if it is not found or expired
+	Get(key string) (*Entry, error)
+	// store the entry,it is expired after ttl,the key is indexed by the tags of entry
+	Set(key string, entry *Entry, ttl time.Duration) error
+	// remove the entries that have any of the tags
+	InvalidateTags(tagList ...string) error
+}
+
+// #region memory store
+
+// default capacity of memory store
+const DefaultMemoryCapacity = 10000
+
+const metricsCacheName = "responsecache"
+
+type memoryStore struct {
+	cache *cache.Cache[string, *Entry]
+
+	lock      sync.Mutex
+	tagMap    map[string]map[string]struct{}
+	lastSweep time.Time
+}
+
+// entries in system/cache.Cache of current instance,the least recently used entry is removed if capacity is reached.
+// it is used in test or single instance deployment,the metrics of the cache are exported as cache=responsecache
+func NewMemoryStore(capacity uint64) IStore {
+	if capacity <= 0 {
+		capacity = DefaultMemoryCapacity
+	}
+	s := &memoryStore{
+		cache:  cache.New(cache.WithCapacity[string, *Entry](capacity), cache.WithDisableTouchOnHit[string, *Entry]()),
+		tagMap: make(map[string]map[string]struct{}),
+	}
+	go s.cache.Start()
+	metrics.RegisterCache(metricsCacheName, s.cache)
+	return s
+}
+
+func (s *memoryStore) Get(key string) (*Entry, error) {
+	item := s.cache.Get(key)
+	if item == nil {
+		return nil, nil
+	}
+	return item.Value(), nil
+}
+
+func (s *memoryStore) Set(key string, entry *Entry, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sweep()
+	for _, eachTag := range entry.TagList {
+		keyMap, ok := s.tagMap[eachTag]
+		if !ok {
+			keyMap = make(map[string]struct{})
+			s.tagMap[eachTag] = keyMap
+		}
+		keyMap[key] = struct{}{}
+	}
+	s.cache.Set(key, entry, ttl)
+	return nil
+}
+
+func (s *memoryStore) InvalidateTags(tagList ...string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, eachTag := range tagList {
+		for eachKey := range s.tagMap[eachTag] {
+			s.cache.Delete(eachKey)
+		}
+		delete(s.tagMap, eachTag)
+	}
+	return nil
+}
+
+// remove the keys that are not in cache from the tag index,at most once a minute
+func (s *memoryStore) sweep() {
+	now := time.Now()
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for tag, keyMap := range s.tagMap {
+		for eachKey := range keyMap {
+			if s.cache.Get(eachKey) == nil {
+				delete(keyMap, eachKey)
+			}
+		}
+		if len(keyMap) <= 0 {
+			delete(s.tagMap, tag)
+		}
+	}
+}
+
+// #endregion
@@ -0,0 +1,129 @@
+package responsecache
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/shanluzhineng/fwpkg/app"
+	"github.com/shanluzhineng/fwpkg/redisx"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+var (
+	// KEYS[1] is the key of entry,KEYS[2..] are the sets of tags,
+	// the ttl of a tag set is extended to the ttl of its entries
+	setScript = redis.NewScript(`redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+local ttl = tonumber(ARGV[2])
+for i = 2, #KEYS do
+	redis.call("SADD", KEYS[i], KEYS[1])
+	if redis.call("PTTL", KEYS[i]) < ttl then
+		redis.call("PEXPIRE", KEYS[i], ttl)
+	end
+end
+return 1`)
+	invalidateScript = redis.NewScript(`for i = 1, #KEYS do
+	local keyList = redis.call("SMEMBERS", KEYS[i])
+	for _, key in ipairs(keyList) do
+		redis.call("DEL", key)
+	end
+	redis.call("DEL", KEYS[i])
+end
+return 1`)
+)
+
+const tagKeyPrefix = "tag:"
+
+type redisStore struct {
+	redisService redisx.IRedisService
+}
+
+// entries in redis,the entries are shared by the instances of cluster.
+// keys are prefixed with the key prefix of redisService
+func NewRedisStore(redisService redisx.IRedisService) IStore {
+	return &redisStore{
+		redisService: redisService,
+	}
+}
+
+func (s *redisStore) Get(key string) (*Entry, error) {
+	value := s.redisService.StringGet(key)
+	if err := value.Err(); err != nil {
+		return nil, err
+	}
+	if !value.Exist() {
+		return nil, nil
+	}
+	entry := &Entry{}
+	if err := json.Unmarshal(value.Bytes(), entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *redisStore) Set(key string, entry *Entry, ttl time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	keyList := append([]string{key}, tagKeyList(entry.TagList)...)
+	_, err = s.redisService.RunScript(setScript, keyList, []interface{}{data, ttl.Milliseconds()})
+	return err
+}
+
+func (s *redisStore) InvalidateTags(tagList ...string) error {
+	if len(tagList) <= 0 {
+		return nil
+	}
+	_, err := s.redisService.RunScript(invalidateScript, tagKeyList(tagList), nil)
+	return err
+}
+
+func tagKeyList(tagList []string) []string {
+	keyList := make([]string, 0, len(tagList))
+	for _, eachTag := range tagList {
+		keyList = append(keyList, tagKeyPrefix+eachTag)
+	}
+	return keyList
+}
+
+// #region lazy redis store
+
+// the redis service is registered when the web application is built,so it is resolved at the first request
+type lazyRedisStore struct {
+	once  sync.Once
+	store IStore
+}
+
+func newLazyRedisStore() IStore {
+	return &lazyRedisStore{}
+}
+
+func (s *lazyRedisStore) getStore() IStore {
+	s.once.Do(func() {
+		if app.Context != nil {
+			if redisService, ok := app.Context.GetInstance(new(redisx.IRedisService)).(redisx.IRedisService); ok {
+				s.store = NewRedisStore(redisService)
+				return
+			}
+		}
+		log.Logger.Warn("redisx.IRedisService is not registered,the memory store of response cache is used")
+		s.store = NewMemoryStore(DefaultMemoryCapacity)
+	})
+	return s.store
+}
+
+func (s *lazyRedisStore) Get(key string) (*Entry, error) {
+	return s.getStore().Get(key)
+}
+
+func (s *lazyRedisStore) Set(key string, entry *Entry, ttl time.Duration) error {
+	return s.getStore().Set(key, entry, ttl)
+}
+
+func (s *lazyRedisStore) InvalidateTags(tagList ...string) error {
+	return s.getStore().InvalidateTags(tagList...)
+}
+
+// #endregion
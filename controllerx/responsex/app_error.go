@@ -0,0 +1,326 @@
+package responsex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/shanluzhineng/fwpkg/system/i18n"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// module of the error codes of fwpkg
+const ErrorModuleCommon = "common"
+
+// an error code of a module,the code is unique in application
+type ErrorCode struct {
+	Module string `json:"module"`
+	Code   int    `json:"code"`
+	// http status of the response
+	Status int `json:"status"`
+	// key of the localized message
+	MessageKey string `json:"messageKey"`
+	// message if the message key is not localized
+	Message string `json:"message"`
+}
+
+// #region registry
+
+var (
+	_errorCodeMap  = map[int]*ErrorCode{}
+	_errorCodeLock sync.RWMutex
+)
+
+// regist an error code of module,it panics if the code is registed by other error
+func RegistErrorCode(module string, code int, status int, messageKey string, message string) *ErrorCode {
+	_errorCodeLock.Lock()
+	defer _errorCodeLock.Unlock()
+	if registed, ok := _errorCodeMap[code]; ok {
+		panic(fmt.Errorf("error code %d is registed by module %s,key:%s", code, registed.Module, registed.MessageKey))
+	}
+	errorCode := &ErrorCode{
+		Module:     module,
+		Code:       code,
+		Status:     status,
+		MessageKey: messageKey,
+		Message:    message,
+	}
+	_errorCodeMap[code] = errorCode
+	return errorCode
+}
+
+func GetErrorCode(code int) (*ErrorCode, bool) {
+	_errorCodeLock.RLock()
+	defer _errorCodeLock.RUnlock()
+	errorCode, ok := _errorCodeMap[code]
+	return errorCode, ok
+}
+
+// registed error codes ordered by code,e.g. for the document of api
+func GetErrorCodeList() []*ErrorCode {
+	_errorCodeLock.RLock()
+	defer _errorCodeLock.RUnlock()
+	errorCodeList := make([]*ErrorCode, 0, len(_errorCodeMap))
+	for _, eachErrorCode := range _errorCodeMap {
+		errorCodeList = append(errorCodeList, eachErrorCode)
+	}
+	sort.Slice(errorCodeList, func(i, j int) bool {
+		return errorCodeList[i].Code < errorCodeList[j].Code
+	})
+	return errorCodeList
+}
+
+// #endregion
+
+// common error codes,codes of other modules should not use the range 40000-59999
+var (
+	ErrCodeBadRequest          = RegistErrorCode(ErrorModuleCommon, 40000, http.StatusBadRequest, "error.badRequest", "bad request")
+	ErrCodeValidationFailed    = RegistErrorCode(ErrorModuleCommon, 40001, http.StatusBadRequest, "error.validationFailed", "validation failed")
+	ErrCodeUnauthorized        = RegistErrorCode(ErrorModuleCommon, 40100, http.StatusUnauthorized, "error.unauthorized", "unauthorized")
+	ErrCodeForbidden           = RegistErrorCode(ErrorModuleCommon, 40300, http.StatusForbidden, "error.forbidden", "forbidden")
+	ErrCodeNotFound            = RegistErrorCode(ErrorModuleCommon, 40400, http.StatusNotFound, "error.notFound", "not found")
+	ErrCodeConflict            = RegistErrorCode(ErrorModuleCommon, 40900, http.StatusConflict, "error.conflict", "conflict")
+	ErrCodeDuplicateKey        = RegistErrorCode(ErrorModuleCommon, 40901, http.StatusConflict, "error.duplicateKey", "duplicate key")
+	ErrCodePreconditionFailed  = RegistErrorCode(ErrorModuleCommon, 41200, http.StatusPreconditionFailed, "error.preconditionFailed", "precondition failed")
+	ErrCodePayloadTooLarge     = RegistErrorCode(ErrorModuleCommon, 41300, http.StatusRequestEntityTooLarge, "error.payloadTooLarge", "payload too large")
+	ErrCodeUnprocessableEntity = RegistErrorCode(ErrorModuleCommon, 42200, http.StatusUnprocessableEntity, "error.unprocessableEntity", "unprocessable entity")
+	ErrCodeTooManyRequests     = RegistErrorCode(ErrorModuleCommon, 42900, http.StatusTooManyRequests, "error.tooManyRequests", "too many requests")
+	ErrCodeRequestCanceled     = RegistErrorCode(ErrorModuleCommon, 49900, 499, "error.requestCanceled", "request canceled")
+	ErrCodeInternal            = RegistErrorCode(ErrorModuleCommon, 50000, http.StatusInternalServerError, "error.internal", "internal server error")
+	ErrCodeServiceUnavailable  = RegistErrorCode(ErrorModuleCommon, 50300, http.StatusServiceUnavailable, "error.serviceUnavailable", "service unavailable")
+	ErrCodeTimeout             = RegistErrorCode(ErrorModuleCommon, 50400, http.StatusGatewayTimeout, "error.timeout", "timeout")
+)
+
+var (
+	statusErrorCodeMap        = map[int]*ErrorCode{}
+	errorCodeOfStatusInitOnce sync.Once
+)
+
+// the common error code of http status,ErrCodeInternal for an unknown status
+func ErrorCodeOfStatus(status int) *ErrorCode {
+	errorCodeOfStatusInitOnce.Do(func() {
+		for _, eachErrorCode := range []*ErrorCode{ErrCodeBadRequest, ErrCodeUnauthorized, ErrCodeForbidden, ErrCodeNotFound,
+			ErrCodeConflict, ErrCodePreconditionFailed, ErrCodePayloadTooLarge, ErrCodeUnprocessableEntity, ErrCodeTooManyRequests, ErrCodeRequestCanceled,
+			ErrCodeInternal, ErrCodeServiceUnavailable, ErrCodeTimeout} {
+			statusErrorCodeMap[eachErrorCode.Status] = eachErrorCode
+		}
+	})
+	if errorCode, ok := statusErrorCodeMap[status]; ok {
+		return errorCode
+	}
+	if status >= 400 && status < 500 {
+		return ErrCodeBadRequest
+	}
+	return ErrCodeInternal
+}
+
+// #region AppError
+
+// error of application with the code,http status,message key and details of response
+type AppError struct {
+	*ErrorCode
+	// message of response,the message of error code is used if it is empty
+	Detail  string
+	Details interface{}
+	Cause   error
+	// arguments of the localized message,e.g. {permission} of "permission denied:{permission}"
+	Args map[string]interface{}
+}
+
+// new an error of the code,message is the detail message of response
+func (c *ErrorCode) New(message string) *AppError {
+	return &AppError{
+		ErrorCode: c,
+		Detail:    message,
+	}
+}
+
+// new an error of the code caused by err,the message of err is the detail message of response
+func (c *ErrorCode) Wrap(err error) *AppError {
+	appError := &AppError{
+		ErrorCode: c,
+		Cause:     err,
+	}
+	if err != nil {
+		appError.Detail = err.Error()
+	}
+	return appError
+}
+
+// a copy of the error with details
+func (e *AppError) WithDetails(details interface{}) *AppError {
+	appError := *e
+	appError.Details = details
+	return &appError
+}
+
+// a copy of the error with the arguments of localized message
+func (e *AppError) WithArgs(args map[string]interface{}) *AppError {
+	appError := *e
+	appError.Args = args
+	return &appError
+}
+
+func (e *AppError) Error() string {
+	return e.GetMessage()
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
+// detail message,or the message of error code
+func (e *AppError) GetMessage() string {
+	if len(e.Detail) > 0 {
+		return e.Detail
+	}
+	return e.ErrorCode.Message
+}
+
+// #endregion
+
+// #region mapping
+
+// map an error to AppError,nil if the error is not recognized by the mapper
+type ErrorMapper func(err error) *AppError
+
+var (
+	_errorMapperList []ErrorMapper
+	_errorMapperLock sync.RWMutex
+)
+
+// regist a mapper of the errors of module,the mappers are called in order
+func RegistErrorMapper(mapper ErrorMapper) {
+	_errorMapperLock.Lock()
+	defer _errorMapperLock.Unlock()
+	_errorMapperList = append(_errorMapperList, mapper)
+}
+
+// map the error to AppError,nil if the error is not recognized
+func MapError(err error) *AppError {
+	if err == nil {
+		return nil
+	}
+	var appError *AppError
+	if errors.As(err, &appError) {
+		return appError
+	}
+	_errorMapperLock.RLock()
+	mapperList := _errorMapperList
+	_errorMapperLock.RUnlock()
+	for _, eachMapper := range mapperList {
+		if appError := eachMapper(err); appError != nil {
+			return appError
+		}
+	}
+	return mapCommonError(err)
+}
+
+// map the error to AppError,the error code of status is used if the error is not recognized,
+// so a recognized error overrides the status,e.g. mongo.ErrNoDocuments is always 404
+func ToAppError(status int, err error) *AppError {
+	if appError := MapError(err); appError != nil {
+		return appError
+	}
+	errorCode := ErrorCodeOfStatus(status)
+	if errorCode.Status != status {
+		// keep the status of caller,e.g. 422
+		statusErrorCode := *errorCode
+		statusErrorCode.Status = status
+		errorCode = &statusErrorCode
+	}
+	return errorCode.Wrap(err)
+}
+
+func mapCommonError(err error) *AppError {
+	var validationErrors validator.ValidationErrors
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrCodeNotFound.Wrap(err)
+	case mongo.IsDuplicateKeyError(err):
+		return ErrCodeDuplicateKey.Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded) || mongo.IsTimeout(err):
+		return ErrCodeTimeout.Wrap(err)
+	case errors.Is(err, context.Canceled):
+		return ErrCodeRequestCanceled.Wrap(err)
+	case errors.As(err, &validationErrors):
+		return ErrCodeValidationFailed.Wrap(err).WithDetails(validationErrorDetails(validationErrors))
+	case errors.As(err, &maxBytesError):
+		return ErrCodePayloadTooLarge.Wrap(err)
+	}
+	return nil
+}
+
+type ValidationErrorDetail struct {
+	Field string `json:"field"`
+	Tag   string `json:"tag"`
+	Param string `json:"param,omitempty"`
+	// localized message,e.g. name is required
+	Message string `json:"message,omitempty"`
+
+	name string
+}
+
+func validationErrorDetails(validationErrors validator.ValidationErrors) []ValidationErrorDetail {
+	detailList := make([]ValidationErrorDetail, 0, len(validationErrors))
+	for _, eachError := range validationErrors {
+		detailList = append(detailList, ValidationErrorDetail{
+			Field: eachError.Namespace(),
+			Tag:   eachError.Tag(),
+			Param: eachError.Param(),
+			name:  eachError.Field(),
+		})
+	}
+	return detailList
+}
+
+// error response of the error in the default locale,the status of response is returned
+func NewAppErrorResponse(status int, err error) (int, *BaseResponse) {
+	return NewLocalizedAppErrorResponse(i18n.Default().GetDefaultLocale(), status, err)
+}
+
+// error response of the error,the message is localized by the message key of error code,
+// the detail message is kept in Detail if it is localized
+func NewLocalizedAppErrorResponse(locale string, status int, err error) (int, *BaseResponse) {
+	appError := ToAppError(status, err)
+	return appError.Status, NewErrorResponse(func(br *BaseResponse) {
+		br.SetCode(appError.Code)
+		br.SetMessage(appError.GetMessage())
+		br.MessageKey = appError.MessageKey
+		br.Details = appError.Details
+		if message, ok := i18n.Default().Localize(locale, appError.MessageKey, appError.Args); ok {
+			br.SetMessage(message)
+			if len(appError.Detail) > 0 && appError.Detail != message {
+				br.Detail = appError.Detail
+			}
+		}
+		if detailList, ok := appError.Details.([]ValidationErrorDetail); ok {
+			br.Details = localizeValidationErrorDetails(locale, detailList)
+		}
+	})
+}
+
+// #endregion
+
+func localizeValidationErrorDetails(locale string, detailList []ValidationErrorDetail) []ValidationErrorDetail {
+	localizedList := make([]ValidationErrorDetail, 0, len(detailList))
+	for _, eachDetail := range detailList {
+		name := eachDetail.name
+		if len(name) <= 0 {
+			name = eachDetail.Field
+		}
+		args := map[string]interface{}{"field": name, "param": eachDetail.Param}
+		message, ok := i18n.Default().Localize(locale, "validation."+eachDetail.Tag, args)
+		if !ok {
+			message = i18n.Default().T(locale, "validation.default", args)
+		}
+		eachDetail.Message = message
+		localizedList = append(localizedList, eachDetail)
+	}
+	return localizedList
+}
@@ -0,0 +1,71 @@
+package responsex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestToAppError(t *testing.T) {
+	appError := ToAppError(http.StatusInternalServerError, fmt.Errorf("find user:%w", mongo.ErrNoDocuments))
+	assert.Equal(t, http.StatusNotFound, appError.Status)
+	assert.Equal(t, ErrCodeNotFound.Code, appError.Code)
+
+	appError = ToAppError(http.StatusInternalServerError, mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}})
+	assert.Equal(t, ErrCodeDuplicateKey, appError.ErrorCode)
+
+	appError = ToAppError(http.StatusBadRequest, context.DeadlineExceeded)
+	assert.Equal(t, http.StatusGatewayTimeout, appError.Status)
+
+	type user struct {
+		Name string `validate:"required"`
+	}
+	appError = ToAppError(http.StatusInternalServerError, validator.New().Struct(&user{}))
+	assert.Equal(t, ErrCodeValidationFailed, appError.ErrorCode)
+	assert.Equal(t, "user.Name", appError.Details.([]ValidationErrorDetail)[0].Field)
+
+	// the status of caller is kept for an unknown error
+	appError = ToAppError(http.StatusUnprocessableEntity, errors.New("invalid"))
+	assert.Equal(t, http.StatusUnprocessableEntity, appError.Status)
+	assert.Equal(t, "invalid", appError.Error())
+	appError = ToAppError(http.StatusTeapot, errors.New("teapot"))
+	assert.Equal(t, http.StatusTeapot, appError.Status)
+	assert.Equal(t, ErrCodeBadRequest.Code, appError.Code)
+	assert.Equal(t, http.StatusBadRequest, ErrCodeBadRequest.Status)
+
+	// typed error of module
+	errCodeOrderClosed := RegistErrorCode("order", 100001, http.StatusConflict, "order.closed", "order is closed")
+	status, r := NewAppErrorResponse(http.StatusInternalServerError, fmt.Errorf("pay:%w", errCodeOrderClosed.New("")))
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, 100001, r.Code)
+	assert.Equal(t, "order.closed", r.MessageKey)
+	assert.Equal(t, "order is closed", r.Message)
+	assert.Panics(t, func() {
+		RegistErrorCode("other", 100001, http.StatusConflict, "other", "")
+	})
+}
+
+func TestLocalizedAppErrorResponse(t *testing.T) {
+	status, r := NewLocalizedAppErrorResponse("zh-CN", http.StatusInternalServerError, mongo.ErrNoDocuments)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "数据不存在", r.Message)
+	assert.Equal(t, mongo.ErrNoDocuments.Error(), r.Detail)
+
+	type user struct {
+		Name string `validate:"required"`
+		Age  int    `validate:"min=18"`
+	}
+	_, r = NewLocalizedAppErrorResponse("zh", http.StatusBadRequest, validator.New().Struct(&user{Age: 1}))
+	assert.Equal(t, "参数校验失败", r.Message)
+	detailList := r.Details.([]ValidationErrorDetail)
+	assert.Equal(t, "Name为必填字段", detailList[0].Message)
+	assert.Equal(t, "Age最小为18", detailList[1].Message)
+	_, r = NewLocalizedAppErrorResponse("en-US", http.StatusBadRequest, validator.New().Struct(&user{Name: "n"}))
+	assert.Equal(t, "Age must be at least 18", r.Details.([]ValidationErrorDetail)[0].Message)
+}
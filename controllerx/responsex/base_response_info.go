@@ -25,6 +25,12 @@ type BaseResponseInfo struct {
 	Code    int    `json:"code" schema:"HTTP response code"`
 	Status  string `json:"status"`
 	Message string `json:"message,omitempty" schema:"HTTP response message"`
+	// key of the localized message of error,see ErrorCode
+	MessageKey string `json:"messageKey,omitempty"`
+	// message of error that is not localized,it is set if Message is localized
+	Detail string `json:"detail,omitempty"`
+	// details of error,e.g. the fields that fail to validate
+	Details interface{} `json:"details,omitempty"`
 }
 
 // #region ResponseInfo Members
@@ -0,0 +1,86 @@
+package responsex
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gin version of the recorder of iris,the status and body are kept in memory
+// and sent by Send,so the middleware can change the response after the handler
+type GinRecorder struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+// record the response of c,the writer of c is replaced by the recorder
+func RecordGin(c *gin.Context) *GinRecorder {
+	r := &GinRecorder{
+		ResponseWriter: c.Writer,
+		status:         http.StatusOK,
+	}
+	c.Writer = r
+	return r
+}
+
+func (r *GinRecorder) WriteHeader(code int) {
+	if code > 0 && !r.written {
+		r.status = code
+	}
+}
+
+func (r *GinRecorder) WriteHeaderNow() {
+	r.written = true
+}
+
+func (r *GinRecorder) Write(data []byte) (int, error) {
+	r.written = true
+	return r.body.Write(data)
+}
+
+func (r *GinRecorder) WriteString(s string) (int, error) {
+	r.written = true
+	return r.body.WriteString(s)
+}
+
+func (r *GinRecorder) Status() int {
+	return r.status
+}
+
+func (r *GinRecorder) Size() int {
+	if !r.written {
+		return -1
+	}
+	return r.body.Len()
+}
+
+func (r *GinRecorder) Written() bool {
+	return r.written
+}
+
+// the body is sent by Send
+func (r *GinRecorder) Flush() {
+}
+
+func (r *GinRecorder) Body() []byte {
+	return r.body.Bytes()
+}
+
+// the response can be written again after reset
+func (r *GinRecorder) ResetBody() {
+	r.body.Reset()
+	r.written = false
+}
+
+// send the status and body,the writer of c is restored
+func (r *GinRecorder) Send(c *gin.Context) {
+	c.Writer = r.ResponseWriter
+	c.Writer.WriteHeader(r.status)
+	c.Writer.WriteHeaderNow()
+	if r.body.Len() > 0 {
+		_, _ = c.Writer.Write(r.body.Bytes())
+	}
+}
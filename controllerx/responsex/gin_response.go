@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shanluzhineng/fwpkg/system/i18n"
 )
 
 // type Response struct {
@@ -73,3 +74,53 @@ func FailWithMessage(message string, c *gin.Context) {
 func FailWithDetailed(data interface{}, message string, c *gin.Context) {
 	Result(ERROR, data, message, c)
 }
+
+// resolve the locale of error messages of gin,the default one matches the Accept-Language header
+type GinLocaleResolver func(c *gin.Context) string
+
+var _ginLocaleResolver GinLocaleResolver = func(c *gin.Context) string {
+	return i18n.Default().Match(c.GetHeader("Accept-Language"))
+}
+
+func SetGinLocaleResolver(resolver GinLocaleResolver) {
+	_ginLocaleResolver = resolver
+}
+
+// locale of the messages of current gin request
+func GetGinLocale(c *gin.Context) string {
+	return _ginLocaleResolver(c)
+}
+
+// abort the request with an error response,the status and code are mapped by ToAppError
+func FailWithStatus(statusCode int, err error, c *gin.Context) {
+	_ = c.Error(err)
+	status, r := NewLocalizedAppErrorResponse(GetGinLocale(c), statusCode, err)
+	c.AbortWithStatusJSON(status, r)
+}
+
+// abort the request with the error response of AppError or the mapped error,500 if the error is not recognized
+func FailWithError(err error, c *gin.Context) {
+	FailWithStatus(http.StatusInternalServerError, err, c)
+}
+
+// #region the same response as iris handlers
+
+func GinHandleSuccess(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusOK, NewSuccessResponse())
+}
+
+func GinHandleSuccessWithData(c *gin.Context, data interface{}) {
+	c.AbortWithStatusJSON(http.StatusOK, NewSuccessResponse(func(br *BaseResponse) {
+		br.SetData(data)
+	}))
+}
+
+func GinHandleSuccessWithListData(c *gin.Context, data interface{}, total int64) {
+	c.AbortWithStatusJSON(http.StatusOK, NewSuccessListResponse(data, total))
+}
+
+func GinHandleSuccessWithCursorListData(c *gin.Context, data interface{}, total int64, nextCursor string, prevCursor string) {
+	c.AbortWithStatusJSON(http.StatusOK, NewSuccessListResponse(data, total, ListResponseWithCursor(nextCursor, prevCursor)))
+}
+
+// #endregion
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/system/i18n"
 	"github.com/shanluzhineng/fwpkg/system/log"
 	"github.com/shanluzhineng/fwpkg/utils/common"
 	"github.com/shanluzhineng/fwpkg/utils/json"
@@ -17,11 +18,32 @@ func SetNoLogUriList(noLogUri []string) {
 	NoLogUriList = noLogUri
 }
 
+// resolve the locale of error messages,the default one matches the Accept-Language header
+type LocaleResolver func(ctx iris.Context) string
+
+var _localeResolver LocaleResolver = func(ctx iris.Context) string {
+	return i18n.Default().Match(ctx.GetHeader("Accept-Language"))
+}
+
+func SetLocaleResolver(resolver LocaleResolver) {
+	_localeResolver = resolver
+}
+
+// locale of the messages of current request
+func GetLocale(ctx iris.Context) string {
+	return _localeResolver(ctx)
+}
+
+// write the error response,the status and code are mapped by ToAppError and the message is localized
 func HandleError(statusCode int, ctx iris.Context, err error) {
-	ctx.StopWithError(statusCode, err)
-	// ctx.StopWithJSON(statusCode, model.NewErrorResponse(func(br *model.BaseResponse) {
-	// 	br.SetMessage(err.Error())
-	// }))
+	ctx.SetErr(err)
+	status, r := NewLocalizedAppErrorResponse(GetLocale(ctx), statusCode, err)
+	ctx.StopWithJSON(status, r)
+}
+
+// write the error response of AppError or the mapped error,500 if the error is not recognized
+func HandleAppError(ctx iris.Context, err error) {
+	HandleError(http.StatusInternalServerError, ctx, err)
 }
 
 // handle StatusBadRequest
@@ -63,6 +85,10 @@ func HandleSuccessWithListData(ctx iris.Context, data interface{}, total int64)
 	ctx.StopWithJSON(http.StatusOK, NewSuccessListResponse(data, total))
 }
 
+func HandleSuccessWithCursorListData(ctx iris.Context, data interface{}, total int64, nextCursor string, prevCursor string) {
+	ctx.StopWithJSON(http.StatusOK, NewSuccessListResponse(data, total, ListResponseWithCursor(nextCursor, prevCursor)))
+}
+
 func HandlerBinary(ctx iris.Context, data []byte) (int, error) {
 	return ctx.Binary(data)
 }
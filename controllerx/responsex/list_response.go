@@ -1,9 +1,16 @@
 package responsex
 
+// total is not counted
+const ListTotalNotCounted = -1
+
 type ListResponse struct {
 	BaseResponse
 
+	// ListTotalNotCounted if total is not counted
 	Total int64 `json:"total"`
+	// keyset pagination cursors
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
 }
 
 // 构建一个成功的回应
@@ -22,6 +29,14 @@ func NewSuccessListResponse(data interface{}, total int64, opts ...func(*ListRes
 	return r
 }
 
+// set keyset pagination cursors
+func ListResponseWithCursor(nextCursor string, prevCursor string) func(*ListResponse) {
+	return func(lr *ListResponse) {
+		lr.NextCursor = nextCursor
+		lr.PrevCursor = prevCursor
+	}
+}
+
 // create error list response
 func NewErrorListResponse(opts ...func(*ListResponse)) *ListResponse {
 	r := &ListResponse{}
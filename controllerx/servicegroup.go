@@ -9,3 +9,38 @@ import (
 func GetEntityService[T mongodbr.IEntity]() entity.IEntityService[T] {
 	return app.Context.GetInstance(new(entity.IEntityService[T])).(entity.IEntityService[T])
 }
+
+// get the export service from ioc container,
+// if it is not registered,a new service is created with the registered entity.IExportStore and entity.IExportFileStore
+func GetEntityExportService[T mongodbr.IEntity](repository mongodbr.IRepository) entity.IEntityExportService[T] {
+	if app.Context == nil {
+		return entity.NewEntityExportService[T](repository)
+	}
+	if service, ok := app.Context.GetInstance(new(entity.IEntityExportService[T])).(entity.IEntityExportService[T]); ok {
+		return service
+	}
+	opts := make([]entity.EntityExportServiceOption, 0)
+	if store, ok := app.Context.GetInstance(new(entity.IExportStore)).(entity.IExportStore); ok {
+		opts = append(opts, entity.EntityExportServiceWithStore(store))
+	}
+	if fileStore, ok := app.Context.GetInstance(new(entity.IExportFileStore)).(entity.IExportFileStore); ok {
+		opts = append(opts, entity.EntityExportServiceWithFileStore(fileStore))
+	}
+	return entity.NewEntityExportService[T](repository, opts...)
+}
+
+// get the import service from ioc container,
+// if it is not registered,a new service is created with the registered entity.IImportStore
+func GetEntityImportService[T mongodbr.IEntity](repository mongodbr.IRepository) entity.IEntityImportService[T] {
+	if app.Context == nil {
+		return entity.NewEntityImportService[T](repository)
+	}
+	if service, ok := app.Context.GetInstance(new(entity.IEntityImportService[T])).(entity.IEntityImportService[T]); ok {
+		return service
+	}
+	opts := make([]entity.EntityImportServiceOption, 0)
+	if store, ok := app.Context.GetInstance(new(entity.IImportStore)).(entity.IImportStore); ok {
+		opts = append(opts, entity.EntityImportServiceWithStore(store))
+	}
+	return entity.NewEntityImportService[T](repository, opts...)
+}
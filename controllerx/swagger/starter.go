@@ -0,0 +1,163 @@
+package swagger
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/app"
+	"github.com/shanluzhineng/fwpkg/controllerx"
+	"github.com/shanluzhineng/fwpkg/controllerx/openapi"
+	"github.com/shanluzhineng/fwpkg/system/log"
+)
+
+const (
+	OpenApiPath   = "/api/openapi.json"
+	SwaggerUIPath = "/api/swagger"
+	// the embedded swagger-ui assets are served under the path
+	SwaggerUIAssetsPath = SwaggerUIPath + "/assets"
+	// version of the embedded swagger-ui-dist
+	SwaggerUIVersion = "5.18.2"
+)
+
+var (
+	// swagger-ui-dist assets are served from the binary by default,
+	// set it to load them from a cdn or self hosted url,e.g. https://unpkg.com/swagger-ui-dist@5.18.2
+	SwaggerUIAssetsUrl = ""
+	// routes that starts with these prefix are not documented
+	ExcludePathPrefixList = []string{"/debug/", "/api/health/", OpenApiPath, SwaggerUIPath}
+
+	//go:embed index.html
+	_indexHtml string
+	//go:embed dist
+	_distFS        embed.FS
+	_indexTemplate = template.Must(template.New("swagger").Parse(_indexHtml))
+)
+
+func init() {
+	app.RegisterStartupAction(swaggerStartupAction, ginSwaggerStartupAction)
+}
+
+func swaggerStartupAction(webApp *controllerx.IrisApplication) app.IStartupAction {
+	return app.NewStartupAction(func() {
+		if app.HostApplication.SystemConfig().App.IsRunInCli {
+			return
+		}
+		log.Logger.Debug("正在构建openapi路径组件,api/openapi.json...")
+		h := &handler{generate: func(opts ...openapi.GeneratorOption) *openapi.Document {
+			return openapi.Generate(webApp.GetRoutes(), opts...)
+		}}
+		webApp.Get(OpenApiPath, h.serveDocument)
+		webApp.Get(SwaggerUIPath, h.serveUI)
+		if len(SwaggerUIAssetsUrl) <= 0 {
+			webApp.HandleDir(SwaggerUIAssetsPath, assetsFS())
+		}
+	})
+}
+
+// the same routes as swaggerStartupAction for GinApplication
+func ginSwaggerStartupAction(webApp *controllerx.GinApplication) app.IStartupAction {
+	return app.NewStartupAction(func() {
+		if app.HostApplication.SystemConfig().App.IsRunInCli {
+			return
+		}
+		log.Logger.Debug("正在构建gin openapi路径组件,api/openapi.json...")
+		h := &handler{generate: func(opts ...openapi.GeneratorOption) *openapi.Document {
+			return openapi.GenerateGin(webApp.Routes(), opts...)
+		}}
+		webApp.GET(OpenApiPath, h.serveGinDocument)
+		webApp.GET(SwaggerUIPath, h.serveGinUI)
+		if len(SwaggerUIAssetsUrl) <= 0 {
+			webApp.StaticFS(SwaggerUIAssetsPath, assetsFS())
+		}
+	})
+}
+
+type handler struct {
+	generate func(opts ...openapi.GeneratorOption) *openapi.Document
+
+	once     sync.Once
+	document *openapi.Document
+}
+
+// the document is generated at first request,all routes have been registered at that time
+func (h *handler) getDocument() *openapi.Document {
+	h.once.Do(func() {
+		h.document = h.generate(openapi.GeneratorWithInfo(appInfo()),
+			openapi.GeneratorWithExcludePathPrefix(ExcludePathPrefixList...))
+	})
+	return h.document
+}
+
+func (h *handler) serveDocument(ctx iris.Context) {
+	ctx.JSON(h.getDocument())
+}
+
+func (h *handler) serveGinDocument(c *gin.Context) {
+	c.JSON(http.StatusOK, h.getDocument())
+}
+
+func (h *handler) serveUI(ctx iris.Context) {
+	ctx.ContentType("text/html; charset=utf-8")
+	renderUI(ctx)
+}
+
+func (h *handler) serveGinUI(c *gin.Context) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	renderUI(c.Writer)
+}
+
+func renderUI(w io.Writer) {
+	if err := executeIndex(w, appInfo().Title); err != nil {
+		log.Logger.Error(err.Error())
+	}
+}
+
+func executeIndex(w io.Writer, title string) error {
+	return _indexTemplate.Execute(w, map[string]string{
+		"Title":     title,
+		"AssetsUrl": assetsUrl(),
+		"SpecUrl":   OpenApiPath,
+	})
+}
+
+// the embedded assets are used if SwaggerUIAssetsUrl is not set
+func assetsUrl() string {
+	if len(SwaggerUIAssetsUrl) > 0 {
+		return SwaggerUIAssetsUrl
+	}
+	return SwaggerUIAssetsPath
+}
+
+// the embedded swagger-ui-dist assets
+func assetsFS() http.FileSystem {
+	distFS, err := fs.Sub(_distFS, "dist")
+	if err != nil {
+		panic(err)
+	}
+	return http.FS(distFS)
+}
+
+// document info from system.App
+func appInfo() openapi.Info {
+	appConfig := app.HostApplication.SystemConfig().App
+	info := openapi.Info{
+		Title:          appConfig.Title,
+		Description:    appConfig.Description,
+		TermsOfService: appConfig.TermsOfService,
+		Version:        appConfig.Version,
+	}
+	if len(info.Title) <= 0 {
+		info.Title = appConfig.Name
+	}
+	if len(info.Version) <= 0 {
+		info.Version = "v1"
+	}
+	return info
+}
@@ -0,0 +1,41 @@
+package swagger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedAssets(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, executeIndex(buf, "test"))
+	assert.Contains(t, buf.String(), SwaggerUIAssetsPath+"/swagger-ui-bundle.js")
+	assert.NotContains(t, buf.String(), "unpkg.com")
+
+	// the assets are served from the binary
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.StaticFS(SwaggerUIAssetsPath, assetsFS())
+	for _, eachFile := range []string{"swagger-ui-bundle.js", "swagger-ui.css"} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, SwaggerUIAssetsPath+"/"+eachFile, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, w.Body.Bytes())
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, SwaggerUIAssetsPath+"/swagger-ui-bundle.js", nil))
+	assert.Contains(t, w.Body.String(), `PACKAGE_VERSION:"`+SwaggerUIVersion+`"`)
+
+	// the cdn is opt-in
+	SwaggerUIAssetsUrl = "https://unpkg.com/swagger-ui-dist@" + SwaggerUIVersion
+	defer func() {
+		SwaggerUIAssetsUrl = ""
+	}()
+	buf.Reset()
+	assert.NoError(t, executeIndex(buf, "test"))
+	assert.Contains(t, buf.String(), SwaggerUIAssetsUrl+"/swagger-ui-bundle.js")
+}
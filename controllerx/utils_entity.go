@@ -0,0 +1,110 @@
+package controllerx
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/controllerx/responsex"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// get the id route parameter as ObjectID,write a bad request response if it is invalid
+func getObjectIdParam(ctx iris.Context) (primitive.ObjectID, bool) {
+	idValue := ctx.Params().Get("id")
+	if len(idValue) <= 0 {
+		responsex.HandleAppError(ctx, ErrCodeIdRequired.New(""))
+		return primitive.NilObjectID, false
+	}
+	id, err := primitive.ObjectIDFromHex(idValue)
+	if err != nil {
+		responsex.HandleErrorBadRequest(ctx, fmt.Errorf("invalid id,id must be bson id format,id:%s", idValue))
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// gin version of getObjectIdParam
+func getGinObjectIdParam(c *gin.Context) (primitive.ObjectID, bool) {
+	idValue := c.Param("id")
+	if len(idValue) <= 0 {
+		responsex.FailWithError(ErrCodeIdRequired.New(""), c)
+		return primitive.NilObjectID, false
+	}
+	id, err := primitive.ObjectIDFromHex(idValue)
+	if err != nil {
+		responsex.FailWithStatus(http.StatusBadRequest, fmt.Errorf("invalid id,id must be bson id format,id:%s", idValue), c)
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// get the value of *T that implements the entity interfaces,
+// T is usually a pointer type, in that case *T is dereferenced
+func entityValue[T any](item *T) interface{} {
+	if item == nil {
+		return nil
+	}
+	if reflect.TypeOf(item).Elem().Kind() == reflect.Ptr {
+		return *item
+	}
+	return item
+}
+
+const headerIfMatch = "If-Match"
+
+// get the ETag of entity,only IVersionedEntity has ETag
+func entityETag(v interface{}) (string, bool) {
+	versioned, ok := v.(mongodbr.IVersionedEntity)
+	if !ok {
+		return "", false
+	}
+	return strconv.Quote(strconv.FormatInt(versioned.GetVersion(), 10)), true
+}
+
+// check the If-Match header against the entity,write a 412 response if no ETag is matched.
+// the header is ignored if the entity is not a IVersionedEntity
+func checkIfMatch(ctx iris.Context, v interface{}) bool {
+	if err := matchIfMatch(ctx.GetHeader(headerIfMatch), v); err != nil {
+		responsex.HandleError(http.StatusPreconditionFailed, ctx, err)
+		return false
+	}
+	return true
+}
+
+// gin version of checkIfMatch
+func ginCheckIfMatch(c *gin.Context, v interface{}) bool {
+	if err := matchIfMatch(c.GetHeader(headerIfMatch), v); err != nil {
+		responsex.FailWithStatus(http.StatusPreconditionFailed, err, c)
+		return false
+	}
+	return true
+}
+
+func matchIfMatch(value string, v interface{}) error {
+	value = strings.TrimSpace(value)
+	if len(value) <= 0 || value == "*" {
+		return nil
+	}
+	etag, ok := entityETag(v)
+	if !ok {
+		return nil
+	}
+	for _, eachTag := range strings.Split(value, ",") {
+		// weak ETag never matches
+		if strings.TrimSpace(eachTag) == etag {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w,If-Match:%s,ETag:%s", mongodbr.ErrConcurrencyConflict, value, etag)
+}
+
+// write the error of create,update,delete,concurrency conflict is 412 and not found is 404
+func handleEntityWriteError(ctx iris.Context, err error) {
+	responsex.HandleAppError(ctx, err)
+}
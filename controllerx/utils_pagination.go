@@ -1,6 +1,9 @@
 package controllerx
 
 import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
 	"github.com/kataras/iris/v12"
 	"github.com/shanluzhineng/fwpkg/entity"
 )
@@ -18,6 +21,7 @@ func GetPagination(ctx iris.Context) (p *entity.Pagination, err error) {
 	if err := ctx.ReadQuery(&_p); err != nil {
 		return GetDefaultPagination(), err
 	}
+	_p.Size = clampPageSize(_p.Size)
 	return &_p, nil
 }
 
@@ -35,3 +39,87 @@ func GetBatchRequestPayload(ctx iris.Context) (payload entity.BatchRequestPayloa
 	}
 	return payload, err
 }
+
+// get keyset pagination,return false if after,before and limit are not set
+func GetCursorPagination(ctx iris.Context) (p *entity.CursorPagination, ok bool) {
+	return getCursorPagination(ctx.URLParam)
+}
+
+func getCursorPagination(urlParam func(string) string) (p *entity.CursorPagination, ok bool) {
+	limit, _ := strconv.Atoi(urlParam("limit"))
+	p = &entity.CursorPagination{
+		After:  urlParam("after"),
+		Before: urlParam("before"),
+		Limit:  limit,
+	}
+	if p.IsZero() {
+		return nil, false
+	}
+	if p.Limit <= 0 {
+		p.Limit = entity.PaginationDefaultSize
+	}
+	p.Limit = clampPageSize(p.Limit)
+	return p, true
+}
+
+// page size and cursor limit cannot be larger than entity.PaginationMaxSize
+func clampPageSize(size int) int {
+	if size > entity.PaginationMaxSize {
+		return entity.PaginationMaxSize
+	}
+	return size
+}
+
+// total=1 or total=0 to count total or not
+func MustGetListTotal(ctx iris.Context, defaultValue bool) bool {
+	return mustGetListTotal(ctx.URLParam, defaultValue)
+}
+
+func mustGetListTotal(urlParam func(string) string, defaultValue bool) bool {
+	value := urlParam("total")
+	if len(value) <= 0 {
+		return defaultValue
+	}
+	total, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return total
+}
+
+// #region gin
+
+func GetGinPagination(c *gin.Context) (p *entity.Pagination, err error) {
+	var _p entity.Pagination
+
+	if err := c.ShouldBindQuery(&_p); err != nil {
+		return GetDefaultPagination(), err
+	}
+	_p.Size = clampPageSize(_p.Size)
+	return &_p, nil
+}
+
+func MustGetGinPagination(c *gin.Context) (p *entity.Pagination) {
+	p, err := GetGinPagination(c)
+	if err != nil || p == nil {
+		return GetDefaultPagination()
+	}
+	return p
+}
+
+func GetGinBatchRequestPayload(c *gin.Context) (payload entity.BatchRequestPayload, err error) {
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		return payload, err
+	}
+	return payload, err
+}
+
+func GetGinCursorPagination(c *gin.Context) (p *entity.CursorPagination, ok bool) {
+	return getCursorPagination(c.Query)
+}
+
+func MustGetGinListTotal(c *gin.Context, defaultValue bool) bool {
+	return mustGetListTotal(c.Query, defaultValue)
+}
+
+// #endregion
@@ -0,0 +1,35 @@
+package controllerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCursorPagination(t *testing.T) {
+	var p *entity.CursorPagination
+	var ok bool
+	app := iris.New()
+	app.Get("/", func(ctx iris.Context) {
+		p, ok = GetCursorPagination(ctx)
+	})
+	assert.NoError(t, app.Build())
+	get := func(query string) {
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?"+query, nil))
+	}
+
+	get("")
+	assert.False(t, ok)
+
+	get("after=a")
+	assert.True(t, ok)
+	assert.Equal(t, entity.PaginationDefaultSize, p.Limit)
+
+	// the limit is clamped as the page size of offset pagination
+	get("after=a&limit=100000")
+	assert.Equal(t, entity.PaginationMaxSize, p.Limit)
+}
@@ -0,0 +1,151 @@
+package controllerx
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kataras/iris/v12"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+)
+
+const (
+	// fields=name,status,owner.id
+	ProjectionQueryFieldFields = "fields"
+	// exclude=items,history
+	ProjectionQueryFieldExclude = "exclude"
+)
+
+// get the projection from fields and exclude query parameters,
+// return nil if no parameter is set
+func GetProjection(ctx iris.Context, entityValue interface{}) (*mongodbr.Projection, error) {
+	return getProjection(ctx.URLParam, entityValue)
+}
+
+// gin version of GetProjection
+func GetGinProjection(c *gin.Context, entityValue interface{}) (*mongodbr.Projection, error) {
+	return getProjection(c.Query, entityValue)
+}
+
+func getProjection(urlParam func(string) string, entityValue interface{}) (*mongodbr.Projection, error) {
+	fields := splitQueryList(urlParam(ProjectionQueryFieldFields))
+	exclude := splitQueryList(urlParam(ProjectionQueryFieldExclude))
+	if len(fields) <= 0 && len(exclude) <= 0 {
+		return nil, nil
+	}
+	return mongodbr.NewProjection(mongodbr.GetEntityFieldSet(entityValue), fields, exclude)
+}
+
+// keep the projected fields only,so that the fields not loaded are not returned with zero values
+func ProjectItem(item interface{}, projection *mongodbr.Projection, entityValue interface{}) (interface{}, error) {
+	if projection.IsEmpty() {
+		return item, nil
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var source map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keep the precision of int64
+	decoder.UseNumber()
+	if err := decoder.Decode(&source); err != nil {
+		return nil, err
+	}
+
+	if projection.IsExclude() {
+		for _, eachPath := range projection.JsonPathList() {
+			removeJsonPath(source, strings.Split(eachPath, "."))
+		}
+		return source, nil
+	}
+	result := make(map[string]interface{})
+	// _id is always returned by mongodb
+	if idField, ok := mongodbr.GetEntityFieldSet(entityValue).Field("_id"); ok && len(idField.JsonName) > 0 {
+		pickJsonPath(result, source, []string{idField.JsonName})
+	}
+	for _, eachPath := range projection.JsonPathList() {
+		pickJsonPath(result, source, strings.Split(eachPath, "."))
+	}
+	return result, nil
+}
+
+// ProjectItem for each item of list
+func ProjectList[T any](list []T, projection *mongodbr.Projection, entityValue interface{}) (interface{}, error) {
+	if projection.IsEmpty() {
+		return list, nil
+	}
+	result := make([]interface{}, 0, len(list))
+	for _, eachItem := range list {
+		projected, err := ProjectItem(eachItem, projection, entityValue)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, projected)
+	}
+	return result, nil
+}
+
+func pickJsonPath(dst map[string]interface{}, src map[string]interface{}, segments []string) {
+	value, ok := src[segments[0]]
+	if !ok {
+		return
+	}
+	if len(segments) == 1 {
+		dst[segments[0]] = value
+		return
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		child, ok := dst[segments[0]].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+		}
+		pickJsonPath(child, v, segments[1:])
+		dst[segments[0]] = child
+	case []interface{}:
+		// the path is applied to each element of array
+		childList, ok := dst[segments[0]].([]interface{})
+		if !ok {
+			childList = make([]interface{}, len(v))
+		}
+		for index, eachElement := range v {
+			elementMap, ok := eachElement.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			child, ok := childList[index].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+			}
+			pickJsonPath(child, elementMap, segments[1:])
+			childList[index] = child
+		}
+		dst[segments[0]] = childList
+	}
+}
+
+func removeJsonPath(m map[string]interface{}, segments []string) {
+	if len(segments) == 1 {
+		delete(m, segments[0])
+		return
+	}
+	switch v := m[segments[0]].(type) {
+	case map[string]interface{}:
+		removeJsonPath(v, segments[1:])
+	case []interface{}:
+		for _, eachElement := range v {
+			if elementMap, ok := eachElement.(map[string]interface{}); ok {
+				removeJsonPath(elementMap, segments[1:])
+			}
+		}
+	}
+}
+
+func splitQueryList(value string) []string {
+	if len(value) <= 0 {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
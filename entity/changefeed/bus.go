@@ -0,0 +1,154 @@
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// count of recent events kept by the bus for resuming
+	DefaultBusCapacity = 1000
+	// events buffered for a subscriber,the subscriber is closed if it does not receive them in time
+	subscriberBufferSize = 64
+)
+
+var ErrInvalidEventId = errors.New("invalid event id")
+
+// in-process bus of change events,it is used when change streams are not supported,e.g. standalone mongodb.
+// only the published changes are seen,the changes made by other processes are not.
+// recent events are kept so a subscriber can resume from the last received event
+type Bus struct {
+	capacity int
+	// events of a previous process are not replayed
+	epoch string
+
+	lock          sync.Mutex
+	sequence      uint64
+	eventList     []*ChangeEvent
+	subscriberMap map[*busSubscriber]struct{}
+}
+
+type busSubscriber struct {
+	collection string
+	eventChan  chan *ChangeEvent
+}
+
+// capacity is the count of recent events kept for resuming,DefaultBusCapacity is used if it is not positive
+func NewBus(capacity int) *Bus {
+	if capacity <= 0 {
+		capacity = DefaultBusCapacity
+	}
+	return &Bus{
+		capacity:      capacity,
+		epoch:         strconv.FormatInt(time.Now().UnixNano(), 36),
+		subscriberMap: make(map[*busSubscriber]struct{}),
+	}
+}
+
+// publish the event to the subscribers of its collection,the id of event is assigned by the bus.
+// it never blocks,a subscriber that is full is closed
+func (b *Bus) Publish(event *ChangeEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.sequence++
+	event.Id = fmt.Sprintf("%s-%d", b.epoch, b.sequence)
+	b.eventList = append(b.eventList, event)
+	if len(b.eventList) > b.capacity {
+		b.eventList = b.eventList[len(b.eventList)-b.capacity:]
+	}
+	for eachSubscriber := range b.subscriberMap {
+		if eachSubscriber.collection != event.Collection {
+			continue
+		}
+		select {
+		case eachSubscriber.eventChan <- event:
+		default:
+			b.removeSubscriber(eachSubscriber)
+		}
+	}
+}
+
+// subscribe the events of collection,the kept events after lastEventId are sent first.
+// the channel is closed when ctx is done
+func (b *Bus) Subscribe(ctx context.Context, collection string, lastEventId string) (<-chan *ChangeEvent, error) {
+	epoch, lastSequence, err := b.parseEventId(lastEventId)
+	if err != nil {
+		return nil, err
+	}
+
+	b.lock.Lock()
+	replayList := make([]*ChangeEvent, 0)
+	if len(lastEventId) > 0 && epoch == b.epoch {
+		for _, eachEvent := range b.eventList[b.firstIndexAfter(lastSequence):] {
+			if eachEvent.Collection == collection {
+				replayList = append(replayList, eachEvent)
+			}
+		}
+	}
+	subscriber := &busSubscriber{
+		collection: collection,
+		eventChan:  make(chan *ChangeEvent, subscriberBufferSize+len(replayList)),
+	}
+	for _, eachEvent := range replayList {
+		subscriber.eventChan <- eachEvent
+	}
+	b.subscriberMap[subscriber] = struct{}{}
+	b.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		b.removeSubscriber(subscriber)
+	}()
+	return subscriber.eventChan, nil
+}
+
+// must be called with lock held
+func (b *Bus) removeSubscriber(subscriber *busSubscriber) {
+	if _, ok := b.subscriberMap[subscriber]; !ok {
+		return
+	}
+	delete(b.subscriberMap, subscriber)
+	close(subscriber.eventChan)
+}
+
+// index of the first kept event whose sequence is greater than sequence,must be called with lock held
+func (b *Bus) firstIndexAfter(sequence uint64) int {
+	if len(b.eventList) <= 0 {
+		return 0
+	}
+	firstSequence := b.sequence - uint64(len(b.eventList)) + 1
+	if sequence < firstSequence {
+		return 0
+	}
+	if sequence >= b.sequence {
+		return len(b.eventList)
+	}
+	return int(sequence - firstSequence + 1)
+}
+
+// the id is {epoch}-{sequence}
+func (b *Bus) parseEventId(eventId string) (epoch string, sequence uint64, err error) {
+	if len(eventId) <= 0 {
+		return "", 0, nil
+	}
+	epoch, sequenceValue, ok := strings.Cut(eventId, "-")
+	if !ok {
+		return "", 0, fmt.Errorf("%w,id:%s", ErrInvalidEventId, eventId)
+	}
+	sequence, err = strconv.ParseUint(sequenceValue, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w,id:%s", ErrInvalidEventId, eventId)
+	}
+	return epoch, sequence, nil
+}
@@ -0,0 +1,79 @@
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus(2)
+	ctx, cancel := context.WithCancel(context.Background())
+	eventChan, err := bus.Subscribe(ctx, "user", "")
+	assert.Nil(t, err)
+
+	bus.Publish(&ChangeEvent{Operation: OperationCreate, Collection: "user", DocumentId: "1"})
+	bus.Publish(&ChangeEvent{Operation: OperationCreate, Collection: "order", DocumentId: "2"})
+	bus.Publish(&ChangeEvent{Operation: OperationUpdate, Collection: "user", DocumentId: "1"})
+
+	first := <-eventChan
+	assert.Equal(t, OperationCreate, first.Operation)
+	assert.False(t, first.Time.IsZero())
+	second := <-eventChan
+	assert.Equal(t, OperationUpdate, second.Operation)
+	assert.NotEqual(t, first.Id, second.Id)
+
+	cancel()
+	_, ok := <-eventChan
+	assert.False(t, ok)
+}
+
+func TestBusResume(t *testing.T) {
+	bus := NewBus(3)
+	var idList []string
+	for _, eachId := range []string{"1", "2", "3", "4"} {
+		event := &ChangeEvent{Operation: OperationCreate, Collection: "user", DocumentId: eachId}
+		bus.Publish(event)
+		idList = append(idList, event.Id)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// only the kept events are replayed
+	eventChan, err := bus.Subscribe(ctx, "user", idList[0])
+	assert.Nil(t, err)
+	for _, eachId := range []string{"2", "3", "4"} {
+		assert.Equal(t, eachId, (<-eventChan).DocumentId)
+	}
+
+	eventChan, err = bus.Subscribe(ctx, "user", idList[3])
+	assert.Nil(t, err)
+	assert.Len(t, eventChan, 0)
+
+	// id of another process
+	eventChan, err = bus.Subscribe(ctx, "user", "other-1")
+	assert.Nil(t, err)
+	assert.Len(t, eventChan, 0)
+
+	_, err = bus.Subscribe(ctx, "user", "invalid")
+	assert.True(t, errors.Is(err, ErrInvalidEventId))
+}
+
+func TestBusSlowSubscriber(t *testing.T) {
+	bus := NewBus(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	eventChan, err := bus.Subscribe(ctx, "user", "")
+	assert.Nil(t, err)
+
+	for i := 0; i <= subscriberBufferSize; i++ {
+		bus.Publish(&ChangeEvent{Operation: OperationCreate, Collection: "user"})
+	}
+	count := 0
+	for range eventChan {
+		count++
+	}
+	assert.Equal(t, subscriberBufferSize, count)
+}
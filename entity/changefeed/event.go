@@ -0,0 +1,26 @@
+package changefeed
+
+import (
+	"time"
+)
+
+// operation of a change event
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// a change of an item in a collection
+type ChangeEvent struct {
+	// id of the event,it is sent as the SSE id and resumes the subscription as Last-Event-ID.
+	// it is the resume token of change stream or the sequence of the in-process bus
+	Id         string    `json:"id"`
+	Operation  string    `json:"operation"`
+	Collection string    `json:"collection"`
+	DocumentId string    `json:"documentId"`
+	Time       time.Time `json:"time"`
+	// the bson document of item,it is the stored document before deletion for delete event.
+	// nil if it is not available,e.g. the pre-image of change stream is not enabled
+	Document map[string]interface{} `json:"document,omitempty"`
+}
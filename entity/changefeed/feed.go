@@ -0,0 +1,163 @@
+package changefeed
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// timeout of the command that detects whether the deployment supports change streams
+const detectTimeout = 5 * time.Second
+
+type FeedOptions struct {
+	// count of recent events kept by the in-process bus,DefaultBusCapacity is used if not set
+	BusCapacity int
+	// request the pre-images of deleted documents,they are needed to filter the delete events of change stream.
+	// the collection must enable changeStreamPreAndPostImages,it requires mongodb 6.0
+	PreImageEnabled bool
+	// always use the in-process bus
+	ChangeStreamDisabled bool
+}
+
+type FeedOption func(*FeedOptions)
+
+func WithBusCapacity(capacity int) FeedOption {
+	return func(o *FeedOptions) {
+		o.BusCapacity = capacity
+	}
+}
+
+func WithPreImage(v bool) FeedOption {
+	return func(o *FeedOptions) {
+		o.PreImageEnabled = v
+	}
+}
+
+func WithChangeStreamDisabled(v bool) FeedOption {
+	return func(o *FeedOptions) {
+		o.ChangeStreamDisabled = v
+	}
+}
+
+// change events of collections,they are from the change streams of mongodb if the deployment supports them
+// (replica set or sharded cluster),otherwise from the in-process bus that receives the published changes
+type Feed struct {
+	options *FeedOptions
+	bus     *Bus
+	// open a change stream,it is replaced by tests
+	watch func(ctx context.Context, collection *mongo.Collection, tenantId string, lastEventId string, preImageEnabled bool) (<-chan *ChangeEvent, error)
+
+	lock sync.Mutex
+	// whether the deployment of client supports change streams
+	supportedMap map[*mongo.Client]bool
+
+	streamLock sync.Mutex
+	// the change streams that are shared by subscribers
+	streamMap map[streamKey]*sharedStream
+}
+
+func NewFeed(opts ...FeedOption) *Feed {
+	options := &FeedOptions{}
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+	return &Feed{
+		options:      options,
+		bus:          NewBus(options.BusCapacity),
+		watch:        watch,
+		supportedMap: make(map[*mongo.Client]bool),
+		streamMap:    make(map[streamKey]*sharedStream),
+	}
+}
+
+var _defaultFeed = NewFeed()
+
+// feed shared by the entity controllers that do not set their own
+func GetDefaultFeed() *Feed {
+	return _defaultFeed
+}
+
+// subscribe the changes of collection,the events after lastEventId are sent first if it is not empty.
+// the subscribers of the same collection and tenant share one change stream,
+// only the changes of tenant are received from it if tenantId is not empty.
+// the channel is closed when ctx is done or the subscriber is too slow,the subscriber should resume
+// from the last received event after that
+func (f *Feed) Subscribe(ctx context.Context, collection *mongo.Collection, tenantId string, lastEventId string) (<-chan *ChangeEvent, error) {
+	if f.isChangeStreamSupported(ctx, collection) {
+		eventChan, err := f.subscribeStream(ctx, collection, tenantId, lastEventId)
+		if err == nil || !IsChangeStreamNotSupported(err) {
+			return eventChan, err
+		}
+		f.setChangeStreamSupported(collection.Database().Client(), false)
+	}
+	return f.bus.Subscribe(ctx, collection.Name(), lastEventId)
+}
+
+// publish a change of collection to the in-process bus,
+// nothing is done if the changes of collection are from change stream
+func (f *Feed) Publish(collection *mongo.Collection, operation string, documentId string, document map[string]interface{}) {
+	if f.UsesChangeStream(collection) {
+		return
+	}
+	f.bus.Publish(&ChangeEvent{
+		Operation:  operation,
+		Collection: collection.Name(),
+		DocumentId: documentId,
+		Document:   document,
+	})
+}
+
+// the changes of collection are from change stream,the writers do not need to publish them
+func (f *Feed) UsesChangeStream(collection *mongo.Collection) bool {
+	return f.isChangeStreamSupported(context.Background(), collection)
+}
+
+func (f *Feed) isChangeStreamSupported(ctx context.Context, collection *mongo.Collection) bool {
+	if f.options.ChangeStreamDisabled {
+		return false
+	}
+	client := collection.Database().Client()
+	f.lock.Lock()
+	supported, ok := f.supportedMap[client]
+	f.lock.Unlock()
+	if ok {
+		return supported
+	}
+
+	supported, err := detectChangeStreamSupported(ctx, client)
+	if err != nil {
+		// detect again later
+		return false
+	}
+	f.setChangeStreamSupported(client, supported)
+	return supported
+}
+
+func (f *Feed) setChangeStreamSupported(client *mongo.Client, supported bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.supportedMap[client] = supported
+}
+
+// change streams are supported by replica set and sharded cluster
+func detectChangeStreamSupported(ctx context.Context, client *mongo.Client) (bool, error) {
+	detectCtx, cancel := context.WithTimeout(ctx, detectTimeout)
+	defer cancel()
+	result := bson.M{}
+	err := client.Database("admin").RunCommand(detectCtx, bson.D{{Key: "hello", Value: 1}}).Decode(&result)
+	if err != nil {
+		// hello is not supported before mongodb 4.4.2
+		err = client.Database("admin").RunCommand(detectCtx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&result)
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, ok := result["setName"]; ok {
+		return true, nil
+	}
+	msg, _ := result["msg"].(string)
+	return msg == "isdbgrid", nil
+}
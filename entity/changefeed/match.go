@@ -0,0 +1,326 @@
+package changefeed
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the document matches the mongodb query,e.g. the query of filter.FilterToQuery,the tenant filter or the permission scope.
+// the operators are $and,$or,$nor,$eq,$ne,$gt,$gte,$lt,$lte,$in,$nin,$exists,$regex with $options and $not,
+// a query with other operators does not match.
+// as mongodb does,a condition of array field matches if any element matches
+func Match(query map[string]interface{}, document map[string]interface{}) bool {
+	for key, condition := range query {
+		switch key {
+		case "$and", "$or", "$nor":
+			subQueryList, ok := asList(condition)
+			if !ok {
+				return false
+			}
+			matchedCount := 0
+			for _, eachSubQuery := range subQueryList {
+				subQuery, ok := asDocument(eachSubQuery)
+				if !ok {
+					return false
+				}
+				if Match(subQuery, document) {
+					matchedCount++
+				}
+			}
+			switch {
+			case key == "$and" && matchedCount != len(subQueryList),
+				key == "$or" && matchedCount <= 0,
+				key == "$nor" && matchedCount > 0:
+				return false
+			}
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false
+			}
+			value, exists := lookupPath(document, key)
+			if !matchCondition(condition, value, exists) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchCondition(condition interface{}, value interface{}, exists bool) bool {
+	operatorMap, ok := asDocument(condition)
+	if !ok || !isOperatorDocument(operatorMap) {
+		return matchEqual(value, exists, condition)
+	}
+	for operator, operand := range operatorMap {
+		var matched bool
+		switch operator {
+		case "$eq":
+			matched = matchEqual(value, exists, operand)
+		case "$ne":
+			matched = !matchEqual(value, exists, operand)
+		case "$gt", "$gte", "$lt", "$lte":
+			matched = exists && matchAny(value, func(v interface{}) bool {
+				result, ok := compareValue(v, operand)
+				if !ok {
+					return false
+				}
+				switch operator {
+				case "$gt":
+					return result > 0
+				case "$gte":
+					return result >= 0
+				case "$lt":
+					return result < 0
+				}
+				return result <= 0
+			})
+		case "$in", "$nin":
+			operandList, ok := asList(operand)
+			if !ok {
+				return false
+			}
+			for _, eachOperand := range operandList {
+				if matchEqual(value, exists, eachOperand) {
+					matched = true
+					break
+				}
+			}
+			if operator == "$nin" {
+				matched = !matched
+			}
+		case "$exists":
+			matched = isTruthy(operand) == exists
+		case "$regex":
+			options, _ := operatorMap["$options"].(string)
+			matched = exists && matchRegex(value, operand, options)
+		case "$options":
+			matched = true
+		case "$not":
+			if subCondition, ok := asDocument(operand); ok {
+				matched = !matchCondition(subCondition, value, exists)
+			} else {
+				matched = !(exists && matchRegex(value, operand, ""))
+			}
+		default:
+			return false
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func isOperatorDocument(document map[string]interface{}) bool {
+	for key := range document {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+	return len(document) > 0
+}
+
+// a missing field equals nil,an array field equals the value if the array or any element equals it
+func matchEqual(value interface{}, exists bool, expected interface{}) bool {
+	if !exists {
+		return expected == nil
+	}
+	if equalValue(value, expected) {
+		return true
+	}
+	if list, ok := asList(value); ok {
+		for _, eachValue := range list {
+			if equalValue(eachValue, expected) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchAny(value interface{}, predicate func(v interface{}) bool) bool {
+	if list, ok := asList(value); ok {
+		for _, eachValue := range list {
+			if predicate(eachValue) {
+				return true
+			}
+		}
+		return false
+	}
+	return predicate(value)
+}
+
+func matchRegex(value interface{}, pattern interface{}, options string) bool {
+	var expression string
+	switch p := pattern.(type) {
+	case primitive.Regex:
+		expression, options = p.Pattern, p.Options
+	case string:
+		expression = p
+	default:
+		return false
+	}
+	if strings.Contains(options, "i") {
+		expression = "(?i)" + expression
+	}
+	re, err := regexp.Compile(expression)
+	if err != nil {
+		return false
+	}
+	return matchAny(value, func(v interface{}) bool {
+		s, ok := v.(string)
+		return ok && re.MatchString(s)
+	})
+}
+
+func equalValue(a interface{}, b interface{}) bool {
+	if result, ok := compareValue(a, b); ok {
+		return result == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compare numbers,strings and times,ok is false if they are not comparable
+func compareValue(a interface{}, b interface{}) (int, bool) {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	if x, ok := a.(string); ok {
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	}
+	if x, ok := toTime(a); ok {
+		y, ok := toTime(b)
+		if !ok {
+			return 0, false
+		}
+		return x.Compare(y), true
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case int:
+		return float64(value), true
+	case int32:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case float32:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
+
+func toTime(v interface{}) (time.Time, bool) {
+	switch value := v.(type) {
+	case time.Time:
+		return value, true
+	case primitive.DateTime:
+		return value.Time(), true
+	}
+	return time.Time{}, false
+}
+
+func isTruthy(v interface{}) bool {
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	if f, ok := toFloat(v); ok {
+		return f != 0
+	}
+	return v != nil
+}
+
+// value of the dotted path,the values of the documents in an array are collected as a list
+func lookupPath(document map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = document
+	for _, eachSegment := range strings.Split(path, ".") {
+		if subDocument, ok := asDocument(current); ok {
+			value, ok := subDocument[eachSegment]
+			if !ok {
+				return nil, false
+			}
+			current = value
+			continue
+		}
+		list, ok := asList(current)
+		if !ok {
+			return nil, false
+		}
+		if index, err := strconv.Atoi(eachSegment); err == nil {
+			if index < 0 || index >= len(list) {
+				return nil, false
+			}
+			current = list[index]
+			continue
+		}
+		valueList := make([]interface{}, 0, len(list))
+		for _, eachItem := range list {
+			if itemDocument, ok := asDocument(eachItem); ok {
+				if value, ok := itemDocument[eachSegment]; ok {
+					valueList = append(valueList, value)
+				}
+			}
+		}
+		if len(valueList) <= 0 {
+			return nil, false
+		}
+		current = valueList
+	}
+	return current, true
+}
+
+func asDocument(v interface{}) (map[string]interface{}, bool) {
+	switch value := v.(type) {
+	case bson.M:
+		return value, true
+	case map[string]interface{}:
+		return value, true
+	case primitive.D:
+		return value.Map(), true
+	}
+	return nil, false
+}
+
+func asList(v interface{}) ([]interface{}, bool) {
+	switch value := v.(type) {
+	case []interface{}:
+		return value, true
+	case primitive.A:
+		return value, true
+	case nil, []byte, string:
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
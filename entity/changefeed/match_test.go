@@ -0,0 +1,80 @@
+package changefeed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMatch(t *testing.T) {
+	now := time.Now()
+	document := bson.M{
+		"tenantId":     "t1",
+		"name":         "Alice",
+		"age":          int32(30),
+		"tags":         bson.A{"a", "b"},
+		"creationTime": primitive.NewDateTimeFromTime(now),
+		"address":      bson.M{"city": "x"},
+		"items":        bson.A{bson.M{"sku": "s1"}, bson.M{"sku": "s2"}},
+	}
+
+	for _, eachCase := range []struct {
+		query   map[string]interface{}
+		matched bool
+	}{
+		{map[string]interface{}{}, true},
+		{map[string]interface{}{"tenantId": "t1"}, true},
+		{map[string]interface{}{"tenantId": "t2"}, false},
+		{map[string]interface{}{"age": 30}, true},
+		{map[string]interface{}{"age": map[string]interface{}{"$gte": 18, "$lt": 30}}, false},
+		{map[string]interface{}{"age": map[string]interface{}{"$in": []interface{}{10, 30}}}, true},
+		{map[string]interface{}{"age": map[string]interface{}{"$nin": []int{30}}}, false},
+		{map[string]interface{}{"name": map[string]interface{}{"$regex": "ali", "$options": "i"}}, true},
+		{map[string]interface{}{"name": map[string]interface{}{"$not": map[string]interface{}{"$regex": "Ali"}}}, false},
+		{map[string]interface{}{"name": map[string]interface{}{"$ne": "Bob"}}, true},
+		{map[string]interface{}{"tags": "b"}, true},
+		{map[string]interface{}{"address.city": "x"}, true},
+		{map[string]interface{}{"items.sku": "s2"}, true},
+		{map[string]interface{}{"deleterId": nil}, true},
+		{map[string]interface{}{"deleterId": map[string]interface{}{"$exists": true}}, false},
+		{map[string]interface{}{"creationTime": map[string]interface{}{"$gt": now.Add(-time.Hour)}}, true},
+		{bson.M{"$or": bson.A{bson.M{"name": "Bob"}, bson.M{"age": 30}}}, true},
+		{bson.M{"$and": []bson.M{{"name": "Alice"}, {"age": 31}}}, false},
+		{bson.M{"$nor": bson.A{bson.M{"name": "Bob"}}}, true},
+		{bson.M{"$where": "true"}, false},
+		{bson.M{"name": bson.M{"$size": 1}}, false},
+	} {
+		assert.Equal(t, eachCase.matched, Match(eachCase.query, document), "%v", eachCase.query)
+	}
+}
+
+func TestChangeStreamDocumentToEvent(t *testing.T) {
+	id := primitive.NewObjectID()
+	token, _ := bson.Marshal(bson.M{"_data": "8265"})
+	document := &changeStreamDocument{
+		Id:            token,
+		OperationType: "update",
+		ClusterTime:   primitive.Timestamp{T: 1700000000},
+		DocumentKey:   bson.M{"_id": id},
+		FullDocument:  bson.M{"_id": id, "isDeleted": true},
+	}
+	event := document.toEvent("user")
+	assert.Equal(t, "8265", event.Id)
+	assert.Equal(t, OperationDelete, event.Operation)
+	assert.Equal(t, id.Hex(), event.DocumentId)
+	assert.Equal(t, int64(1700000000), event.Time.Unix())
+
+	document.FullDocument = nil
+	assert.Nil(t, document.toEvent("user"))
+
+	document.OperationType = "delete"
+	event = document.toEvent("user")
+	assert.Equal(t, OperationDelete, event.Operation)
+	assert.Nil(t, event.Document)
+
+	document.OperationType = "drop"
+	assert.Nil(t, document.toEvent("user"))
+}
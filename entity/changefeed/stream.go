@@ -0,0 +1,151 @@
+package changefeed
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// a change stream is shared by the subscribers of the same collection and tenant
+type streamKey struct {
+	client     *mongo.Client
+	database   string
+	collection string
+	tenantId   string
+}
+
+// change stream that is shared by subscribers,the events are fanned out in process.
+// it is closed when the last subscriber leaves
+type sharedStream struct {
+	key    streamKey
+	cancel context.CancelFunc
+
+	// guarded by Feed.streamLock
+	subscriberMap map[*busSubscriber]struct{}
+	// recent events,a subscriber resumes from them when it reconnects
+	eventList []*ChangeEvent
+}
+
+// subscribe the shared stream of collection and tenant,the stream is opened by the first subscriber.
+// a subscriber resumes from the recent events of the shared stream,
+// a private stream is opened if lastEventId is not one of them
+func (f *Feed) subscribeStream(ctx context.Context, collection *mongo.Collection, tenantId string, lastEventId string) (<-chan *ChangeEvent, error) {
+	key := streamKey{
+		client:     collection.Database().Client(),
+		database:   collection.Database().Name(),
+		collection: collection.Name(),
+		tenantId:   tenantId,
+	}
+	f.streamLock.Lock()
+	defer f.streamLock.Unlock()
+	stream, ok := f.streamMap[key]
+	replayList := make([]*ChangeEvent, 0)
+	if len(lastEventId) > 0 {
+		if !ok {
+			return f.watch(ctx, collection, tenantId, lastEventId, f.options.PreImageEnabled)
+		}
+		index := stream.indexOf(lastEventId)
+		if index < 0 {
+			return f.watch(ctx, collection, tenantId, lastEventId, f.options.PreImageEnabled)
+		}
+		replayList = append(replayList, stream.eventList[index+1:]...)
+	}
+	if !ok {
+		var err error
+		stream, err = f.openStream(key, collection)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	subscriber := &busSubscriber{
+		collection: collection.Name(),
+		eventChan:  make(chan *ChangeEvent, subscriberBufferSize+len(replayList)),
+	}
+	for _, eachEvent := range replayList {
+		subscriber.eventChan <- eachEvent
+	}
+	stream.subscriberMap[subscriber] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		f.streamLock.Lock()
+		defer f.streamLock.Unlock()
+		f.removeStreamSubscriber(stream, subscriber)
+	}()
+	return subscriber.eventChan, nil
+}
+
+// open the change stream and fan out its events,must be called with streamLock held
+func (f *Feed) openStream(key streamKey, collection *mongo.Collection) (*sharedStream, error) {
+	streamCtx, cancel := context.WithCancel(context.Background())
+	eventChan, err := f.watch(streamCtx, collection, key.tenantId, "", f.options.PreImageEnabled)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	stream := &sharedStream{
+		key:           key,
+		cancel:        cancel,
+		subscriberMap: make(map[*busSubscriber]struct{}),
+	}
+	f.streamMap[key] = stream
+	go func() {
+		for event := range eventChan {
+			f.streamLock.Lock()
+			stream.publish(f, event)
+			f.streamLock.Unlock()
+		}
+		// the stream fails or is closed,the subscribers resume from the last received event
+		f.streamLock.Lock()
+		defer f.streamLock.Unlock()
+		for eachSubscriber := range stream.subscriberMap {
+			f.removeStreamSubscriber(stream, eachSubscriber)
+		}
+		f.closeStream(stream)
+	}()
+	return stream, nil
+}
+
+// must be called with streamLock held
+func (s *sharedStream) publish(f *Feed, event *ChangeEvent) {
+	s.eventList = append(s.eventList, event)
+	if capacity := f.bus.capacity; len(s.eventList) > capacity {
+		s.eventList = s.eventList[len(s.eventList)-capacity:]
+	}
+	for eachSubscriber := range s.subscriberMap {
+		select {
+		case eachSubscriber.eventChan <- event:
+		default:
+			f.removeStreamSubscriber(s, eachSubscriber)
+		}
+	}
+}
+
+// index of the recent event,-1 if it is not kept
+func (s *sharedStream) indexOf(eventId string) int {
+	for index := len(s.eventList) - 1; index >= 0; index-- {
+		if s.eventList[index].Id == eventId {
+			return index
+		}
+	}
+	return -1
+}
+
+// the stream is closed when the last subscriber leaves,must be called with streamLock held
+func (f *Feed) removeStreamSubscriber(stream *sharedStream, subscriber *busSubscriber) {
+	if _, ok := stream.subscriberMap[subscriber]; ok {
+		delete(stream.subscriberMap, subscriber)
+		close(subscriber.eventChan)
+	}
+	if len(stream.subscriberMap) <= 0 {
+		f.closeStream(stream)
+	}
+}
+
+// must be called with streamLock held
+func (f *Feed) closeStream(stream *sharedStream) {
+	if f.streamMap[stream.key] == stream {
+		delete(f.streamMap, stream.key)
+	}
+	stream.cancel()
+}
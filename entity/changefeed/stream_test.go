@@ -0,0 +1,97 @@
+package changefeed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// change streams of the test,the events are sent by the test
+type testWatcher struct {
+	openCount int
+	streamMap map[string]chan *ChangeEvent
+	ctxMap    map[string]context.Context
+}
+
+func (w *testWatcher) watch(ctx context.Context, collection *mongo.Collection, tenantId string, lastEventId string, preImageEnabled bool) (<-chan *ChangeEvent, error) {
+	w.openCount++
+	eventChan := make(chan *ChangeEvent)
+	key := tenantId + "/" + lastEventId
+	w.streamMap[key] = eventChan
+	w.ctxMap[key] = ctx
+	go func() {
+		<-ctx.Done()
+		close(eventChan)
+	}()
+	return eventChan, nil
+}
+
+func TestFeedSharesStream(t *testing.T) {
+	// the client does not connect until an operation is executed
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	assert.NoError(t, err)
+	collection := client.Database("test").Collection("order")
+	watcher := &testWatcher{streamMap: make(map[string]chan *ChangeEvent), ctxMap: make(map[string]context.Context)}
+	feed := NewFeed(WithBusCapacity(2))
+	feed.watch = watcher.watch
+	feed.setChangeStreamSupported(client, true)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	eventChan1, err := feed.Subscribe(ctx1, collection, "t1", "")
+	assert.NoError(t, err)
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	eventChan2, err := feed.Subscribe(ctx2, collection, "t1", "")
+	assert.NoError(t, err)
+	// the subscribers of the same tenant share one stream
+	assert.Equal(t, 1, watcher.openCount)
+	otherCtx, otherCancel := context.WithCancel(context.Background())
+	defer otherCancel()
+	_, err = feed.Subscribe(otherCtx, collection, "t2", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, watcher.openCount)
+
+	for _, eachId := range []string{"1", "2", "3"} {
+		watcher.streamMap["t1/"] <- &ChangeEvent{Id: eachId, Operation: OperationUpdate, Collection: "order", DocumentId: eachId}
+	}
+	for _, eachId := range []string{"1", "2", "3"} {
+		assert.Equal(t, eachId, (<-eventChan1).Id)
+		assert.Equal(t, eachId, (<-eventChan2).Id)
+	}
+
+	// the subscriber resumes from the recent events of the shared stream
+	ctx3, cancel3 := context.WithCancel(context.Background())
+	eventChan3, err := feed.Subscribe(ctx3, collection, "t1", "2")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, watcher.openCount)
+	assert.Equal(t, "3", (<-eventChan3).Id)
+	// the event is not kept,a private stream is opened
+	ctx4, cancel4 := context.WithCancel(context.Background())
+	_, err = feed.Subscribe(ctx4, collection, "t1", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, watcher.openCount)
+	cancel4()
+
+	// the stream is closed when the last subscriber leaves
+	streamCtx := watcher.ctxMap["t1/"]
+	cancel1()
+	_, ok := <-eventChan1
+	assert.False(t, ok)
+	cancel2()
+	_, ok = <-eventChan2
+	assert.False(t, ok)
+	assert.NoError(t, streamCtx.Err())
+	cancel3()
+	_, ok = <-eventChan3
+	assert.False(t, ok)
+	<-streamCtx.Done()
+
+	// a new stream is opened by the next subscriber
+	ctx5, cancel5 := context.WithCancel(context.Background())
+	defer cancel5()
+	_, err = feed.Subscribe(ctx5, collection, "t1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, watcher.openCount)
+}
@@ -0,0 +1,143 @@
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shanluzhineng/fwpkg/entity"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// error code of standalone server,"$changeStream stage is only supported on replica sets"
+const errorCodeChangeStreamNotSupported = 40573
+
+// the deployment does not support change streams,e.g. standalone server
+func IsChangeStreamNotSupported(err error) bool {
+	var commandError mongo.CommandError
+	if errors.As(err, &commandError) && commandError.Code == errorCodeChangeStreamNotSupported {
+		return true
+	}
+	return err != nil && strings.Contains(err.Error(), "only supported on replica sets")
+}
+
+// event of change stream
+type changeStreamDocument struct {
+	Id                       bson.Raw            `bson:"_id"`
+	OperationType            string              `bson:"operationType"`
+	ClusterTime              primitive.Timestamp `bson:"clusterTime"`
+	DocumentKey              bson.M              `bson:"documentKey"`
+	FullDocument             bson.M              `bson:"fullDocument"`
+	FullDocumentBeforeChange bson.M              `bson:"fullDocumentBeforeChange"`
+}
+
+// nil if the event is not a change of item
+func (d *changeStreamDocument) toEvent(collection string) *ChangeEvent {
+	event := &ChangeEvent{
+		Collection: collection,
+		DocumentId: documentIdString(d.DocumentKey["_id"]),
+		Time:       time.Unix(int64(d.ClusterTime.T), 0),
+	}
+	if token, ok := d.Id.Lookup("_data").StringValueOK(); ok {
+		event.Id = token
+	}
+	switch d.OperationType {
+	case "insert":
+		event.Operation = OperationCreate
+		event.Document = d.FullDocument
+	case "update", "replace":
+		if d.FullDocument == nil {
+			// the item is deleted after the update,the delete event follows
+			return nil
+		}
+		event.Operation = OperationUpdate
+		if isDeleted, _ := d.FullDocument[mongodbr.SoftDeleteFieldIsDeleted].(bool); isDeleted {
+			event.Operation = OperationDelete
+		}
+		event.Document = d.FullDocument
+	case "delete":
+		event.Operation = OperationDelete
+		event.Document = d.FullDocumentBeforeChange
+	default:
+		return nil
+	}
+	return event
+}
+
+// watch the changes of collection,the stream resumes after lastEventId if it is a valid resume token,
+// otherwise it starts from now. only the changes of tenant are watched if tenantId is not empty
+func watch(ctx context.Context, collection *mongo.Collection, tenantId string, lastEventId string, preImageEnabled bool) (<-chan *ChangeEvent, error) {
+	match := bson.M{
+		"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}},
+	}
+	if len(tenantId) > 0 {
+		// the delete event without pre-image cannot be checked,it is not sent to the subscribers of tenant
+		match["$or"] = bson.A{
+			bson.M{"fullDocument." + entity.TenantFieldName: tenantId},
+			bson.M{"fullDocumentBeforeChange." + entity.TenantFieldName: tenantId},
+		}
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: match}}}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if preImageEnabled {
+		opts.SetFullDocumentBeforeChange(options.WhenAvailable)
+	}
+	if len(lastEventId) > 0 {
+		opts.SetResumeAfter(bson.M{"_data": lastEventId})
+	}
+	stream, err := collection.Watch(ctx, pipeline, opts)
+	if err != nil && len(lastEventId) > 0 && !IsChangeStreamNotSupported(err) {
+		// the token is invalid or it is out of the oplog
+		log.FromContext(ctx).Warn(fmt.Sprintf("change stream resume fail,collection:%s,err:%s", collection.Name(), err.Error()))
+		opts.ResumeAfter = nil
+		stream, err = collection.Watch(ctx, pipeline, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	eventChan := make(chan *ChangeEvent, subscriberBufferSize)
+	go func() {
+		defer close(eventChan)
+		defer stream.Close(context.Background())
+		for stream.Next(ctx) {
+			document := &changeStreamDocument{}
+			if err := stream.Decode(document); err != nil {
+				log.FromContext(ctx).Warn(fmt.Sprintf("change stream decode fail,collection:%s,err:%s", collection.Name(), err.Error()))
+				continue
+			}
+			event := document.toEvent(collection.Name())
+			if event == nil {
+				continue
+			}
+			select {
+			case eventChan <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			log.FromContext(ctx).Warn(fmt.Sprintf("change stream fail,collection:%s,err:%s", collection.Name(), err.Error()))
+		}
+	}()
+	return eventChan, nil
+}
+
+func documentIdString(id interface{}) string {
+	switch value := id.(type) {
+	case primitive.ObjectID:
+		return value.Hex()
+	case string:
+		return value
+	case nil:
+		return ""
+	}
+	return fmt.Sprintf("%v", id)
+}
@@ -0,0 +1,175 @@
+package diff
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// fields tagged audit:"-" are masked,e.g. Password string `bson:"password" audit:"-"`
+	MaskTagName  = "audit"
+	MaskTagValue = "-"
+	// value of the masked fields
+	MaskedValue = "******"
+)
+
+// a field that is changed,Field is the dotted bson path
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+	// the values are replaced by MaskedValue
+	Masked bool `json:"masked,omitempty"`
+}
+
+// field level changes from before to after,they can be entities or bson documents and either of them can be nil.
+// nested documents are compared field by field,arrays are compared as a whole.
+// fieldSet is used to find the masked fields,the masked fields in array elements are masked too,it can be nil
+func Compare(fieldSet *mongodbr.EntityFieldSet, before interface{}, after interface{}) ([]*FieldChange, error) {
+	beforeDoc, err := toDocument(before)
+	if err != nil {
+		return nil, err
+	}
+	afterDoc, err := toDocument(after)
+	if err != nil {
+		return nil, err
+	}
+	changeList := make([]*FieldChange, 0)
+	compareDocument(fieldSet, "", beforeDoc, afterDoc, &changeList)
+	return changeList, nil
+}
+
+func compareDocument(fieldSet *mongodbr.EntityFieldSet, prefix string, before map[string]interface{}, after map[string]interface{}, changeList *[]*FieldChange) {
+	keyList := make([]string, 0, len(before)+len(after))
+	for eachKey := range before {
+		keyList = append(keyList, eachKey)
+	}
+	for eachKey := range after {
+		if _, ok := before[eachKey]; !ok {
+			keyList = append(keyList, eachKey)
+		}
+	}
+	sort.Strings(keyList)
+
+	for _, eachKey := range keyList {
+		path := joinPath(prefix, eachKey)
+		beforeValue, beforeOk := before[eachKey]
+		afterValue, afterOk := after[eachKey]
+		if beforeOk && afterOk && reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+
+		var field *mongodbr.EntityField
+		if fieldSet != nil {
+			field, _ = fieldSet.Field(eachKey)
+		}
+		if field != nil && isMasked(field) {
+			change := &FieldChange{Field: path, Masked: true}
+			if beforeOk {
+				change.Before = MaskedValue
+			}
+			if afterOk {
+				change.After = MaskedValue
+			}
+			*changeList = append(*changeList, change)
+			continue
+		}
+
+		beforeDoc, beforeIsDoc := asDocument(beforeValue)
+		afterDoc, afterIsDoc := asDocument(afterValue)
+		if (beforeIsDoc || !beforeOk) && (afterIsDoc || !afterOk) {
+			var nestedFieldSet *mongodbr.EntityFieldSet
+			if field != nil && !field.IsArray {
+				nestedFieldSet = field.Fields
+			}
+			compareDocument(nestedFieldSet, path, beforeDoc, afterDoc, changeList)
+			continue
+		}
+		// array of documents or a document replaced by a value,the masked sub-fields are not recorded
+		var valueFieldSet *mongodbr.EntityFieldSet
+		if field != nil {
+			valueFieldSet = field.Fields
+		}
+		change := &FieldChange{Field: path}
+		if beforeOk {
+			change.Before = maskValue(valueFieldSet, beforeValue)
+		}
+		if afterOk {
+			change.After = maskValue(valueFieldSet, afterValue)
+		}
+		*changeList = append(*changeList, change)
+	}
+}
+
+// copy of a document or an array of documents with the masked fields replaced by MaskedValue,
+// other values are returned as is
+func maskValue(fieldSet *mongodbr.EntityFieldSet, v interface{}) interface{} {
+	if fieldSet == nil || v == nil {
+		return v
+	}
+	if doc, ok := asDocument(v); ok {
+		masked := make(map[string]interface{}, len(doc))
+		for key, value := range doc {
+			field, _ := fieldSet.Field(key)
+			switch {
+			case field == nil:
+				masked[key] = value
+			case isMasked(field):
+				masked[key] = MaskedValue
+			default:
+				masked[key] = maskValue(field.Fields, value)
+			}
+		}
+		return masked
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return v
+	}
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		return v
+	}
+	list := make(primitive.A, rv.Len())
+	for i := range list {
+		list[i] = maskValue(fieldSet, rv.Index(i).Interface())
+	}
+	return list
+}
+
+func isMasked(field *mongodbr.EntityField) bool {
+	return field.Tag.Get(MaskTagName) == MaskTagValue
+}
+
+func joinPath(prefix string, key string) string {
+	if len(prefix) <= 0 {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// entity or document to a bson map,nil is an empty document
+func toDocument(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return map[string]interface{}{}, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return map[string]interface{}{}, nil
+	}
+	return mongodbr.ToBsonMap(v)
+}
+
+func asDocument(v interface{}) (map[string]interface{}, bool) {
+	switch value := v.(type) {
+	case bson.M:
+		return value, true
+	case map[string]interface{}:
+		return value, true
+	case primitive.D:
+		return value.Map(), true
+	}
+	return nil, false
+}
@@ -0,0 +1,96 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type testProfile struct {
+	City  string `bson:"city"`
+	Token string `bson:"token" audit:"-"`
+}
+
+type testUser struct {
+	Name     string      `bson:"name"`
+	Age      int         `bson:"age"`
+	Password string      `bson:"password" audit:"-"`
+	Tags     []string    `bson:"tags"`
+	Profile  testProfile `bson:"profile"`
+}
+
+func TestCompare(t *testing.T) {
+	fieldSet := mongodbr.GetEntityFieldSet(testUser{})
+	before := &testUser{Name: "a", Age: 1, Password: "p1", Tags: []string{"t1"}, Profile: testProfile{City: "x", Token: "k1"}}
+	after := map[string]interface{}{
+		"name":     "a",
+		"age":      2,
+		"password": "p2",
+		"tags":     []string{"t1", "t2"},
+		"profile":  map[string]interface{}{"city": "y", "token": "k2"},
+	}
+
+	changeList, err := Compare(fieldSet, before, after)
+	assert.Nil(t, err)
+	assert.Equal(t, []*FieldChange{
+		{Field: "age", Before: int32(1), After: int32(2)},
+		{Field: "password", Before: MaskedValue, After: MaskedValue, Masked: true},
+		{Field: "profile.city", Before: "x", After: "y"},
+		{Field: "profile.token", Before: MaskedValue, After: MaskedValue, Masked: true},
+		{Field: "tags", Before: primitive.A{"t1"}, After: primitive.A{"t1", "t2"}},
+	}, changeList)
+}
+
+func TestCompareNil(t *testing.T) {
+	fieldSet := mongodbr.GetEntityFieldSet(testUser{})
+	item := &testUser{Name: "a", Password: "p1"}
+
+	changeList, err := Compare(fieldSet, nil, item)
+	assert.Nil(t, err)
+	assert.Len(t, changeList, 6)
+	for _, eachChange := range changeList {
+		assert.Nil(t, eachChange.Before)
+		if eachChange.Field == "password" {
+			assert.Equal(t, MaskedValue, eachChange.After)
+		}
+	}
+
+	var deleted *testUser
+	changeList, err = Compare(fieldSet, item, deleted)
+	assert.Nil(t, err)
+	assert.Len(t, changeList, 6)
+
+	changeList, err = Compare(fieldSet, item, item)
+	assert.Nil(t, err)
+	assert.Empty(t, changeList)
+}
+
+type testDevice struct {
+	Name   string `bson:"name"`
+	Secret string `bson:"secret" audit:"-"`
+}
+
+type testAccount struct {
+	Devices []testDevice `bson:"devices"`
+}
+
+func TestCompareMaskedArrayElement(t *testing.T) {
+	fieldSet := mongodbr.GetEntityFieldSet(testAccount{})
+	before := &testAccount{Devices: []testDevice{{Name: "a", Secret: "s1"}}}
+	after := &testAccount{Devices: []testDevice{{Name: "a", Secret: "s2"}, {Name: "b", Secret: "s3"}}}
+
+	changeList, err := Compare(fieldSet, before, after)
+	assert.Nil(t, err)
+	assert.Equal(t, []*FieldChange{
+		{
+			Field:  "devices",
+			Before: primitive.A{map[string]interface{}{"name": "a", "secret": MaskedValue}},
+			After: primitive.A{
+				map[string]interface{}{"name": "a", "secret": MaskedValue},
+				map[string]interface{}{"name": "b", "secret": MaskedValue},
+			},
+		},
+	}, changeList)
+}
@@ -1,11 +1,16 @@
 package entity
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	"github.com/shanluzhineng/fwpkg/mongodbr"
 	mongodbBuilder "github.com/shanluzhineng/fwpkg/mongodbr/builder"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type IEntityService[T mongodbr.IEntity] interface {
@@ -13,19 +18,53 @@ type IEntityService[T mongodbr.IEntity] interface {
 
 	FindAll() ([]T, error)
 	FindList(filter interface{}, opts ...mongodbr.FindOption) (list []T, err error)
+	// keyset pagination
+	FindPage(filter interface{}, page *mongodbr.KeysetPage, opts ...mongodbr.FindOption) (*mongodbr.KeysetResult[T], error)
 	Count(filter interface{}) (count int64, err error)
-	FindById(id primitive.ObjectID) (*T, error)
-	FindOne(filter interface{}) (*T, error)
+	FindById(id primitive.ObjectID, opts ...mongodbr.FindOneOption) (*T, error)
+	FindOne(filter interface{}, opts ...mongodbr.FindOneOption) (*T, error)
 
 	Create(interface{}) (*T, error)
+	// create items with one insert,an ordered insert stops at the first failed item
+	CreateMany(itemList []interface{}, ordered bool) ([]primitive.ObjectID, error)
+	// soft delete if the repository enables soft delete,otherwise hard delete
 	Delete(primitive.ObjectID) error
+	SoftDelete(id primitive.ObjectID, deleterId string) error
+	// restore a soft deleted item
+	Restore(id primitive.ObjectID) error
+	// hard delete,the item is removed even if soft delete is enabled
+	Purge(id primitive.ObjectID) error
+	// hard delete with optimistic concurrency control,return mongodbr.ErrConcurrencyConflict if version is not matched
+	PurgeWithVersion(id primitive.ObjectID, version int64) error
 	DeleteMany(interface{}) (*mongo.DeleteResult, error)
 	DeleteManyByIdList(idList []primitive.ObjectID) (*mongo.DeleteResult, error)
 	UpdateFields(id primitive.ObjectID, update map[string]interface{}) error
+	UpdateById(id primitive.ObjectID, update interface{}) error
+	ReplaceById(id primitive.ObjectID, item interface{}) error
+	// update items with one bulk write,an ordered bulk write stops at the first failed item
+	BulkUpdateById(itemList []mongodbr.BulkUpdateItem, ordered bool) (*mongo.BulkWriteResult, error)
+
+	// optimistic concurrency control,return mongodbr.ErrConcurrencyConflict if version is not matched
+	DeleteWithVersion(id primitive.ObjectID, version int64) error
+	SoftDeleteWithVersion(id primitive.ObjectID, version int64, deleterId string) error
+	UpdateByIdWithVersion(id primitive.ObjectID, version int64, update interface{}) error
+	ReplaceByIdWithVersion(id primitive.ObjectID, version int64, item interface{}) error
+
+	// a view of service that only reads and writes the items of tenant,
+	// tenantId is set on created items and can not be changed by update.
+	// the service itself is returned if T is not a tenant entity
+	WithTenant(tenantId string) IEntityService[T]
+	// empty if the service is not scoped to a tenant
+	GetTenantId() string
+	// a view of service that only reads and writes the items matched by scope
+	WithScope(scope bson.M) IEntityService[T]
+	// a view of service that runs the commands with ctx,such as the context of request
+	WithContext(ctx context.Context) IEntityService[T]
 }
 
 type EntityService[T mongodbr.IEntity] struct {
 	repository mongodbr.IRepository
+	tenantId   string
 }
 
 func NewEntityService[T mongodbr.IEntity](repository mongodbr.IRepository) IEntityService[T] {
@@ -53,19 +92,27 @@ func (s *EntityService[T]) FindList(filter interface{}, opts ...mongodbr.FindOpt
 	return mongodbr.FindTByFilter[T](s.repository, filter, opts...)
 }
 
+func (s *EntityService[T]) FindPage(filter interface{}, page *mongodbr.KeysetPage, opts ...mongodbr.FindOption) (*mongodbr.KeysetResult[T], error) {
+	return mongodbr.FindTByKeyset[T](s.repository, filter, page, opts...)
+}
+
 func (s *EntityService[T]) Count(filter interface{}) (count int64, err error) {
 	return s.repository.CountByFilter(filter)
 }
 
-func (s *EntityService[T]) FindById(id primitive.ObjectID) (*T, error) {
+func (s *EntityService[T]) FindById(id primitive.ObjectID, opts ...mongodbr.FindOneOption) (*T, error) {
+	if len(opts) > 0 {
+		return mongodbr.FindOneTByFilter[T](s.repository, bson.M{"_id": id}, opts...)
+	}
 	return mongodbr.FindTByObjectId[T](s.repository, id)
 }
 
-func (s *EntityService[T]) FindOne(filter interface{}) (*T, error) {
-	return mongodbr.FindOneTByFilter[T](s.repository, filter)
+func (s *EntityService[T]) FindOne(filter interface{}, opts ...mongodbr.FindOneOption) (*T, error) {
+	return mongodbr.FindOneTByFilter[T](s.repository, filter, opts...)
 }
 
 func (s *EntityService[T]) Create(item interface{}) (*T, error) {
+	s.stampTenant(item)
 	oid, err := s.repository.Create(item)
 
 	if err != nil {
@@ -78,7 +125,29 @@ func (s *EntityService[T]) Create(item interface{}) (*T, error) {
 	return dbItem, nil
 }
 
+func (s *EntityService[T]) CreateMany(itemList []interface{}, ordered bool) ([]primitive.ObjectID, error) {
+	for _, eachItem := range itemList {
+		s.stampTenant(eachItem)
+	}
+	return s.repository.CreateMany(itemList, options.InsertMany().SetOrdered(ordered))
+}
+
 func (s *EntityService[T]) Delete(id primitive.ObjectID) error {
+	return s.SoftDelete(id, "")
+}
+
+func (s *EntityService[T]) SoftDelete(id primitive.ObjectID, deleterId string) error {
+	if s.repository.IsSoftDeleteEnabled() {
+		return s.repository.SoftDeleteOne(id, deleterId)
+	}
+	return s.Purge(id)
+}
+
+func (s *EntityService[T]) Restore(id primitive.ObjectID) error {
+	return s.repository.Restore(id)
+}
+
+func (s *EntityService[T]) Purge(id primitive.ObjectID) error {
 	_, err := s.repository.DeleteOne(id)
 	if err != nil {
 		return err
@@ -86,7 +155,19 @@ func (s *EntityService[T]) Delete(id primitive.ObjectID) error {
 	return nil
 }
 
+func (s *EntityService[T]) PurgeWithVersion(id primitive.ObjectID, version int64) error {
+	_, err := s.repository.WithDeleted().DeleteOneWithVersion(id, version)
+	return err
+}
+
 func (s *EntityService[T]) DeleteMany(filter interface{}) (*mongo.DeleteResult, error) {
+	if s.repository.IsSoftDeleteEnabled() {
+		count, err := s.repository.SoftDeleteMany(filter, "")
+		if err != nil {
+			return nil, err
+		}
+		return &mongo.DeleteResult{DeletedCount: count}, nil
+	}
 	return s.repository.DeleteMany(filter)
 }
 
@@ -104,8 +185,157 @@ func (service *EntityService[T]) DeleteManyByIdList(idList []primitive.ObjectID)
 
 // update fields value
 func (s *EntityService[T]) UpdateFields(id primitive.ObjectID, update map[string]interface{}) error {
+	if len(s.tenantId) > 0 {
+		update = s.keepTenant(update).(bson.M)
+	}
 	value := mongodbBuilder.NewBsonBuilder().NewOrUpdateSet(update).ToValue()
 	return s.repository.FindOneAndUpdateWithId(id, value)
 }
 
+// update with a update document,update operators such as $set,$unset,$push can be used
+func (s *EntityService[T]) UpdateById(id primitive.ObjectID, update interface{}) error {
+	return s.repository.FindOneAndUpdateWithId(id, s.keepTenant(update))
+}
+
+// replace the whole document
+func (s *EntityService[T]) ReplaceById(id primitive.ObjectID, item interface{}) error {
+	s.stampTenant(item)
+	return s.repository.ReplaceById(id, item)
+}
+
+func (s *EntityService[T]) BulkUpdateById(itemList []mongodbr.BulkUpdateItem, ordered bool) (*mongo.BulkWriteResult, error) {
+	for i := range itemList {
+		if update, ok := s.keepTenant(itemList[i].Update).(bson.M); ok {
+			itemList[i].Update = update
+		}
+	}
+	return s.repository.BulkUpdateById(itemList, options.BulkWrite().SetOrdered(ordered))
+}
+
+func (s *EntityService[T]) DeleteWithVersion(id primitive.ObjectID, version int64) error {
+	return s.SoftDeleteWithVersion(id, version, "")
+}
+
+func (s *EntityService[T]) SoftDeleteWithVersion(id primitive.ObjectID, version int64, deleterId string) error {
+	if !s.repository.IsSoftDeleteEnabled() {
+		_, err := s.repository.DeleteOneWithVersion(id, version)
+		return err
+	}
+	count, err := s.repository.SoftDeleteMany(bson.M{"_id": id, mongodbr.VersionFieldName: mongodbr.VersionCondition(version)}, deleterId)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	item, err := s.FindById(id)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return mongo.ErrNoDocuments
+	}
+	return fmt.Errorf("%w,id:%s", mongodbr.ErrConcurrencyConflict, id.Hex())
+}
+
+func (s *EntityService[T]) UpdateByIdWithVersion(id primitive.ObjectID, version int64, update interface{}) error {
+	return s.repository.FindOneAndUpdateWithVersion(id, version, s.keepTenant(update))
+}
+
+func (s *EntityService[T]) ReplaceByIdWithVersion(id primitive.ObjectID, version int64, item interface{}) error {
+	s.stampTenant(item)
+	return s.repository.ReplaceByIdWithVersion(id, version, item)
+}
+
+// #endregion
+
+// #region tenant
+
+func (s *EntityService[T]) WithTenant(tenantId string) IEntityService[T] {
+	if !IsTenantEntity(new(T)) {
+		return s
+	}
+	return &EntityService[T]{
+		repository: scopeTenant(s.repository, tenantId),
+		tenantId:   tenantId,
+	}
+}
+
+func (s *EntityService[T]) GetTenantId() string {
+	return s.tenantId
+}
+
+func (s *EntityService[T]) WithScope(scope bson.M) IEntityService[T] {
+	return &EntityService[T]{
+		repository: s.repository.WithScope(scope),
+		tenantId:   s.tenantId,
+	}
+}
+
+func (s *EntityService[T]) WithContext(ctx context.Context) IEntityService[T] {
+	return &EntityService[T]{
+		repository: s.repository.WithContext(ctx),
+		tenantId:   s.tenantId,
+	}
+}
+
+func (s *EntityService[T]) stampTenant(item interface{}) {
+	if len(s.tenantId) > 0 {
+		SetItemTenantId(item, s.tenantId)
+	}
+}
+
+// the tenant of item can not be changed by update,
+// tenantId is forced in $set and removed from the other operators
+func (s *EntityService[T]) keepTenant(update interface{}) interface{} {
+	if len(s.tenantId) <= 0 {
+		return update
+	}
+	var doc map[string]interface{}
+	switch v := update.(type) {
+	case bson.M:
+		doc = v
+	case map[string]interface{}:
+		doc = v
+	default:
+		s.stampTenant(update)
+		return update
+	}
+	result := bson.M{}
+	isOperator := false
+	for key, value := range doc {
+		result[key] = value
+		isOperator = isOperator || strings.HasPrefix(key, "$")
+	}
+	if !isOperator {
+		result[TenantFieldName] = s.tenantId
+		return result
+	}
+	for key, value := range result {
+		operand, ok := value.(bson.M)
+		if !ok {
+			if m, isMap := value.(map[string]interface{}); isMap {
+				operand, ok = m, true
+			}
+		}
+		if !ok {
+			continue
+		}
+		if _, has := operand[TenantFieldName]; !has {
+			continue
+		}
+		copied := bson.M{}
+		for eachKey, eachValue := range operand {
+			copied[eachKey] = eachValue
+		}
+		if key == "$set" || key == "$setOnInsert" {
+			copied[TenantFieldName] = s.tenantId
+		} else {
+			delete(copied, TenantFieldName)
+		}
+		result[key] = copied
+	}
+	return result
+}
+
 // #endregion
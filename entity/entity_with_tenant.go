@@ -1,5 +1,18 @@
 package entity
 
+import (
+	"context"
+	"reflect"
+
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// bson field name of EntityWithTenant.TenantId
+const TenantFieldName = "tenantId"
+
+var _entityWithTenantType = reflect.TypeOf((*IEntityWithTenant)(nil)).Elem()
+
 type IEntityWithTenant interface {
 	SetTenantId(tenantId string)
 	GetTenantId() string
@@ -9,7 +22,7 @@ type EntityWithTenant struct {
 	TenantId string `json:"tenantId" bson:"tenantId"`
 }
 
-// #region IEntityWithUser Members
+// #region IEntityWithTenant Members
 
 func (t *EntityWithTenant) SetTenantId(tenantId string) {
 	t.TenantId = tenantId
@@ -28,3 +41,60 @@ func CheckEntityIsIEntityWithTenant(entityValue interface{}) IEntityWithTenant {
 	}
 	return v
 }
+
+// v is a tenant entity or a pointer to it,the items of tenant entity are isolated by tenant
+func IsTenantEntity(v interface{}) bool {
+	t := reflect.TypeOf(v)
+	for t != nil {
+		if t.Implements(_entityWithTenantType) || reflect.PtrTo(t).Implements(_entityWithTenantType) {
+			return true
+		}
+		if t.Kind() != reflect.Ptr {
+			return false
+		}
+		t = t.Elem()
+	}
+	return false
+}
+
+// set tenantId to the item,item is a IEntityWithTenant or a map
+func SetItemTenantId(item interface{}, tenantId string) {
+	switch v := item.(type) {
+	case IEntityWithTenant:
+		v.SetTenantId(tenantId)
+	case bson.M:
+		v[TenantFieldName] = tenantId
+	case map[string]interface{}:
+		v[TenantFieldName] = tenantId
+	case *map[string]interface{}:
+		if v != nil && *v != nil {
+			(*v)[TenantFieldName] = tenantId
+		}
+	}
+}
+
+// a view of repository that only reads and writes the items of tenant,
+// repository is returned if tenantId is empty
+func scopeTenant(repository mongodbr.IRepository, tenantId string) mongodbr.IRepository {
+	if len(tenantId) <= 0 {
+		return repository
+	}
+	return repository.WithScope(bson.M{TenantFieldName: tenantId})
+}
+
+// #region tenant context
+
+type tenantContextKey struct{}
+
+// a copy of ctx that carries the tenant of current request
+func ContextWithTenantId(ctx context.Context, tenantId string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantId)
+}
+
+// tenant of current request,empty if it is not resolved
+func TenantIdFromContext(ctx context.Context) string {
+	tenantId, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenantId
+}
+
+// #endregion
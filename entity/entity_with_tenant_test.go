@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type testTenantEntity struct {
+	mongodbr.Entity  `bson:",inline"`
+	EntityWithTenant `bson:",inline"`
+	Name             string `json:"name" bson:"name"`
+}
+
+func TestTenant(t *testing.T) {
+	assert.True(t, IsTenantEntity(new(*testTenantEntity)))
+	assert.False(t, IsTenantEntity(&testImportEntity{}))
+
+	item := &testTenantEntity{}
+	SetItemTenantId(item, "t1")
+	assert.Equal(t, "t1", item.TenantId)
+	doc := bson.M{"name": "a"}
+	SetItemTenantId(doc, "t1")
+	assert.Equal(t, bson.M{"name": "a", TenantFieldName: "t1"}, doc)
+
+	ctx := ContextWithTenantId(context.Background(), "t1")
+	assert.Equal(t, "t1", TenantIdFromContext(ctx))
+	assert.Equal(t, "", TenantIdFromContext(context.Background()))
+}
+
+func TestKeepTenant(t *testing.T) {
+	s := &EntityService[*testTenantEntity]{}
+	update := bson.M{"$set": bson.M{TenantFieldName: "t2"}}
+	assert.Equal(t, update, s.keepTenant(update))
+
+	s.tenantId = "t1"
+	assert.Equal(t, bson.M{"name": "a", TenantFieldName: "t1"}, s.keepTenant(map[string]interface{}{"name": "a", TenantFieldName: "t2"}))
+	assert.Equal(t, bson.M{
+		"$set":   bson.M{"name": "a", TenantFieldName: "t1"},
+		"$unset": bson.M{"code": ""},
+	}, s.keepTenant(bson.M{
+		"$set":   bson.M{"name": "a", TenantFieldName: "t2"},
+		"$unset": bson.M{"code": "", TenantFieldName: ""},
+	}))
+	// the update document of caller is not changed
+	assert.Equal(t, bson.M{"$set": bson.M{TenantFieldName: "t2"}}, update)
+	assert.Equal(t, bson.M{"$inc": bson.M{"n": 1}}, s.keepTenant(bson.M{"$inc": bson.M{"n": 1}}))
+}
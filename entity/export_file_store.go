@@ -0,0 +1,157 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrExportFileNotFound = errors.New("export file not found")
+
+// storage of export files,the files must be readable by all replicas that serve the downloads
+type IExportFileStore interface {
+	// the file is readable after the writer is closed
+	Create(fileName string) (io.WriteCloser, error)
+	// return ErrExportFileNotFound if the file does not exist
+	Open(fileName string) (io.ReadCloser, error)
+	// delete the files that are created before the time
+	DeleteExpired(before time.Time) error
+}
+
+// #region local store
+
+type localExportFileStore struct {
+	dir string
+}
+
+// files are saved in dir,default is _temp/export in working directory.
+// the dir must be shared if the files are downloaded from multiple replicas
+func NewLocalExportFileStore(dir string) IExportFileStore {
+	return &localExportFileStore{
+		dir: dir,
+	}
+}
+
+func (s *localExportFileStore) Create(fileName string) (io.WriteCloser, error) {
+	dir, err := s.getDir()
+	if err != nil {
+		return nil, err
+	}
+	return os.Create(path.Join(dir, fileName))
+}
+
+func (s *localExportFileStore) Open(fileName string) (io.ReadCloser, error) {
+	dir, err := s.getDir()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path.Join(dir, fileName))
+	if os.IsNotExist(err) {
+		return nil, ErrExportFileNotFound
+	}
+	return file, err
+}
+
+func (s *localExportFileStore) DeleteExpired(before time.Time) error {
+	dir, err := s.getDir()
+	if err != nil {
+		return err
+	}
+	entryList, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, eachEntry := range entryList {
+		if eachEntry.IsDir() {
+			continue
+		}
+		info, err := eachEntry.Info()
+		if err != nil || !info.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(path.Join(dir, eachEntry.Name())); err != nil && !os.IsNotExist(err) {
+			log.Logger.Warn(fmt.Sprintf("remove expired export file error,file:%s,err:%s", eachEntry.Name(), err.Error()))
+		}
+	}
+	return nil
+}
+
+func (s *localExportFileStore) getDir() (string, error) {
+	dir := s.dir
+	if len(dir) <= 0 {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		dir = path.Join(wd, "_temp", "export")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// #endregion
+
+// #region gridfs store
+
+type gridFSExportFileStore struct {
+	bucket *gridfs.Bucket
+}
+
+// files are saved in the gridfs bucket of database,so they are shared by replicas
+func NewGridFSExportFileStore(database *mongo.Database, bucketName string) (IExportFileStore, error) {
+	bucket, err := gridfs.NewBucket(database, options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		return nil, err
+	}
+	return &gridFSExportFileStore{
+		bucket: bucket,
+	}, nil
+}
+
+func (s *gridFSExportFileStore) Create(fileName string) (io.WriteCloser, error) {
+	return s.bucket.OpenUploadStream(fileName)
+}
+
+func (s *gridFSExportFileStore) Open(fileName string) (io.ReadCloser, error) {
+	stream, err := s.bucket.OpenDownloadStreamByName(fileName)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, ErrExportFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (s *gridFSExportFileStore) DeleteExpired(before time.Time) error {
+	cursor, err := s.bucket.Find(bson.M{"uploadDate": bson.M{"$lt": before}})
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var file gridfs.File
+		if err := cursor.Decode(&file); err != nil {
+			return err
+		}
+		if err := s.bucket.Delete(file.ID); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			log.Logger.Warn(fmt.Sprintf("remove expired export file error,file:%s,err:%s", file.Name, err.Error()))
+		}
+	}
+	return cursor.Err()
+}
+
+// #endregion
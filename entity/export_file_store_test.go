@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalExportFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocalExportFileStore(dir)
+
+	_, err := store.Open("a.csv")
+	assert.ErrorIs(t, err, ErrExportFileNotFound)
+
+	w, err := store.Create("a.csv")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("name\nfoo\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	r, err := store.Open("a.csv")
+	assert.NoError(t, err)
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, "name\nfoo\n", string(data))
+
+	// only the files created before the time are removed
+	old := time.Now().Add(-time.Hour * 2)
+	assert.NoError(t, os.Chtimes(path.Join(dir, "a.csv"), old, old))
+	w, err = store.Create("b.csv")
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.NoError(t, store.DeleteExpired(time.Now().Add(-time.Hour)))
+	_, err = store.Open("a.csv")
+	assert.ErrorIs(t, err, ErrExportFileNotFound)
+	r, err = store.Open("b.csv")
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+}
@@ -2,37 +2,39 @@ package entity
 
 import (
 	"context"
-	"encoding/csv"
 	"errors"
 	"fmt"
-	"os"
-	"path"
-	"strconv"
+	"io"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/ReneKroon/ttlcache"
-	tuple "github.com/barweiss/go-tuple"
 	uuid "github.com/satori/go.uuid"
 	"github.com/shanluzhineng/fwpkg/mongodbr"
 	"github.com/shanluzhineng/fwpkg/system/lang"
 	"github.com/shanluzhineng/fwpkg/system/log"
 	jsonUtil "github.com/shanluzhineng/fwpkg/utils/json"
-	"github.com/shanluzhineng/fwpkg/utils/slicex"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type ExportOptions struct {
-	Type   string      `json:"type"`
-	Target string      `json:"target"`
-	Filter interface{} `json:"filter"`
-	Async  bool        `json:"async"`
-
-	GetFieldNameFunc  func(entity interface{}, name string) string `json:"-"`
-	Skip              int                                          `json:"skip"`
-	FieldNameList     []string                                     `json:"fieldNameList"`
-	FieldNameTitleMap map[string]string                            `json:"fieldNameTitleMap"`
+	Type   string      `json:"type" bson:"type"`
+	Target string      `json:"target" bson:"target"`
+	Filter interface{} `json:"filter" bson:"-"`
+	Sort   bson.D      `json:"sort" bson:"-"`
+	Async  bool        `json:"async" bson:"async"`
+	// user who starts the export
+	CreatorId string `json:"creatorId" bson:"creatorId"`
+	// only the items of tenant are exported if it is not empty
+	TenantId string `json:"tenantId" bson:"tenantId"`
+
+	GetFieldNameFunc  func(entity interface{}, name string) string `json:"-" bson:"-"`
+	Skip              int                                          `json:"skip" bson:"skip"`
+	FieldNameList     []string                                     `json:"fieldNameList" bson:"fieldNameList"`
+	FieldNameTitleMap map[string]string                            `json:"fieldNameTitleMap" bson:"fieldNameTitleMap"`
+	// fields that are not exported,cannot be used with FieldNameList
+	ExcludeFieldNameList []string `json:"excludeFieldNameList" bson:"excludeFieldNameList"`
 }
 
 const (
@@ -42,63 +44,241 @@ const (
 )
 
 type EntityExport struct {
-	ExportOptions
-
-	Id           string     `json:"id"`
-	Status       string     `json:"status"`
-	StartTime    time.Time  `json:"startTime"`
-	EndTime      *time.Time `json:"endTime"`
-	FileName     string     `json:"fileName"`
-	DownloadPath string     `json:"-"`
-	Limit        int        `json:"-"`
+	ExportOptions `bson:",inline"`
+
+	Id        string     `json:"id" bson:"_id"`
+	Status    string     `json:"status" bson:"status"`
+	StartTime time.Time  `json:"startTime" bson:"startTime"`
+	EndTime   *time.Time `json:"endTime" bson:"endTime"`
+	// updated when the progress is saved,running exports that are not updated for a long time are failed
+	UpdateTime time.Time `json:"updateTime" bson:"updateTime"`
+	// the export file and record are removed after expire time
+	ExpireTime time.Time `json:"expireTime" bson:"expireTime"`
+	FileName   string    `json:"fileName" bson:"fileName"`
+	// rows written to file
+	RowCount int64 `json:"rowCount" bson:"rowCount"`
+	// total rows matched by filter when the export starts
+	Total int64  `json:"total" bson:"total"`
+	Error string `json:"error,omitempty" bson:"error,omitempty"`
+	Limit int    `json:"-" bson:"-"`
+
+	projection *mongodbr.Projection
+	exporter   IExporter
 }
 
+// the fields of export options are invalid
+var ErrInvalidExportOptions = errors.New("invalid export options")
+
 type IEntityExportService[TEntity mongodbr.IEntity] interface {
 	GetRepository() mongodbr.IRepository
+	// store of export records,services sharing a store need only one cleanup
+	GetStore() IExportStore
 
+	// export with the exporter of options.Type,csv is used if type is empty
+	Export(options ExportOptions) (exportId string, err error)
 	ExportToCSV(options ExportOptions) (exportId string, err error)
 	GetExport(exportId string) (*EntityExport, error)
+	// open the file of a finished export,return ErrExportFileNotFound if the file is removed
+	OpenFile(export *EntityExport) (io.ReadCloser, error)
+	// remove expired export files and records
+	CleanupExpired() error
+	// cleanup periodically until ctx is done
+	RunCleanup(ctx context.Context, interval time.Duration)
 }
 
+const (
+	DefaultExportExpiration = time.Hour
+	// running exports that are not updated in the timeout are failed
+	DefaultExportStaleTimeout = time.Minute * 5
+	// collection of export records and gridfs bucket of export files
+	DefaultExportCollectionName = "entity_export"
+	DefaultExportBucketName     = "entity_export"
+	// export record is saved every n rows or interval to report progress
+	exportProgressRowCount  = 1000
+	exportHeartbeatInterval = time.Second * 30
+)
+
 type EntityExportService[T mongodbr.IEntity] struct {
 	repository mongodbr.IRepository
 
-	cache *ttlcache.Cache
+	store        IExportStore
+	fileStore    IExportFileStore
+	expiration   time.Duration
+	staleTimeout time.Duration
 }
 
-func NewEntityExportService[T mongodbr.IEntity](repository mongodbr.IRepository) IEntityExportService[T] {
-	s := &EntityExportService[T]{
-		repository: repository,
-		cache:      ttlcache.NewCache(),
+type EntityExportServiceOption func(*exportServiceOptions)
+
+type exportServiceOptions struct {
+	store        IExportStore
+	fileStore    IExportFileStore
+	expiration   time.Duration
+	staleTimeout time.Duration
+}
+
+// store of export records,records are saved in the entity_export collection of entity database if not set
+func EntityExportServiceWithStore(store IExportStore) EntityExportServiceOption {
+	return func(o *exportServiceOptions) {
+		o.store = store
+	}
+}
+
+// store of export files,files are saved in the entity_export gridfs bucket of entity database if not set
+func EntityExportServiceWithFileStore(fileStore IExportFileStore) EntityExportServiceOption {
+	return func(o *exportServiceOptions) {
+		o.fileStore = fileStore
+	}
+}
+
+// save export files in a local directory,
+// use a shared directory if the export files are downloaded from multiple replicas
+func EntityExportServiceWithExportDir(dir string) EntityExportServiceOption {
+	return func(o *exportServiceOptions) {
+		o.fileStore = NewLocalExportFileStore(dir)
+	}
+}
+
+func EntityExportServiceWithExpiration(expiration time.Duration) EntityExportServiceOption {
+	return func(o *exportServiceOptions) {
+		o.expiration = expiration
 	}
-	s.cache.SetTTL(time.Minute * 5)
+}
 
+// running exports that are not updated in the timeout are failed,default is 5 minutes.
+// the timeout must be longer than the heartbeat interval of 30 seconds
+func EntityExportServiceWithStaleTimeout(timeout time.Duration) EntityExportServiceOption {
+	return func(o *exportServiceOptions) {
+		o.staleTimeout = timeout
+	}
+}
+
+// records and files are saved in the database of repository by default,
+// running exports left by a stopped process are failed when the service is created
+func NewEntityExportService[T mongodbr.IEntity](repository mongodbr.IRepository, opts ...EntityExportServiceOption) IEntityExportService[T] {
+	o := &exportServiceOptions{
+		expiration:   DefaultExportExpiration,
+		staleTimeout: DefaultExportStaleTimeout,
+	}
+	for _, eachOpt := range opts {
+		eachOpt(o)
+	}
+	var database *mongo.Database
+	if repository != nil && repository.GetCollection() != nil {
+		database = repository.GetCollection().Database()
+	}
+	if o.store == nil {
+		o.store = newDefaultExportStore(database, o.expiration)
+	}
+	if o.fileStore == nil {
+		o.fileStore = newDefaultExportFileStore(database)
+	}
+	s := &EntityExportService[T]{
+		repository:   repository,
+		store:        o.store,
+		fileStore:    o.fileStore,
+		expiration:   o.expiration,
+		staleTimeout: o.staleTimeout,
+	}
+	s.failStale()
 	return s
 }
 
+// key of the default stores of a database
+type databaseKey struct {
+	client *mongo.Client
+	name   string
+}
+
+// default export stores of each database,the services of a database share the store
+var _defaultExportStores sync.Map
+
+// records are kept in memory if there is no database
+func newDefaultExportStore(database *mongo.Database, expiration time.Duration) IExportStore {
+	if database == nil {
+		return NewMemoryExportStore(expiration)
+	}
+	key := databaseKey{client: database.Client(), name: database.Name()}
+	if store, ok := _defaultExportStores.Load(key); ok {
+		return store.(IExportStore)
+	}
+	repository, err := mongodbr.NewRepositoryBase(func() *mongo.Collection {
+		return database.Collection(DefaultExportCollectionName)
+	})
+	if err != nil {
+		log.Logger.Warn(fmt.Sprintf("create export store error,records are kept in memory,err:%s", err.Error()))
+		return NewMemoryExportStore(expiration)
+	}
+	store, _ := _defaultExportStores.LoadOrStore(key, NewMongoExportStore(repository))
+	return store.(IExportStore)
+}
+
+// files are saved in local directory if there is no database
+func newDefaultExportFileStore(database *mongo.Database) IExportFileStore {
+	if database == nil {
+		return NewLocalExportFileStore("")
+	}
+	fileStore, err := NewGridFSExportFileStore(database, DefaultExportBucketName)
+	if err != nil {
+		log.Logger.Warn(fmt.Sprintf("create export file store error,files are saved in local directory,err:%s", err.Error()))
+		return NewLocalExportFileStore("")
+	}
+	return fileStore
+}
+
 func (s *EntityExportService[T]) GetRepository() mongodbr.IRepository {
 	return s.repository
 }
 
+func (s *EntityExportService[T]) GetStore() IExportStore {
+	return s.store
+}
+
 func (s *EntityExportService[T]) ExportToCSV(options ExportOptions) (exportId string, err error) {
+	options.Type = ExportType_CSV
+	return s.Export(options)
+}
+
+func (s *EntityExportService[T]) Export(options ExportOptions) (exportId string, err error) {
+	exporter, err := NewExporter(options.Type)
+	if err != nil {
+		return "", err
+	}
+	if len(options.Type) <= 0 {
+		options.Type = exporter.Extension()
+	}
+	projection, err := s.getProjection(&options)
+	if err != nil {
+		return "", fmt.Errorf("%w,%s", ErrInvalidExportOptions, err.Error())
+	}
+	total, err := scopeTenant(s.repository, options.TenantId).CountByFilter(options.Filter)
+	if err != nil {
+		return "", err
+	}
 	exportId = s.generateId()
+	now := time.Now()
+	fileName := s.getFileName(exportId, exporter.Extension())
 	entityExport := &EntityExport{
 		Id:            exportId,
 		ExportOptions: options,
 		Status:        ExportStatus_Running,
-		StartTime:     time.Now(),
-		FileName:      s.getFileName(exportId),
-		DownloadPath:  s.getDownloadPath(exportId),
+		StartTime:     now,
+		UpdateTime:    now,
+		ExpireTime:    now.Add(s.expiration),
+		FileName:      fileName,
+		Total:         total,
+		projection:    projection,
+		exporter:      exporter,
 	}
 
-	s.cache.Set(exportId, entityExport)
+	if err := s.store.Save(entityExport); err != nil {
+		return "", err
+	}
 	if options.Async {
 		//new threading to start export
 		go func() {
 			defer func() {
 				if p := recover(); p != nil {
-					msg := fmt.Sprint(p)
-					log.Logger.Error(msg)
+					s.fail(entityExport, fmt.Errorf("export panic,%v", p))
 				}
 			}()
 			s.export(entityExport)
@@ -111,110 +291,145 @@ func (s *EntityExportService[T]) ExportToCSV(options ExportOptions) (exportId st
 }
 
 func (s *EntityExportService[T]) GetExport(exportId string) (*EntityExport, error) {
-	res, ok := s.cache.Get(exportId)
-	if !ok {
-		return nil, errors.New("export not found")
+	return s.store.Get(exportId)
+}
+
+func (s *EntityExportService[T]) OpenFile(export *EntityExport) (io.ReadCloser, error) {
+	return s.fileStore.Open(export.FileName)
+}
+
+func (s *EntityExportService[T]) CleanupExpired() error {
+	now := time.Now()
+	s.failStale()
+	if err := s.store.DeleteExpired(now); err != nil {
+		return err
+	}
+	return s.fileStore.DeleteExpired(now.Add(-s.expiration))
+}
+
+func (s *EntityExportService[T]) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.CleanupExpired(); err != nil {
+				log.Logger.Warn(fmt.Sprintf("cleanup expired export error,err:%s", err.Error()))
+			}
+		}
 	}
-	export := res.(*EntityExport)
-	return export, nil
+}
+
+// fail the running exports that are not updated in stale timeout
+func (s *EntityExportService[T]) failStale() {
+	if s.staleTimeout <= 0 {
+		return
+	}
+	if err := s.store.FailStale(time.Now().Add(-s.staleTimeout), "export is interrupted"); err != nil {
+		log.Logger.Warn(fmt.Sprintf("fail stale export error,err:%s", err.Error()))
+	}
+}
+
+// save the export record,the export is not interrupted by store error
+func (s *EntityExportService[T]) save(export *EntityExport) {
+	export.UpdateTime = time.Now()
+	if err := s.store.Save(export); err != nil {
+		log.Logger.Warn(fmt.Sprintf("save export error (id: %s),err: %s", export.Id, err.Error()))
+	}
+}
+
+func (s *EntityExportService[T]) fail(export *EntityExport, err error) {
+	export.Status = ExportStatus_Error
+	export.Error = err.Error()
+	export.EndTime = lang.NowToPtr()
+	log.Logger.Error(fmt.Sprintf("export error (id: %s),err: %s", export.Id, err.Error()))
+	s.save(export)
+}
+
+func (s *EntityExportService[T]) finish(export *EntityExport) {
+	export.Status = ExportStatus_Finished
+	export.EndTime = lang.NowToPtr()
+	log.Logger.Debug(fmt.Sprintf("export finished (id: %s)", export.Id))
+	s.save(export)
 }
 
 func (s *EntityExportService[T]) export(export *EntityExport) {
-	cursor := s.repository.FindByFilter(export.Filter).GetCursor()
-
-	//csv writer
-	csvWriter, csvFile, err := s.getCsvWriter(export)
-	defer func() {
-		csvWriter.Flush()
-		_ = csvFile.Close()
-	}()
+	findOptions := []mongodbr.FindOption{mongodbr.FindOptionWithProjection(export.projection)}
+	if len(export.Sort) > 0 {
+		findOptions = append(findOptions, mongodbr.FindOptionWithSort(export.Sort))
+	}
+	findResult := scopeTenant(s.repository, export.TenantId).FindByFilter(export.Filter, findOptions...)
+	if err := findResult.GetError(); err != nil {
+		s.fail(export, err)
+		return
+	}
+	cursor := findResult.GetCursor()
+
+	file, err := s.fileStore.Create(export.FileName)
 	if err != nil {
-		export.Status = ExportStatus_Error
-		export.EndTime = lang.NowToPtr()
-		log.Logger.Error(fmt.Sprintf("export error (id: %s),err:%s", export.Id, err.Error()))
-		s.cache.Set(export.Id, export)
+		s.fail(export, err)
 		return
 	}
+	defer file.Close()
 
 	//write header
 	columns, err := s.mapColumns(export)
 	if err != nil {
-		export.Status = ExportStatus_Error
-		export.EndTime = lang.NowToPtr()
-		log.Logger.Error(fmt.Sprintf("export error (id: %s),err:%s", export.Id, err.Error()))
-		s.cache.Set(export.Id, export)
+		s.fail(export, err)
 		return
 	}
-	columnTitle := slicex.ToSliceV[tuple.T2[string, string], string](columns, func(item tuple.T2[string, string]) string {
-		return item.V2
-	})
-	err = csvWriter.Write(columnTitle)
-	if err != nil {
-		export.Status = ExportStatus_Error
-		export.EndTime = lang.NowToPtr()
-		log.Logger.Error(fmt.Sprintf("export error (id: %s),err: %s", export.Id, err.Error()))
-		s.cache.Set(export.Id, export)
+	exporter := export.exporter
+	if err := exporter.Begin(file, columns); err != nil {
+		s.fail(export, err)
 		return
 	}
-	csvWriter.Flush()
 
 	i := 0
-	for {
+	for cursor.Next(context.Background()) {
 		i++
 
-		err := cursor.Err()
-		if err != nil {
-			if err != mongo.ErrNoDocuments {
-				export.Status = ExportStatus_Error
-				export.EndTime = lang.NowToPtr()
-				log.Logger.Error(fmt.Sprintf("export error (id: %s),err: %s", export.Id, err.Error()))
-			} else {
-				export.Status = ExportStatus_Finished
-				export.EndTime = lang.NowToPtr()
-				log.Logger.Debug(fmt.Sprintf("export finished (id: %s)", export.Id))
-			}
-			s.cache.Set(export.Id, export)
-			return
-		}
-
-		//has data
-		if !cursor.Next(context.Background()) {
-			export.Status = ExportStatus_Finished
-			export.EndTime = lang.NowToPtr()
-			log.Logger.Debug(fmt.Sprintf("export finished (id: %s)", export.Id))
-			s.cache.Set(export.Id, export)
-			return
-		}
-
 		entityItem := new(T)
 		err = cursor.Decode(entityItem)
 		if err != nil {
-			export.Status = ExportStatus_Error
-			export.EndTime = lang.NowToPtr()
-			log.Logger.Error(fmt.Sprintf("export error (id: %s),err: %s", export.Id, err.Error()))
-			s.cache.Set(export.Id, export)
+			s.fail(export, err)
 			return
 		}
-
-		columnNameList := slicex.ToSliceV[tuple.T2[string, string], string](columns, func(item tuple.T2[string, string]) string {
-			return item.V1
-		})
-		cells := s.getRowCells(columnNameList, entityItem, &export.ExportOptions)
-		err = csvWriter.Write(cells)
+		err = exporter.WriteRow(s.getRowValues(columns, entityItem, &export.ExportOptions))
 		if err != nil {
-			export.Status = ExportStatus_Error
-			export.EndTime = lang.NowToPtr()
-			log.Logger.Error(fmt.Sprintf("export error (id: %s),err: %s", export.Id, err.Error()))
-			s.cache.Set(export.Id, export)
+			s.fail(export, err)
 			return
 		}
+		export.RowCount++
 
 		//flush
 		if export.Limit > 0 && i >= export.Limit {
-			csvWriter.Flush()
+			if err := exporter.Flush(); err != nil {
+				s.fail(export, err)
+				return
+			}
 			i = 0
 		}
+		//report progress,the update time is also the heartbeat of running export
+		if export.RowCount%exportProgressRowCount == 0 || time.Since(export.UpdateTime) >= exportHeartbeatInterval {
+			s.save(export)
+		}
+	}
+	if err := cursor.Err(); err != nil && err != mongo.ErrNoDocuments {
+		s.fail(export, err)
+		return
 	}
+	if err := exporter.End(); err != nil {
+		s.fail(export, err)
+		return
+	}
+	// the file is readable after it is closed
+	if err := file.Close(); err != nil {
+		s.fail(export, err)
+		return
+	}
+	s.finish(export)
 }
 
 func (s *EntityExportService[T]) generateId() string {
@@ -222,52 +437,22 @@ func (s *EntityExportService[T]) generateId() string {
 	return exportId
 }
 
-func (s *EntityExportService[T]) getExportDir() (dir string, err error) {
-	wd, err := os.Getwd()
-	if err != nil {
-		return "", err
-	}
-	exportDir := path.Join(wd, "_temp", "export", "csv")
-	if !s.exists(exportDir) {
-		err := os.MkdirAll(exportDir, 0755)
-		if err != nil {
-			return "", err
-		}
-	}
-	return exportDir, nil
-}
-
-func (svc *EntityExportService[T]) getFileName(exportId string) (fileName string) {
-	return exportId + "_" + time.Now().Format("20060102150405") + ".csv"
-}
-
-// getDownloadPath returns the download path for the export
-// format: /wd/<tempDir>/export/<exportId>/<exportId>_<timestamp>.csv
-func (s *EntityExportService[T]) getDownloadPath(exportId string) (downloadPath string) {
-	exportDir, err := s.getExportDir()
-	if err != nil {
-		return ""
-	}
-	downloadPath = path.Join(exportDir, s.getFileName(exportId))
-	return downloadPath
+func (svc *EntityExportService[T]) getFileName(exportId string, extension string) (fileName string) {
+	return exportId + "_" + time.Now().Format("20060102150405") + "." + extension
 }
 
-func (s *EntityExportService[T]) getCsvWriter(export *EntityExport) (csvWriter *csv.Writer, csvFile *os.File, err error) {
-	// open file
-	csvFile, err = os.Create(export.DownloadPath)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	// create csv writer
-	csvWriter = csv.NewWriter(csvFile)
-
-	return csvWriter, csvFile, nil
-}
-
-func (s *EntityExportService[T]) mapColumns(export *EntityExport) (columns []tuple.T2[string, string], err error) {
+// columns of export file,document fields are flattened to dotted columns
+func (s *EntityExportService[T]) mapColumns(export *EntityExport) (columns []ExportColumn, err error) {
+	fieldSet := mongodbr.GetEntityFieldSet(new(T))
 	if len(export.FieldNameList) > 0 {
-		for _, eachColumn := range export.FieldNameList {
+		for index, eachColumn := range export.FieldNameList {
+			columnName := eachColumn
+			//field path can be a bson name,cell value is read by json name
+			if !export.projection.IsEmpty() && !export.projection.IsExclude() {
+				if jsonPath := export.projection.FieldPathList()[index].JsonPath; len(jsonPath) > 0 {
+					columnName = jsonPath
+				}
+			}
 			columnTitle := eachColumn
 			if export.FieldNameTitleMap != nil && len(export.FieldNameTitleMap) > 0 {
 				columnTitleMap, ok := export.FieldNameTitleMap[eachColumn]
@@ -275,82 +460,109 @@ func (s *EntityExportService[T]) mapColumns(export *EntityExport) (columns []tup
 					columnTitle = columnTitleMap
 				}
 			}
-			columns = append(columns, tuple.New2(eachColumn, columnTitle))
+			column := ExportColumn{Path: columnName, Title: columnTitle}
+			//field name is virtual when GetFieldNameFunc is set
+			if export.GetFieldNameFunc != nil {
+				columns = append(columns, column)
+				continue
+			}
+			if fieldPath, err := fieldSet.ResolveFieldPath(columnName); err == nil {
+				column.Type = fieldPath.Type
+			}
+			columns = append(columns, flattenExportColumn(column, fieldSet, export.FieldNameTitleMap)...)
 		}
 		return columns, nil
 	}
 
-	var data []bson.M
-	if err := s.repository.FindByFilter(export.Filter, mongodbr.FindOptionWithLimit(10)).All(&data); err != nil {
+	var data []T
+	if err := scopeTenant(s.repository, export.TenantId).FindByFilter(export.Filter, mongodbr.FindOptionWithLimit(10),
+		mongodbr.FindOptionWithProjection(export.projection)).All(&data); err != nil {
 		return nil, err
 	}
 
 	// columns set
-	columnsSet := make(map[string]bool)
-	for _, d := range data {
-		for k := range d {
-			columnsSet[k] = true
-		}
+	pathList := make([]string, 0)
+	seen := make(map[string]bool)
+	for index := range data {
+		var doc map[string]interface{}
+		jsonUtil.ConvertObjectTo(data[index], &doc)
+		collectJsonLeafPaths("", doc, seen, &pathList)
 	}
 
 	// columns
-	columns = make([]tuple.T2[string, string], 0)
-	for k := range columnsSet {
-		columns = append(columns, tuple.New2(k, k))
+	columns = make([]ExportColumn, 0, len(pathList))
+	for _, eachPath := range pathList {
+		column := ExportColumn{Path: eachPath, Title: eachPath}
+		if title, ok := export.FieldNameTitleMap[eachPath]; ok {
+			column.Title = title
+		}
+		if fieldPath, err := fieldSet.ResolveFieldPath(eachPath); err == nil {
+			column.Type = fieldPath.Type
+		}
+		columns = append(columns, column)
 	}
-
 	return columns, nil
 }
 
-func (s *EntityExportService[T]) getRowCells(columns []string, entityItem *T, options *ExportOptions) []string {
-	var cells []string
+// typed values of a row,values are string if GetFieldNameFunc is set
+func (s *EntityExportService[T]) getRowValues(columns []ExportColumn, entityItem *T, options *ExportOptions) []interface{} {
+	values := make([]interface{}, 0, len(columns))
 	var data bson.M
 	if options.GetFieldNameFunc == nil {
 		jsonUtil.ConvertObjectTo(entityItem, &data)
 	}
 	for _, c := range columns {
 		if options.GetFieldNameFunc != nil {
-			cellValue := options.GetFieldNameFunc(entityItem, c)
-			cells = append(cells, cellValue)
-		} else {
-			v, ok := data[c]
-			if !ok {
-				cells = append(cells, "")
-				continue
-			}
-			switch v.(type) {
-			case string:
-				cells = append(cells, v.(string))
-			case time.Time:
-				cells = append(cells, v.(time.Time).Format("2006-01-02 15:04:05"))
-			case int:
-				cells = append(cells, strconv.Itoa(v.(int)))
-			case int32:
-				cells = append(cells, strconv.Itoa(int(v.(int32))))
-			case int64:
-				cells = append(cells, strconv.FormatInt(v.(int64), 10))
-			case float32:
-				cells = append(cells, strconv.FormatFloat(float64(v.(float32)), 'f', -1, 32))
-			case float64:
-				cells = append(cells, strconv.FormatFloat(v.(float64), 'f', -1, 64))
-			case bool:
-				cells = append(cells, strconv.FormatBool(v.(bool)))
-			case primitive.ObjectID:
-				cells = append(cells, v.(primitive.ObjectID).Hex())
-			case primitive.DateTime:
-				cells = append(cells, v.(primitive.DateTime).Time().Format("2006-01-02 15:04:05"))
-			default:
-				cells = append(cells, fmt.Sprintf("%v", v))
-			}
+			values = append(values, options.GetFieldNameFunc(entityItem, c.Path))
+			continue
+		}
+		v, ok := lookupCellValue(data, c.Path)
+		if !ok {
+			values = append(values, nil)
+			continue
 		}
+		values = append(values, toExportCellValue(v, c.Type))
 	}
-	return cells
+	return values
 }
 
-func (svc *EntityExportService[T]) exists(path string) bool {
-	_, err := os.Stat(path)
+// projection of exported fields,the fields are validated against entity.
+// when GetFieldNameFunc is set,field names can be virtual and no projection is used
+func (s *EntityExportService[T]) getProjection(options *ExportOptions) (*mongodbr.Projection, error) {
+	if options.GetFieldNameFunc != nil {
+		if len(options.ExcludeFieldNameList) > 0 {
+			return nil, errors.New("excludeFieldNameList cannot be used with GetFieldNameFunc")
+		}
+		return nil, nil
+	}
+	if len(options.FieldNameList) <= 0 && len(options.ExcludeFieldNameList) <= 0 {
+		return nil, nil
+	}
+	projection, err := mongodbr.NewProjection(mongodbr.GetEntityFieldSet(new(T)), options.FieldNameList, options.ExcludeFieldNameList)
 	if err != nil {
-		return os.IsExist(err)
+		return nil, err
+	}
+	if !projection.IsExclude() && len(projection.FieldPathList()) != len(options.FieldNameList) {
+		return nil, errors.New("fieldNameList contains duplicated or overlapped fields")
+	}
+	return projection, nil
+}
+
+// value of column,column can be a dotted path
+func lookupCellValue(data bson.M, column string) (interface{}, bool) {
+	if v, ok := data[column]; ok {
+		return v, true
+	}
+	var current interface{} = map[string]interface{}(data)
+	for _, eachSegment := range strings.Split(column, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[eachSegment]
+		if !ok {
+			return nil, false
+		}
 	}
-	return true
+	return current, true
 }
@@ -0,0 +1,114 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ReneKroon/ttlcache"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrExportNotFound = errors.New("export not found")
+
+// store of export records
+type IExportStore interface {
+	Save(export *EntityExport) error
+	// return ErrExportNotFound if the record does not exist
+	Get(exportId string) (*EntityExport, error)
+	// delete records that expire before the time
+	DeleteExpired(before time.Time) error
+	// mark running exports that are not updated since the time as failed,
+	// they are left by a process that has stopped
+	FailStale(before time.Time, message string) error
+}
+
+// #region memory store
+
+type memoryExportStore struct {
+	cache *ttlcache.Cache
+}
+
+// records are kept in memory for ttl,they are lost when the process restarts
+func NewMemoryExportStore(ttl time.Duration) IExportStore {
+	s := &memoryExportStore{
+		cache: ttlcache.NewCache(),
+	}
+	s.cache.SetTTL(ttl)
+	return s
+}
+
+func (s *memoryExportStore) Save(export *EntityExport) error {
+	s.cache.Set(export.Id, export)
+	return nil
+}
+
+func (s *memoryExportStore) Get(exportId string) (*EntityExport, error) {
+	res, ok := s.cache.Get(exportId)
+	if !ok {
+		return nil, ErrExportNotFound
+	}
+	return res.(*EntityExport), nil
+}
+
+// records are removed by ttl
+func (s *memoryExportStore) DeleteExpired(before time.Time) error {
+	return nil
+}
+
+// records of stopped process are lost with the memory
+func (s *memoryExportStore) FailStale(before time.Time, message string) error {
+	return nil
+}
+
+// #endregion
+
+// #region mongodb store
+
+type mongoExportStore struct {
+	repository mongodbr.IRepository
+}
+
+// records are persisted in mongodb,so the status survives restarts and is shared by replicas
+func NewMongoExportStore(repository mongodbr.IRepository) IExportStore {
+	return &mongoExportStore{
+		repository: repository,
+	}
+}
+
+func (s *mongoExportStore) Save(export *EntityExport) error {
+	return s.repository.Replace(bson.M{"_id": export.Id}, export, options.Replace().SetUpsert(true))
+}
+
+func (s *mongoExportStore) Get(exportId string) (*EntityExport, error) {
+	export := &EntityExport{}
+	err := s.repository.FindOne(bson.M{"_id": exportId}).One(export)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+	return export, nil
+}
+
+func (s *mongoExportStore) DeleteExpired(before time.Time) error {
+	_, err := s.repository.DeleteMany(bson.M{"expireTime": bson.M{"$lt": before}})
+	return err
+}
+
+func (s *mongoExportStore) FailStale(before time.Time, message string) error {
+	_, err := s.repository.UpdateMany(bson.M{
+		"status":     ExportStatus_Running,
+		"updateTime": bson.M{"$lt": before},
+	}, bson.M{"$set": bson.M{
+		"status":  ExportStatus_Error,
+		"error":   message,
+		"endTime": time.Now(),
+	}})
+	return err
+}
+
+// #endregion
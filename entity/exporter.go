@@ -0,0 +1,234 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ExportType_CSV     = "csv"
+	ExportType_XLSX    = "xlsx"
+	ExportType_NDJSON  = "ndjson"
+	ExportType_Parquet = "parquet"
+)
+
+var ErrUnsupportedExportType = errors.New("unsupported export type")
+
+// a column of export file
+type ExportColumn struct {
+	// dotted json path of the value,it is the column name of ndjson and parquet
+	Path string
+	// header of csv and xlsx
+	Title string
+	// go type of the value,nil if it is unknown
+	Type reflect.Type
+}
+
+// write the exported rows to a file,an exporter instance is used by only one export
+type IExporter interface {
+	// extension of export file,without dot
+	Extension() string
+	Begin(w io.Writer, columns []ExportColumn) error
+	// values are in the same order as columns,value can be nil,string,bool,int64,float64 or time.Time
+	WriteRow(values []interface{}) error
+	// flush the buffered rows
+	Flush() error
+	// complete the file,End is not called if the export is failed
+	End() error
+}
+
+var (
+	_exporterMapping = map[string]func() IExporter{
+		ExportType_CSV:     func() IExporter { return &csvExporter{} },
+		ExportType_XLSX:    func() IExporter { return &xlsxExporter{} },
+		ExportType_NDJSON:  func() IExporter { return &ndjsonExporter{} },
+		ExportType_Parquet: func() IExporter { return &parquetExporter{} },
+	}
+	_exporterLock sync.RWMutex
+)
+
+// regist an exporter for ExportOptions.Type,a registed exporter is replaced
+func RegistExporter(exportType string, newExporter func() IExporter) {
+	_exporterLock.Lock()
+	defer _exporterLock.Unlock()
+	_exporterMapping[strings.ToLower(exportType)] = newExporter
+}
+
+// new exporter by export type,csv is used if export type is empty
+func NewExporter(exportType string) (IExporter, error) {
+	if len(exportType) <= 0 {
+		exportType = ExportType_CSV
+	}
+	_exporterLock.RLock()
+	newExporter, ok := _exporterMapping[strings.ToLower(exportType)]
+	_exporterLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w,type:%s", ErrUnsupportedExportType, exportType)
+	}
+	return newExporter(), nil
+}
+
+// #region columns
+
+// expand a document field to its leaf fields with dotted path,
+// the title of leaf field is from titleMap or prefixed with the title of document field
+func flattenExportColumn(column ExportColumn, fieldSet *mongodbr.EntityFieldSet, titleMap map[string]string) []ExportColumn {
+	if column.Type == nil || !isExportDocumentType(column.Type) {
+		return []ExportColumn{column}
+	}
+	fieldPath, err := fieldSet.ResolveFieldPath(column.Path)
+	if err != nil || fieldPath.HasArrayIndex {
+		return []ExportColumn{column}
+	}
+	field := lastField(fieldSet, fieldPath.BsonPath)
+	if field == nil || field.Fields == nil || field.IsArray || field.IsMap {
+		return []ExportColumn{column}
+	}
+	columns := make([]ExportColumn, 0)
+	for _, eachField := range field.Fields.FieldList() {
+		if len(eachField.JsonName) <= 0 {
+			continue
+		}
+		path := column.Path + "." + eachField.JsonName
+		title, ok := titleMap[path]
+		if !ok {
+			title = column.Title + "." + eachField.JsonName
+		}
+		columns = append(columns, flattenExportColumn(ExportColumn{Path: path, Title: title, Type: eachField.Type}, fieldSet, titleMap)...)
+	}
+	return columns
+}
+
+func lastField(fieldSet *mongodbr.EntityFieldSet, bsonPath string) *mongodbr.EntityField {
+	var field *mongodbr.EntityField
+	current := fieldSet
+	for _, eachSegment := range strings.Split(bsonPath, ".") {
+		if current == nil {
+			return nil
+		}
+		f, ok := current.Field(eachSegment)
+		if !ok {
+			return nil
+		}
+		field = f
+		current = f.Fields
+	}
+	return field
+}
+
+// struct type except time,nested fields of it are exported as columns
+func isExportDocumentType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}) && t != reflect.TypeOf(primitive.ObjectID{})
+}
+
+// collect dotted leaf paths of json documents,in the order of first appearance
+func collectJsonLeafPaths(prefix string, doc map[string]interface{}, seen map[string]bool, pathList *[]string) {
+	keyList := make([]string, 0, len(doc))
+	for eachKey := range doc {
+		keyList = append(keyList, eachKey)
+	}
+	sort.Strings(keyList)
+	for _, eachKey := range keyList {
+		path := eachKey
+		if len(prefix) > 0 {
+			path = prefix + "." + eachKey
+		}
+		if nested, ok := doc[eachKey].(map[string]interface{}); ok && len(nested) > 0 {
+			collectJsonLeafPaths(path, nested, seen, pathList)
+			continue
+		}
+		if !seen[path] {
+			seen[path] = true
+			*pathList = append(*pathList, path)
+		}
+	}
+}
+
+// #endregion
+
+// #region cell value
+
+// convert a json value to the typed value of column
+func toExportCellValue(v interface{}, t reflect.Type) interface{} {
+	if v == nil {
+		return nil
+	}
+	if t != nil {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+	switch value := v.(type) {
+	case string:
+		if t == reflect.TypeOf(time.Time{}) {
+			if tm, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				return tm
+			}
+		}
+		return value
+	case float64:
+		if t != nil {
+			switch t.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				return int64(value)
+			}
+		}
+		return value
+	case bool, int64, time.Time:
+		return value
+	default:
+		// array and map are exported as json
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprintf("%v", value)
+		}
+		return string(data)
+	}
+}
+
+// format a cell value as text
+func formatExportCellValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case time.Time:
+		return value.Format("2006-01-02 15:04:05")
+	case int:
+		return strconv.Itoa(value)
+	case int32:
+		return strconv.Itoa(int(value))
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float32:
+		return strconv.FormatFloat(float64(value), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case primitive.ObjectID:
+		return value.Hex()
+	case primitive.DateTime:
+		return value.Time().Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// #endregion
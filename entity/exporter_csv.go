@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type csvExporter struct {
+	writer *csv.Writer
+}
+
+var _ IExporter = (*csvExporter)(nil)
+
+func (e *csvExporter) Extension() string {
+	return ExportType_CSV
+}
+
+func (e *csvExporter) Begin(w io.Writer, columns []ExportColumn) error {
+	e.writer = csv.NewWriter(w)
+	titleList := make([]string, 0, len(columns))
+	for _, eachColumn := range columns {
+		titleList = append(titleList, eachColumn.Title)
+	}
+	if err := e.writer.Write(titleList); err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+func (e *csvExporter) WriteRow(values []interface{}) error {
+	cells := make([]string, 0, len(values))
+	for _, eachValue := range values {
+		cells = append(cells, formatExportCellValue(eachValue))
+	}
+	return e.writer.Write(cells)
+}
+
+func (e *csvExporter) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExporter) End() error {
+	return e.Flush()
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// json lines,each row is a json object with column path as key
+type ndjsonExporter struct {
+	writer  *bufio.Writer
+	keyList [][]byte
+}
+
+var _ IExporter = (*ndjsonExporter)(nil)
+
+func (e *ndjsonExporter) Extension() string {
+	return ExportType_NDJSON
+}
+
+func (e *ndjsonExporter) Begin(w io.Writer, columns []ExportColumn) error {
+	e.writer = bufio.NewWriter(w)
+	e.keyList = make([][]byte, 0, len(columns))
+	for _, eachColumn := range columns {
+		key, err := json.Marshal(eachColumn.Path)
+		if err != nil {
+			return err
+		}
+		e.keyList = append(e.keyList, key)
+	}
+	return nil
+}
+
+// keep the column order,so the row is not encoded as a map
+func (e *ndjsonExporter) WriteRow(values []interface{}) error {
+	_ = e.writer.WriteByte('{')
+	for index, eachValue := range values {
+		if index > 0 {
+			_ = e.writer.WriteByte(',')
+		}
+		data, err := json.Marshal(eachValue)
+		if err != nil {
+			return err
+		}
+		_, _ = e.writer.Write(e.keyList[index])
+		_ = e.writer.WriteByte(':')
+		_, _ = e.writer.Write(data)
+	}
+	_, err := e.writer.WriteString("}\n")
+	return err
+}
+
+func (e *ndjsonExporter) Flush() error {
+	return e.writer.Flush()
+}
+
+func (e *ndjsonExporter) End() error {
+	return e.Flush()
+}
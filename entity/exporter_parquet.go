@@ -0,0 +1,110 @@
+package entity
+
+import (
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/shanluzhineng/fwpkg/utils/parquetx"
+)
+
+// the schema is derived from the go type of columns,column path is the field name
+type parquetExporter struct {
+	writer  *parquetx.Writer
+	columns []parquetx.Column
+}
+
+var _ IExporter = (*parquetExporter)(nil)
+
+func (e *parquetExporter) Extension() string {
+	return ExportType_Parquet
+}
+
+func (e *parquetExporter) Begin(w io.Writer, columns []ExportColumn) (err error) {
+	e.columns = make([]parquetx.Column, 0, len(columns))
+	for _, eachColumn := range columns {
+		e.columns = append(e.columns, parquetColumn(eachColumn))
+	}
+	e.writer, err = parquetx.NewWriter(w, e.columns)
+	return err
+}
+
+func (e *parquetExporter) WriteRow(values []interface{}) error {
+	row := make([]interface{}, len(values))
+	for index, eachValue := range values {
+		row[index] = parquetValue(eachValue, e.columns[index])
+	}
+	return e.writer.Write(row)
+}
+
+// rows are buffered until a row group is full
+func (e *parquetExporter) Flush() error {
+	return nil
+}
+
+func (e *parquetExporter) End() error {
+	return e.writer.Close()
+}
+
+func parquetColumn(column ExportColumn) parquetx.Column {
+	result := parquetx.Column{
+		Name:          column.Path,
+		Type:          parquetx.TypeByteArray,
+		ConvertedType: parquetx.ConvertedTypeUTF8,
+	}
+	t := column.Type
+	if t == nil {
+		return result
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		result.Type = parquetx.TypeInt64
+		result.ConvertedType = parquetx.ConvertedTypeTimestampMillis
+		return result
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		result.Type = parquetx.TypeBoolean
+		result.ConvertedType = parquetx.ConvertedTypeNone
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		result.Type = parquetx.TypeInt64
+		result.ConvertedType = parquetx.ConvertedTypeNone
+	case reflect.Float32, reflect.Float64:
+		result.Type = parquetx.TypeDouble
+		result.ConvertedType = parquetx.ConvertedTypeNone
+	}
+	return result
+}
+
+// the value must match the type of column,a value of other type is written as null
+func parquetValue(v interface{}, column parquetx.Column) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch column.Type {
+	case parquetx.TypeByteArray:
+		return formatExportCellValue(v)
+	case parquetx.TypeInt64:
+		switch x := v.(type) {
+		case int64, time.Time:
+			return x
+		case float64:
+			return int64(x)
+		}
+	case parquetx.TypeDouble:
+		switch x := v.(type) {
+		case float64:
+			return x
+		case int64:
+			return float64(x)
+		}
+	case parquetx.TypeBoolean:
+		if x, ok := v.(bool); ok {
+			return x
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package entity
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+type testExportAddress struct {
+	City   string `json:"city" bson:"city"`
+	Street string `json:"street" bson:"street"`
+}
+
+type testExportEntity struct {
+	mongodbr.Entity `bson:",inline"`
+	Name            string            `json:"name" bson:"name"`
+	Age             int               `json:"age" bson:"age"`
+	Address         testExportAddress `json:"address" bson:"address"`
+}
+
+func TestFlattenExportColumn(t *testing.T) {
+	fieldSet := mongodbr.GetEntityFieldSet(testExportEntity{})
+	column := ExportColumn{Path: "address", Title: "地址", Type: reflect.TypeOf(testExportAddress{})}
+	columns := flattenExportColumn(column, fieldSet, map[string]string{"address.city": "城市"})
+	assert.Equal(t, []ExportColumn{
+		{Path: "address.city", Title: "城市", Type: reflect.TypeOf("")},
+		{Path: "address.street", Title: "地址.street", Type: reflect.TypeOf("")},
+	}, columns)
+
+	pathList := make([]string, 0)
+	collectJsonLeafPaths("", map[string]interface{}{
+		"name":    "a",
+		"address": map[string]interface{}{"city": "x"},
+	}, map[string]bool{}, &pathList)
+	assert.Equal(t, []string{"address.city", "name"}, pathList)
+}
+
+func TestToExportCellValue(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.Equal(t, now, toExportCellValue(now.Format(time.RFC3339Nano), reflect.TypeOf(time.Time{})))
+	assert.Equal(t, int64(3), toExportCellValue(float64(3), reflect.TypeOf(0)))
+	assert.Equal(t, float64(3.5), toExportCellValue(float64(3.5), nil))
+	assert.Equal(t, `["a","b"]`, toExportCellValue([]interface{}{"a", "b"}, nil))
+	assert.Nil(t, toExportCellValue(nil, nil))
+}
+
+func TestExporter(t *testing.T) {
+	columns := []ExportColumn{
+		{Path: "name", Title: "名称", Type: reflect.TypeOf("")},
+		{Path: "age", Title: "age", Type: reflect.TypeOf(0)},
+		{Path: "creationTime", Title: "creationTime", Type: reflect.TypeOf(time.Time{})},
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rowList := [][]interface{}{
+		{"a", int64(1), now},
+		{"b", nil, nil},
+	}
+	write := func(exportType string) []byte {
+		exporter, err := NewExporter(exportType)
+		assert.Nil(t, err)
+		buf := &bytes.Buffer{}
+		assert.Nil(t, exporter.Begin(buf, columns))
+		for _, eachRow := range rowList {
+			assert.Nil(t, exporter.WriteRow(eachRow))
+		}
+		assert.Nil(t, exporter.End())
+		return buf.Bytes()
+	}
+
+	assert.Equal(t, "名称,age,creationTime\na,1,2024-01-02 03:04:05\nb,,\n", string(write(ExportType_CSV)))
+	assert.Equal(t, `{"name":"a","age":1,"creationTime":"2024-01-02T03:04:05Z"}`+"\n"+`{"name":"b","age":null,"creationTime":null}`+"\n",
+		string(write(ExportType_NDJSON)))
+
+	f, err := excelize.OpenReader(bytes.NewReader(write(ExportType_XLSX)))
+	assert.Nil(t, err)
+	rows, err := f.GetRows("Sheet1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"名称", "age", "creationTime"}, rows[0])
+	assert.Equal(t, "a", rows[1][0])
+	assert.Equal(t, "1", rows[1][1])
+
+	// a new sheet is added when the sheet is full
+	xlsx := &xlsxExporter{maxRowsSheet: 2}
+	buf := &bytes.Buffer{}
+	assert.Nil(t, xlsx.Begin(buf, columns))
+	for _, eachRow := range rowList {
+		assert.Nil(t, xlsx.WriteRow(eachRow))
+	}
+	assert.Nil(t, xlsx.End())
+	f, err = excelize.OpenReader(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Sheet1", "Sheet2"}, f.GetSheetList())
+	rows, err = f.GetRows("Sheet2")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"名称", "age", "creationTime"}, {"b"}}, rows)
+
+	parquetData := write(ExportType_Parquet)
+	assert.True(t, strings.HasPrefix(string(parquetData), "PAR1"))
+	assert.True(t, strings.HasSuffix(string(parquetData), "PAR1"))
+
+	_, err = NewExporter("pdf")
+	assert.True(t, errors.Is(err, ErrUnsupportedExportType))
+}
@@ -0,0 +1,123 @@
+package entity
+
+import (
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// rows of a sheet,header included.a new sheet is added when a sheet is full
+const xlsxMaxRowsPerSheet = 1048576
+
+type xlsxExporter struct {
+	writer  io.Writer
+	file    *excelize.File
+	stream  *excelize.StreamWriter
+	columns []ExportColumn
+
+	sheetIndex   int
+	rowIndex     int
+	headerStyle  int
+	dateStyle    int
+	maxRowsSheet int
+}
+
+var _ IExporter = (*xlsxExporter)(nil)
+
+func (e *xlsxExporter) Extension() string {
+	return ExportType_XLSX
+}
+
+func (e *xlsxExporter) Begin(w io.Writer, columns []ExportColumn) (err error) {
+	e.writer = w
+	e.columns = columns
+	if e.maxRowsSheet <= 0 {
+		e.maxRowsSheet = xlsxMaxRowsPerSheet
+	}
+	e.file = excelize.NewFile()
+	e.headerStyle, err = e.file.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9E1F2"}},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	if err != nil {
+		return err
+	}
+	e.dateStyle, err = e.file.NewStyle(&excelize.Style{NumFmt: 22})
+	if err != nil {
+		return err
+	}
+	return e.newSheet()
+}
+
+func (e *xlsxExporter) WriteRow(values []interface{}) error {
+	if e.rowIndex >= e.maxRowsSheet {
+		if err := e.stream.Flush(); err != nil {
+			return err
+		}
+		if err := e.newSheet(); err != nil {
+			return err
+		}
+	}
+	e.rowIndex++
+	row := make([]interface{}, 0, len(values))
+	for _, eachValue := range values {
+		if t, ok := eachValue.(time.Time); ok {
+			row = append(row, excelize.Cell{StyleID: e.dateStyle, Value: t})
+			continue
+		}
+		row = append(row, eachValue)
+	}
+	cell, err := excelize.CoordinatesToCellName(1, e.rowIndex)
+	if err != nil {
+		return err
+	}
+	return e.stream.SetRow(cell, row)
+}
+
+// rows are buffered by stream writer until the sheet is completed
+func (e *xlsxExporter) Flush() error {
+	return nil
+}
+
+func (e *xlsxExporter) End() error {
+	defer e.file.Close()
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := e.file.WriteTo(e.writer)
+	return err
+}
+
+// add a sheet and write the header
+func (e *xlsxExporter) newSheet() error {
+	e.sheetIndex++
+	sheetName := fmt.Sprintf("Sheet%d", e.sheetIndex)
+	if e.sheetIndex > 1 {
+		if _, err := e.file.NewSheet(sheetName); err != nil {
+			return err
+		}
+	}
+	stream, err := e.file.NewStreamWriter(sheetName)
+	if err != nil {
+		return err
+	}
+	e.stream = stream
+	e.rowIndex = 1
+
+	header := make([]interface{}, 0, len(e.columns))
+	for index, eachColumn := range e.columns {
+		header = append(header, excelize.Cell{StyleID: e.headerStyle, Value: eachColumn.Title})
+		width := float64(utf8.RuneCountInString(eachColumn.Title)) + 4
+		if width < 12 {
+			width = 12
+		}
+		if err := stream.SetColWidth(index+1, index+1, width); err != nil {
+			return err
+		}
+	}
+	return stream.SetRow("A1", header)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/shanluzhineng/fwpkg/app"
 	"github.com/shanluzhineng/fwpkg/app/web"
+	"github.com/shanluzhineng/fwpkg/system/health"
 	"github.com/shanluzhineng/fwpkg/system/log"
 
 	"github.com/shanluzhineng/configurationx"
@@ -32,7 +33,11 @@ func init() {
 }
 
 func serviceConfigurator(web web.WebApplication) {
-	app.Context.RegistInstanceAs(newGormDbManager(), new(IGormDbManager))
+	manager := newGormDbManager()
+	app.Context.RegistInstanceAs(manager, new(IGormDbManager))
+	for _, eachChecker := range manager.HealthCheckers() {
+		health.Register(eachChecker, health.WithTags(health.TagReadiness))
+	}
 }
 
 func newGormDbManager() *gormDbManager {
@@ -0,0 +1,32 @@
+package gorminit
+
+import (
+	"context"
+
+	"github.com/shanluzhineng/fwpkg/system/health"
+	"gorm.io/gorm"
+)
+
+const (
+	HealthCheckerName = "gorm"
+)
+
+// ping the database of db
+func NewHealthChecker(name string, db *gorm.DB) health.HealthChecker {
+	return health.NewCheckerFunc(name, func(ctx context.Context) error {
+		sqlDb, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDb.PingContext(ctx)
+	})
+}
+
+// checkers of the databases of the manager,named gorm:{key}
+func (m *gormDbManager) HealthCheckers() []health.HealthChecker {
+	checkerList := make([]health.HealthChecker, 0, len(m._dbMap))
+	for eachKey, eachDb := range m._dbMap {
+		checkerList = append(checkerList, NewHealthChecker(HealthCheckerName+":"+eachKey, eachDb))
+	}
+	return checkerList
+}
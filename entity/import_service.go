@@ -0,0 +1,876 @@
+package entity
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/shanluzhineng/fwpkg/entity/patch"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/shanluzhineng/fwpkg/system/lang"
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// insert new items
+	ImportMode_Insert = "insert"
+	// update the item matched by key fields,insert a new one if it is not found
+	ImportMode_Upsert = "upsert"
+)
+
+type ImportOptions struct {
+	// csv,xlsx or ndjson,it is detected from the extension of FileName if it is empty
+	Type string `json:"type" bson:"type"`
+	// insert or upsert,default is insert
+	Mode string `json:"mode" bson:"mode"`
+	// validate rows only,nothing is written
+	DryRun bool `json:"dryRun" bson:"dryRun"`
+	// key is the column of file,value is the field path of entity.
+	// if it is empty,columns are mapped to the fields with the same name and unknown columns are ignored
+	ColumnFieldMap map[string]string `json:"columnFieldMap" bson:"columnFieldMap"`
+	// fields to match the stored item in upsert mode,they must be imported in each row
+	KeyFieldNameList []string `json:"keyFieldNameList" bson:"keyFieldNameList"`
+	// rows of a bulk write
+	BatchSize int  `json:"batchSize" bson:"batchSize"`
+	Async     bool `json:"async" bson:"async"`
+	// user who starts the import,it is the creator of inserted items
+	CreatorId string `json:"creatorId" bson:"creatorId"`
+	// tenant of imported items,items of the other tenants are not matched in upsert mode
+	TenantId string `json:"tenantId" bson:"tenantId"`
+	// name of the uploaded file
+	FileName string `json:"fileName" bson:"fileName"`
+	// called with the written items of each batch,the stored items are loaded as the snapshots only if it is set.
+	// it is called by the goroutine of the import
+	ItemWrittenFunc func(itemList []ImportedItem) `json:"-" bson:"-"`
+}
+
+// an item written by import,Before is nil for the inserted item
+type ImportedItem struct {
+	Id     primitive.ObjectID
+	Before interface{}
+	After  interface{}
+}
+
+const (
+	ImportStatus_Error    = "error"
+	ImportStatus_Running  = "running"
+	ImportStatus_Finished = "finished"
+)
+
+// an invalid row of import
+type ImportRowError struct {
+	// sheet name of xlsx
+	Sheet   string `json:"sheet,omitempty" bson:"sheet,omitempty"`
+	Row     int    `json:"row" bson:"row"`
+	Column  string `json:"column,omitempty" bson:"column,omitempty"`
+	Field   string `json:"field,omitempty" bson:"field,omitempty"`
+	Message string `json:"message" bson:"message"`
+}
+
+func (e *ImportRowError) Error() string {
+	return fmt.Sprintf("row %d,column %s:%s", e.Row, e.Column, e.Message)
+}
+
+type EntityImport struct {
+	ImportOptions `bson:",inline"`
+
+	Id        string     `json:"id" bson:"_id"`
+	Status    string     `json:"status" bson:"status"`
+	StartTime time.Time  `json:"startTime" bson:"startTime"`
+	EndTime   *time.Time `json:"endTime" bson:"endTime"`
+	// the report file and record are removed after expire time
+	ExpireTime time.Time `json:"expireTime" bson:"expireTime"`
+	// rows read from file
+	RowCount int64 `json:"rowCount" bson:"rowCount"`
+	// rows written,or valid rows for dry run
+	SuccessCount  int64 `json:"successCount" bson:"successCount"`
+	InsertedCount int64 `json:"insertedCount" bson:"insertedCount"`
+	// items matched by key fields in upsert mode
+	UpdatedCount int64 `json:"updatedCount" bson:"updatedCount"`
+	ErrorCount   int64 `json:"errorCount" bson:"errorCount"`
+	// the first errors,all errors are in the report file
+	ErrorList []ImportRowError `json:"errorList" bson:"errorList"`
+	// error that stops the import
+	Error string `json:"error,omitempty" bson:"error,omitempty"`
+	// csv file of row errors,it is empty if there is no error
+	ReportFileName string `json:"reportFileName,omitempty" bson:"reportFileName,omitempty"`
+	ReportPath     string `json:"-" bson:"reportPath,omitempty"`
+	FilePath       string `json:"-" bson:"filePath"`
+
+	importer  IImporter
+	fieldSet  *mongodbr.EntityFieldSet
+	fieldMap  map[string]*mongodbr.EntityFieldPath
+	keyList   []*mongodbr.EntityFieldPath
+	report    *csv.Writer
+	reportOut *os.File
+}
+
+// the fields of import options are invalid
+var ErrInvalidImportOptions = errors.New("invalid import options")
+
+type IEntityImportService[TEntity mongodbr.IEntity] interface {
+	GetRepository() mongodbr.IRepository
+	// store of import records,services sharing a store need only one cleanup
+	GetStore() IImportStore
+
+	// the file is saved to import dir before the import starts,
+	// invalid options are returned as error and invalid rows are recorded in the report
+	Import(r io.Reader, options ImportOptions) (importId string, err error)
+	GetImport(importId string) (*EntityImport, error)
+	// remove expired report files and records
+	CleanupExpired() error
+	// cleanup periodically until ctx is done
+	RunCleanup(ctx context.Context, interval time.Duration)
+}
+
+const (
+	DefaultImportBatchSize  = 500
+	DefaultImportExpiration = time.Hour
+	// collection of import records
+	DefaultImportCollectionName = "entity_import"
+	// import record is saved every n rows to report progress
+	importProgressRowCount = 1000
+	// max size of EntityImport.ErrorList
+	importErrorListMaxSize = 100
+)
+
+// fields that are updated by BeforeUpdate
+var modificationAuditFieldList = []string{"lastModificationTime"}
+
+type EntityImportService[T mongodbr.IEntity] struct {
+	repository mongodbr.IRepository
+
+	store      IImportStore
+	importDir  string
+	expiration time.Duration
+}
+
+type EntityImportServiceOption func(*importServiceOptions)
+
+type importServiceOptions struct {
+	store      IImportStore
+	importDir  string
+	expiration time.Duration
+}
+
+// store of import records,records are saved in the entity_import collection of entity database if not set
+func EntityImportServiceWithStore(store IImportStore) EntityImportServiceOption {
+	return func(o *importServiceOptions) {
+		o.store = store
+	}
+}
+
+// directory of uploaded and report files,default is _temp/import in working directory.
+// use a shared directory if the reports are downloaded from multiple replicas
+func EntityImportServiceWithImportDir(dir string) EntityImportServiceOption {
+	return func(o *importServiceOptions) {
+		o.importDir = dir
+	}
+}
+
+func EntityImportServiceWithExpiration(expiration time.Duration) EntityImportServiceOption {
+	return func(o *importServiceOptions) {
+		o.expiration = expiration
+	}
+}
+
+func NewEntityImportService[T mongodbr.IEntity](repository mongodbr.IRepository, opts ...EntityImportServiceOption) IEntityImportService[T] {
+	o := &importServiceOptions{
+		expiration: DefaultImportExpiration,
+	}
+	for _, eachOpt := range opts {
+		eachOpt(o)
+	}
+	if o.store == nil {
+		var database *mongo.Database
+		if repository != nil && repository.GetCollection() != nil {
+			database = repository.GetCollection().Database()
+		}
+		o.store = newDefaultImportStore(database, o.expiration)
+	}
+	return &EntityImportService[T]{
+		repository: repository,
+		store:      o.store,
+		importDir:  o.importDir,
+		expiration: o.expiration,
+	}
+}
+
+// default import stores of each database,the services of a database share the store
+var _defaultImportStores sync.Map
+
+// records are kept in memory if there is no database
+func newDefaultImportStore(database *mongo.Database, expiration time.Duration) IImportStore {
+	if database == nil {
+		return NewMemoryImportStore(expiration)
+	}
+	key := databaseKey{client: database.Client(), name: database.Name()}
+	if store, ok := _defaultImportStores.Load(key); ok {
+		return store.(IImportStore)
+	}
+	repository, err := mongodbr.NewRepositoryBase(func() *mongo.Collection {
+		return database.Collection(DefaultImportCollectionName)
+	})
+	if err != nil {
+		log.Logger.Warn(fmt.Sprintf("create import store error,records are kept in memory,err:%s", err.Error()))
+		return NewMemoryImportStore(expiration)
+	}
+	store, _ := _defaultImportStores.LoadOrStore(key, NewMongoImportStore(repository))
+	return store.(IImportStore)
+}
+
+func (s *EntityImportService[T]) GetRepository() mongodbr.IRepository {
+	return s.repository
+}
+
+func (s *EntityImportService[T]) GetStore() IImportStore {
+	return s.store
+}
+
+func (s *EntityImportService[T]) Import(r io.Reader, options ImportOptions) (importId string, err error) {
+	importer, err := NewImporter(options.Type, options.FileName)
+	if err != nil {
+		return "", err
+	}
+	if len(options.Type) <= 0 {
+		options.Type = strings.ToLower(strings.TrimPrefix(path.Ext(options.FileName), "."))
+	}
+	if len(options.Mode) <= 0 {
+		options.Mode = ImportMode_Insert
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultImportBatchSize
+	}
+	entityImport := &EntityImport{
+		ImportOptions: options,
+		importer:      importer,
+		fieldSet:      mongodbr.GetEntityFieldSet(new(T)),
+		fieldMap:      make(map[string]*mongodbr.EntityFieldPath),
+	}
+	if err := s.checkOptions(entityImport); err != nil {
+		return "", fmt.Errorf("%w,%s", ErrInvalidImportOptions, err.Error())
+	}
+
+	importDir, err := s.getImportDir()
+	if err != nil {
+		return "", err
+	}
+	importId = uuid.NewV4().String()
+	entityImport.Id = importId
+	entityImport.FilePath = path.Join(importDir, importId+"."+options.Type)
+	if err := saveImportFile(entityImport.FilePath, r); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	entityImport.Status = ImportStatus_Running
+	entityImport.StartTime = now
+	entityImport.ExpireTime = now.Add(s.expiration)
+	if err := s.store.Save(entityImport); err != nil {
+		os.Remove(entityImport.FilePath)
+		return "", err
+	}
+	if options.Async {
+		//new threading to start import
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					s.fail(entityImport, fmt.Errorf("import panic,%v", p))
+				}
+			}()
+			s.run(entityImport)
+		}()
+	} else {
+		s.run(entityImport)
+	}
+	return importId, nil
+}
+
+func (s *EntityImportService[T]) GetImport(importId string) (*EntityImport, error) {
+	return s.store.Get(importId)
+}
+
+func (s *EntityImportService[T]) CleanupExpired() error {
+	now := time.Now()
+	if err := s.store.DeleteExpired(now); err != nil {
+		return err
+	}
+	importDir, err := s.getImportDir()
+	if err != nil {
+		return err
+	}
+	entryList, err := os.ReadDir(importDir)
+	if err != nil {
+		return err
+	}
+	for _, eachEntry := range entryList {
+		if eachEntry.IsDir() {
+			continue
+		}
+		info, err := eachEntry.Info()
+		if err != nil || info.ModTime().Add(s.expiration).After(now) {
+			continue
+		}
+		if err := os.Remove(path.Join(importDir, eachEntry.Name())); err != nil && !os.IsNotExist(err) {
+			log.Logger.Warn(fmt.Sprintf("remove expired import file error,file:%s,err:%s", eachEntry.Name(), err.Error()))
+		}
+	}
+	return nil
+}
+
+func (s *EntityImportService[T]) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.CleanupExpired(); err != nil {
+				log.Logger.Warn(fmt.Sprintf("cleanup expired import error,err:%s", err.Error()))
+			}
+		}
+	}
+}
+
+// validate mode,mapped fields and key fields
+func (s *EntityImportService[T]) checkOptions(entityImport *EntityImport) error {
+	switch entityImport.Mode {
+	case ImportMode_Insert, ImportMode_Upsert:
+	default:
+		return fmt.Errorf("invalid import mode:%s", entityImport.Mode)
+	}
+	for eachColumn, eachField := range entityImport.ColumnFieldMap {
+		fieldPath, err := resolveImportField(entityImport.fieldSet, eachField)
+		if err != nil {
+			return fmt.Errorf("invalid field of column %s,err:%s", eachColumn, err.Error())
+		}
+		entityImport.fieldMap[eachColumn] = fieldPath
+	}
+	if entityImport.Mode != ImportMode_Upsert {
+		return nil
+	}
+	if len(entityImport.KeyFieldNameList) <= 0 {
+		return errors.New("keyFieldNameList must not be empty in upsert mode")
+	}
+	for _, eachKey := range entityImport.KeyFieldNameList {
+		fieldPath, err := resolveImportField(entityImport.fieldSet, eachKey)
+		if err != nil {
+			return fmt.Errorf("invalid key field,err:%s", err.Error())
+		}
+		entityImport.keyList = append(entityImport.keyList, fieldPath)
+	}
+	return nil
+}
+
+// field of a column,nil if the column is not imported
+func (e *EntityImport) field(column string) *mongodbr.EntityFieldPath {
+	if fieldPath, ok := e.fieldMap[column]; ok || len(e.ColumnFieldMap) > 0 {
+		return fieldPath
+	}
+	fieldPath, err := resolveImportField(e.fieldSet, column)
+	if err != nil {
+		fieldPath = nil
+	}
+	e.fieldMap[column] = fieldPath
+	return fieldPath
+}
+
+// column that is mapped to the field,the field is a dotted json path
+func (e *EntityImport) columnOfField(jsonPath string) string {
+	for eachColumn, eachField := range e.fieldMap {
+		if eachField != nil && eachField.JsonPath == jsonPath {
+			return eachColumn
+		}
+	}
+	return ""
+}
+
+func resolveImportField(fieldSet *mongodbr.EntityFieldSet, name string) (*mongodbr.EntityFieldPath, error) {
+	fieldPath, err := fieldSet.ResolveFieldPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if fieldPath.HasArrayIndex {
+		return nil, fmt.Errorf("array index is not supported,path:%s", name)
+	}
+	return fieldPath, nil
+}
+
+// #region run
+
+// a valid row
+type importItem struct {
+	value  interface{}
+	set    bson.M
+	filter bson.M
+	sheet  string
+	row    int
+}
+
+func (s *EntityImportService[T]) save(entityImport *EntityImport) {
+	if err := s.store.Save(entityImport); err != nil {
+		log.Logger.Warn(fmt.Sprintf("save import error (id: %s),err: %s", entityImport.Id, err.Error()))
+	}
+}
+
+func (s *EntityImportService[T]) fail(entityImport *EntityImport, err error) {
+	entityImport.Status = ImportStatus_Error
+	entityImport.Error = err.Error()
+	entityImport.EndTime = lang.NowToPtr()
+	log.Logger.Error(fmt.Sprintf("import error (id: %s),err: %s", entityImport.Id, err.Error()))
+	s.save(entityImport)
+}
+
+func (s *EntityImportService[T]) finish(entityImport *EntityImport) {
+	entityImport.Status = ImportStatus_Finished
+	entityImport.EndTime = lang.NowToPtr()
+	log.Logger.Debug(fmt.Sprintf("import finished (id: %s)", entityImport.Id))
+	s.save(entityImport)
+}
+
+func (s *EntityImportService[T]) run(entityImport *EntityImport) {
+	defer os.Remove(entityImport.FilePath)
+	defer entityImport.closeReport()
+
+	file, err := os.Open(entityImport.FilePath)
+	if err != nil {
+		s.fail(entityImport, err)
+		return
+	}
+	defer file.Close()
+
+	importer := entityImport.importer
+	if err := importer.Begin(file); err != nil {
+		s.fail(entityImport, err)
+		return
+	}
+	batch := make([]*importItem, 0, entityImport.BatchSize)
+	for {
+		row, err := importer.ReadRow()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *ImportRowError
+		if errors.As(err, &rowErr) {
+			entityImport.RowCount++
+			if err := entityImport.addError(*rowErr); err != nil {
+				s.fail(entityImport, err)
+				return
+			}
+			continue
+		}
+		if err != nil {
+			s.fail(entityImport, err)
+			return
+		}
+		entityImport.RowCount++
+
+		sheet, rowNumber := importer.Position()
+		item, errList := s.buildItem(entityImport, row)
+		for _, eachError := range errList {
+			eachError.Sheet = sheet
+			eachError.Row = rowNumber
+			if err := entityImport.addError(eachError); err != nil {
+				s.fail(entityImport, err)
+				return
+			}
+		}
+		if item != nil {
+			item.sheet = sheet
+			item.row = rowNumber
+			batch = append(batch, item)
+		}
+		if len(batch) >= entityImport.BatchSize {
+			if err := s.writeBatch(entityImport, batch); err != nil {
+				s.fail(entityImport, err)
+				return
+			}
+			batch = batch[:0]
+		}
+		//report progress
+		if entityImport.RowCount%importProgressRowCount == 0 {
+			s.save(entityImport)
+		}
+	}
+	if err := s.writeBatch(entityImport, batch); err != nil {
+		s.fail(entityImport, err)
+		return
+	}
+	if err := importer.End(); err != nil {
+		s.fail(entityImport, err)
+		return
+	}
+	if err := entityImport.closeReport(); err != nil {
+		s.fail(entityImport, err)
+		return
+	}
+	s.finish(entityImport)
+}
+
+// convert a row to entity,return nil item if the row is invalid
+func (s *EntityImportService[T]) buildItem(entityImport *EntityImport, row map[string]interface{}) (*importItem, []ImportRowError) {
+	columnList := make([]string, 0, len(row))
+	for eachColumn := range row {
+		columnList = append(columnList, eachColumn)
+	}
+	sort.Strings(columnList)
+
+	doc := bson.M{}
+	item := &importItem{set: bson.M{}}
+	// field path to column,used to locate validation errors
+	columnMap := make(map[string]string)
+	errList := make([]ImportRowError, 0)
+	for _, eachColumn := range columnList {
+		fieldPath := entityImport.field(eachColumn)
+		value := row[eachColumn]
+		if fieldPath == nil || value == nil || value == "" {
+			continue
+		}
+		v, err := toImportValue(value, fieldPath.Type)
+		if err != nil {
+			errList = append(errList, ImportRowError{Column: eachColumn, Field: fieldPath.BsonPath, Message: err.Error()})
+			continue
+		}
+		setImportDocValue(doc, fieldPath.BsonPath, v)
+		item.set[fieldPath.BsonPath] = v
+		columnMap[fieldPath.BsonPath] = eachColumn
+		if len(fieldPath.JsonPath) > 0 {
+			columnMap[fieldPath.JsonPath] = eachColumn
+		}
+	}
+	if len(errList) > 0 {
+		return nil, errList
+	}
+	if len(item.set) <= 0 {
+		return nil, []ImportRowError{{Message: "no field is imported"}}
+	}
+	if entityImport.Mode == ImportMode_Upsert {
+		item.filter = bson.M{}
+		for _, eachKey := range entityImport.keyList {
+			v, ok := item.set[eachKey.BsonPath]
+			if !ok {
+				errList = append(errList, ImportRowError{Field: eachKey.BsonPath, Message: "key field must not be empty"})
+				continue
+			}
+			item.filter[eachKey.BsonPath] = v
+		}
+		if len(errList) > 0 {
+			return nil, errList
+		}
+	}
+
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, []ImportRowError{{Message: err.Error()}}
+	}
+	entityItem := new(T)
+	if err := bson.Unmarshal(data, entityItem); err != nil {
+		return nil, []ImportRowError{{Message: err.Error()}}
+	}
+	item.value = importEntityValue(entityItem)
+	for _, eachError := range validateImportEntity(item.value) {
+		column, ok := columnMap[eachError.Field]
+		// fields that are not imported may be stored in upsert mode
+		if !ok && entityImport.Mode == ImportMode_Upsert && len(eachError.Field) > 0 {
+			continue
+		}
+		if !ok {
+			column = entityImport.columnOfField(eachError.Field)
+		}
+		eachError.Column = column
+		errList = append(errList, eachError)
+	}
+	if len(errList) > 0 {
+		return nil, errList
+	}
+	return item, nil
+}
+
+// write items with an unordered bulk write,failed items are recorded in the report
+func (s *EntityImportService[T]) writeBatch(entityImport *EntityImport, batch []*importItem) error {
+	if len(batch) <= 0 {
+		return nil
+	}
+	if entityImport.DryRun {
+		entityImport.SuccessCount += int64(len(batch))
+		return nil
+	}
+	var result *mongo.BulkWriteResult
+	var err error
+	var beforeList []interface{}
+	bulkOptions := options.BulkWrite().SetOrdered(false)
+	if entityImport.Mode == ImportMode_Upsert {
+		if entityImport.ItemWrittenFunc != nil {
+			if beforeList, err = s.findImportedList(entityImport, batch); err != nil {
+				return err
+			}
+		}
+		itemList := make([]mongodbr.BulkUpsertItem, 0, len(batch))
+		for _, eachItem := range batch {
+			upsertItem, err := s.buildUpsertItem(entityImport, eachItem)
+			if err != nil {
+				return err
+			}
+			itemList = append(itemList, upsertItem)
+		}
+		result, err = scopeTenant(s.repository, entityImport.TenantId).BulkUpsert(itemList, bulkOptions)
+	} else {
+		modelList := make([]mongo.WriteModel, 0, len(batch))
+		for _, eachItem := range batch {
+			s.beforeCreate(entityImport, eachItem.value)
+			modelList = append(modelList, mongo.NewInsertOneModel().SetDocument(eachItem.value))
+		}
+		result, err = scopeTenant(s.repository, entityImport.TenantId).BulkWrite(modelList, bulkOptions)
+	}
+	errMap, ok := mongodbr.BulkWriteErrorMap(err)
+	if err != nil && !ok {
+		return err
+	}
+	if result != nil {
+		entityImport.InsertedCount += result.InsertedCount + result.UpsertedCount
+		entityImport.UpdatedCount += result.MatchedCount
+	}
+	for index, eachItem := range batch {
+		if itemErr, failed := errMap[index]; failed {
+			if err := entityImport.addError(ImportRowError{Sheet: eachItem.sheet, Row: eachItem.row, Message: itemErr.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		entityImport.SuccessCount++
+	}
+	if entityImport.ItemWrittenFunc != nil {
+		return s.notifyWritten(entityImport, batch, errMap, beforeList)
+	}
+	return nil
+}
+
+// stored item of each upsert item,nil if it is not stored
+func (s *EntityImportService[T]) findImportedList(entityImport *EntityImport, batch []*importItem) ([]interface{}, error) {
+	repository := scopeTenant(s.repository, entityImport.TenantId)
+	itemList := make([]interface{}, len(batch))
+	for index, eachItem := range batch {
+		stored, err := mongodbr.FindOneTByFilter[T](repository, eachItem.filter)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil {
+			itemList[index] = importEntityValue(stored)
+		}
+	}
+	return itemList, nil
+}
+
+// call ItemWrittenFunc with the snapshots of written items
+func (s *EntityImportService[T]) notifyWritten(entityImport *EntityImport, batch []*importItem, errMap map[int]error, beforeList []interface{}) error {
+	var afterList []interface{}
+	if entityImport.Mode == ImportMode_Upsert {
+		var err error
+		if afterList, err = s.findImportedList(entityImport, batch); err != nil {
+			return err
+		}
+	}
+	itemList := make([]ImportedItem, 0, len(batch))
+	for index, eachItem := range batch {
+		if _, failed := errMap[index]; failed {
+			continue
+		}
+		item := ImportedItem{After: eachItem.value}
+		if afterList != nil {
+			item.Before = beforeList[index]
+			item.After = afterList[index]
+		}
+		if e, ok := item.After.(mongodbr.IEntity); ok {
+			item.Id = e.GetObjectId()
+		}
+		itemList = append(itemList, item)
+	}
+	if len(itemList) > 0 {
+		entityImport.ItemWrittenFunc(itemList)
+	}
+	return nil
+}
+
+// imported fields are set,other fields are set only when the item is inserted
+func (s *EntityImportService[T]) buildUpsertItem(entityImport *EntityImport, item *importItem) (mongodbr.BulkUpsertItem, error) {
+	s.beforeCreate(entityImport, item.value)
+	set := bson.M{}
+	for key, value := range item.set {
+		// the fields that cannot be patched are not updated,they are set only when the item is inserted
+		if patch.IsReadonlyField(key) {
+			continue
+		}
+		set[key] = value
+	}
+	hookable, isHookable := item.value.(mongodbr.IEntityBeforeUpdate)
+	if isHookable {
+		hookable.BeforeUpdate()
+	}
+	doc, err := mongodbr.ToBsonMap(item.value)
+	if err != nil {
+		return mongodbr.BulkUpsertItem{}, err
+	}
+	if isHookable {
+		for _, eachField := range modificationAuditFieldList {
+			if v, ok := doc[eachField]; ok {
+				set[eachField] = v
+			}
+		}
+	}
+	insert := bson.M{}
+	for key, value := range doc {
+		if !isImportPathConflicted(key, set) {
+			insert[key] = value
+		}
+	}
+	update := bson.M{"$set": set}
+	if len(insert) > 0 {
+		update["$setOnInsert"] = insert
+	}
+	_, versioned := item.value.(mongodbr.IVersionedEntity)
+	return mongodbr.BulkUpsertItem{Filter: item.filter, Update: update, Versioned: versioned}, nil
+}
+
+func (s *EntityImportService[T]) beforeCreate(entityImport *EntityImport, value interface{}) {
+	if hookable, ok := value.(mongodbr.IEntityBeforeCreate); ok {
+		hookable.BeforeCreate()
+	}
+	if userinfoProvider, ok := value.(IEntityWithUser); ok && len(entityImport.CreatorId) > 0 {
+		userinfoProvider.SetUserCreator(entityImport.CreatorId)
+	}
+	if len(entityImport.TenantId) > 0 {
+		SetItemTenantId(value, entityImport.TenantId)
+	}
+}
+
+// #endregion
+
+// #region report
+
+func (e *EntityImport) addError(rowError ImportRowError) error {
+	e.ErrorCount++
+	if len(e.ErrorList) < importErrorListMaxSize {
+		e.ErrorList = append(e.ErrorList, rowError)
+	}
+	if e.report == nil {
+		if err := e.createReport(); err != nil {
+			return err
+		}
+	}
+	record := []string{strconv.Itoa(rowError.Row), rowError.Column, rowError.Field, rowError.Message}
+	if e.Type == ExportType_XLSX {
+		record = append([]string{rowError.Sheet}, record...)
+	}
+	return e.report.Write(record)
+}
+
+// report is a csv file in the same directory of uploaded file
+func (e *EntityImport) createReport() (err error) {
+	reportFileName := e.Id + "_report_" + time.Now().Format("20060102150405") + "." + ExportType_CSV
+	reportPath := path.Join(path.Dir(e.FilePath), reportFileName)
+	e.reportOut, err = os.Create(reportPath)
+	if err != nil {
+		return err
+	}
+	e.ReportFileName = reportFileName
+	e.ReportPath = reportPath
+	e.report = csv.NewWriter(e.reportOut)
+	header := []string{"row", "column", "field", "message"}
+	if e.Type == ExportType_XLSX {
+		header = append([]string{"sheet"}, header...)
+	}
+	return e.report.Write(header)
+}
+
+func (e *EntityImport) closeReport() error {
+	if e.report == nil {
+		return nil
+	}
+	e.report.Flush()
+	err := e.report.Error()
+	if closeErr := e.reportOut.Close(); err == nil {
+		err = closeErr
+	}
+	e.report = nil
+	return err
+}
+
+// #endregion
+
+func (s *EntityImportService[T]) getImportDir() (dir string, err error) {
+	importDir := s.importDir
+	if len(importDir) <= 0 {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		importDir = path.Join(wd, "_temp", "import")
+	}
+	if _, err := os.Stat(importDir); err != nil {
+		if err := os.MkdirAll(importDir, 0755); err != nil {
+			return "", err
+		}
+	}
+	return importDir, nil
+}
+
+func saveImportFile(filePath string, r io.Reader) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		os.Remove(filePath)
+		return err
+	}
+	return file.Close()
+}
+
+// set value of a dotted path,nested documents are created
+func setImportDocValue(doc bson.M, bsonPath string, value interface{}) {
+	segments := strings.Split(bsonPath, ".")
+	current := doc
+	for _, eachSegment := range segments[:len(segments)-1] {
+		nested, ok := current[eachSegment].(bson.M)
+		if !ok {
+			nested = bson.M{}
+			current[eachSegment] = nested
+		}
+		current = nested
+	}
+	current[segments[len(segments)-1]] = value
+}
+
+// a field of $setOnInsert cannot be the same as or overlapped with a field of $set
+func isImportPathConflicted(key string, set bson.M) bool {
+	for eachPath := range set {
+		if eachPath == key || strings.HasPrefix(eachPath, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// value of entity pointer,the pointer is removed if T is a pointer type
+func importEntityValue[T any](item *T) interface{} {
+	if reflect.TypeOf(item).Elem().Kind() == reflect.Ptr {
+		return *item
+	}
+	return item
+}
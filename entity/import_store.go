@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ReneKroon/ttlcache"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrImportNotFound = errors.New("import not found")
+
+// store of import records
+type IImportStore interface {
+	Save(entityImport *EntityImport) error
+	// return ErrImportNotFound if the record does not exist
+	Get(importId string) (*EntityImport, error)
+	// delete records that expire before the time
+	DeleteExpired(before time.Time) error
+}
+
+// #region memory store
+
+type memoryImportStore struct {
+	cache *ttlcache.Cache
+}
+
+// records are kept in memory for ttl,they are lost when the process restarts
+func NewMemoryImportStore(ttl time.Duration) IImportStore {
+	s := &memoryImportStore{
+		cache: ttlcache.NewCache(),
+	}
+	s.cache.SetTTL(ttl)
+	return s
+}
+
+func (s *memoryImportStore) Save(entityImport *EntityImport) error {
+	s.cache.Set(entityImport.Id, entityImport)
+	return nil
+}
+
+func (s *memoryImportStore) Get(importId string) (*EntityImport, error) {
+	res, ok := s.cache.Get(importId)
+	if !ok {
+		return nil, ErrImportNotFound
+	}
+	return res.(*EntityImport), nil
+}
+
+// records are removed by ttl
+func (s *memoryImportStore) DeleteExpired(before time.Time) error {
+	return nil
+}
+
+// #endregion
+
+// #region mongodb store
+
+type mongoImportStore struct {
+	repository mongodbr.IRepository
+}
+
+// records are persisted in mongodb,so the status survives restarts and is shared by replicas
+func NewMongoImportStore(repository mongodbr.IRepository) IImportStore {
+	return &mongoImportStore{
+		repository: repository,
+	}
+}
+
+func (s *mongoImportStore) Save(entityImport *EntityImport) error {
+	return s.repository.Replace(bson.M{"_id": entityImport.Id}, entityImport, options.Replace().SetUpsert(true))
+}
+
+func (s *mongoImportStore) Get(importId string) (*EntityImport, error) {
+	entityImport := &EntityImport{}
+	err := s.repository.FindOne(bson.M{"_id": importId}).One(entityImport)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrImportNotFound
+		}
+		return nil, err
+	}
+	return entityImport, nil
+}
+
+func (s *mongoImportStore) DeleteExpired(before time.Time) error {
+	_, err := s.repository.DeleteMany(bson.M{"expireTime": bson.M{"$lt": before}})
+	return err
+}
+
+// #endregion
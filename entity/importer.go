@@ -0,0 +1,245 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrUnsupportedImportType = errors.New("unsupported import type")
+
+// read the rows of an import file,an importer instance is used by only one import
+type IImporter interface {
+	Begin(r io.Reader) error
+	// key is the column name,value is string for csv and xlsx,json value for ndjson.
+	// nested objects of ndjson are flattened to dotted columns.return io.EOF if there is no more row
+	ReadRow() (map[string]interface{}, error)
+	// position of the last read row,row is 1-based and the header is counted.
+	// sheet is the sheet name for xlsx and empty for other types
+	Position() (sheet string, row int)
+	End() error
+}
+
+var (
+	_importerMapping = map[string]func() IImporter{
+		ExportType_CSV:    func() IImporter { return &csvImporter{} },
+		ExportType_XLSX:   func() IImporter { return &xlsxImporter{} },
+		ExportType_NDJSON: func() IImporter { return &ndjsonImporter{} },
+	}
+	_importerLock sync.RWMutex
+)
+
+// regist an importer for ImportOptions.Type,a registed importer is replaced
+func RegistImporter(importType string, newImporter func() IImporter) {
+	_importerLock.Lock()
+	defer _importerLock.Unlock()
+	_importerMapping[strings.ToLower(importType)] = newImporter
+}
+
+// new importer by import type,the type is detected from the extension of fileName if it is empty
+func NewImporter(importType string, fileName string) (IImporter, error) {
+	if len(importType) <= 0 {
+		importType = strings.TrimPrefix(path.Ext(fileName), ".")
+	}
+	_importerLock.RLock()
+	newImporter, ok := _importerMapping[strings.ToLower(importType)]
+	_importerLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w,type:%s", ErrUnsupportedImportType, importType)
+	}
+	return newImporter(), nil
+}
+
+// flatten nested objects to dotted keys
+func flattenImportRow(prefix string, doc map[string]interface{}, row map[string]interface{}) {
+	for eachKey, eachValue := range doc {
+		key := eachKey
+		if len(prefix) > 0 {
+			key = prefix + "." + eachKey
+		}
+		if nested, ok := eachValue.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenImportRow(key, nested, row)
+			continue
+		}
+		row[key] = eachValue
+	}
+}
+
+// #region value
+
+// layouts of time cell,time without zone is parsed in local time
+var importTimeLayoutList = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+// convert a cell value to the go type of field.
+// time is parsed by importTimeLayoutList or as the excel serial number,
+// array,map and struct are parsed from json
+func toImportValue(v interface{}, t reflect.Type) (interface{}, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() == reflect.Interface {
+		return v, nil
+	}
+	text, isText := v.(string)
+	if isText {
+		text = strings.TrimSpace(text)
+	}
+	switch t {
+	case reflect.TypeOf(primitive.ObjectID{}):
+		if !isText {
+			return nil, fmt.Errorf("invalid object id:%v", v)
+		}
+		return primitive.ObjectIDFromHex(text)
+	case reflect.TypeOf(time.Time{}):
+		if !isText {
+			return nil, fmt.Errorf("invalid time:%v", v)
+		}
+		return parseImportTime(text)
+	}
+	switch t.Kind() {
+	case reflect.String:
+		if isText {
+			return reflect.ValueOf(v).Convert(t).Interface(), nil
+		}
+		return reflect.ValueOf(formatExportCellValue(v)).Convert(t).Interface(), nil
+	case reflect.Bool:
+		if b, ok := v.(bool); ok {
+			return reflect.ValueOf(b).Convert(t).Interface(), nil
+		}
+		b, err := strconv.ParseBool(text)
+		if err != nil || !isText {
+			return nil, fmt.Errorf("invalid bool:%v", v)
+		}
+		return reflect.ValueOf(b).Convert(t).Interface(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := parseImportInt(v, text, isText)
+		if err != nil || reflect.Zero(t).OverflowInt(n) {
+			return nil, fmt.Errorf("invalid integer:%v", v)
+		}
+		return reflect.ValueOf(n).Convert(t).Interface(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := parseImportInt(v, text, isText)
+		if err != nil || n < 0 || reflect.Zero(t).OverflowUint(uint64(n)) {
+			return nil, fmt.Errorf("invalid unsigned integer:%v", v)
+		}
+		return reflect.ValueOf(uint64(n)).Convert(t).Interface(), nil
+	case reflect.Float32, reflect.Float64:
+		f, ok := v.(float64)
+		if isText {
+			var err error
+			f, err = strconv.ParseFloat(text, 64)
+			ok = err == nil
+		}
+		if !ok || reflect.Zero(t).OverflowFloat(f) {
+			return nil, fmt.Errorf("invalid number:%v", v)
+		}
+		return reflect.ValueOf(f).Convert(t).Interface(), nil
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		data := []byte(text)
+		if !isText {
+			var err error
+			if data, err = json.Marshal(v); err != nil {
+				return nil, err
+			}
+		}
+		result := reflect.New(t)
+		if err := json.Unmarshal(data, result.Interface()); err != nil {
+			return nil, fmt.Errorf("invalid json value,err:%s", err.Error())
+		}
+		return result.Elem().Interface(), nil
+	}
+	return nil, fmt.Errorf("unsupported field type:%s", t.String())
+}
+
+func parseImportInt(v interface{}, text string, isText bool) (int64, error) {
+	f, ok := v.(float64)
+	if isText {
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n, nil
+		}
+		var err error
+		f, err = strconv.ParseFloat(text, 64)
+		ok = err == nil
+	}
+	if !ok || f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, errors.New("invalid integer")
+	}
+	return int64(f), nil
+}
+
+func parseImportTime(text string) (time.Time, error) {
+	for _, eachLayout := range importTimeLayoutList {
+		if t, err := time.ParseInLocation(eachLayout, text, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	// date cell of xlsx
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return excelize.ExcelDateToTime(f, false)
+	}
+	return time.Time{}, fmt.Errorf("invalid time:%s", text)
+}
+
+var (
+	_importValidator     *validator.Validate
+	_importValidatorOnce sync.Once
+)
+
+// validate tags and IValidation of entity,field of error is the dotted json path
+func validateImportEntity(v interface{}) []ImportRowError {
+	_importValidatorOnce.Do(func() {
+		_importValidator = validator.New()
+		_importValidator.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	})
+	errList := make([]ImportRowError, 0)
+	err := _importValidator.Struct(v)
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, eachError := range validationErrors {
+			namespace := eachError.Namespace()
+			// remove the name of struct
+			if index := strings.Index(namespace, "."); index >= 0 {
+				namespace = namespace[index+1:]
+			}
+			errList = append(errList, ImportRowError{Field: namespace, Message: eachError.Error()})
+		}
+	} else if err != nil {
+		var invalidErr *validator.InvalidValidationError
+		if !errors.As(err, &invalidErr) {
+			errList = append(errList, ImportRowError{Message: err.Error()})
+		}
+	}
+	if err := mongodbr.Validate(v); err != nil {
+		errList = append(errList, ImportRowError{Message: err.Error()})
+	}
+	return errList
+}
+
+// #endregion
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// the first line is the header
+type csvImporter struct {
+	reader  *csv.Reader
+	columns []string
+	row     int
+}
+
+var _ IImporter = (*csvImporter)(nil)
+
+func (i *csvImporter) Begin(r io.Reader) error {
+	i.reader = csv.NewReader(r)
+	i.reader.FieldsPerRecord = -1
+	header, err := i.reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return errors.New("header of csv file is not found")
+		}
+		return err
+	}
+	i.row = 1
+	i.columns = make([]string, 0, len(header))
+	for index, eachColumn := range header {
+		if index == 0 {
+			// utf-8 bom is written by excel
+			eachColumn = strings.TrimPrefix(eachColumn, "\ufeff")
+		}
+		i.columns = append(i.columns, strings.TrimSpace(eachColumn))
+	}
+	return nil
+}
+
+func (i *csvImporter) ReadRow() (map[string]interface{}, error) {
+	record, err := i.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	i.row++
+	return importRowFromCells(i.columns, record), nil
+}
+
+func (i *csvImporter) Position() (string, int) {
+	return "", i.row
+}
+
+func (i *csvImporter) End() error {
+	return nil
+}
+
+// cells of a row keyed by column,cells out of the header are ignored
+func importRowFromCells(columns []string, cells []string) map[string]interface{} {
+	row := make(map[string]interface{}, len(columns))
+	for index, eachCell := range cells {
+		if index >= len(columns) || len(columns[index]) <= 0 {
+			continue
+		}
+		row[columns[index]] = eachCell
+	}
+	return row
+}
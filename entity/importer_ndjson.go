@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// max size of a line
+const ndjsonMaxLineSize = 16 * 1024 * 1024
+
+// each line is a json object,blank lines are skipped
+type ndjsonImporter struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+var _ IImporter = (*ndjsonImporter)(nil)
+
+func (i *ndjsonImporter) Begin(r io.Reader) error {
+	i.scanner = bufio.NewScanner(r)
+	i.scanner.Buffer(make([]byte, 0, 64*1024), ndjsonMaxLineSize)
+	return nil
+}
+
+func (i *ndjsonImporter) ReadRow() (map[string]interface{}, error) {
+	for i.scanner.Scan() {
+		i.row++
+		line := bytes.TrimSpace(i.scanner.Bytes())
+		if len(line) <= 0 {
+			continue
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(line, &doc); err != nil {
+			return nil, &ImportRowError{Row: i.row, Message: fmt.Sprintf("invalid json object,err:%s", err.Error())}
+		}
+		row := make(map[string]interface{}, len(doc))
+		flattenImportRow("", doc, row)
+		return row, nil
+	}
+	if err := i.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (i *ndjsonImporter) Position() (string, int) {
+	return "", i.row
+}
+
+func (i *ndjsonImporter) End() error {
+	return nil
+}
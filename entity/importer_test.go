@@ -0,0 +1,204 @@
+package entity
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type testImportEntity struct {
+	mongodbr.Entity `bson:",inline"`
+	Code            string            `json:"code" bson:"code" validate:"required"`
+	Age             int               `json:"age" bson:"age" validate:"gte=0"`
+	Enabled         bool              `json:"enabled" bson:"enabled"`
+	Birthday        *time.Time        `json:"birthday" bson:"birthday"`
+	Tags            []string          `json:"tags" bson:"tags"`
+	Address         testExportAddress `json:"address" bson:"address"`
+}
+
+func readImportRows(t *testing.T, importer IImporter, data []byte) []map[string]interface{} {
+	assert.Nil(t, importer.Begin(bytes.NewReader(data)))
+	rowList := make([]map[string]interface{}, 0)
+	for {
+		row, err := importer.ReadRow()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		rowList = append(rowList, row)
+	}
+	assert.Nil(t, importer.End())
+	return rowList
+}
+
+func TestImporter(t *testing.T) {
+	importer, err := NewImporter("", "a.CSV")
+	assert.Nil(t, err)
+	rowList := readImportRows(t, importer, []byte("\ufeffcode, age\na,1\nb\n"))
+	assert.Equal(t, []map[string]interface{}{{"code": "a", "age": "1"}, {"code": "b"}}, rowList)
+	_, row := importer.Position()
+	assert.Equal(t, 3, row)
+
+	importer, _ = NewImporter(ExportType_NDJSON, "")
+	rowList = readImportRows(t, importer, []byte(`{"code":"a","address":{"city":"x"}}`+"\n\n"+`{"age":1}`+"\n"))
+	assert.Equal(t, []map[string]interface{}{{"code": "a", "address.city": "x"}, {"age": float64(1)}}, rowList)
+
+	importer, _ = NewImporter(ExportType_NDJSON, "")
+	assert.Nil(t, importer.Begin(strings.NewReader("{x\n")))
+	_, err = importer.ReadRow()
+	var rowErr *ImportRowError
+	assert.True(t, errors.As(err, &rowErr))
+	assert.Equal(t, 1, rowErr.Row)
+
+	f := excelize.NewFile()
+	assert.Nil(t, f.SetSheetRow("Sheet1", "A1", &[]interface{}{"code", "age"}))
+	assert.Nil(t, f.SetSheetRow("Sheet1", "A2", &[]interface{}{"a", 1}))
+	_, err = f.NewSheet("Sheet2")
+	assert.Nil(t, err)
+	assert.Nil(t, f.SetSheetRow("Sheet2", "A1", &[]interface{}{"code"}))
+	assert.Nil(t, f.SetSheetRow("Sheet2", "A2", &[]interface{}{"b"}))
+	buf := &bytes.Buffer{}
+	_, err = f.WriteTo(buf)
+	assert.Nil(t, err)
+	importer, _ = NewImporter(ExportType_XLSX, "")
+	rowList = readImportRows(t, importer, buf.Bytes())
+	assert.Equal(t, []map[string]interface{}{{"code": "a", "age": "1"}, {"code": "b"}}, rowList)
+
+	_, err = NewImporter("", "a.pdf")
+	assert.True(t, errors.Is(err, ErrUnsupportedImportType))
+}
+
+func TestToImportValue(t *testing.T) {
+	oid := primitive.NewObjectID()
+	v, err := toImportValue(oid.Hex(), reflect.TypeOf(primitive.ObjectID{}))
+	assert.Nil(t, err)
+	assert.Equal(t, oid, v)
+
+	v, err = toImportValue("2024-01-02 03:04:05", reflect.TypeOf(&time.Time{}))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local), v)
+	v, err = toImportValue("45293", reflect.TypeOf(time.Time{}))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), v)
+
+	v, err = toImportValue(" 12 ", reflect.TypeOf(int32(0)))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(12), v)
+	v, err = toImportValue(float64(3), reflect.TypeOf(0))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, v)
+	_, err = toImportValue("1.5", reflect.TypeOf(0))
+	assert.NotNil(t, err)
+	_, err = toImportValue("300", reflect.TypeOf(int8(0)))
+	assert.NotNil(t, err)
+	_, err = toImportValue("-1", reflect.TypeOf(uint(0)))
+	assert.NotNil(t, err)
+
+	v, err = toImportValue("TRUE", reflect.TypeOf(true))
+	assert.Nil(t, err)
+	assert.Equal(t, true, v)
+	v, err = toImportValue(float64(1.5), reflect.TypeOf(""))
+	assert.Nil(t, err)
+	assert.Equal(t, "1.5", v)
+	v, err = toImportValue(`["a","b"]`, reflect.TypeOf([]string{}))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, v)
+}
+
+func TestImportBuildItem(t *testing.T) {
+	s := &EntityImportService[*testImportEntity]{}
+	entityImport := &EntityImport{
+		ImportOptions: ImportOptions{Mode: ImportMode_Upsert, KeyFieldNameList: []string{"code"}},
+		fieldSet:      mongodbr.GetEntityFieldSet(new(testImportEntity)),
+		fieldMap:      make(map[string]*mongodbr.EntityFieldPath),
+	}
+	assert.Nil(t, s.checkOptions(entityImport))
+
+	item, errList := s.buildItem(entityImport, map[string]interface{}{
+		"code": "a", "age": "3", "address.city": "x", "unknown": "y", "enabled": "",
+	})
+	assert.Empty(t, errList)
+	assert.Equal(t, bson.M{"code": "a", "age": 3, "address.city": "x"}, item.set)
+	assert.Equal(t, bson.M{"code": "a"}, item.filter)
+	value := item.value.(*testImportEntity)
+	assert.Equal(t, "x", value.Address.City)
+
+	_, errList = s.buildItem(entityImport, map[string]interface{}{"code": "a", "age": "-1"})
+	assert.Equal(t, 1, len(errList))
+	assert.Equal(t, "age", errList[0].Column)
+	_, errList = s.buildItem(entityImport, map[string]interface{}{"age": "x"})
+	assert.Equal(t, "age", errList[0].Column)
+	_, errList = s.buildItem(entityImport, map[string]interface{}{"age": "1"})
+	assert.Equal(t, "key field must not be empty", errList[0].Message)
+
+	upsertItem, err := s.buildUpsertItem(entityImport, item)
+	assert.Nil(t, err)
+	insert := upsertItem.Update["$setOnInsert"].(bson.M)
+	assert.Contains(t, insert, "_id")
+	assert.Contains(t, insert, "enabled")
+	assert.NotContains(t, insert, "address")
+	assert.NotContains(t, insert, "code")
+}
+
+func TestImportUpsertReadonlyFields(t *testing.T) {
+	s := &EntityImportService[*testImportEntity]{}
+	entityImport := &EntityImport{ImportOptions: ImportOptions{Mode: ImportMode_Upsert, TenantId: "t1"}}
+	item := &importItem{
+		value:  &testImportEntity{Code: "a"},
+		set:    bson.M{"code": "a", "creatorId": "u2", "tenantId": "t2", "isDeleted": true, "version": int64(3)},
+		filter: bson.M{"code": "a"},
+	}
+	upsertItem, err := s.buildUpsertItem(entityImport, item)
+	assert.Nil(t, err)
+	// the stored audit,tenant,soft delete and version fields are not updated
+	assert.Equal(t, bson.M{"code": "a"}, upsertItem.Update["$set"])
+}
+
+func TestImportDryRun(t *testing.T) {
+	if log.Logger == nil {
+		log.BuildDefaultLogger()
+	}
+	dir := t.TempDir()
+	s := NewEntityImportService[*testImportEntity](nil, EntityImportServiceWithImportDir(dir))
+	_, err := s.Import(strings.NewReader(""), ImportOptions{FileName: "a.csv", Mode: "replace"})
+	assert.True(t, errors.Is(err, ErrInvalidImportOptions))
+	_, err = s.Import(strings.NewReader(""), ImportOptions{FileName: "a.csv", ColumnFieldMap: map[string]string{"a": "x"}})
+	assert.NotNil(t, err)
+
+	importId, err := s.Import(strings.NewReader("编码,age\na,1\n,2\nc,x\n"), ImportOptions{
+		FileName:       "a.csv",
+		DryRun:         true,
+		ColumnFieldMap: map[string]string{"编码": "code", "age": "age"},
+	})
+	assert.Nil(t, err)
+	entityImport, err := s.GetImport(importId)
+	assert.Nil(t, err)
+	assert.Equal(t, ImportStatus_Finished, entityImport.Status)
+	assert.Equal(t, int64(3), entityImport.RowCount)
+	assert.Equal(t, int64(1), entityImport.SuccessCount)
+	assert.Equal(t, int64(2), entityImport.ErrorCount)
+	assert.Equal(t, 3, entityImport.ErrorList[0].Row)
+	assert.Equal(t, "编码", entityImport.ErrorList[0].Column)
+	assert.Equal(t, 4, entityImport.ErrorList[1].Row)
+
+	report, err := os.ReadFile(entityImport.ReportPath)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(report)), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, "row,column,field,message", lines[0])
+	assert.True(t, strings.HasPrefix(lines[2], "4,age,age,"))
+	_, err = os.Stat(entityImport.FilePath)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,91 @@
+package entity
+
+import (
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// all sheets are imported,the first row of each sheet is the header.
+// cell values are raw values,so a date cell is the excel serial number
+type xlsxImporter struct {
+	file      *excelize.File
+	sheetList []string
+	sheet     int
+	rows      *excelize.Rows
+	columns   []string
+	row       int
+}
+
+var _ IImporter = (*xlsxImporter)(nil)
+
+func (i *xlsxImporter) Begin(r io.Reader) (err error) {
+	i.file, err = excelize.OpenReader(r)
+	if err != nil {
+		return err
+	}
+	i.sheetList = i.file.GetSheetList()
+	i.sheet = -1
+	return nil
+}
+
+func (i *xlsxImporter) ReadRow() (map[string]interface{}, error) {
+	for {
+		if i.rows == nil {
+			if err := i.nextSheet(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !i.rows.Next() {
+			if err := i.rows.Error(); err != nil {
+				return nil, err
+			}
+			i.rows.Close()
+			i.rows = nil
+			continue
+		}
+		i.row++
+		cells, err := i.rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, err
+		}
+		if i.row == 1 {
+			i.columns = make([]string, 0, len(cells))
+			for _, eachCell := range cells {
+				i.columns = append(i.columns, strings.TrimSpace(eachCell))
+			}
+			continue
+		}
+		if len(strings.Join(cells, "")) <= 0 {
+			continue
+		}
+		return importRowFromCells(i.columns, cells), nil
+	}
+}
+
+func (i *xlsxImporter) Position() (string, int) {
+	if i.sheet < 0 || i.sheet >= len(i.sheetList) {
+		return "", i.row
+	}
+	return i.sheetList[i.sheet], i.row
+}
+
+func (i *xlsxImporter) End() error {
+	if i.rows != nil {
+		i.rows.Close()
+	}
+	return i.file.Close()
+}
+
+func (i *xlsxImporter) nextSheet() (err error) {
+	i.sheet++
+	if i.sheet >= len(i.sheetList) {
+		return io.EOF
+	}
+	i.rows, err = i.file.Rows(i.sheetList[i.sheet])
+	i.row = 0
+	i.columns = nil
+	return err
+}
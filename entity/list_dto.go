@@ -5,3 +5,12 @@ import "go.mongodb.org/mongo-driver/bson/primitive"
 type BatchRequestPayload struct {
 	Ids []primitive.ObjectID `form:"ids" json:"ids"`
 }
+
+// result of an item in batch create or batch update,
+// status is a http status code,424 means the item is skipped by an ordered batch
+type BatchItemResult struct {
+	Index  int    `json:"index"`
+	Id     string `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
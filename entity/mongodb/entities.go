@@ -30,5 +30,8 @@ func createEntityRepositoryOption[T mongodbr.IEntity](opts ...mongodbr.Repositor
 			return fo.SetSort(bson.D{{Key: "_id", Value: -1}})
 		}))
 	}
+	if mongodbr.IsSoftDeleteEntity(new(T)) {
+		opts = append(opts, mongodbr.WithSoftDelete())
+	}
 	return opts
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/shanluzhineng/fwpkg/app"
 	"github.com/shanluzhineng/fwpkg/app/web"
 	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/shanluzhineng/fwpkg/system/health"
 	"github.com/shanluzhineng/fwpkg/system/log"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -39,6 +40,7 @@ func initMongodb() {
 			// enable command monitor
 			opts = append(opts, mongodbr.EnableMongodbMonitor())
 		}
+		opts = append(opts, mongodbr.EnableMongodbMetrics(), mongodbr.EnableMongodbTracing())
 
 		if eachKey == mongodb.AliasName_Default {
 			if mongodbr.DefaultClient == nil {
@@ -49,7 +51,7 @@ func initMongodb() {
 				}
 			}
 		} else {
-			client, err = mongodbr.RegistClient(eachKey, eachOption.Uri, func(co *options.ClientOptions) {})
+			client, err = mongodbr.RegistClient(eachKey, eachOption.Uri, mongodbr.EnableMongodbMetrics(), mongodbr.EnableMongodbTracing())
 			if err != nil {
 				log.Logger.Error(err.Error())
 				panic(err)
@@ -67,4 +69,18 @@ func initMongodb() {
 		}
 		log.Logger.Info(fmt.Sprintf(">>> mongo init DONE，uri: %s", eachOption.Uri))
 	}
+	for _, eachChecker := range mongodbr.RegisteredHealthCheckers() {
+		health.Register(eachChecker, health.WithTags(health.TagReadiness))
+	}
+	initCursorSecret()
+}
+
+// 从配置中读取keyset cursor的签名密钥,没有配置时使用随机密钥,cursor只在当前进程内有效
+func initCursorSecret() {
+	cursorOptions := &mongodbr.CursorOptions{}
+	if ok := configurationx.GetInstance().UnmarshalPropertiesTo(mongodbr.CursorConfigurationKey, cursorOptions); !ok || len(cursorOptions.Secret) <= 0 {
+		log.Logger.Warn(fmt.Sprintf("没有配置keyset cursor的密钥,使用随机密钥,cursor在其他实例或重启后无效,key:%s.secret", mongodbr.CursorConfigurationKey))
+		return
+	}
+	mongodbr.SetCursorSecret([]byte(cursorOptions.Secret))
 }
@@ -3,6 +3,9 @@ package entity
 var PaginationDefaultPage = 1
 var PaginationDefaultSize = 10
 
+// max page size of offset pagination and max limit of keyset pagination,larger values are clamped
+var PaginationMaxSize = 1000
+
 type Pagination struct {
 	Page int `form:"page" url:"page"`
 	Size int `form:"size" url:"size"`
@@ -16,3 +19,14 @@ func (p *Pagination) IsDefault() (ok bool) {
 	return p.Page == PaginationDefaultPage &&
 		p.Size == PaginationDefaultSize
 }
+
+// keyset pagination,cursors are returned by list api
+type CursorPagination struct {
+	After  string `form:"after" url:"after"`
+	Before string `form:"before" url:"before"`
+	Limit  int    `form:"limit" url:"limit"`
+}
+
+func (p *CursorPagination) IsZero() (ok bool) {
+	return len(p.After) <= 0 && len(p.Before) <= 0 && p.Limit == 0
+}
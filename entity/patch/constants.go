@@ -0,0 +1,32 @@
+package patch
+
+import (
+	"strings"
+
+	"github.com/shanluzhineng/fwpkg/utils/str"
+)
+
+const (
+	// RFC 7396 JSON Merge Patch
+	ContentTypeMergePatch = "application/merge-patch+json"
+	// RFC 6902 JSON Patch
+	ContentTypeJsonPatch = "application/json-patch+json"
+)
+
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// fields that can never be patched,they are maintained by the repository and the controller:
+// id,creation audit,tenant,soft delete and version
+var readonlyFieldList = []string{"_id", "creatorId", "creationTime", "tenantId", "isDeleted", "deletionTime", "deleterId", "version"}
+
+// whether the top level field of bson path can never be patched
+func IsReadonlyField(bsonPath string) bool {
+	return str.InSlice(strings.Split(bsonPath, ".")[0], readonlyFieldList)
+}
@@ -0,0 +1,199 @@
+package patch
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// get the value located at bson path of a decoded document
+func lookupValue(doc interface{}, path string) (interface{}, bool) {
+	current := doc
+	for _, eachSegment := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case bson.M:
+			value, ok := v[eachSegment]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case map[string]interface{}:
+			value, ok := v[eachSegment]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case primitive.D:
+			value, ok := v.Map()[eachSegment]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case primitive.A:
+			value, ok := arrayElement(v, eachSegment)
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			value, ok := arrayElement(v, eachSegment)
+			if !ok {
+				return nil, false
+			}
+			current = value
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func lookupArray(doc interface{}, path string) ([]interface{}, bool) {
+	value, ok := lookupValue(doc, path)
+	if !ok {
+		return nil, false
+	}
+	switch v := value.(type) {
+	case primitive.A:
+		return v, true
+	case []interface{}:
+		return v, true
+	}
+	return nil, false
+}
+
+func arrayElement(list []interface{}, segment string) (interface{}, bool) {
+	index, err := strconv.Atoi(segment)
+	if err != nil || index < 0 || index >= len(list) {
+		return nil, false
+	}
+	return list[index], true
+}
+
+func isDocumentValue(v interface{}) bool {
+	switch v.(type) {
+	case bson.M, map[string]interface{}, primitive.D:
+		return true
+	}
+	return false
+}
+
+// compare a stored bson value with a json value, both are normalized to
+// their json representation through the go type of the field
+func jsonEqual(stored interface{}, expected json.RawMessage, t reflect.Type) (bool, error) {
+	storedValue, err := bsonToJsonValue(stored, t)
+	if err != nil {
+		return false, err
+	}
+	expectedValue, err := coerceValue(expected, t)
+	if err != nil {
+		return false, err
+	}
+	expectedValue, err = jsonRoundTrip(expectedValue)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(storedValue, expectedValue), nil
+}
+
+func bsonToJsonValue(v interface{}, t reflect.Type) (interface{}, error) {
+	if t == nil || indirect(t).Kind() == reflect.Interface {
+		return jsonRoundTrip(normalizeBsonValue(v))
+	}
+	raw, err := bson.Marshal(bson.D{{Key: "v", Value: v}})
+	if err != nil {
+		return nil, err
+	}
+	holder := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: "V",
+		Type: t,
+		Tag:  `bson:"v"`,
+	}}))
+	if err := bson.Unmarshal(raw, holder.Interface()); err != nil {
+		return nil, err
+	}
+	return jsonRoundTrip(holder.Elem().Field(0).Interface())
+}
+
+// convert bson specific container types into plain go values
+func normalizeBsonValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case primitive.D:
+		m := make(map[string]interface{}, len(value))
+		for _, e := range value {
+			m[e.Key] = normalizeBsonValue(e.Value)
+		}
+		return m
+	case bson.M:
+		m := make(map[string]interface{}, len(value))
+		for k, e := range value {
+			m[k] = normalizeBsonValue(e)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, e := range value {
+			m[k] = normalizeBsonValue(e)
+		}
+		return m
+	case primitive.A:
+		return normalizeBsonValue([]interface{}(value))
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, e := range value {
+			list[i] = normalizeBsonValue(e)
+		}
+		return list
+	case primitive.DateTime:
+		return value.Time()
+	}
+	return v
+}
+
+func jsonRoundTrip(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// decode json value into the go type of the field, so that it is stored
+// with the same bson type as the entity would be (ObjectID, time and so on)
+func coerceValue(data json.RawMessage, t reflect.Type) (interface{}, error) {
+	if t == nil || indirect(t).Kind() == reflect.Interface {
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func isArrayType(t reflect.Type) bool {
+	t = indirect(t)
+	if t == nil || (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) {
+		return false
+	}
+	return t.Elem().Kind() != reflect.Uint8
+}
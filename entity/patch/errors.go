@@ -0,0 +1,21 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// patch document cannot be parsed
+	ErrInvalidPatch = errors.New("invalid patch document")
+	// path does not exist in the entity,or the value cannot be applied to it
+	ErrInvalidPath = errors.New("invalid patch path")
+	// operation cannot be translated into a single mongodb update
+	ErrUnsupportedOperation = errors.New("unsupported patch operation")
+	// json patch test operation failed
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+func newPatchError(kind error, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", kind, fmt.Sprintf(format, args...))
+}
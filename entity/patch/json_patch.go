@@ -0,0 +1,216 @@
+package patch
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// RFC 6902 json patch operation
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// translate a RFC 6902 json patch into a mongodb update document.
+// all operations are evaluated against current (the stored document), so operations
+// that touch the same path are rejected instead of being applied one after another.
+//
+//	add     -> $set, or $push for "/list/-" and "/list/{index}"
+//	remove  -> $unset, or $pull of the element for "/list/{index}"
+//	replace -> $set
+//	move    -> $unset from + $set path
+//	copy    -> $set path
+//	test    -> compared with current,ErrTestFailed when not equal
+func JsonPatchToUpdate(data []byte, fieldSet *mongodbr.EntityFieldSet, current map[string]interface{}) (bson.M, error) {
+	var operationList []Operation
+	if err := json.Unmarshal(data, &operationList); err != nil {
+		return nil, newPatchError(ErrInvalidPatch, "json patch must be an array of operations,%s", err.Error())
+	}
+	b := newUpdateBuilder(fieldSet)
+	for _, eachOperation := range operationList {
+		if err := b.applyOperation(eachOperation, current); err != nil {
+			return nil, err
+		}
+	}
+	return b.toValue(), nil
+}
+
+func (b *updateBuilder) applyOperation(operation Operation, current map[string]interface{}) error {
+	path, err := pointerToPath(operation.Path)
+	if err != nil {
+		return err
+	}
+	switch operation.Op {
+	case OpAdd:
+		if len(operation.Value) <= 0 {
+			return newPatchError(ErrInvalidPatch, "value is required,op:%s,path:%s", operation.Op, operation.Path)
+		}
+		return b.add(path, operation.Value, nil)
+	case OpRemove:
+		return b.remove(path, current)
+	case OpReplace:
+		if len(operation.Value) <= 0 {
+			return newPatchError(ErrInvalidPatch, "value is required,op:%s,path:%s", operation.Op, operation.Path)
+		}
+		bsonPath, t, err := b.resolve(path)
+		if err != nil {
+			return err
+		}
+		if _, ok := lookupValue(current, bsonPath); !ok {
+			return newPatchError(ErrInvalidPath, "path not exist,path:%s", operation.Path)
+		}
+		v, err := b.coerce(path, operation.Value, t)
+		if err != nil {
+			return err
+		}
+		return b.set(bsonPath, v)
+	case OpMove, OpCopy:
+		from, err := pointerToPath(operation.From)
+		if err != nil {
+			return err
+		}
+		fromBsonPath, _, err := b.resolve(from)
+		if err != nil {
+			return err
+		}
+		value, ok := lookupValue(current, fromBsonPath)
+		if !ok {
+			return newPatchError(ErrInvalidPath, "from path not exist,from:%s", operation.From)
+		}
+		if operation.Op == OpMove {
+			if err := b.remove(from, current); err != nil {
+				return err
+			}
+		}
+		return b.add(path, nil, value)
+	case OpTest:
+		bsonPath, t, err := b.resolve(path)
+		if err != nil {
+			return err
+		}
+		value, ok := lookupValue(current, bsonPath)
+		if !ok {
+			return newPatchError(ErrTestFailed, "path not exist,path:%s", operation.Path)
+		}
+		equal, err := jsonEqual(value, operation.Value, t)
+		if err != nil {
+			return newPatchError(ErrInvalidPatch, "invalid value for %s,%s", operation.Path, err.Error())
+		}
+		if !equal {
+			return newPatchError(ErrTestFailed, "value not equal,path:%s", operation.Path)
+		}
+		return nil
+	}
+	return newPatchError(ErrInvalidPatch, "unknown op %s", operation.Op)
+}
+
+// add json value (data) or an already stored bson value (storedValue) at path
+func (b *updateBuilder) add(path string, data json.RawMessage, storedValue interface{}) error {
+	parentPath, last := splitLastSegment(path)
+	if len(parentPath) > 0 && (last == "-" || isIndex(last)) {
+		parentBsonPath, parentType, err := b.resolve(parentPath)
+		if err != nil {
+			return err
+		}
+		if isArrayType(parentType) {
+			value := storedValue
+			if data != nil {
+				if value, err = b.coerce(path, data, indirect(parentType).Elem()); err != nil {
+					return err
+				}
+			}
+			if last == "-" {
+				return b.push(parentBsonPath, value)
+			}
+			index, _ := strconv.Atoi(last)
+			return b.pushAt(parentBsonPath, index, value)
+		}
+		if last == "-" {
+			return newPatchError(ErrInvalidPath, "%s is not an array,path:%s", parentPath, path)
+		}
+	}
+	bsonPath, t, err := b.resolve(path)
+	if err != nil {
+		return err
+	}
+	value := storedValue
+	if data != nil {
+		if value, err = b.coerce(path, data, t); err != nil {
+			return err
+		}
+	}
+	return b.set(bsonPath, value)
+}
+
+func (b *updateBuilder) remove(path string, current map[string]interface{}) error {
+	parentPath, last := splitLastSegment(path)
+	if len(parentPath) > 0 && isIndex(last) {
+		parentBsonPath, parentType, err := b.resolve(parentPath)
+		if err != nil {
+			return err
+		}
+		if isArrayType(parentType) {
+			list, ok := lookupArray(current, parentBsonPath)
+			index, _ := strconv.Atoi(last)
+			if !ok || index >= len(list) {
+				return newPatchError(ErrInvalidPath, "path not exist,path:%s", path)
+			}
+			//$pull removes every equal element,only allowed when the element is unique
+			element := list[index]
+			for i, eachElement := range list {
+				if i != index && reflect.DeepEqual(eachElement, element) {
+					return newPatchError(ErrUnsupportedOperation, "array element is not unique,path:%s", path)
+				}
+			}
+			return b.pull(parentBsonPath, element)
+		}
+	}
+	bsonPath, _, err := b.resolve(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := lookupValue(current, bsonPath); !ok {
+		return newPatchError(ErrInvalidPath, "path not exist,path:%s", path)
+	}
+	return b.unset(bsonPath)
+}
+
+// convert json pointer (RFC 6901) to dotted path
+func pointerToPath(pointer string) (string, error) {
+	if len(pointer) <= 0 {
+		return "", newPatchError(ErrUnsupportedOperation, "the whole document cannot be patched")
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return "", newPatchError(ErrInvalidPath, "json pointer must start with /,path:%s", pointer)
+	}
+	segmentList := strings.Split(pointer[1:], "/")
+	for i, eachSegment := range segmentList {
+		eachSegment = strings.ReplaceAll(eachSegment, "~1", "/")
+		eachSegment = strings.ReplaceAll(eachSegment, "~0", "~")
+		if len(eachSegment) <= 0 || strings.Contains(eachSegment, ".") {
+			return "", newPatchError(ErrInvalidPath, "invalid path segment,path:%s", pointer)
+		}
+		segmentList[i] = eachSegment
+	}
+	return strings.Join(segmentList, "."), nil
+}
+
+func splitLastSegment(path string) (string, string) {
+	index := strings.LastIndex(path, ".")
+	if index < 0 {
+		return "", path
+	}
+	return path[:index], path[index+1:]
+}
+
+func isIndex(segment string) bool {
+	index, err := strconv.Atoi(segment)
+	return err == nil && index >= 0 && strconv.Itoa(index) == segment
+}
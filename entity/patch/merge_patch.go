@@ -0,0 +1,145 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// translate a RFC 7396 json merge patch into a mongodb update document.
+// null members become $unset, nested objects are merged field by field with dotted $set
+// when the stored value is a document, any other value is replaced as a whole.
+// current is the stored document,used to decide whether a nested object can be merged
+func MergePatchToUpdate(data []byte, fieldSet *mongodbr.EntityFieldSet, current map[string]interface{}) (bson.M, error) {
+	patch, ok := decodeObject(data)
+	if !ok {
+		return nil, newPatchError(ErrInvalidPatch, "merge patch must be a json object")
+	}
+	b := newUpdateBuilder(fieldSet)
+	if err := b.mergeObject("", patch, current); err != nil {
+		return nil, err
+	}
+	return b.toValue(), nil
+}
+
+func (b *updateBuilder) mergeObject(prefix string, patch map[string]json.RawMessage, current map[string]interface{}) error {
+	keyList := make([]string, 0, len(patch))
+	for eachKey := range patch {
+		keyList = append(keyList, eachKey)
+	}
+	sort.Strings(keyList)
+
+	for _, eachKey := range keyList {
+		value := patch[eachKey]
+		path := eachKey
+		if len(prefix) > 0 {
+			path = prefix + "." + eachKey
+		}
+		bsonPath, t, err := b.resolve(path)
+		if err != nil {
+			return err
+		}
+		if isJsonNull(value) {
+			if err := b.unset(bsonPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if patchObject, ok := decodeObject(value); ok {
+			currentValue, exists := lookupValue(current, bsonPath)
+			if !exists || isDocumentValue(currentValue) {
+				if err := b.mergeObject(path, patchObject, current); err != nil {
+					return err
+				}
+				continue
+			}
+			//stored value is not a document,replace it with the patch without null members
+			value, _ = json.Marshal(removeNullMembers(patchObject))
+		}
+		v, err := b.coerce(path, value, t)
+		if err != nil {
+			return err
+		}
+		if err := b.set(bsonPath, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeObject(data []byte) (map[string]json.RawMessage, bool) {
+	data = bytes.TrimSpace(data)
+	if len(data) <= 0 || data[0] != '{' {
+		return nil, false
+	}
+	object := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, false
+	}
+	return object, true
+}
+
+func isJsonNull(data json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+func removeNullMembers(object map[string]json.RawMessage) map[string]interface{} {
+	result := make(map[string]interface{}, len(object))
+	for eachKey, eachValue := range object {
+		if isJsonNull(eachValue) {
+			continue
+		}
+		if nested, ok := decodeObject(eachValue); ok {
+			result[eachKey] = removeNullMembers(nested)
+			continue
+		}
+		result[eachKey] = eachValue
+	}
+	return result
+}
+
+// apply a RFC 7396 json merge patch to a json document and return the patched document,
+// it can be used to validate the result of a patch before the update is written
+func ApplyMergePatch(doc []byte, data []byte) ([]byte, error) {
+	patch, ok := decodeObject(data)
+	if !ok {
+		return nil, newPatchError(ErrInvalidPatch, "merge patch must be a json object")
+	}
+	target := make(map[string]interface{})
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := mergeJsonObject(target, patch); err != nil {
+		return nil, err
+	}
+	return json.Marshal(target)
+}
+
+func mergeJsonObject(target map[string]interface{}, patch map[string]json.RawMessage) error {
+	for eachKey, eachValue := range patch {
+		if isJsonNull(eachValue) {
+			delete(target, eachKey)
+			continue
+		}
+		if patchObject, ok := decodeObject(eachValue); ok {
+			targetObject, ok := target[eachKey].(map[string]interface{})
+			if !ok {
+				targetObject = make(map[string]interface{})
+			}
+			if err := mergeJsonObject(targetObject, patchObject); err != nil {
+				return err
+			}
+			target[eachKey] = targetObject
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(eachValue, &v); err != nil {
+			return newPatchError(ErrInvalidPatch, "invalid value of %s", eachKey)
+		}
+		target[eachKey] = v
+	}
+	return nil
+}
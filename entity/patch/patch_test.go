@@ -0,0 +1,88 @@
+package patch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type testAddress struct {
+	City string `json:"city" bson:"city"`
+}
+
+type testUser struct {
+	Name    string      `json:"name" bson:"name"`
+	Age     int         `json:"age" bson:"age"`
+	Tags    []string    `json:"tags" bson:"tags"`
+	Address testAddress `json:"address" bson:"address"`
+}
+
+func TestMergePatchToUpdate(t *testing.T) {
+	fieldSet := mongodbr.GetEntityFieldSet(testUser{})
+	current := map[string]interface{}{"name": "a", "age": 1, "address": map[string]interface{}{"city": "x"}}
+
+	update, err := MergePatchToUpdate([]byte(`{"age":2,"name":null,"address":{"city":"y"}}`), fieldSet, current)
+	assert.Nil(t, err)
+	assert.Equal(t, bson.M{"age": 2, "address.city": "y"}, update["$set"])
+	assert.Equal(t, bson.M{"name": ""}, update["$unset"])
+
+	_, err = MergePatchToUpdate([]byte(`{"unknown":1}`), fieldSet, current)
+	assert.True(t, errors.Is(err, ErrInvalidPath))
+}
+
+func TestJsonPatchToUpdate(t *testing.T) {
+	fieldSet := mongodbr.GetEntityFieldSet(testUser{})
+	current := map[string]interface{}{"name": "a", "age": 1, "tags": []interface{}{"t1"}}
+
+	update, err := JsonPatchToUpdate([]byte(`[{"op":"test","path":"/name","value":"a"},{"op":"replace","path":"/age","value":3},{"op":"add","path":"/tags/-","value":"t2"}]`), fieldSet, current)
+	assert.Nil(t, err)
+	assert.Equal(t, bson.M{"age": 3}, update["$set"])
+	assert.NotNil(t, update["$push"])
+
+	_, err = JsonPatchToUpdate([]byte(`[{"op":"test","path":"/name","value":"b"}]`), fieldSet, current)
+	assert.True(t, errors.Is(err, ErrTestFailed))
+}
+
+type testDocument struct {
+	Id           string    `json:"id" bson:"_id"`
+	Name         string    `json:"name" bson:"name"`
+	CreatorId    string    `json:"creatorId" bson:"creatorId"`
+	CreationTime time.Time `json:"creationTime" bson:"creationTime"`
+	TenantId     string    `json:"tenantId" bson:"tenantId"`
+	IsDeleted    bool      `json:"isDeleted" bson:"isDeleted"`
+	DeletionTime time.Time `json:"deletionTime" bson:"deletionTime"`
+	DeleterId    string    `json:"deleterId" bson:"deleterId"`
+	Version      int64     `json:"version" bson:"version"`
+}
+
+func TestPatchReadonlyFields(t *testing.T) {
+	fieldSet := mongodbr.GetEntityFieldSet(testDocument{})
+	current := map[string]interface{}{"name": "a", "creatorId": "u1", "tenantId": "t1", "version": 1}
+	for _, eachField := range []string{"id", "creatorId", "creationTime", "tenantId", "isDeleted", "deletionTime", "deleterId", "version"} {
+		_, err := MergePatchToUpdate([]byte(`{"name":"b","`+eachField+`":null}`), fieldSet, current)
+		assert.True(t, errors.Is(err, ErrInvalidPath), eachField)
+		_, err = JsonPatchToUpdate([]byte(`[{"op":"replace","path":"/`+eachField+`","value":"x"}]`), fieldSet, current)
+		assert.True(t, errors.Is(err, ErrInvalidPath), eachField)
+		_, err = JsonPatchToUpdate([]byte(`[{"op":"remove","path":"/`+eachField+`"}]`), fieldSet, current)
+		assert.True(t, errors.Is(err, ErrInvalidPath), eachField)
+	}
+	_, err := MergePatchToUpdate([]byte(`{"isDeleted":true}`), fieldSet, current)
+	assert.True(t, errors.Is(err, ErrInvalidPath))
+
+	update, err := MergePatchToUpdate([]byte(`{"name":"b"}`), fieldSet, current)
+	assert.Nil(t, err)
+	assert.Equal(t, bson.M{"name": "b"}, update["$set"])
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	result, err := ApplyMergePatch([]byte(`{"name":"a","age":1,"address":{"city":"x"}}`), []byte(`{"name":null,"age":2,"address":{"city":"y"}}`))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"age":2,"address":{"city":"y"}}`, string(result))
+
+	_, err = ApplyMergePatch([]byte(`{}`), []byte(`[]`))
+	assert.True(t, errors.Is(err, ErrInvalidPatch))
+}
@@ -0,0 +1,122 @@
+package patch
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/shanluzhineng/fwpkg/mongodbr"
+	"github.com/shanluzhineng/fwpkg/mongodbr/builder"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// collect the update operators of a patch,mongodb rejects an update
+// that touches the same path (or a parent and a child path) twice
+type updateBuilder struct {
+	fieldSet *mongodbr.EntityFieldSet
+	builder  *builder.BsonBuilder
+
+	pathList  []string
+	pushList  map[string][]interface{}
+	pushOrder []string
+}
+
+func newUpdateBuilder(fieldSet *mongodbr.EntityFieldSet) *updateBuilder {
+	return &updateBuilder{
+		fieldSet: fieldSet,
+		builder:  builder.NewBsonBuilder(),
+		pushList: make(map[string][]interface{}),
+	}
+}
+
+// resolve path against the entity fields
+func (b *updateBuilder) resolve(path string) (string, reflect.Type, error) {
+	for _, eachSegment := range strings.Split(path, ".") {
+		if strings.HasPrefix(eachSegment, "$") {
+			return "", nil, newPatchError(ErrInvalidPath, "invalid path segment %s,path:%s", eachSegment, path)
+		}
+	}
+	bsonPath, t, err := b.fieldSet.ResolvePath(path)
+	if err != nil {
+		return "", nil, newPatchError(ErrInvalidPath, err.Error())
+	}
+	if IsReadonlyField(bsonPath) {
+		return "", nil, newPatchError(ErrInvalidPath, "field is readonly,path:%s", path)
+	}
+	return bsonPath, t, nil
+}
+
+func (b *updateBuilder) coerce(path string, data json.RawMessage, t reflect.Type) (interface{}, error) {
+	v, err := coerceValue(data, t)
+	if err != nil {
+		return nil, newPatchError(ErrInvalidPath, "invalid value for %s,%s", path, err.Error())
+	}
+	return v, nil
+}
+
+func (b *updateBuilder) touch(path string) error {
+	for _, eachPath := range b.pathList {
+		if eachPath == path ||
+			strings.HasPrefix(eachPath, path+".") ||
+			strings.HasPrefix(path, eachPath+".") {
+			return newPatchError(ErrUnsupportedOperation, "path %s conflicts with %s", path, eachPath)
+		}
+	}
+	b.pathList = append(b.pathList, path)
+	return nil
+}
+
+func (b *updateBuilder) set(path string, v interface{}) error {
+	if err := b.touch(path); err != nil {
+		return err
+	}
+	b.builder.SetField(path, v)
+	return nil
+}
+
+func (b *updateBuilder) unset(path string) error {
+	if err := b.touch(path); err != nil {
+		return err
+	}
+	b.builder.UnsetField(path)
+	return nil
+}
+
+// append to the end of array,several appends to the same array are merged into one $push
+func (b *updateBuilder) push(path string, v interface{}) error {
+	if _, ok := b.pushList[path]; !ok {
+		if err := b.touch(path); err != nil {
+			return err
+		}
+		b.pushOrder = append(b.pushOrder, path)
+	}
+	b.pushList[path] = append(b.pushList[path], v)
+	return nil
+}
+
+func (b *updateBuilder) pushAt(path string, position int, v interface{}) error {
+	if err := b.touch(path); err != nil {
+		return err
+	}
+	b.builder.PushFieldAt(path, position, v)
+	return nil
+}
+
+func (b *updateBuilder) pull(path string, v interface{}) error {
+	if err := b.touch(path); err != nil {
+		return err
+	}
+	b.builder.PullField(path, v)
+	return nil
+}
+
+func (b *updateBuilder) toValue() bson.M {
+	for _, eachPath := range b.pushOrder {
+		b.builder.PushField(eachPath, b.pushList[eachPath]...)
+	}
+	value := b.builder.ToValue()
+	if value == nil {
+		return bson.M{}
+	}
+	return value
+}
@@ -24,6 +24,50 @@ func (b *BsonBuilder) NewOrUpdateSet(v interface{}) *BsonBuilder {
 	return b
 }
 
+// 设置指定字段的值,{$set:{fieldName:v}}
+func (b *BsonBuilder) SetField(fieldName string, v interface{}) *BsonBuilder {
+	return b.withOpField(Op_Set(), fieldName, v)
+}
+
+// 删除指定字段,{$unset:{fieldName:""}}
+func (b *BsonBuilder) UnsetField(fieldName string) *BsonBuilder {
+	return b.withOpField(Op_Unset(), fieldName, "")
+}
+
+// 字段值增加v,{$inc:{fieldName:v}}
+func (b *BsonBuilder) IncField(fieldName string, v interface{}) *BsonBuilder {
+	return b.withOpField(Op_Inc(), fieldName, v)
+}
+
+// 向数组字段追加元素,{$push:{fieldName:{$each:values}}}
+func (b *BsonBuilder) PushField(fieldName string, values ...interface{}) *BsonBuilder {
+	return b.withOpField(Op_Push(), fieldName, bson.M{Op_Each().String(): values})
+}
+
+// 在数组字段的指定位置插入元素,{$push:{fieldName:{$each:values,$position:position}}}
+func (b *BsonBuilder) PushFieldAt(fieldName string, position int, values ...interface{}) *BsonBuilder {
+	return b.withOpField(Op_Push(), fieldName, bson.M{
+		Op_Each().String():     values,
+		Op_Position().String(): position,
+	})
+}
+
+// 从数组字段中删除所有与v匹配的元素,{$pull:{fieldName:v}}
+func (b *BsonBuilder) PullField(fieldName string, v interface{}) *BsonBuilder {
+	return b.withOpField(Op_Pull(), fieldName, v)
+}
+
+func (b *BsonBuilder) withOpField(op *Op, fieldName string, v interface{}) *BsonBuilder {
+	b.ensureBson()
+	opValue, ok := b.bson[op.String()].(bson.M)
+	if !ok {
+		opValue = bson.M{}
+		b.bson[op.String()] = opValue
+	}
+	opValue[fieldName] = v
+	return b
+}
+
 func (b *BsonBuilder) ensureBson() *BsonBuilder {
 	if b.bson == nil {
 		b.bson = bson.M{}
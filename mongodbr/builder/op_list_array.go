@@ -17,6 +17,11 @@ const (
 	//The $elemMatch operator matches documents that contain an array field with at least one element
 	// that matches all the specified query criteria.
 	op_array_elemMatch string = "$elemMatch"
+
+	// Modifies the $push and $addToSet operators to append multiple items for array updates.
+	op_array_each string = "$each"
+	// Modifies the $push operator to specify the position in the array to add elements.
+	op_array_position string = "$position"
 )
 
 func init() {
@@ -26,6 +31,8 @@ func init() {
 	_opList[op_array_push] = &Op{name: op_array_push}
 	_opList[op_array_pullAll] = &Op{name: op_array_pullAll}
 	_opList[op_array_elemMatch] = &Op{name: op_array_elemMatch}
+	_opList[op_array_each] = &Op{name: op_array_each}
+	_opList[op_array_position] = &Op{name: op_array_position}
 }
 
 func Op_AddToSet() *Op {
@@ -51,3 +58,11 @@ func Op_PullAll() *Op {
 func Op_ElemMatch() *Op {
 	return _opList[op_array_elemMatch]
 }
+
+func Op_Each() *Op {
+	return _opList[op_array_each]
+}
+
+func Op_Position() *Op {
+	return _opList[op_array_position]
+}
@@ -0,0 +1,30 @@
+package builder
+
+const (
+	//https://www.mongodb.com/docs/manual/reference/operator/update-field/
+
+	// Increments the value of the field by the specified amount.
+	op_field_inc string = "$inc"
+	// Removes the specified field from a document.
+	op_field_unset string = "$unset"
+	// Sets the value of a field if an update results in an insert of a document.
+	op_field_setOnInsert string = "$setOnInsert"
+)
+
+func init() {
+	_opList[op_field_inc] = &Op{name: op_field_inc}
+	_opList[op_field_unset] = &Op{name: op_field_unset}
+	_opList[op_field_setOnInsert] = &Op{name: op_field_setOnInsert}
+}
+
+func Op_Inc() *Op {
+	return _opList[op_field_inc]
+}
+
+func Op_Unset() *Op {
+	return _opList[op_field_unset]
+}
+
+func Op_SetOnInsert() *Op {
+	return _opList[op_field_setOnInsert]
+}
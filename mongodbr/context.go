@@ -0,0 +1,30 @@
+package mongodbr
+
+import (
+	"context"
+)
+
+type IEntityContext interface {
+	// a view of repository that runs the commands with ctx,
+	// the commands are canceled with ctx and the spans of commands are children of the span in ctx
+	WithContext(ctx context.Context) IRepository
+}
+
+// #region context members
+
+// a view of collection that runs the commands with ctx
+func (r *MongoCol) WithContext(ctx context.Context) *MongoCol {
+	return &MongoCol{
+		configuration: r.configuration,
+		collection:    r.collection,
+		scope:         r.scope,
+		ctx:           ctx,
+	}
+}
+
+// #endregion
+
+// context of a command,the parent is the context of view or background
+func (r *MongoCol) createContext() (context.Context, context.CancelFunc) {
+	return CreateContextWithParent(r.ctx, r.configuration)
+}
@@ -0,0 +1,46 @@
+package mongodbr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shanluzhineng/fwpkg/system/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithContextSpanParent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock).ClientOptions(options.Client().SetMonitor(newTracingMonitor(nil))))
+	mt.Run("find", func(mt *mtest.T) {
+		repository, err := NewRepositoryBase(func() *mongo.Collection { return mt.Coll })
+		assert.NoError(mt, err)
+		ctx, parent := tracing.Tracer().Start(context.Background(), "request")
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, bson.D{{Key: "_id", Value: primitive.NewObjectID()}}))
+		assert.NoError(mt, repository.WithContext(ctx).FindOne(bson.M{}).One(&bson.M{}))
+		parent.End()
+
+		// the span of command is the child of request span
+		spanList := recorder.Ended()
+		assert.Len(mt, spanList, 2)
+		assert.Equal(mt, mt.Coll.Name()+".find", spanList[0].Name())
+		assert.Equal(mt, parent.SpanContext().SpanID(), spanList[0].Parent().SpanID())
+
+		// the view keeps the context
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch))
+		count, err := repository.WithContext(ctx).WithScope(bson.M{"tenantId": "t1"}).CountByFilter(nil)
+		assert.NoError(mt, err)
+		assert.Equal(mt, int64(0), count)
+		spanList = recorder.Ended()
+		assert.Len(mt, spanList, 3)
+		assert.Equal(mt, parent.SpanContext().SpanID(), spanList[2].Parent().SpanID())
+	})
+}
@@ -1,6 +1,8 @@
 package mongodbr
 
 import (
+	"errors"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -12,20 +14,43 @@ import (
 type EntityUpdate struct {
 }
 type IEntityBulkWrite interface {
+	// models are written as they are,the scope of repository is not added
 	BulkWrite(models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error)
 	BulkWriteEntityList(entityList []IEntity, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error)
+	// update documents by id in one bulk write
+	BulkUpdateById(itemList []BulkUpdateItem, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error)
+	// update the document matched by filter or insert a new one in one bulk write
+	BulkUpsert(itemList []BulkUpsertItem, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error)
+}
+
+// a document update in bulk write
+type BulkUpdateItem struct {
+	Id primitive.ObjectID
+	// update document,update operators such as $set,$unset can be used
+	Update bson.M
+	// expected version for versioned entity,the version is checked and increased if it is not nil
+	Version *int64
+}
+
+// a document upsert in bulk write
+type BulkUpsertItem struct {
+	Filter interface{}
+	// update document,$setOnInsert sets the fields of inserted document
+	Update bson.M
+	// the version of versioned entity is increased,it is 1 for the inserted document
+	Versioned bool
 }
 
 var _ IEntityBulkWrite = (*MongoCol)(nil)
 
-func _buildWriteModelForUpdate(list []IEntity) []mongo.WriteModel {
+func _buildWriteModelForUpdate(list []IEntity, scopeFilter func(filter interface{}) interface{}) []mongo.WriteModel {
 	modelList := make([]mongo.WriteModel, 0)
 	if len(list) <= 0 {
 		return modelList
 	}
 	for _, eachEntity := range list {
 		currentModel := mongo.NewUpdateOneModel()
-		currentModel.SetFilter(bson.M{"_id": eachEntity.GetObjectId()})
+		currentModel.SetFilter(scopeFilter(bson.M{"_id": eachEntity.GetObjectId()}))
 		currentModel.SetUpdate(builder.NewBsonBuilder().NewOrUpdateSet(eachEntity))
 		modelList = append(modelList, currentModel)
 	}
@@ -62,6 +87,19 @@ func BuildWriteModelList(filterList []interface{}, getUpdateFn func(filter inter
 	return modelList
 }
 
+// key is the index of the failed write model,return false if err is not a bulk write error
+func BulkWriteErrorMap(err error) (map[int]error, bool) {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) {
+		return nil, false
+	}
+	result := make(map[int]error)
+	for _, eachError := range bulkErr.WriteErrors {
+		result[eachError.Index] = eachError
+	}
+	return result, true
+}
+
 // #region update members
 
 func (c *MongoCol) BulkWrite(models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (
@@ -70,7 +108,7 @@ func (c *MongoCol) BulkWrite(models []mongo.WriteModel, opts ...*options.BulkWri
 		return nil, nil
 	}
 	//没有设置参数，使用默认的
-	ctx, cancel := CreateContext(c.configuration)
+	ctx, cancel := c.createContext()
 	defer cancel()
 
 	res, err := c.collection.BulkWrite(
@@ -87,7 +125,45 @@ func (c *MongoCol) BulkWrite(models []mongo.WriteModel, opts ...*options.BulkWri
 
 func (c *MongoCol) BulkWriteEntityList(entityList []IEntity, opts ...*options.BulkWriteOptions) (
 	*mongo.BulkWriteResult, error) {
-	modelList := _buildWriteModelForUpdate(entityList)
+	modelList := _buildWriteModelForUpdate(entityList, c.scopeFilter)
+	return c.BulkWrite(modelList, opts...)
+}
+
+func (c *MongoCol) BulkUpdateById(itemList []BulkUpdateItem, opts ...*options.BulkWriteOptions) (
+	*mongo.BulkWriteResult, error) {
+	modelList := make([]mongo.WriteModel, 0, len(itemList))
+	for _, eachItem := range itemList {
+		var filter interface{} = bson.M{"_id": eachItem.Id}
+		update := eachItem.Update
+		if eachItem.Version != nil {
+			filter = versionFilter(filter, *eachItem.Version)
+			update = withVersionIncrement(update)
+		}
+		currentModel := mongo.NewUpdateOneModel()
+		currentModel.SetFilter(c.scopeFilter(filter))
+		currentModel.SetUpdate(update)
+		modelList = append(modelList, currentModel)
+	}
+	return c.BulkWrite(modelList, opts...)
+}
+
+func (c *MongoCol) BulkUpsert(itemList []BulkUpsertItem, opts ...*options.BulkWriteOptions) (
+	*mongo.BulkWriteResult, error) {
+	modelList := make([]mongo.WriteModel, 0, len(itemList))
+	for _, eachItem := range itemList {
+		update := eachItem.Update
+		if eachItem.Versioned {
+			if insertValue, ok := update["$setOnInsert"]; ok {
+				update["$setOnInsert"] = removeDocumentKey(insertValue, VersionFieldName)
+			}
+			update = withVersionIncrement(update)
+		}
+		currentModel := mongo.NewUpdateOneModel()
+		currentModel.SetFilter(c.scopeFilter(eachItem.Filter))
+		currentModel.SetUpdate(update)
+		currentModel.SetUpsert(true)
+		modelList = append(modelList, currentModel)
+	}
 	return c.BulkWrite(modelList, opts...)
 }
 
@@ -0,0 +1,314 @@
+package mongodbr
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	_entityFieldSetCache = make(map[reflect.Type]*EntityFieldSet)
+	_entityFieldSetLock  sync.RWMutex
+
+	_timeType           = reflect.TypeOf(time.Time{})
+	_jsonMarshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	_bsonMarshalerType  = reflect.TypeOf((*bson.ValueMarshaler)(nil)).Elem()
+	_emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// describe a field of an entity as it is stored in mongodb
+type EntityField struct {
+	//name of the field in bson document
+	BsonName string
+	//name of the field in json document
+	JsonName string
+	//go type of the field
+	Type reflect.Type
+	//field is a slice or array (not []byte)
+	IsArray bool
+	//field is a map,any key can be used as sub path
+	IsMap bool
+	//nested document fields, nil if field is not a document (or array of document)
+	Fields *EntityFieldSet
+	//struct field tags
+	Tag reflect.StructTag
+}
+
+// the bson field list of an entity type
+type EntityFieldSet struct {
+	fieldList []*EntityField
+	bsonIndex map[string]*EntityField
+	jsonIndex map[string]*EntityField
+}
+
+// get the field set of entity,v can be an entity instance or a reflect.Type
+func GetEntityFieldSet(v interface{}) *EntityFieldSet {
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+	t = indirectType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return newEntityFieldSet()
+	}
+	_entityFieldSetLock.RLock()
+	s, ok := _entityFieldSetCache[t]
+	_entityFieldSetLock.RUnlock()
+	if ok {
+		return s
+	}
+	_entityFieldSetLock.Lock()
+	defer _entityFieldSetLock.Unlock()
+	return buildEntityFieldSet(t)
+}
+
+// field list in declaration order
+func (s *EntityFieldSet) FieldList() []*EntityField {
+	return s.fieldList
+}
+
+// find a field by bson name or json name
+func (s *EntityFieldSet) Field(name string) (*EntityField, bool) {
+	if f, ok := s.bsonIndex[name]; ok {
+		return f, true
+	}
+	f, ok := s.jsonIndex[name]
+	return f, ok
+}
+
+// a resolved field path
+type EntityFieldPath struct {
+	//dotted path with bson names
+	BsonPath string
+	//dotted path with json names,empty if the field is not serialized to json
+	JsonPath string
+	//go type of the value located at path
+	Type reflect.Type
+	//path contains array index segments
+	HasArrayIndex bool
+}
+
+// resolve a dotted path (bson or json names, numeric segments for array elements),
+// return the bson path and the go type of the value located at path
+func (s *EntityFieldSet) ResolvePath(path string) (bsonPath string, t reflect.Type, err error) {
+	fieldPath, err := s.ResolveFieldPath(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return fieldPath.BsonPath, fieldPath.Type, nil
+}
+
+// resolve a dotted path (bson or json names, numeric segments for array elements)
+func (s *EntityFieldSet) ResolveFieldPath(path string) (*EntityFieldPath, error) {
+	if len(path) <= 0 {
+		return nil, fmt.Errorf("path must not be empty")
+	}
+	segments := strings.Split(path, ".")
+	bsonSegments := make([]string, 0, len(segments))
+	jsonSegments := make([]string, 0, len(segments))
+	jsonVisible := true
+	hasArrayIndex := false
+	result := func(t reflect.Type) *EntityFieldPath {
+		fieldPath := &EntityFieldPath{
+			BsonPath:      strings.Join(bsonSegments, "."),
+			Type:          t,
+			HasArrayIndex: hasArrayIndex,
+		}
+		if jsonVisible {
+			fieldPath.JsonPath = strings.Join(jsonSegments, ".")
+		}
+		return fieldPath
+	}
+
+	currentSet := s
+	var currentType reflect.Type
+	for index, eachSegment := range segments {
+		if len(eachSegment) <= 0 {
+			return nil, fmt.Errorf("invalid path,path:%s", path)
+		}
+		if currentType != nil {
+			valueType := indirectType(currentType)
+			switch {
+			case valueType.Kind() == reflect.Interface:
+				//untyped value,any sub path is accepted
+				bsonSegments = append(bsonSegments, segments[index:]...)
+				jsonSegments = append(jsonSegments, segments[index:]...)
+				return result(_emptyInterfaceType), nil
+			case valueType.Kind() == reflect.Map:
+				//any key is accepted
+				bsonSegments = append(bsonSegments, eachSegment)
+				jsonSegments = append(jsonSegments, eachSegment)
+				currentType, currentSet = elemTypeAndFieldSet(valueType)
+				continue
+			case isArrayType(valueType) && isArrayIndexSegment(eachSegment):
+				bsonSegments = append(bsonSegments, eachSegment)
+				jsonSegments = append(jsonSegments, eachSegment)
+				hasArrayIndex = true
+				currentType, currentSet = elemTypeAndFieldSet(valueType)
+				continue
+			}
+			if currentSet == nil {
+				return nil, fmt.Errorf("invalid path,%s is not a document,path:%s", strings.Join(bsonSegments, "."), path)
+			}
+		}
+		field, ok := currentSet.Field(eachSegment)
+		if !ok {
+			return nil, fmt.Errorf("unknown field %s,path:%s", eachSegment, path)
+		}
+		bsonSegments = append(bsonSegments, field.BsonName)
+		jsonSegments = append(jsonSegments, field.JsonName)
+		if len(field.JsonName) <= 0 {
+			jsonVisible = false
+		}
+		currentType = field.Type
+		currentSet = field.Fields
+	}
+	return result(currentType), nil
+}
+
+func newEntityFieldSet() *EntityFieldSet {
+	return &EntityFieldSet{
+		fieldList: make([]*EntityField, 0),
+		bsonIndex: make(map[string]*EntityField),
+		jsonIndex: make(map[string]*EntityField),
+	}
+}
+
+// must be called with _entityFieldSetLock held
+func buildEntityFieldSet(t reflect.Type) *EntityFieldSet {
+	if cached, ok := _entityFieldSetCache[t]; ok {
+		return cached
+	}
+	s := newEntityFieldSet()
+	//store before walking so that recursive types can be resolved
+	_entityFieldSetCache[t] = s
+	s.appendStructFields(t)
+	return s
+}
+
+func (s *EntityFieldSet) appendStructFields(t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+		bsonName, inline, skip := parseBsonTag(structField)
+		if skip {
+			continue
+		}
+		fieldType := indirectType(structField.Type)
+		if inline && fieldType.Kind() == reflect.Struct {
+			s.appendStructFields(fieldType)
+			continue
+		}
+		field := &EntityField{
+			BsonName: bsonName,
+			JsonName: parseJsonName(structField),
+			Type:     structField.Type,
+			Tag:      structField.Tag,
+		}
+		switch {
+		case isArrayType(fieldType):
+			field.IsArray = true
+			if elemType := indirectType(fieldType.Elem()); isDocumentType(elemType) {
+				field.Fields = buildEntityFieldSet(elemType)
+			}
+		case fieldType.Kind() == reflect.Map:
+			field.IsMap = true
+		case isDocumentType(fieldType):
+			field.Fields = buildEntityFieldSet(fieldType)
+		}
+		s.fieldList = append(s.fieldList, field)
+		s.bsonIndex[field.BsonName] = field
+		if len(field.JsonName) > 0 {
+			s.jsonIndex[field.JsonName] = field
+		}
+	}
+}
+
+func parseBsonTag(structField reflect.StructField) (name string, inline bool, skip bool) {
+	tag, ok := structField.Tag.Lookup("bson")
+	if !ok {
+		return strings.ToLower(structField.Name), false, false
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	for _, eachPart := range parts[1:] {
+		if eachPart == "inline" {
+			inline = true
+		}
+	}
+	if len(name) <= 0 {
+		name = strings.ToLower(structField.Name)
+	}
+	return name, inline, false
+}
+
+func parseJsonName(structField reflect.StructField) string {
+	tag, ok := structField.Tag.Lookup("json")
+	if !ok {
+		return structField.Name
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if len(name) <= 0 {
+		return structField.Name
+	}
+	return name
+}
+
+func elemTypeAndFieldSet(t reflect.Type) (reflect.Type, *EntityFieldSet) {
+	elemType := t.Elem()
+	if valueType := indirectType(elemType); isDocumentType(valueType) {
+		return elemType, GetEntityFieldSet(valueType)
+	}
+	return elemType, nil
+}
+
+func isArrayIndexSegment(segment string) bool {
+	if segment == "$" {
+		return true
+	}
+	_, err := strconv.Atoi(segment)
+	return err == nil
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func isArrayType(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return false
+	}
+	//[]byte is stored as binary
+	return t.Elem().Kind() != reflect.Uint8
+}
+
+// struct that is stored as an embedded document
+func isDocumentType(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == _timeType {
+		return false
+	}
+	if t.Implements(_bsonMarshalerType) || reflect.PtrTo(t).Implements(_bsonMarshalerType) {
+		return false
+	}
+	if t.Implements(_jsonMarshalerType) || reflect.PtrTo(t).Implements(_jsonMarshalerType) {
+		return false
+	}
+	return true
+}
@@ -17,14 +17,16 @@ type IEntityFind interface {
 	FindByFilter(filter interface{}, opts ...FindOption) IFindResult
 
 	Distinct(fieldName string, filter interface{}) ([]interface{}, error)
+	// sort of FindAll and FindByFilter if the sort option is not set,nil if it is not configured
+	GetDefaultSort() bson.D
 }
 
 var _ IEntityFind = (*MongoCol)(nil)
 
 func (r *MongoCol) CountByFilter(filter interface{}) (int64, error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
-	total, err := r.collection.CountDocuments(ctx, filter)
+	total, err := r.collection.CountDocuments(ctx, r.scopeFilter(filter))
 	if err != nil {
 		return 0, err
 	}
@@ -32,7 +34,10 @@ func (r *MongoCol) CountByFilter(filter interface{}) (int64, error) {
 }
 
 func (r *MongoCol) CountAll() (count int64, err error) {
-	ctx, cancel := CreateContext(r.configuration)
+	if r.configuration.softDelete || len(r.scope) > 0 {
+		return r.CountByFilter(bson.M{})
+	}
+	ctx, cancel := r.createContext()
 	defer cancel()
 	total, err := r.collection.EstimatedDocumentCount(ctx)
 	if err != nil {
@@ -52,7 +57,7 @@ func (r *MongoCol) FindByObjectId(id primitive.ObjectID) IFindResult {
 
 // 查找一条记录
 func (r *MongoCol) FindOne(filter interface{}, opts ...FindOneOption) IFindResult {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
 	//设置默认搜索参数
@@ -61,22 +66,24 @@ func (r *MongoCol) FindOne(filter interface{}, opts ...FindOneOption) IFindResul
 		o(findOneOptions)
 	}
 
-	res := r.collection.FindOne(ctx, filter, findOneOptions)
+	res := r.collection.FindOne(ctx, r.scopeFilter(filter), findOneOptions)
 	if res.Err() != nil {
 		return &findResult{
 			configuration: r.configuration,
+			ctx:           r.ctx,
 			err:           res.Err(),
 		}
 	}
 	return &findResult{
 		configuration: r.configuration,
+		ctx:           r.ctx,
 		res:           res,
 	}
 }
 
 // 根据条件来筛选
 func (r *MongoCol) FindByFilter(filter interface{}, opts ...FindOption) IFindResult {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
 	//设置默认搜索参数
@@ -87,22 +94,32 @@ func (r *MongoCol) FindByFilter(filter interface{}, opts ...FindOption) IFindRes
 	for _, o := range opts {
 		o(findOptions)
 	}
-	cur, err := r.collection.Find(ctx, filter, findOptions)
+	cur, err := r.collection.Find(ctx, r.scopeFilter(filter), findOptions)
 	if err != nil {
 		return &findResult{
 			configuration: r.configuration,
+			ctx:           r.ctx,
 			err:           err,
 		}
 	}
 	return &findResult{
 		configuration: r.configuration,
+		ctx:           r.ctx,
 		cur:           cur,
 	}
 }
 
 func (r *MongoCol) Distinct(fieldName string, filter interface{}) ([]interface{}, error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
-	return r.collection.Distinct(ctx, fieldName, filter)
+	return r.collection.Distinct(ctx, fieldName, r.scopeFilter(filter))
+}
+
+func (r *MongoCol) GetDefaultSort() bson.D {
+	if r.configuration.setDefaultSort == nil {
+		return nil
+	}
+	sort, _ := r.configuration.setDefaultSort(options.Find()).Sort.(bson.D)
+	return sort
 }
@@ -21,7 +21,7 @@ var _ IEntityIndex = (*MongoCol)(nil)
 // #region indexes members
 
 func (r *MongoCol) CreateIndex(indexModel mongo.IndexModel, opts ...*options.CreateIndexesOptions) (string, error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 	name, err := r.collection.Indexes().CreateOne(ctx, indexModel, opts...)
 	if err != nil {
@@ -31,7 +31,7 @@ func (r *MongoCol) CreateIndex(indexModel mongo.IndexModel, opts ...*options.Cre
 }
 
 func (r *MongoCol) CreateIndexes(indexModelList []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
 	return r.collection.Indexes().CreateMany(ctx, indexModelList, opts...)
@@ -46,7 +46,7 @@ func (r *MongoCol) MustCreateIndexes(indexModelList []mongo.IndexModel, opts ...
 }
 
 func (r *MongoCol) DeleteIndex(name string) (err error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
 	_, err = r.collection.Indexes().DropOne(ctx, name)
@@ -57,7 +57,7 @@ func (r *MongoCol) DeleteIndex(name string) (err error) {
 }
 
 func (r *MongoCol) DeleteAllIndexes() (err error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
 	_, err = r.collection.Indexes().DropAll(ctx)
@@ -68,7 +68,7 @@ func (r *MongoCol) DeleteAllIndexes() (err error) {
 }
 
 func (r *MongoCol) ListIndexes() (indexes []map[string]interface{}, err error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
 	cur, err := r.collection.Indexes().List(ctx)
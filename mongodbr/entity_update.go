@@ -13,6 +13,7 @@ import (
 type IEntityUpdate interface {
 	FindOneAndUpdate(entity IEntity, opts ...*options.FindOneAndUpdateOptions) error
 	FindOneAndUpdateWithId(objectId primitive.ObjectID, update interface{}, opts ...*options.FindOneAndUpdateOptions) error
+	FindOneAndUpdateWithVersion(objectId primitive.ObjectID, version int64, update interface{}, opts ...*options.FindOneAndUpdateOptions) error
 	UpdateOne(filter interface{}, update interface{}, opts ...*options.UpdateOptions) error
 	UpdateMany(filter interface{}, update interface{}, opts ...*options.UpdateOptions) (interface{}, error)
 }
@@ -28,37 +29,28 @@ func (r *MongoCol) FindOneAndUpdate(entity IEntity, opts ...*options.FindOneAndU
 
 	objectId := entity.GetObjectId()
 	update := builder.NewBsonBuilder().NewOrUpdateSet(entity).ToValue()
+	if versioned, ok := entity.(IVersionedEntity); ok {
+		return r.FindOneAndUpdateWithVersion(objectId, versioned.GetVersion(), update, opts...)
+	}
 	return r.FindOneAndUpdateWithId(objectId, update, opts...)
 }
 
 func (r *MongoCol) FindOneAndUpdateWithId(objectId primitive.ObjectID, update interface{}, opts ...*options.FindOneAndUpdateOptions) error {
 	//没有设置参数，使用默认的
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
 	if len(opts) <= 0 {
 		opts = make([]*options.FindOneAndUpdateOptions, 0)
 		opts = append(opts, options.FindOneAndUpdate().SetUpsert(false))
 	}
-	// 检查传入数据，只更新非 _id 字段
-	upMap, err := ToBsonMap(update)
+	upMap, err := normalizeUpdate(update)
 	if err != nil {
 		return err
 	}
-	if _, ok := upMap["$set"]; !ok {
-		_, incOk := upMap["$inc"]
-		_, pushOk := upMap["$push"]
-		_, pullOk := upMap["$pull"]
-		_, popOk := upMap["$pop"]
-		_, unsetOk := upMap["$unset"]
-		if !incOk && !pushOk && !pullOk && !popOk && !unsetOk {
-			delete(upMap, "_id")
-			upMap = bson.M{"$set": upMap} // 无其他特殊操作符时再加set。。
-		}
-	}
 	if err := r.collection.FindOneAndUpdate(
 		ctx,
-		bson.M{"_id": objectId},
+		r.scopeFilter(bson.M{"_id": objectId}),
 		upMap,
 		opts...,
 	).Err(); err != nil {
@@ -67,23 +59,36 @@ func (r *MongoCol) FindOneAndUpdateWithId(objectId primitive.ObjectID, update in
 	return nil
 }
 
+// if update is a IVersionedEntity,the document is updated only if its version is matched,
+// return ErrConcurrencyConflict if the version is not matched and mongo.ErrNoDocuments if nothing is matched by filter
 func (r *MongoCol) UpdateOne(filter interface{}, update interface{}, opts ...*options.UpdateOptions) error {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
-	_, err := r.collection.UpdateOne(ctx, filter, update, opts...)
+	versioned, ok := update.(IVersionedEntity)
+	if !ok {
+		_, err := r.collection.UpdateOne(ctx, r.scopeFilter(filter), update, opts...)
+		return err
+	}
+	upMap, err := normalizeUpdate(update)
 	if err != nil {
 		return err
 	}
-
+	result, err := r.collection.UpdateOne(ctx, versionFilter(r.scopeFilter(filter), versioned.GetVersion()), withVersionIncrement(upMap), opts...)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount <= 0 {
+		return r.conflictOrNotFoundByFilter(filter, fmt.Sprintf("version:%d", versioned.GetVersion()))
+	}
 	return nil
 }
 
 func (r *MongoCol) UpdateOneWithCount(filter interface{}, update interface{}, opts ...*options.UpdateOptions) (int64, error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
-	result, err := r.collection.UpdateOne(ctx, filter, update, opts...)
+	result, err := r.collection.UpdateOne(ctx, r.scopeFilter(filter), update, opts...)
 	if err != nil {
 		return 0, err
 	}
@@ -94,10 +99,10 @@ func (r *MongoCol) UpdateOneWithCount(filter interface{}, update interface{}, op
 }
 
 func (r *MongoCol) UpdateMany(filter interface{}, update interface{}, opts ...*options.UpdateOptions) (interface{}, error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
-	result, err := r.collection.UpdateMany(ctx, filter, update, opts...)
+	result, err := r.collection.UpdateMany(ctx, r.scopeFilter(filter), update, opts...)
 	if err != nil {
 		if result != nil {
 			return result.UpsertedID, err
@@ -114,6 +119,26 @@ func (r *MongoCol) UpdateMany(filter interface{}, update interface{}, opts ...*o
 
 // #endregion
 
+// 检查传入数据，只更新非 _id 字段
+func normalizeUpdate(update interface{}) (bson.M, error) {
+	upMap, err := ToBsonMap(update)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := upMap["$set"]; !ok {
+		_, incOk := upMap["$inc"]
+		_, pushOk := upMap["$push"]
+		_, pullOk := upMap["$pull"]
+		_, popOk := upMap["$pop"]
+		_, unsetOk := upMap["$unset"]
+		if !incOk && !pushOk && !pullOk && !popOk && !unsetOk {
+			delete(upMap, "_id")
+			upMap = bson.M{"$set": upMap} // 无其他特殊操作符时再加set。。
+		}
+	}
+	return upMap, nil
+}
+
 // struct to bsonMap
 func ToBsonMap(data interface{}) (map[string]interface{}, error) {
 	var m = make(map[string]interface{})
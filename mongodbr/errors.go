@@ -6,3 +6,8 @@ var (
 	ErrInvalidType = errors.New("invalid type")
 	ErrNoCursor    = errors.New("no cursor")
 )
+
+var (
+	// the version of entity has been changed by other request
+	ErrConcurrencyConflict = errors.New("concurrency conflict,entity has been modified")
+)
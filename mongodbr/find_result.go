@@ -1,6 +1,7 @@
 package mongodbr
 
 import (
+	"context"
 	"errors"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -22,6 +23,8 @@ type findResult struct {
 	cur           *mongo.Cursor
 	err           error
 	configuration *Configuration
+	// parent context of cursor commands
+	ctx context.Context
 }
 
 // #IFindResult members
@@ -35,7 +38,7 @@ func (r *findResult) One(val interface{}) (err error) {
 	}
 
 	//没有设置参数，使用默认的
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := CreateContextWithParent(r.ctx, r.configuration)
 	defer cancel()
 
 	if !r.cur.TryNext(ctx) {
@@ -58,7 +61,7 @@ func (r *findResult) All(val interface{}) (err error) {
 		return r.err
 	}
 
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := CreateContextWithParent(r.ctx, r.configuration)
 	defer cancel()
 	if r.cur == nil {
 		return ErrNoCursor
@@ -77,7 +80,7 @@ func (r *findResult) ToAll() ([]interface{}, error) {
 		return nil, nil
 	}
 	//没有设置参数，使用默认的
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := CreateContextWithParent(r.ctx, r.configuration)
 	defer cancel()
 	defer r.cur.Close(ctx)
 
@@ -0,0 +1,37 @@
+package mongodbr
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shanluzhineng/fwpkg/system/health"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+const (
+	HealthCheckerName = "mongodb"
+)
+
+// ping the primary of the client
+func NewHealthChecker(name string, client *mongo.Client) health.HealthChecker {
+	return health.NewCheckerFunc(name, func(ctx context.Context) error {
+		if client == nil {
+			return fmt.Errorf("client is nil")
+		}
+		return client.Ping(ctx, readpref.Primary())
+	})
+}
+
+// checkers of DefaultClient and the clients registered by RegistClient,
+// the checker of DefaultClient is named mongodb,the others are named mongodb:{key}
+func RegisteredHealthCheckers() []health.HealthChecker {
+	checkerList := make([]health.HealthChecker, 0, len(_cachedClient)+1)
+	if DefaultClient != nil {
+		checkerList = append(checkerList, NewHealthChecker(HealthCheckerName, DefaultClient))
+	}
+	for eachKey, eachClient := range _cachedClient {
+		checkerList = append(checkerList, NewHealthChecker(HealthCheckerName+":"+eachKey, eachClient))
+	}
+	return checkerList
+}
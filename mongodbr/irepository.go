@@ -14,12 +14,16 @@ type IRepository interface {
 	IEntityDelete
 	IEntityIndex
 	IEntityBulkWrite
+	IEntitySoftDelete
+	IEntityScope
+	IEntityContext
 
 	// aggregate
 	Aggregate(pipeline interface{}, dataList interface{}, opts ...AggregateOption) (err error)
 
 	// replace*
 	ReplaceById(id primitive.ObjectID, doc interface{}, opts ...*options.ReplaceOptions) (err error)
+	ReplaceByIdWithVersion(id primitive.ObjectID, version int64, doc interface{}, opts ...*options.ReplaceOptions) (err error)
 	Replace(filter interface{}, doc interface{}, opts ...*options.ReplaceOptions) (err error)
 
 	GetName() (name string)
@@ -35,6 +39,16 @@ type IEntityCreate interface {
 type IEntityDelete interface {
 	// delete
 	DeleteOne(id primitive.ObjectID, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+	DeleteOneWithVersion(id primitive.ObjectID, version int64, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
 	DeleteOneByFilter(filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
 	DeleteMany(filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
 }
+
+type IEntitySoftDelete interface {
+	IsSoftDeleteEnabled() bool
+	// a view of repository that includes the soft deleted documents
+	WithDeleted() IRepository
+	SoftDeleteOne(id primitive.ObjectID, deleterId string) error
+	SoftDeleteMany(filter interface{}, deleterId string) (int64, error)
+	Restore(id primitive.ObjectID) error
+}
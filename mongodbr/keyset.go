@@ -0,0 +1,382 @@
+package mongodbr
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// configuration key of the keyset cursor options
+const CursorConfigurationKey = "mongodb.cursor"
+
+// options of keyset cursors
+type CursorOptions struct {
+	// secret to sign keyset cursors,all instances of a service must use the same secret
+	Secret string `mapstructure:"secret" json:"secret" yaml:"secret"`
+}
+
+var (
+	// cursor cannot be decoded,is tampered or does not match the sort
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	_cursorSecret     []byte
+	_cursorSecretLock sync.RWMutex
+)
+
+func init() {
+	//random secret,cursors are only valid in current process until SetCursorSecret is called.
+	//the secret of configuration is set by the mongodb starter
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	_cursorSecret = secret
+}
+
+// set the secret used to sign keyset cursors,
+// all instances of a service must use the same secret
+func SetCursorSecret(secret []byte) {
+	if len(secret) <= 0 {
+		return
+	}
+	_cursorSecretLock.Lock()
+	defer _cursorSecretLock.Unlock()
+	_cursorSecret = secret
+}
+
+// a keyset page request
+type KeysetPage struct {
+	// sort of list,the default sort of repository is used if it is empty.
+	// _id is appended as tie-breaker if not set,the keys after _id are ignored
+	Sort bson.D
+	// return items after this cursor
+	After string
+	// return items before this cursor
+	Before string
+	Limit  int64
+}
+
+// a keyset page
+type KeysetResult[T any] struct {
+	List []T
+	// cursor of the last item,empty if there is no more item
+	NextCursor string
+	// cursor of the first item,empty if it is the first page
+	PrevCursor string
+}
+
+// the content of cursor
+type keysetCursor struct {
+	// sort keys and directions,used to check the cursor is created with the same sort
+	Keys       []string `bson:"k"`
+	Directions []int32  `bson:"d"`
+	Values     bson.A   `bson:"v"`
+}
+
+// find a page of T with keyset pagination,
+// the filter is combined with the sort key values of cursor so that no skip is used
+func FindTByKeyset[T any](repository IRepository, filter interface{}, page *KeysetPage, opts ...FindOption) (*KeysetResult[T], error) {
+	if page.After != "" && page.Before != "" {
+		return nil, errors.New("after and before cannot be used together")
+	}
+	if page.Limit <= 0 {
+		return nil, errors.New("limit must be greater than 0")
+	}
+	pageSort := page.Sort
+	if len(pageSort) <= 0 {
+		// the same order as FindByFilter without sort
+		pageSort = repository.GetDefaultSort()
+	}
+	sort := keysetSort(pageSort)
+	backward := page.Before != ""
+
+	var cursor *keysetCursor
+	if value := page.After + page.Before; value != "" {
+		var err error
+		cursor, err = decodeKeysetCursor(value, sort)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	querySort := sort
+	if backward {
+		querySort = reverseSort(sort)
+	}
+	query := filter
+	if cursor != nil {
+		keysetFilter := buildKeysetFilter(querySort, cursor.Values)
+		if isEmptyFilter(filter) {
+			query = keysetFilter
+		} else {
+			query = bson.M{"$and": bson.A{filter, keysetFilter}}
+		}
+	}
+	if isEmptyFilter(query) {
+		query = bson.M{}
+	}
+
+	findOpts := make([]FindOption, 0, len(opts)+3)
+	findOpts = append(findOpts, opts...)
+	findOpts = append(findOpts,
+		FindOptionWithSort(querySort),
+		FindOptionWithLimit(page.Limit+1),
+		keysetProjectionOption(sort))
+	//read as raw bson so that the sort key values can be read by bson path
+	var rawList []bson.Raw
+	if err := repository.FindByFilter(query, findOpts...).All(&rawList); err != nil {
+		return nil, err
+	}
+	hasMore := int64(len(rawList)) > page.Limit
+	if hasMore {
+		rawList = rawList[:page.Limit]
+	}
+	if backward {
+		for i, j := 0, len(rawList)-1; i < j; i, j = i+1, j-1 {
+			rawList[i], rawList[j] = rawList[j], rawList[i]
+		}
+	}
+
+	result := &KeysetResult[T]{
+		List: make([]T, 0, len(rawList)),
+	}
+	for _, eachRaw := range rawList {
+		item := new(T)
+		if err := bson.Unmarshal(eachRaw, item); err != nil {
+			return nil, err
+		}
+		result.List = append(result.List, *item)
+	}
+	if len(rawList) <= 0 {
+		return result, nil
+	}
+
+	first, last := rawList[0], rawList[len(rawList)-1]
+	//there are items in the opposite direction of a cursor
+	hasNext := (!backward && hasMore) || (backward && cursor != nil)
+	hasPrev := (backward && hasMore) || (!backward && cursor != nil)
+	if hasNext {
+		result.NextCursor = encodeKeysetCursor(sort, last)
+	}
+	if hasPrev {
+		result.PrevCursor = encodeKeysetCursor(sort, first)
+	}
+	return result, nil
+}
+
+func isEmptyFilter(filter interface{}) bool {
+	switch v := filter.(type) {
+	case nil:
+		return true
+	case bson.M:
+		return len(v) <= 0
+	case map[string]interface{}:
+		return len(v) <= 0
+	case bson.D:
+		return len(v) <= 0
+	}
+	return false
+}
+
+// _id is used as tie-breaker,it is always the last key so the order of items is unique.
+// the keys after _id and the repeated keys are removed
+func keysetSort(sort bson.D) bson.D {
+	result := make(bson.D, 0, len(sort)+1)
+	keySet := make(map[string]struct{}, len(sort))
+	for _, eachItem := range sort {
+		if _, ok := keySet[eachItem.Key]; ok {
+			continue
+		}
+		keySet[eachItem.Key] = struct{}{}
+		result = append(result, bson.E{Key: eachItem.Key, Value: sortDirection(eachItem.Value)})
+		if eachItem.Key == "_id" {
+			return result
+		}
+	}
+	direction := int32(1)
+	if len(result) > 0 {
+		direction = result[len(result)-1].Value.(int32)
+	}
+	return append(result, bson.E{Key: "_id", Value: direction})
+}
+
+func reverseSort(sort bson.D) bson.D {
+	result := make(bson.D, 0, len(sort))
+	for _, eachItem := range sort {
+		result = append(result, bson.E{Key: eachItem.Key, Value: -eachItem.Value.(int32)})
+	}
+	return result
+}
+
+func sortDirection(v interface{}) int32 {
+	switch value := v.(type) {
+	case int:
+		if value < 0 {
+			return -1
+		}
+	case int32:
+		if value < 0 {
+			return -1
+		}
+	case int64:
+		if value < 0 {
+			return -1
+		}
+	case float64:
+		if value < 0 {
+			return -1
+		}
+	}
+	return 1
+}
+
+// (k1 > v1) or (k1 = v1 and k2 > v2) or ...
+// null and missing values are the smallest in the sort order of mongodb,
+// so they are after any value in descending order and before any value in ascending order
+func buildKeysetFilter(sort bson.D, values bson.A) bson.M {
+	orList := bson.A{}
+	for index, eachItem := range sort {
+		value := values[index]
+		ascending := eachItem.Value.(int32) > 0
+		newCondition := func(keyCondition interface{}) bson.M {
+			condition := bson.M{}
+			for equalIndex := 0; equalIndex < index; equalIndex++ {
+				//null also matches missing field
+				condition[sort[equalIndex].Key] = values[equalIndex]
+			}
+			condition[eachItem.Key] = keyCondition
+			return condition
+		}
+		switch {
+		case value == nil && ascending:
+			//null is the smallest value
+			orList = append(orList, newCondition(bson.M{"$ne": nil}))
+		case value == nil:
+			//nothing is smaller than null
+			continue
+		case ascending:
+			orList = append(orList, newCondition(bson.M{"$gt": value}))
+		default:
+			//$lt does not match null and missing field,they are after any value in descending order
+			orList = append(orList, newCondition(bson.M{"$lt": value}))
+			if eachItem.Key != "_id" {
+				orList = append(orList, newCondition(nil))
+			}
+		}
+	}
+	if len(orList) <= 0 {
+		//nothing can be matched
+		return bson.M{"_id": bson.M{"$exists": false}}
+	}
+	return bson.M{"$or": orList}
+}
+
+// make sure the sort keys are loaded when a projection is used
+func keysetProjectionOption(sort bson.D) FindOption {
+	return func(fo *options.FindOptions) {
+		projection, ok := fo.Projection.(bson.D)
+		if !ok || len(projection) <= 0 {
+			return
+		}
+		exclude := projection[0].Value == 0
+		result := bson.D{}
+		for _, eachItem := range projection {
+			if exclude && isSortKeyPath(sort, eachItem.Key) {
+				continue
+			}
+			result = append(result, eachItem)
+		}
+		if !exclude {
+			for _, eachItem := range sort {
+				if eachItem.Key != "_id" && !isProjected(result, eachItem.Key) {
+					result = append(result, bson.E{Key: eachItem.Key, Value: 1})
+				}
+			}
+		}
+		if len(result) <= 0 {
+			fo.Projection = nil
+			return
+		}
+		fo.SetProjection(result)
+	}
+}
+
+// path is a sort key or contains a sort key
+func isSortKeyPath(sort bson.D, path string) bool {
+	for _, eachItem := range sort {
+		if eachItem.Key == path || strings.HasPrefix(eachItem.Key, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func isProjected(projection bson.D, path string) bool {
+	for _, eachItem := range projection {
+		if eachItem.Key == path || strings.HasPrefix(path, eachItem.Key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeKeysetCursor(sort bson.D, doc bson.Raw) string {
+	cursor := &keysetCursor{}
+	for _, eachItem := range sort {
+		cursor.Keys = append(cursor.Keys, eachItem.Key)
+		cursor.Directions = append(cursor.Directions, eachItem.Value.(int32))
+		value, err := doc.LookupErr(strings.Split(eachItem.Key, ".")...)
+		if err != nil {
+			//missing field is sorted as null
+			cursor.Values = append(cursor.Values, nil)
+			continue
+		}
+		cursor.Values = append(cursor.Values, value)
+	}
+	data, err := bson.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + signCursor(payload)
+}
+
+func decodeKeysetCursor(value string, sort bson.D) (*keysetCursor, error) {
+	payload, signature, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signCursor(payload))) {
+		return nil, ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	cursor := &keysetCursor{}
+	if err := bson.Unmarshal(data, cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if len(cursor.Keys) != len(sort) || len(cursor.Values) != len(sort) || len(cursor.Directions) != len(sort) {
+		return nil, fmt.Errorf("%w,cursor does not match the sort", ErrInvalidCursor)
+	}
+	for index, eachItem := range sort {
+		if cursor.Keys[index] != eachItem.Key || cursor.Directions[index] != eachItem.Value.(int32) {
+			return nil, fmt.Errorf("%w,cursor does not match the sort", ErrInvalidCursor)
+		}
+	}
+	return cursor, nil
+}
+
+func signCursor(payload string) string {
+	_cursorSecretLock.RLock()
+	defer _cursorSecretLock.RUnlock()
+	mac := hmac.New(sha256.New, _cursorSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,87 @@
+package mongodbr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestKeysetCursor(t *testing.T) {
+	sort := keysetSort(bson.D{{Key: "name", Value: 1}, {Key: "age", Value: -1}})
+	assert.Equal(t, bson.D{{Key: "name", Value: int32(1)}, {Key: "age", Value: int32(-1)}, {Key: "_id", Value: int32(-1)}}, sort)
+
+	id := primitive.NewObjectID()
+	doc, _ := bson.Marshal(bson.M{"_id": id, "name": "a", "age": 3})
+	value := encodeKeysetCursor(sort, doc)
+
+	cursor, err := decodeKeysetCursor(value, sort)
+	assert.Nil(t, err)
+	assert.Equal(t, bson.A{"a", int32(3), id}, cursor.Values)
+
+	_, err = decodeKeysetCursor(value+"x", sort)
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+	_, err = decodeKeysetCursor(value, keysetSort(bson.D{{Key: "name", Value: 1}}))
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+
+	filter := buildKeysetFilter(sort, cursor.Values)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"name": bson.M{"$gt": "a"}},
+		bson.M{"name": "a", "age": bson.M{"$lt": int32(3)}},
+		bson.M{"name": "a", "age": nil},
+		bson.M{"name": "a", "age": int32(3), "_id": bson.M{"$lt": id}},
+	}}, filter)
+}
+
+func TestKeysetSortIdIsLast(t *testing.T) {
+	sort := keysetSort(bson.D{{Key: "name", Value: -1}, {Key: "_id", Value: 1}, {Key: "age", Value: 1}})
+	assert.Equal(t, bson.D{{Key: "name", Value: int32(-1)}, {Key: "_id", Value: int32(1)}}, sort)
+
+	sort = keysetSort(bson.D{{Key: "name", Value: 1}, {Key: "name", Value: -1}})
+	assert.Equal(t, bson.D{{Key: "name", Value: int32(1)}, {Key: "_id", Value: int32(1)}}, sort)
+}
+
+func TestKeysetFilterNull(t *testing.T) {
+	id := primitive.NewObjectID()
+	//null is the smallest value,the non-null values are after it in ascending order
+	filter := buildKeysetFilter(keysetSort(bson.D{{Key: "name", Value: 1}}), bson.A{nil, id})
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"name": bson.M{"$ne": nil}},
+		bson.M{"name": nil, "_id": bson.M{"$gt": id}},
+	}}, filter)
+
+	//nothing is after null in descending order except the same null values
+	filter = buildKeysetFilter(keysetSort(bson.D{{Key: "name", Value: -1}}), bson.A{nil, id})
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"name": nil, "_id": bson.M{"$lt": id}},
+	}}, filter)
+
+	//null and missing values are after any value in descending order
+	filter = buildKeysetFilter(keysetSort(bson.D{{Key: "name", Value: -1}}), bson.A{"a", id})
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"name": bson.M{"$lt": "a"}},
+		bson.M{"name": nil},
+		bson.M{"name": "a", "_id": bson.M{"$lt": id}},
+	}}, filter)
+}
+
+func TestKeysetDefaultSort(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("find", func(mt *mtest.T) {
+		repository, err := NewRepositoryBase(func() *mongo.Collection { return mt.Coll }, WithDefaultSort(func(fo *options.FindOptions) *options.FindOptions {
+			return fo.SetSort(bson.D{{Key: "_id", Value: -1}})
+		}))
+		assert.NoError(mt, err)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch))
+		_, err = FindTByKeyset[bson.M](repository, nil, &KeysetPage{Limit: 10})
+		assert.NoError(mt, err)
+		// the order is the same as the offset paging without sort
+		sort := mt.GetStartedEvent().Command.Lookup("sort").Document()
+		assert.Equal(mt, int32(-1), sort.Lookup("_id").Int32())
+	})
+}
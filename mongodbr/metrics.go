@@ -0,0 +1,56 @@
+package mongodbr
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shanluzhineng/fwpkg/system/metrics"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	mongodbCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongodb_command_duration_seconds",
+		Help:    "Duration of mongodb commands by database,command and result.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"database", "command", "result"})
+	mongodbCommandErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mongodb_command_errors_total",
+		Help: "Total number of failed mongodb commands by database and command.",
+	}, []string{"database", "command"})
+)
+
+func init() {
+	metrics.MustRegister(mongodbCommandDuration, mongodbCommandErrors)
+}
+
+// record the latency and errors of the commands,
+// the monitor that is already set such as EnableMongodbMonitor is still called
+func EnableMongodbMetrics() func(*options.ClientOptions) {
+	return func(co *options.ClientOptions) {
+		co.SetMonitor(newMetricsMonitor(co.Monitor))
+	}
+}
+
+func newMetricsMonitor(next *event.CommandMonitor) *event.CommandMonitor {
+	monitor := &event.CommandMonitor{
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			mongodbCommandDuration.WithLabelValues(e.DatabaseName, e.CommandName, "success").Observe(e.Duration.Seconds())
+			if next != nil && next.Succeeded != nil {
+				next.Succeeded(ctx, e)
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			mongodbCommandDuration.WithLabelValues(e.DatabaseName, e.CommandName, "failure").Observe(e.Duration.Seconds())
+			mongodbCommandErrors.WithLabelValues(e.DatabaseName, e.CommandName).Inc()
+			if next != nil && next.Failed != nil {
+				next.Failed(ctx, e)
+			}
+		},
+	}
+	if next != nil {
+		monitor.Started = next.Started
+	}
+	return monitor
+}
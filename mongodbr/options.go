@@ -86,14 +86,23 @@ type Configuration struct {
 	createItemFunc func() interface{}
 	//查询时设置默认的排序
 	setDefaultSort func(*options.FindOptions) *options.FindOptions
+	//软删除,查询时自动排除已删除的记录
+	softDelete bool
 }
 
 func CreateContext(c *Configuration) (context.Context, context.CancelFunc) {
+	return CreateContextWithParent(context.Background(), c)
+}
+
+// the context is canceled with parent and keeps the values of parent,such as the span of request
+func CreateContextWithParent(parent context.Context, c *Configuration) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
 	if c == nil || c.QueryTimeout <= 0 {
-		ctx := context.TODO()
-		return context.WithCancel(ctx)
+		return context.WithCancel(parent)
 	}
-	return context.WithTimeout(context.Background(), c.QueryTimeout)
+	return context.WithTimeout(parent, c.QueryTimeout)
 }
 
 func (c *Configuration) safeCreateItem() interface{} {
@@ -0,0 +1,150 @@
+package mongodbr
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// a projection of entity fields,fields are either included or excluded
+type Projection struct {
+	fieldPathList []*EntityFieldPath
+	exclude       bool
+}
+
+// build a projection,the field paths are validated against the field set of entity.
+// includeList and excludeList cannot be used together
+func NewProjection(fieldSet *EntityFieldSet, includeList []string, excludeList []string) (*Projection, error) {
+	includeList = trimPathList(includeList)
+	excludeList = trimPathList(excludeList)
+	if len(includeList) > 0 && len(excludeList) > 0 {
+		return nil, errors.New("fields and exclude cannot be used together")
+	}
+	p := &Projection{
+		exclude: len(excludeList) > 0,
+	}
+	pathList := includeList
+	if p.exclude {
+		pathList = excludeList
+	}
+	for _, eachPath := range pathList {
+		fieldPath, err := fieldSet.ResolveFieldPath(eachPath)
+		if err != nil {
+			return nil, err
+		}
+		if fieldPath.HasArrayIndex {
+			return nil, fmt.Errorf("array index cannot be used in projection,path:%s", eachPath)
+		}
+		if p.exclude && fieldPath.BsonPath == "_id" {
+			return nil, errors.New("_id cannot be excluded")
+		}
+		p.fieldPathList = append(p.fieldPathList, fieldPath)
+	}
+	p.fieldPathList = removeCoveredPath(p.fieldPathList)
+	return p, nil
+}
+
+// no field is included or excluded
+func (p *Projection) IsEmpty() bool {
+	return p == nil || len(p.fieldPathList) <= 0
+}
+
+func (p *Projection) IsExclude() bool {
+	return p.exclude
+}
+
+// the resolved field paths
+func (p *Projection) FieldPathList() []*EntityFieldPath {
+	if p == nil {
+		return nil
+	}
+	return p.fieldPathList
+}
+
+// the dotted json paths of fields,fields that are not serialized to json are ignored
+func (p *Projection) JsonPathList() []string {
+	result := make([]string, 0, len(p.FieldPathList()))
+	for _, eachPath := range p.FieldPathList() {
+		if len(eachPath.JsonPath) > 0 {
+			result = append(result, eachPath.JsonPath)
+		}
+	}
+	return result
+}
+
+// mongodb projection document,nil if projection is empty
+func (p *Projection) ToBson() bson.D {
+	if p.IsEmpty() {
+		return nil
+	}
+	value := 1
+	if p.exclude {
+		value = 0
+	}
+	result := bson.D{}
+	for _, eachPath := range p.fieldPathList {
+		result = append(result, bson.E{Key: eachPath.BsonPath, Value: value})
+	}
+	return result
+}
+
+func FindOptionWithProjection(p *Projection) FindOption {
+	return func(fo *options.FindOptions) {
+		if !p.IsEmpty() {
+			fo.SetProjection(p.ToBson())
+		}
+	}
+}
+
+func FindOneOptionWithProjection(p *Projection) FindOneOption {
+	return func(fo *options.FindOneOptions) {
+		if !p.IsEmpty() {
+			fo.SetProjection(p.ToBson())
+		}
+	}
+}
+
+func trimPathList(pathList []string) []string {
+	result := make([]string, 0, len(pathList))
+	for _, eachPath := range pathList {
+		eachPath = strings.TrimSpace(eachPath)
+		if len(eachPath) > 0 {
+			result = append(result, eachPath)
+		}
+	}
+	return result
+}
+
+// a.b is covered by a,mongodb reports path collision if both are used
+func removeCoveredPath(pathList []*EntityFieldPath) []*EntityFieldPath {
+	sorted := make([]*EntityFieldPath, len(pathList))
+	copy(sorted, pathList)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].BsonPath) < len(sorted[j].BsonPath)
+	})
+	covered := make(map[string]bool)
+	for _, eachPath := range sorted {
+		if covered[eachPath.BsonPath] {
+			continue
+		}
+		for _, eachOther := range sorted {
+			if strings.HasPrefix(eachOther.BsonPath, eachPath.BsonPath+".") {
+				covered[eachOther.BsonPath] = true
+			}
+		}
+	}
+	result := make([]*EntityFieldPath, 0, len(pathList))
+	seen := make(map[string]bool)
+	for _, eachPath := range pathList {
+		if covered[eachPath.BsonPath] || seen[eachPath.BsonPath] {
+			continue
+		}
+		seen[eachPath.BsonPath] = true
+		result = append(result, eachPath)
+	}
+	return result
+}
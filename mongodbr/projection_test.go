@@ -0,0 +1,41 @@
+package mongodbr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type projectionOwner struct {
+	Id   string `json:"id" bson:"ownerId"`
+	Name string `json:"name" bson:"name"`
+}
+
+type projectionEntity struct {
+	Entity `bson:",inline"`
+	Name   string            `json:"name" bson:"name"`
+	Owner  projectionOwner   `json:"owner" bson:"owner"`
+	Items  []projectionOwner `json:"items" bson:"items"`
+}
+
+func TestNewProjection(t *testing.T) {
+	fieldSet := GetEntityFieldSet(projectionEntity{})
+
+	p, err := NewProjection(fieldSet, []string{"name", "owner.id", "owner"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, bson.D{{Key: "name", Value: 1}, {Key: "owner", Value: 1}}, p.ToBson())
+	assert.Equal(t, []string{"name", "owner"}, p.JsonPathList())
+
+	p, err = NewProjection(fieldSet, nil, []string{"items.id"})
+	assert.Nil(t, err)
+	assert.True(t, p.IsExclude())
+	assert.Equal(t, bson.D{{Key: "items.ownerId", Value: 0}}, p.ToBson())
+
+	_, err = NewProjection(fieldSet, []string{"unknown"}, nil)
+	assert.NotNil(t, err)
+	_, err = NewProjection(fieldSet, []string{"name"}, []string{"owner"})
+	assert.NotNil(t, err)
+	_, err = NewProjection(fieldSet, []string{"items.0"}, nil)
+	assert.NotNil(t, err)
+}
@@ -1,6 +1,7 @@
 package mongodbr
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -13,6 +14,10 @@ import (
 type MongoCol struct {
 	configuration *Configuration
 	collection    *mongo.Collection
+	// condition added to every filter,see WithScope
+	scope bson.M
+	// parent context of commands,see WithContext
+	ctx context.Context
 }
 
 // new MongoCol instance, panic if col is nil
@@ -58,7 +63,7 @@ func NewRepositoryBase(getDbCollection func() *mongo.Collection, opts ...Reposit
 
 // aggregate
 func (r *RepositoryBase) Aggregate(pipeline interface{}, dataList interface{}, opts ...AggregateOption) (err error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
 	//设置默认搜索参数
@@ -66,7 +71,7 @@ func (r *RepositoryBase) Aggregate(pipeline interface{}, dataList interface{}, o
 	for _, o := range opts {
 		o(aggregateOptions)
 	}
-	cur, err := r.collection.Aggregate(ctx, pipeline, aggregateOptions)
+	cur, err := r.collection.Aggregate(ctx, r.scopePipeline(pipeline), aggregateOptions)
 	if err != nil {
 		return err
 	}
@@ -76,7 +81,7 @@ func (r *RepositoryBase) Aggregate(pipeline interface{}, dataList interface{}, o
 }
 
 func (r *RepositoryBase) AggregateOne(pipeline interface{}, data interface{}, opts ...AggregateOption) (err error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
 	//设置默认搜索参数
@@ -84,7 +89,7 @@ func (r *RepositoryBase) AggregateOne(pipeline interface{}, data interface{}, op
 	for _, o := range opts {
 		o(aggregateOptions)
 	}
-	cur, err := r.collection.Aggregate(ctx, pipeline, aggregateOptions)
+	cur, err := r.collection.Aggregate(ctx, r.scopePipeline(pipeline), aggregateOptions)
 	if err != nil {
 		return err
 	}
@@ -101,7 +106,7 @@ func (r *RepositoryBase) Create(item interface{}, opts ...*options.InsertOneOpti
 	if item == nil {
 		return primitive.NilObjectID, fmt.Errorf("item is nil,col:%s", r.documentName)
 	}
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
 	r.onBeforeCreate(item)
@@ -119,7 +124,7 @@ func (r *RepositoryBase) CreateMany(itemList []interface{}, opts ...*options.Ins
 	if len(itemList) <= 0 {
 		return nil, nil
 	}
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
 	for index := range itemList {
@@ -142,15 +147,30 @@ func (r *RepositoryBase) CreateMany(itemList []interface{}, opts ...*options.Ins
 
 // #endregion
 
+// if doc is a IVersionedEntity,the document is replaced only if its version is matched,
+// return mongo.ErrNoDocuments if the document is not found or soft deleted
 func (r *RepositoryBase) ReplaceById(id primitive.ObjectID, doc interface{}, opts ...*options.ReplaceOptions) (err error) {
-	return r.Replace(bson.M{"_id": id}, doc, opts...)
+	if versioned, ok := doc.(IVersionedEntity); ok {
+		return r.ReplaceByIdWithVersion(id, versioned.GetVersion(), doc, opts...)
+	}
+	ctx, cancel := r.createContext()
+	defer cancel()
+
+	result, err := r.collection.ReplaceOne(ctx, r.scopeFilter(bson.M{"_id": id}), doc, opts...)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount <= 0 && result.UpsertedCount <= 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
 }
 
 func (r *RepositoryBase) Replace(filter interface{}, doc interface{}, opts ...*options.ReplaceOptions) (err error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
-	_, err = r.collection.ReplaceOne(ctx, filter, doc, opts...)
+	_, err = r.collection.ReplaceOne(ctx, r.scopeFilter(filter), doc, opts...)
 	if err != nil {
 		return err
 	}
@@ -159,10 +179,10 @@ func (r *RepositoryBase) Replace(filter interface{}, doc interface{}, opts ...*o
 
 // 删除指定id的记录
 func (r *RepositoryBase) DeleteOne(id primitive.ObjectID, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	result, err := r.collection.DeleteOne(ctx, r.dataScopeFilter(bson.M{"_id": id}))
 	if err != nil {
 		return result, err
 	}
@@ -172,10 +192,10 @@ func (r *RepositoryBase) DeleteOne(id primitive.ObjectID, opts ...*options.Delet
 
 // 删除指定条件的一条记录
 func (r *RepositoryBase) DeleteOneByFilter(filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
-	result, err := r.collection.DeleteOne(ctx, filter, opts...)
+	result, err := r.collection.DeleteOne(ctx, r.dataScopeFilter(filter), opts...)
 	if err != nil {
 		return result, err
 	}
@@ -189,10 +209,10 @@ func (r *RepositoryBase) DeleteMany(filter interface{}, opts ...*options.DeleteO
 		err := fmt.Errorf("无法删除多条%s记录,filter参数不能为null", r.documentName)
 		return nil, err
 	}
-	ctx, cancel := CreateContext(r.configuration)
+	ctx, cancel := r.createContext()
 	defer cancel()
 
-	result, err := r.collection.DeleteMany(ctx, filter, opts...)
+	result, err := r.collection.DeleteMany(ctx, r.dataScopeFilter(filter), opts...)
 	if err != nil {
 		return result, err
 	}
@@ -200,6 +220,30 @@ func (r *RepositoryBase) DeleteMany(filter interface{}, opts ...*options.DeleteO
 	return result, nil
 }
 
+// a view of repository that includes the soft deleted documents
+func (r *RepositoryBase) WithDeleted() IRepository {
+	return &RepositoryBase{
+		documentName: r.documentName,
+		MongoCol:     r.MongoCol.WithDeleted(),
+	}
+}
+
+// a view of repository that only reads and writes the documents matched by scope
+func (r *RepositoryBase) WithScope(scope bson.M) IRepository {
+	return &RepositoryBase{
+		documentName: r.documentName,
+		MongoCol:     r.MongoCol.WithScope(scope),
+	}
+}
+
+// a view of repository that runs the commands with ctx
+func (r *RepositoryBase) WithContext(ctx context.Context) IRepository {
+	return &RepositoryBase{
+		documentName: r.documentName,
+		MongoCol:     r.MongoCol.WithContext(ctx),
+	}
+}
+
 func (r *RepositoryBase) GetName() (name string) {
 	return r.documentName
 }
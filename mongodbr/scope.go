@@ -0,0 +1,85 @@
+package mongodbr
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type IEntityScope interface {
+	// a view of repository that only reads and writes the documents matched by scope,
+	// the scope is added to the filter of find,count,aggregate,update,replace and delete.
+	// created documents and models of BulkWrite are not changed,the caller sets the scope fields
+	WithScope(scope bson.M) IRepository
+	// nil if the repository is not scoped
+	GetScope() bson.M
+}
+
+// #region scope members
+
+// a view of collection that only reads and writes the documents matched by scope,
+// scope is merged with the scope of current collection
+func (r *MongoCol) WithScope(scope bson.M) *MongoCol {
+	merged := bson.M{}
+	for key, value := range r.scope {
+		merged[key] = value
+	}
+	for key, value := range scope {
+		merged[key] = value
+	}
+	return &MongoCol{
+		configuration: r.configuration,
+		collection:    r.collection,
+		scope:         merged,
+		ctx:           r.ctx,
+	}
+}
+
+func (r *MongoCol) GetScope() bson.M {
+	return r.scope
+}
+
+// #endregion
+
+// add the scope to filter,
+// the filter is wrapped with $and if it has a condition on the same field
+func (r *MongoCol) dataScopeFilter(filter interface{}) interface{} {
+	if len(r.scope) <= 0 {
+		return filter
+	}
+	switch v := filter.(type) {
+	case nil:
+		return r.mergeScope(nil)
+	case bson.M:
+		if merged, ok := r.tryMergeScope(v); ok {
+			return merged
+		}
+	case map[string]interface{}:
+		if merged, ok := r.tryMergeScope(v); ok {
+			return merged
+		}
+	case bson.D:
+		if len(v) <= 0 {
+			return r.mergeScope(nil)
+		}
+	}
+	return bson.M{"$and": bson.A{filter, r.mergeScope(nil)}}
+}
+
+func (r *MongoCol) tryMergeScope(filter map[string]interface{}) (bson.M, bool) {
+	for key := range r.scope {
+		if _, ok := filter[key]; ok {
+			return nil, false
+		}
+	}
+	return r.mergeScope(filter), true
+}
+
+func (r *MongoCol) mergeScope(filter map[string]interface{}) bson.M {
+	result := bson.M{}
+	for key, value := range filter {
+		result[key] = value
+	}
+	for key, value := range r.scope {
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,27 @@
+package mongodbr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDataScopeFilter(t *testing.T) {
+	c := &MongoCol{configuration: &Configuration{}}
+	assert.Equal(t, bson.M{"name": "a"}, c.dataScopeFilter(bson.M{"name": "a"}))
+
+	c = c.WithScope(bson.M{"tenantId": "t1"})
+	assert.Equal(t, bson.M{"tenantId": "t1"}, c.dataScopeFilter(nil))
+	assert.Equal(t, bson.M{"name": "a", "tenantId": "t1"}, c.dataScopeFilter(bson.M{"name": "a"}))
+	// condition on the scope field can not widen the scope
+	assert.Equal(t, bson.M{"$and": bson.A{bson.M{"tenantId": "t2"}, bson.M{"tenantId": "t1"}}},
+		c.dataScopeFilter(bson.M{"tenantId": "t2"}))
+
+	deleted := c.WithDeleted()
+	assert.Equal(t, bson.M{"tenantId": "t1"}, deleted.GetScope())
+	c.configuration.softDelete = true
+	assert.Equal(t, bson.M{"tenantId": "t1", SoftDeleteFieldIsDeleted: bson.M{"$ne": true}}, c.scopeFilter(nil))
+	assert.Equal(t, bson.A{bson.M{"$match": c.scopeFilter(nil)}, bson.M{"$limit": 1}},
+		c.scopePipeline([]bson.M{{"$limit": 1}}))
+}
@@ -0,0 +1,207 @@
+package mongodbr
+
+import (
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	SoftDeleteFieldIsDeleted    = "isDeleted"
+	SoftDeleteFieldDeletionTime = "deletionTime"
+	SoftDeleteFieldDeleterId    = "deleterId"
+)
+
+var _softDeleteEntityType = reflect.TypeOf((*ISoftDeleteEntity)(nil)).Elem()
+
+type ISoftDeleteEntity interface {
+	GetIsDeleted() bool
+	GetDeletionTime() *time.Time
+	GetDeleterId() string
+}
+
+var _ ISoftDeleteEntity = (*SoftDeleteEntity)(nil)
+
+// embed into entity to enable soft delete,
+// deleted documents are excluded from find,count,aggregate,update and replace automatically
+type SoftDeleteEntity struct {
+	//是否已删除
+	IsDeleted bool `json:"isDeleted" bson:"isDeleted"`
+	//删除时间
+	DeletionTime *time.Time `json:"deletionTime,omitempty" bson:"deletionTime,omitempty"`
+	//删除人员
+	DeleterId string `json:"deleterId,omitempty" bson:"deleterId,omitempty"`
+}
+
+// #region ISoftDeleteEntity Members
+
+func (e *SoftDeleteEntity) GetIsDeleted() bool {
+	return e.IsDeleted
+}
+
+func (e *SoftDeleteEntity) GetDeletionTime() *time.Time {
+	return e.DeletionTime
+}
+
+func (e *SoftDeleteEntity) GetDeleterId() string {
+	return e.DeleterId
+}
+
+// #endregion
+
+// v is a soft delete entity or a pointer to it
+func IsSoftDeleteEntity(v interface{}) bool {
+	t := reflect.TypeOf(v)
+	for t != nil {
+		if t.Implements(_softDeleteEntityType) || reflect.PtrTo(t).Implements(_softDeleteEntityType) {
+			return true
+		}
+		if t.Kind() != reflect.Ptr {
+			return false
+		}
+		t = t.Elem()
+	}
+	return false
+}
+
+// enable soft delete for repository
+func WithSoftDelete() RepositoryOption {
+	return func(configuration *Configuration) {
+		configuration.softDelete = true
+	}
+}
+
+// #region soft delete members
+
+func (r *MongoCol) IsSoftDeleteEnabled() bool {
+	return r.configuration.softDelete
+}
+
+// a view of collection that includes the soft deleted documents,
+// it is the only way to read them since the not deleted condition cannot be replaced by the filter
+func (r *MongoCol) WithDeleted() *MongoCol {
+	configuration := *r.configuration
+	configuration.softDelete = false
+	return &MongoCol{
+		configuration: &configuration,
+		collection:    r.collection,
+		scope:         r.scope,
+		ctx:           r.ctx,
+	}
+}
+
+// mark the document as deleted
+func (r *MongoCol) SoftDeleteOne(id primitive.ObjectID, deleterId string) error {
+	count, err := r.SoftDeleteMany(bson.M{"_id": id}, deleterId)
+	if err != nil {
+		return err
+	}
+	if count <= 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// mark the documents as deleted,return the count of deleted documents
+func (r *MongoCol) SoftDeleteMany(filter interface{}, deleterId string) (int64, error) {
+	ctx, cancel := r.createContext()
+	defer cancel()
+
+	set := bson.M{
+		SoftDeleteFieldIsDeleted:    true,
+		SoftDeleteFieldDeletionTime: time.Now(),
+	}
+	if len(deleterId) > 0 {
+		set[SoftDeleteFieldDeleterId] = deleterId
+	}
+	result, err := r.collection.UpdateMany(ctx, notDeletedFilter(r.dataScopeFilter(filter)), bson.M{"$set": set})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// restore a soft deleted document
+func (r *MongoCol) Restore(id primitive.ObjectID) error {
+	ctx, cancel := r.createContext()
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		r.dataScopeFilter(bson.M{"_id": id, SoftDeleteFieldIsDeleted: true}),
+		bson.M{
+			"$set":   bson.M{SoftDeleteFieldIsDeleted: false},
+			"$unset": bson.M{SoftDeleteFieldDeletionTime: "", SoftDeleteFieldDeleterId: ""},
+		},
+		options.Update().SetUpsert(false))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount <= 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// #endregion
+
+// add the scope and the not deleted condition to filter if soft delete is enabled
+func (r *MongoCol) scopeFilter(filter interface{}) interface{} {
+	filter = r.dataScopeFilter(filter)
+	if !r.configuration.softDelete {
+		return filter
+	}
+	return notDeletedFilter(filter)
+}
+
+// prepend a $match stage to pipeline if the collection is scoped or soft delete is enabled
+func (r *MongoCol) scopePipeline(pipeline interface{}) interface{} {
+	if !r.configuration.softDelete && len(r.scope) <= 0 {
+		return pipeline
+	}
+	stages := bson.A{bson.M{"$match": r.scopeFilter(nil)}}
+	v := reflect.ValueOf(pipeline)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		//unknown pipeline type,cannot be scoped
+		return pipeline
+	}
+	for i := 0; i < v.Len(); i++ {
+		stages = append(stages, v.Index(i).Interface())
+	}
+	return stages
+}
+
+// the not deleted condition is always added,a condition of isDeleted in filter cannot include the deleted documents.
+// use WithDeleted to read the deleted documents,e.g. restore or list the deleted documents
+func notDeletedFilter(filter interface{}) interface{} {
+	condition := bson.M{SoftDeleteFieldIsDeleted: bson.M{"$ne": true}}
+	switch v := filter.(type) {
+	case nil:
+		return condition
+	case bson.M:
+		if _, ok := v[SoftDeleteFieldIsDeleted]; !ok {
+			return mergeNotDeletedCondition(v, condition)
+		}
+	case map[string]interface{}:
+		if _, ok := v[SoftDeleteFieldIsDeleted]; !ok {
+			return mergeNotDeletedCondition(v, condition)
+		}
+	case bson.D:
+		if len(v) <= 0 {
+			return condition
+		}
+	}
+	return bson.M{"$and": bson.A{filter, condition}}
+}
+
+func mergeNotDeletedCondition(filter map[string]interface{}, condition bson.M) bson.M {
+	result := bson.M{}
+	for key, value := range filter {
+		result[key] = value
+	}
+	result[SoftDeleteFieldIsDeleted] = condition[SoftDeleteFieldIsDeleted]
+	return result
+}
@@ -0,0 +1,34 @@
+package mongodbr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type softDeleteUser struct {
+	Entity           `bson:",inline"`
+	SoftDeleteEntity `bson:",inline"`
+}
+
+func TestNotDeletedFilter(t *testing.T) {
+	notDeleted := bson.M{"$ne": true}
+	assert.Equal(t, bson.M{SoftDeleteFieldIsDeleted: notDeleted}, notDeletedFilter(nil))
+	assert.Equal(t, bson.M{"name": "a", SoftDeleteFieldIsDeleted: notDeleted}, notDeletedFilter(bson.M{"name": "a"}))
+	// condition of filter cannot include the deleted documents
+	assert.Equal(t, bson.M{"$and": bson.A{bson.M{SoftDeleteFieldIsDeleted: true}, bson.M{SoftDeleteFieldIsDeleted: notDeleted}}},
+		notDeletedFilter(bson.M{SoftDeleteFieldIsDeleted: true}))
+	assert.Equal(t, bson.M{"$and": bson.A{bson.D{{Key: SoftDeleteFieldIsDeleted, Value: true}}, bson.M{SoftDeleteFieldIsDeleted: notDeleted}}},
+		notDeletedFilter(bson.D{{Key: SoftDeleteFieldIsDeleted, Value: true}}))
+	assert.Equal(t, bson.M{"$and": bson.A{bson.D{{Key: "name", Value: "a"}}, bson.M{SoftDeleteFieldIsDeleted: notDeleted}}},
+		notDeletedFilter(bson.D{{Key: "name", Value: "a"}}))
+
+	// the deleted documents are read by the view that includes them
+	c := &MongoCol{configuration: &Configuration{softDelete: true}}
+	assert.Equal(t, bson.M{SoftDeleteFieldIsDeleted: true}, c.WithDeleted().scopeFilter(bson.M{SoftDeleteFieldIsDeleted: true}))
+
+	assert.True(t, IsSoftDeleteEntity(&softDeleteUser{}))
+	assert.True(t, IsSoftDeleteEntity(new(*softDeleteUser)))
+	assert.False(t, IsSoftDeleteEntity(&Entity{}))
+}
@@ -0,0 +1,139 @@
+package mongodbr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/shanluzhineng/fwpkg/system/tracing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// the value is replaced in filter shape
+const shapePlaceholder = "?"
+
+// create a client span for each command with the database,collection,operation and the shape of filter,
+// the values of filter are not recorded. the monitor that is already set is still called
+func EnableMongodbTracing() func(*options.ClientOptions) {
+	return func(co *options.ClientOptions) {
+		co.SetMonitor(newTracingMonitor(co.Monitor))
+	}
+}
+
+type tracingMonitor struct {
+	next *event.CommandMonitor
+	// spans of the running commands,the key is connection id and request id
+	spanMap sync.Map
+}
+
+func newTracingMonitor(next *event.CommandMonitor) *event.CommandMonitor {
+	m := &tracingMonitor{
+		next: next,
+	}
+	return &event.CommandMonitor{
+		Started:   m.started,
+		Succeeded: m.succeeded,
+		Failed:    m.failed,
+	}
+}
+
+func (m *tracingMonitor) started(ctx context.Context, e *event.CommandStartedEvent) {
+	attributeList := []attribute.KeyValue{
+		attribute.String("db.system", "mongodb"),
+		attribute.String("db.name", e.DatabaseName),
+		attribute.String("db.operation", e.CommandName),
+	}
+	spanName := e.CommandName
+	if collection, ok := e.Command.Lookup(e.CommandName).StringValueOK(); ok {
+		attributeList = append(attributeList, attribute.String("db.mongodb.collection", collection))
+		spanName = collection + "." + e.CommandName
+	}
+	if statement := commandShape(e.Command); len(statement) > 0 {
+		attributeList = append(attributeList, attribute.String("db.statement", statement))
+	}
+	_, span := tracing.Tracer().Start(ctx, spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributeList...))
+	m.spanMap.Store(spanKey(e.ConnectionID, e.RequestID), span)
+
+	if m.next != nil && m.next.Started != nil {
+		m.next.Started(ctx, e)
+	}
+}
+
+func (m *tracingMonitor) succeeded(ctx context.Context, e *event.CommandSucceededEvent) {
+	if value, ok := m.spanMap.LoadAndDelete(spanKey(e.ConnectionID, e.RequestID)); ok {
+		value.(trace.Span).End()
+	}
+	if m.next != nil && m.next.Succeeded != nil {
+		m.next.Succeeded(ctx, e)
+	}
+}
+
+func (m *tracingMonitor) failed(ctx context.Context, e *event.CommandFailedEvent) {
+	if value, ok := m.spanMap.LoadAndDelete(spanKey(e.ConnectionID, e.RequestID)); ok {
+		span := value.(trace.Span)
+		span.SetStatus(codes.Error, e.Failure)
+		span.End()
+	}
+	if m.next != nil && m.next.Failed != nil {
+		m.next.Failed(ctx, e)
+	}
+}
+
+func spanKey(connectionId string, requestId int64) string {
+	return fmt.Sprintf("%s:%d", connectionId, requestId)
+}
+
+// shape of the filter,query or pipeline of the command,the values are replaced by ?
+func commandShape(command bson.Raw) string {
+	for _, eachKey := range []string{"filter", "query", "pipeline"} {
+		value, err := command.LookupErr(eachKey)
+		if err != nil {
+			continue
+		}
+		shapeBytes, err := json.Marshal(valueShape(value))
+		if err != nil {
+			return ""
+		}
+		return string(shapeBytes)
+	}
+	return ""
+}
+
+// documents keep their keys,arrays of documents keep their items,other values are ?
+func valueShape(value bson.RawValue) interface{} {
+	switch value.Type {
+	case bsontype.EmbeddedDocument:
+		elementList, err := value.Document().Elements()
+		if err != nil {
+			return shapePlaceholder
+		}
+		shape := make(map[string]interface{}, len(elementList))
+		for _, eachElement := range elementList {
+			shape[eachElement.Key()] = valueShape(eachElement.Value())
+		}
+		return shape
+	case bsontype.Array:
+		valueList, err := value.Array().Values()
+		if err != nil {
+			return shapePlaceholder
+		}
+		shapeList := make([]interface{}, 0, len(valueList))
+		for _, eachValue := range valueList {
+			if eachValue.Type != bsontype.EmbeddedDocument && eachValue.Type != bsontype.Array {
+				return shapePlaceholder
+			}
+			shapeList = append(shapeList, valueShape(eachValue))
+		}
+		return shapeList
+	}
+	return shapePlaceholder
+}
@@ -0,0 +1,25 @@
+package mongodbr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCommandShape(t *testing.T) {
+	command, _ := bson.Marshal(bson.D{
+		{Key: "find", Value: "users"},
+		{Key: "filter", Value: bson.M{"name": "tom", "age": bson.M{"$gt": 18}, "tags": bson.M{"$in": bson.A{"a", "b"}}}},
+	})
+	assert.Equal(t, `{"age":{"$gt":"?"},"name":"?","tags":{"$in":"?"}}`, commandShape(command))
+
+	command, _ = bson.Marshal(bson.D{
+		{Key: "aggregate", Value: "users"},
+		{Key: "pipeline", Value: bson.A{bson.M{"$match": bson.M{"tenantId": "t1"}}}},
+	})
+	assert.Equal(t, `[{"$match":{"tenantId":"?"}}]`, commandShape(command))
+
+	command, _ = bson.Marshal(bson.D{{Key: "ping", Value: 1}})
+	assert.Equal(t, "", commandShape(command))
+}
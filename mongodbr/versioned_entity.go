@@ -0,0 +1,184 @@
+package mongodbr
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// bson name of version field
+const VersionFieldName = "version"
+
+// entity with a version field used for optimistic concurrency control
+type IVersionedEntity interface {
+	GetVersion() int64
+}
+
+var _ IVersionedEntity = (*VersionedEntity)(nil)
+
+// embed into entity to enable optimistic concurrency control,
+// version is increased on every update
+type VersionedEntity struct {
+	Version int64 `json:"version" bson:"version"`
+}
+
+// #region IVersionedEntity Members
+
+func (e *VersionedEntity) GetVersion() int64 {
+	return e.Version
+}
+
+// #endregion
+
+// #region versioned update members
+
+// update the document only if its version equals to version,
+// version is increased atomically,return ErrConcurrencyConflict if version is not matched
+func (r *MongoCol) FindOneAndUpdateWithVersion(objectId primitive.ObjectID, version int64, update interface{}, opts ...*options.FindOneAndUpdateOptions) error {
+	ctx, cancel := r.createContext()
+	defer cancel()
+
+	if len(opts) <= 0 {
+		opts = append(opts, options.FindOneAndUpdate().SetUpsert(false))
+	}
+	upMap, err := normalizeUpdate(update)
+	if err != nil {
+		return err
+	}
+	err = r.collection.FindOneAndUpdate(
+		ctx,
+		versionFilter(r.scopeFilter(bson.M{"_id": objectId}), version),
+		withVersionIncrement(upMap),
+		opts...,
+	).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return r.conflictOrNotFound(objectId)
+	}
+	return err
+}
+
+// #endregion
+
+// replace the document only if its version equals to version,
+// version of the new document is set to version+1
+func (r *RepositoryBase) ReplaceByIdWithVersion(id primitive.ObjectID, version int64, doc interface{}, opts ...*options.ReplaceOptions) error {
+	replacement, err := ToBsonMap(doc)
+	if err != nil {
+		return err
+	}
+	replacement["_id"] = id
+	replacement[VersionFieldName] = version + 1
+
+	ctx, cancel := r.createContext()
+	defer cancel()
+
+	result, err := r.collection.ReplaceOne(ctx, versionFilter(r.scopeFilter(bson.M{"_id": id}), version), replacement, opts...)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount <= 0 {
+		return r.conflictOrNotFound(id)
+	}
+	return nil
+}
+
+// delete the document only if its version equals to version
+func (r *RepositoryBase) DeleteOneWithVersion(id primitive.ObjectID, version int64, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	ctx, cancel := r.createContext()
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, versionFilter(r.dataScopeFilter(bson.M{"_id": id}), version), opts...)
+	if err != nil {
+		return result, err
+	}
+	if result.DeletedCount <= 0 {
+		return result, r.conflictOrNotFound(id)
+	}
+	return result, nil
+}
+
+// nothing matched,check the document is exist or not
+func (r *MongoCol) conflictOrNotFound(id primitive.ObjectID) error {
+	return r.conflictOrNotFoundByFilter(bson.M{"_id": id}, "id:"+id.Hex())
+}
+
+// nothing matched by filter and version,the target is added to the message of ErrConcurrencyConflict
+func (r *MongoCol) conflictOrNotFoundByFilter(filter interface{}, target string) error {
+	count, err := r.CountByFilter(filter)
+	if err != nil {
+		return err
+	}
+	if count <= 0 {
+		return mongo.ErrNoDocuments
+	}
+	return fmt.Errorf("%w,%s", ErrConcurrencyConflict, target)
+}
+
+// add the expected version to filter
+func versionFilter(filter interface{}, version int64) interface{} {
+	if filterMap, ok := filter.(bson.M); ok {
+		result := bson.M{VersionFieldName: VersionCondition(version)}
+		for key, value := range filterMap {
+			if key != VersionFieldName {
+				result[key] = value
+			}
+		}
+		return result
+	}
+	return bson.M{"$and": bson.A{filter, bson.M{VersionFieldName: VersionCondition(version)}}}
+}
+
+// condition of version field that matches the expected version,
+// version 0 also matches the legacy documents that are stored without version field
+func VersionCondition(version int64) interface{} {
+	if version == 0 {
+		// null matches the missing field
+		return bson.M{"$in": bson.A{int64(0), nil}}
+	}
+	return version
+}
+
+// version is maintained by repository,remove it from $set and increase it with $inc
+func withVersionIncrement(update bson.M) bson.M {
+	if setValue, ok := update["$set"]; ok {
+		update["$set"] = removeDocumentKey(setValue, VersionFieldName)
+	}
+	incValue := bson.M{}
+	if v, ok := update["$inc"]; ok {
+		if m, err := ToBsonMap(v); err == nil {
+			incValue = m
+		}
+	}
+	incValue[VersionFieldName] = 1
+	update["$inc"] = incValue
+	return update
+}
+
+func removeDocumentKey(doc interface{}, key string) interface{} {
+	switch v := doc.(type) {
+	case bson.M:
+		delete(v, key)
+		return v
+	case map[string]interface{}:
+		delete(v, key)
+		return v
+	case bson.D:
+		result := make(bson.D, 0, len(v))
+		for _, eachElement := range v {
+			if eachElement.Key != key {
+				result = append(result, eachElement)
+			}
+		}
+		return result
+	}
+	m, err := ToBsonMap(doc)
+	if err != nil {
+		return doc
+	}
+	delete(m, key)
+	return m
+}
@@ -0,0 +1,38 @@
+package mongodbr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type testVersionedEntity struct {
+	Entity          `bson:",inline"`
+	VersionedEntity `bson:",inline"`
+	Name            string `bson:"name"`
+}
+
+func TestVersionFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	assert.Equal(t, bson.M{"_id": id, VersionFieldName: int64(2)}, versionFilter(bson.M{"_id": id, VersionFieldName: int64(5)}, 2))
+	assert.Equal(t, bson.M{"$and": bson.A{bson.D{{Key: "_id", Value: id}}, bson.M{VersionFieldName: int64(2)}}},
+		versionFilter(bson.D{{Key: "_id", Value: id}}, 2))
+}
+
+func TestVersionFilterLegacyDocument(t *testing.T) {
+	// document stored before the entity is versioned
+	id := primitive.NewObjectID()
+	data, err := bson.Marshal(bson.M{"_id": id, "name": "a"})
+	assert.Nil(t, err)
+	item := &testVersionedEntity{}
+	assert.Nil(t, bson.Unmarshal(data, item))
+	assert.Equal(t, int64(0), item.GetVersion())
+
+	// the version read from legacy document matches the document without version field
+	filter := versionFilter(bson.M{"_id": id}, item.GetVersion())
+	assert.Equal(t, bson.M{"_id": id, VersionFieldName: bson.M{"$in": bson.A{int64(0), nil}}}, filter)
+	assert.Equal(t, bson.M{"$in": bson.A{int64(0), nil}}, VersionCondition(0))
+	assert.Equal(t, int64(1), VersionCondition(1))
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/shanluzhineng/configurationx"
 	esConfiguration "github.com/shanluzhineng/configurationx/options/elasticsearch"
 	"github.com/shanluzhineng/fwpkg/app"
+	"github.com/shanluzhineng/fwpkg/system/health"
 	"github.com/shanluzhineng/fwpkg/system/log"
 	jsonUtil "github.com/shanluzhineng/fwpkg/utils/json"
 	"go.uber.org/zap"
@@ -34,6 +35,8 @@ func InitElasticsearch() {
 	//注册到ioc中
 	app.Context.RegistInstance(currentClient)
 	app.Context.RegistInstance(elasticsearchTypedClient)
+	//opevents不可用时服务仍然可用
+	health.Register(NewHealthChecker(currentClient), health.WithTags(health.TagReadiness), health.WithNonCritical())
 
 	log.Logger.Info(fmt.Sprintf("elasticsearch初始化成功,url:%s", strings.Join(defaultOptions.Addresses, ",")))
 	//初始化各个索引
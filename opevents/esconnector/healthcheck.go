@@ -0,0 +1,31 @@
+package esconnector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/shanluzhineng/fwpkg/system/health"
+)
+
+const (
+	HealthCheckerName = "elasticsearch"
+)
+
+// ping the elasticsearch cluster
+func NewHealthChecker(client *elasticsearch.Client) health.HealthChecker {
+	return health.NewCheckerFunc(HealthCheckerName, func(ctx context.Context) error {
+		if client == nil {
+			return fmt.Errorf("client is nil")
+		}
+		res, err := client.Ping(client.Ping.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.IsError() {
+			return fmt.Errorf("ping fail,%s", res.String())
+		}
+		return nil
+	})
+}
@@ -1,10 +1,13 @@
 package kafkaconnector
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/shanluzhineng/fwpkg/opevents/pkg"
+	"github.com/shanluzhineng/fwpkg/system/correlation"
+	"github.com/shanluzhineng/fwpkg/system/health"
 	"github.com/shanluzhineng/fwpkg/system/log"
 
 	"github.com/shanluzhineng/configurationx"
@@ -29,6 +32,7 @@ func newOpEventLogKafkaPusher() *opEventLogKafkaPusher {
 	}
 	//确保topic已经被创建完成
 	ensureTopicCreated(kafkaOptions.Brokers[0], Topic_OpEventLog)
+	health.Register(kafkaqueue.NewHealthChecker(kafkaOptions.Brokers), health.WithTags(health.TagReadiness), health.WithNonCritical())
 	pusher := kafkaqueue.NewPusher(kafkaOptions.Brokers,
 		Topic_OpEventLog,
 		kafkaqueue.WithFlushInterval(time.Millisecond*time.Duration(producerOptions.FlushInterval)))
@@ -44,7 +48,12 @@ func (p *opEventLogKafkaPusher) PushOpEvents(item *pkg.OpEventLog) error {
 		return fmt.Errorf("必须先初始化Pusher")
 	}
 	datas := string(item.Bytes())
-	err := p.kafkaPusher.Push(datas)
+	//CorrelationId通过消息header传递给消费者
+	ctx := context.Background()
+	if len(item.CorrelationId) > 0 {
+		ctx = correlation.ContextWithCorrelation(ctx, &correlation.Correlation{RequestId: item.CorrelationId})
+	}
+	err := p.kafkaPusher.PushWithContext(ctx, datas)
 	if err != nil {
 		log.Logger.Error(fmt.Sprintf("在将数据push到kafka中时出现异常,数据:%s,详细异常信息:%s", datas, err.Error()))
 	}
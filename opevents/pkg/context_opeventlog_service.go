@@ -0,0 +1,63 @@
+package pkg
+
+import (
+	"context"
+
+	"github.com/shanluzhineng/fwpkg/app"
+	"go.uber.org/zap/zapcore"
+)
+
+// 在请求中创建的事件日志自动使用ctx中的请求id作为CorrelationId
+type contextOpEventLogService struct {
+	ctx     context.Context
+	service IOpEventLogService
+}
+
+var _ IOpEventLogService = (*contextOpEventLogService)(nil)
+
+func NewContextOpEventLogService(ctx context.Context, service IOpEventLogService) IOpEventLogService {
+	return &contextOpEventLogService{
+		ctx:     ctx,
+		service: service,
+	}
+}
+
+// 获取ioc容器中的IOpEventLogService,事件日志使用ctx中的请求id
+func GetOpEventLogService(ctx context.Context) IOpEventLogService {
+	var service IOpEventLogService
+	if app.Context != nil {
+		service, _ = app.Context.GetInstance(new(IOpEventLogService)).(IOpEventLogService)
+	}
+	if service == nil {
+		service = newDefaultOpEventLogService()
+	}
+	return NewContextOpEventLogService(ctx, service)
+}
+
+func (s *contextOpEventLogService) Save(item *OpEventLog) error {
+	WithContext(s.ctx)(item)
+	return s.service.Save(item)
+}
+
+func (s *contextOpEventLogService) SaveDebugOpEventLog(message string, opts ...EventLogOption) (err error) {
+	return s.service.SaveDebugOpEventLog(message, s.withContext(opts)...)
+}
+
+func (s *contextOpEventLogService) SaveWarnOpEventLog(message string, opts ...EventLogOption) (err error) {
+	return s.service.SaveWarnOpEventLog(message, s.withContext(opts)...)
+}
+
+func (s *contextOpEventLogService) SaveErrorOpEventLog(message string, opts ...EventLogOption) (err error) {
+	return s.service.SaveErrorOpEventLog(message, s.withContext(opts)...)
+}
+
+func (s *contextOpEventLogService) SaveOpEventLog(message string, logLevel zapcore.Level, opts ...EventLogOption) (err error) {
+	return s.service.SaveOpEventLog(message, logLevel, s.withContext(opts)...)
+}
+
+// 在其他选项之后执行,选项中设置的CorrelationId不会被覆盖
+func (s *contextOpEventLogService) withContext(opts []EventLogOption) []EventLogOption {
+	optList := make([]EventLogOption, 0, len(opts)+1)
+	optList = append(optList, opts...)
+	return append(optList, WithContext(s.ctx))
+}
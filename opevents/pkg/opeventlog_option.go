@@ -1,5 +1,11 @@
 package pkg
 
+import (
+	"context"
+
+	"github.com/shanluzhineng/fwpkg/system/correlation"
+)
+
 type EventLogOption func(*OpEventLog)
 
 func WithOpAction(opAction string) EventLogOption {
@@ -19,3 +25,19 @@ func WithAndroidId(androidId string) EventLogOption {
 		oel.AndroidId = androidId
 	}
 }
+
+// 全链路追踪的id
+func WithCorrelationId(correlationId string) EventLogOption {
+	return func(oel *OpEventLog) {
+		oel.CorrelationId = correlationId
+	}
+}
+
+// 使用ctx中的请求id作为CorrelationId,已经设置时不覆盖
+func WithContext(ctx context.Context) EventLogOption {
+	return func(oel *OpEventLog) {
+		if len(oel.CorrelationId) <= 0 {
+			oel.CorrelationId = correlation.RequestIdFromContext(ctx)
+		}
+	}
+}
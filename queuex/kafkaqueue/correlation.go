@@ -0,0 +1,50 @@
+package kafkaqueue
+
+import (
+	"context"
+	"net/http"
+
+	kafka "github.com/segmentio/kafka-go"
+	"github.com/shanluzhineng/fwpkg/system/correlation"
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"github.com/shanluzhineng/fwpkg/system/tracing"
+	"go.uber.org/zap"
+)
+
+// handler that receives the context with the correlation of message headers,
+// it is used instead of Consume if the handler implements it
+type ContextConsumeHandler interface {
+	ConsumeWithContext(ctx context.Context, key, value string) error
+}
+
+// headers of the correlation and span context of ctx,nil if ctx has neither of them.
+// the traceparent of span context replaces the one of correlation
+func correlationHeaders(ctx context.Context) []kafka.Header {
+	header := http.Header{}
+	if messageCorrelation := correlation.FromContext(ctx); messageCorrelation != nil {
+		messageCorrelation.SetHeader(header)
+	}
+	tracing.Inject(ctx, header)
+	if len(header) <= 0 {
+		return nil
+	}
+	headerList := make([]kafka.Header, 0, len(header))
+	for eachKey := range header {
+		headerList = append(headerList, kafka.Header{Key: eachKey, Value: []byte(header.Get(eachKey))})
+	}
+	return headerList
+}
+
+// context with the correlation,remote span context and logger of message headers,
+// a new request id is used if the message has no headers
+func contextFromHeaders(headerList []kafka.Header) context.Context {
+	header := http.Header{}
+	for _, eachHeader := range headerList {
+		header.Set(eachHeader.Key, string(eachHeader.Value))
+	}
+	messageCorrelation := correlation.FromHeader(header)
+	ctx := correlation.ContextWithCorrelation(context.Background(), messageCorrelation)
+	ctx = tracing.Extract(ctx, header)
+	logger := log.FromContext(ctx).With(zap.String(correlation.LogFieldRequestId, messageCorrelation.RequestId))
+	return log.ContextWithLogger(ctx, logger)
+}
@@ -0,0 +1,37 @@
+package kafkaqueue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	kq "github.com/segmentio/kafka-go"
+	"github.com/shanluzhineng/fwpkg/system/health"
+)
+
+const (
+	HealthCheckerName = "kafka"
+)
+
+// dial each broker,the check fails if one of the brokers can not be connected
+func NewHealthChecker(brokers []string) health.HealthChecker {
+	return health.NewCheckerFunc(HealthCheckerName, func(ctx context.Context) error {
+		if len(brokers) <= 0 {
+			return fmt.Errorf("no brokers")
+		}
+		dialer := &kq.Dialer{}
+		errList := make([]string, 0)
+		for _, eachBroker := range brokers {
+			conn, err := dialer.DialContext(ctx, "tcp", eachBroker)
+			if err != nil {
+				errList = append(errList, fmt.Sprintf("%s:%s", eachBroker, err.Error()))
+				continue
+			}
+			conn.Close()
+		}
+		if len(errList) > 0 {
+			return fmt.Errorf("connect to brokers fail,%s", strings.Join(errList, ";"))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,58 @@
+package kafkaqueue
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	kafka "github.com/segmentio/kafka-go"
+	"github.com/shanluzhineng/fwpkg/system/metrics"
+)
+
+var (
+	kafkaProducedMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_produced_messages_total",
+		Help: "Total number of messages written to kafka by topic and result.",
+	}, []string{"topic", "result"})
+	kafkaConsumedMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumed_messages_total",
+		Help: "Total number of messages consumed from kafka by topic,group and result.",
+	}, []string{"topic", "group", "result"})
+	kafkaConsumeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_consume_duration_seconds",
+		Help:    "Duration of the handlers of kafka messages by topic and group.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic", "group"})
+	kafkaConsumerLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "Number of messages behind the high water mark by topic,group and partition.",
+	}, []string{"topic", "group", "partition"})
+)
+
+func init() {
+	metrics.MustRegister(kafkaProducedMessages, kafkaConsumedMessages, kafkaConsumeDuration, kafkaConsumerLag)
+}
+
+func observeProduced(topic string, count int, err error) {
+	kafkaProducedMessages.WithLabelValues(topic, resultLabel(err)).Add(float64(count))
+}
+
+func observeFetched(group string, msg kafka.Message) {
+	lag := msg.HighWaterMark - msg.Offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	kafkaConsumerLag.WithLabelValues(msg.Topic, group, strconv.Itoa(msg.Partition)).Set(float64(lag))
+}
+
+func observeConsumed(group string, msg kafka.Message, startTime time.Time, err error) {
+	kafkaConsumeDuration.WithLabelValues(msg.Topic, group).Observe(time.Since(startTime).Seconds())
+	kafkaConsumedMessages.WithLabelValues(msg.Topic, group, resultLabel(err)).Inc()
+}
+
+func resultLabel(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
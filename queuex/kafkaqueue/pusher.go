@@ -43,7 +43,9 @@ func NewPusher(addrs []string, topic string, opts ...PushOption) *Pusher {
 		for i := range tasks {
 			chunk[i] = tasks[i].(kafka.Message)
 		}
-		if err := pusher.produer.WriteMessages(context.Background(), chunk...); err != nil {
+		err := pusher.produer.WriteMessages(context.Background(), chunk...)
+		observeProduced(topic, len(chunk), err)
+		if err != nil {
 			log.Logger.Error(err.Error())
 		}
 	}, newOptions(opts)...)
@@ -60,14 +62,26 @@ func (p *Pusher) Name() string {
 }
 
 func (p *Pusher) Push(v string) error {
+	return p.PushWithContext(context.Background(), v)
+}
+
+// the request id and span context of ctx are set to the message headers
+func (p *Pusher) PushWithContext(ctx context.Context, v string) (err error) {
+	ctx, span := startProduceSpan(ctx, p.topic)
+	defer func() {
+		endSpan(span, err)
+	}()
 	msg := kafka.Message{
-		Key:   []byte(strconv.FormatInt(time.Now().UnixNano(), 10)),
-		Value: []byte(v),
+		Key:     []byte(strconv.FormatInt(time.Now().UnixNano(), 10)),
+		Value:   []byte(v),
+		Headers: correlationHeaders(ctx),
 	}
 	if p.executor != nil {
 		return p.executor.Add(msg, len(v))
 	} else {
-		return p.produer.WriteMessages(context.Background(), msg)
+		err = p.produer.WriteMessages(context.Background(), msg)
+		observeProduced(p.topic, 1, err)
+		return err
 	}
 }
 
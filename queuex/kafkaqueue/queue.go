@@ -20,7 +20,8 @@ const (
 )
 
 type (
-	ConsumeHandle func(key, value string) error
+	ConsumeHandle        func(key, value string) error
+	ContextConsumeHandle func(ctx context.Context, key, value string) error
 
 	ConsumeHandler interface {
 		Consume(key, value string) error
@@ -117,8 +118,15 @@ func (q *kafkaQueue) Stop() {
 	q.consumer.Close()
 }
 
-func (q *kafkaQueue) consumeOne(key, val string) error {
-	err := q.handler.Consume(key, val)
+func (q *kafkaQueue) consumeOne(msg kafka.Message) (err error) {
+	ctx, span := startConsumeSpan(q.c.Group, msg)
+	defer func() {
+		endSpan(span, err)
+	}()
+	if handler, ok := q.handler.(ContextConsumeHandler); ok {
+		return handler.ConsumeWithContext(ctx, string(msg.Key), string(msg.Value))
+	}
+	err = q.handler.Consume(string(msg.Key), string(msg.Value))
 	return err
 }
 
@@ -126,7 +134,10 @@ func (q *kafkaQueue) startConsumers() {
 	for i := 0; i < q.c.Processors; i++ {
 		q.consumerRoutines.Run(func() {
 			for msg := range q.channel {
-				if err := q.consumeOne(string(msg.Key), string(msg.Value)); err != nil {
+				startTime := time.Now()
+				err := q.consumeOne(msg)
+				observeConsumed(q.c.Group, msg, startTime, err)
+				if err != nil {
 					log.Logger.Error(fmt.Sprintf("Error on consuming: %s, error: %v", string(msg.Value), err))
 				}
 				q.consumer.CommitMessages(context.Background(), msg)
@@ -150,6 +161,7 @@ func (q *kafkaQueue) startProducers() {
 					log.Logger.Error(fmt.Sprintf("Error on reading message, %q", err.Error()))
 					continue
 				}
+				observeFetched(q.c.Group, msg)
 				q.channel <- msg
 			}
 		})
@@ -192,6 +204,25 @@ func WithMaxWait(wait time.Duration) QueueOption {
 	}
 }
 
+// the handle receives the context with the request id of message headers
+func WithContextHandle(handle ContextConsumeHandle) ConsumeHandler {
+	return innerContextConsumeHandler{
+		handle: handle,
+	}
+}
+
+type innerContextConsumeHandler struct {
+	handle ContextConsumeHandle
+}
+
+func (ch innerContextConsumeHandler) Consume(k, v string) error {
+	return ch.handle(context.Background(), k, v)
+}
+
+func (ch innerContextConsumeHandler) ConsumeWithContext(ctx context.Context, k, v string) error {
+	return ch.handle(ctx, k, v)
+}
+
 type innerConsumeHandler struct {
 	handle ConsumeHandle
 }
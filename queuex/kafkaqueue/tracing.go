@@ -0,0 +1,46 @@
+package kafkaqueue
+
+import (
+	"context"
+
+	kafka "github.com/segmentio/kafka-go"
+	"github.com/shanluzhineng/fwpkg/system/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// producer span of the message,its context is propagated by the message headers
+func startProduceSpan(ctx context.Context, topic string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.operation", "publish"),
+			attribute.String("messaging.destination.name", topic),
+		))
+}
+
+// consumer span of the message,the span of producer is the parent.
+// the logger of the returned context writes the trace id
+func startConsumeSpan(group string, msg kafka.Message) (context.Context, trace.Span) {
+	ctx, span := tracing.Tracer().Start(contextFromHeaders(msg.Headers), msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.operation", "process"),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.String("messaging.kafka.consumer.group", group),
+			attribute.Int("messaging.kafka.destination.partition", msg.Partition),
+			attribute.Int64("messaging.kafka.message.offset", msg.Offset),
+		))
+	return tracing.ContextWithTraceLogger(ctx), span
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,23 @@
+package redisx
+
+import (
+	"context"
+	"fmt"
+
+	redis "github.com/go-redis/redis/v8"
+	"github.com/shanluzhineng/fwpkg/system/health"
+)
+
+const (
+	HealthCheckerName = "redis"
+)
+
+// ping the redis server
+func NewHealthChecker(client *redis.Client) health.HealthChecker {
+	return health.NewCheckerFunc(HealthCheckerName, func(ctx context.Context) error {
+		if client == nil {
+			return fmt.Errorf("client is nil")
+		}
+		return client.Ping(ctx).Err()
+	})
+}
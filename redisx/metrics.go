@@ -0,0 +1,78 @@
+package redisx
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	redis "github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shanluzhineng/fwpkg/system/metrics"
+)
+
+const (
+	// command label of pipelines
+	pipelineCommandName = "pipeline"
+)
+
+var (
+	redisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "redis_command_duration_seconds",
+		Help:    "Duration of redis commands by command.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command"})
+	redisCommandErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "redis_command_errors_total",
+		Help: "Total number of failed redis commands by command,redis.Nil is not an error.",
+	}, []string{"command"})
+)
+
+func init() {
+	metrics.MustRegister(redisCommandDuration, redisCommandErrors)
+}
+
+type metricsStartTimeKey struct{}
+
+type metricsHook struct {
+}
+
+var _ redis.Hook = (*metricsHook)(nil)
+
+// hook that records the latency and errors of the commands,add it by client.AddHook
+func NewMetricsHook() redis.Hook {
+	return &metricsHook{}
+}
+
+func (h *metricsHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, metricsStartTimeKey{}, time.Now()), nil
+}
+
+func (h *metricsHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	observeRedisCommand(ctx, cmd.Name(), cmd.Err())
+	return nil
+}
+
+func (h *metricsHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, metricsStartTimeKey{}, time.Now()), nil
+}
+
+func (h *metricsHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, eachCmd := range cmds {
+		if cmdErr := eachCmd.Err(); cmdErr != nil && !errors.Is(cmdErr, redis.Nil) {
+			err = cmdErr
+			break
+		}
+	}
+	observeRedisCommand(ctx, pipelineCommandName, err)
+	return nil
+}
+
+func observeRedisCommand(ctx context.Context, command string, err error) {
+	if startTime, ok := ctx.Value(metricsStartTimeKey{}).(time.Time); ok {
+		redisCommandDuration.WithLabelValues(command).Observe(time.Since(startTime).Seconds())
+	}
+	if err != nil && !errors.Is(err, redis.Nil) {
+		redisCommandErrors.WithLabelValues(command).Inc()
+	}
+}
@@ -14,12 +14,14 @@ type IRedisService interface {
 	IRedisKeyService
 	IRedisStringService
 	IRedisHashService
+	IRedisScriptService
 }
 
 type redisService struct {
 	IRedisKeyService
 	IRedisStringService
 	IRedisHashService
+	IRedisScriptService
 }
 
 // new一个IRedisService
@@ -28,6 +30,7 @@ func NewRedisService(options *RedisOptions) IRedisService {
 		IRedisKeyService:    NewRedisKeyService(options),
 		IRedisStringService: NewRedisStringService(options),
 		IRedisHashService:   NewRedisHashService(options),
+		IRedisScriptService: NewRedisScriptService(options),
 	}
 	return s
 }
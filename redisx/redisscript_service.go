@@ -0,0 +1,38 @@
+package redisx
+
+import "github.com/go-redis/redis/v8"
+
+// 执行lua脚本,脚本在redis中原子执行
+type IRedisScriptService interface {
+	//执行脚本,keyList会加上key前缀,脚本优先使用EVALSHA执行
+	RunScript(script *redis.Script, keyList []string, args []interface{}, opts ...RedisValueOption) (interface{}, error)
+}
+
+var _ IRedisScriptService = (*RedisScriptService)(nil)
+
+// 默认的IRedisScriptService实现
+type RedisScriptService struct {
+	*RedisKeyService
+}
+
+func NewRedisScriptService(options *RedisOptions) IRedisScriptService {
+	s := &RedisScriptService{
+		RedisKeyService: NewRedisKeyService(options),
+	}
+	return s
+}
+
+func (s *RedisScriptService) RunScript(script *redis.Script, keyList []string, args []interface{}, opts ...RedisValueOption) (interface{}, error) {
+	options := s.options.createRedisValueOptions()
+	options.applyOption(opts...)
+
+	prefixKeyList := make([]string, 0, len(keyList))
+	for _, eachKey := range keyList {
+		prefixKeyList = append(prefixKeyList, s.appendKeyPrefix(eachKey, options))
+	}
+	v, err := script.Run(options.ctx, s.options.client, prefixKeyList, args...).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return v, err
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/shanluzhineng/configurationx"
 	"github.com/shanluzhineng/fwpkg/app"
 	"github.com/shanluzhineng/fwpkg/app/web"
+	"github.com/shanluzhineng/fwpkg/system/health"
 	"github.com/shanluzhineng/fwpkg/system/log"
 	"go.uber.org/zap"
 
@@ -48,6 +49,7 @@ func initRedis() *redis.Client {
 	}
 	log.Logger.Info(fmt.Sprintf(">>> Redis init DONE, addr: %s", client.Options().Addr))
 	app.Context.RegistInstance(client)
+	health.Register(redisx.NewHealthChecker(client), health.WithTags(health.TagReadiness))
 	return client
 }
 
@@ -65,6 +67,8 @@ func createRedisClient() *redis.Client {
 		Password: defaultRedisOptions.Password,
 		DB:       defaultRedisOptions.DB,
 	})
+	client.AddHook(redisx.NewMetricsHook())
+	client.AddHook(redisx.NewTracingHook())
 	return client
 }
 
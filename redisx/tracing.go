@@ -0,0 +1,74 @@
+package redisx
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	redis "github.com/go-redis/redis/v8"
+	"github.com/shanluzhineng/fwpkg/system/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracingHook struct {
+}
+
+var _ redis.Hook = (*tracingHook)(nil)
+
+// hook that creates a client span for each command or pipeline,add it by client.AddHook.
+// the arguments of the commands are not recorded
+func NewTracingHook() redis.Hook {
+	return &tracingHook{}
+}
+
+func (h *tracingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = startRedisSpan(ctx, cmd.Name(), cmd.Name())
+	return ctx, nil
+}
+
+func (h *tracingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	endRedisSpan(ctx, cmd.Err())
+	return nil
+}
+
+func (h *tracingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	nameList := make([]string, 0, len(cmds))
+	for _, eachCmd := range cmds {
+		nameList = append(nameList, eachCmd.Name())
+	}
+	ctx, span := startRedisSpan(ctx, pipelineCommandName, strings.Join(nameList, " "))
+	span.SetAttributes(attribute.Int("db.redis.pipeline_length", len(cmds)))
+	return ctx, nil
+}
+
+func (h *tracingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, eachCmd := range cmds {
+		if cmdErr := eachCmd.Err(); cmdErr != nil && !errors.Is(cmdErr, redis.Nil) {
+			err = cmdErr
+			break
+		}
+	}
+	endRedisSpan(ctx, err)
+	return nil
+}
+
+func startRedisSpan(ctx context.Context, spanName string, operation string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "redis "+spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", operation),
+		))
+}
+
+func endRedisSpan(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,26 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shanluzhineng/fwpkg/system/health"
+)
+
+const (
+	HealthCheckerName = "consul"
+)
+
+// query the consul agent of the registry
+func NewHealthChecker(r *Registry) health.HealthChecker {
+	return health.NewCheckerFunc(HealthCheckerName, func(ctx context.Context) error {
+		if r == nil {
+			return fmt.Errorf("registry is nil")
+		}
+		if r.err != nil {
+			return r.err
+		}
+		_, err := r.client.Agent().Self()
+		return err
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/shanluzhineng/fwpkg/app"
 	"github.com/shanluzhineng/fwpkg/app/host"
 	"github.com/shanluzhineng/fwpkg/app/web"
+	"github.com/shanluzhineng/fwpkg/system/health"
 	"github.com/shanluzhineng/fwpkg/system/log"
 	"github.com/shanluzhineng/fwpkg/utils/str"
 )
@@ -45,6 +46,7 @@ func serviceConfigurator(wa web.WebApplication) {
 	}
 	//注册registry对象
 	app.Context.SetInstance(registry)
+	health.Register(NewHealthChecker(registry), health.WithTags(health.TagReadiness), health.WithNonCritical())
 }
 
 func normalizeConsulOption() {
@@ -0,0 +1,147 @@
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	HeaderRequestId = "X-Request-Id"
+	// w3c trace context,https://www.w3.org/TR/trace-context/
+	HeaderTraceparent = "traceparent"
+
+	// field name of the request id in logs
+	LogFieldRequestId = "requestId"
+
+	// a longer request id from client is replaced
+	maxRequestIdLength = 128
+)
+
+// ids of the request that are propagated to logs,events and outbound calls
+type Correlation struct {
+	RequestId   string
+	Traceparent string
+}
+
+type correlationContextKey struct{}
+
+func ContextWithCorrelation(ctx context.Context, correlation *Correlation) context.Context {
+	return context.WithValue(ctx, correlationContextKey{}, correlation)
+}
+
+// nil if the context has no correlation
+func FromContext(ctx context.Context) *Correlation {
+	if ctx == nil {
+		return nil
+	}
+	correlation, _ := ctx.Value(correlationContextKey{}).(*Correlation)
+	return correlation
+}
+
+// request id of the context,empty if the context has no correlation
+func RequestIdFromContext(ctx context.Context) string {
+	if correlation := FromContext(ctx); correlation != nil {
+		return correlation.RequestId
+	}
+	return ""
+}
+
+// the request id is X-Request-Id of header,or the trace id of traceparent,or a new id if both of them are not valid
+func FromHeader(header http.Header) *Correlation {
+	correlation := &Correlation{}
+	if traceId, ok := ParseTraceparent(header.Get(HeaderTraceparent)); ok {
+		correlation.Traceparent = header.Get(HeaderTraceparent)
+		correlation.RequestId = traceId
+	}
+	if requestId := header.Get(HeaderRequestId); IsValidRequestId(requestId) {
+		correlation.RequestId = requestId
+	}
+	if len(correlation.RequestId) <= 0 {
+		correlation.RequestId = NewRequestId()
+	}
+	return correlation
+}
+
+// set the ids to the header of outbound request or message
+func (c *Correlation) SetHeader(header http.Header) {
+	if c == nil {
+		return
+	}
+	if len(c.RequestId) > 0 {
+		header.Set(HeaderRequestId, c.RequestId)
+	}
+	if len(c.Traceparent) > 0 {
+		header.Set(HeaderTraceparent, c.Traceparent)
+	}
+}
+
+// 32 hex chars,it is also a valid trace id of traceparent
+func NewRequestId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// printable ascii without space,at most 128 chars
+func IsValidRequestId(requestId string) bool {
+	if len(requestId) <= 0 || len(requestId) > maxRequestIdLength {
+		return false
+	}
+	for i := 0; i < len(requestId); i++ {
+		if requestId[i] <= ' ' || requestId[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// trace id of traceparent header,version-traceid-parentid-flags
+func ParseTraceparent(traceparent string) (string, bool) {
+	partList := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(partList) < 4 || len(partList[0]) != 2 || partList[0] == "ff" ||
+		len(partList[1]) != 32 || len(partList[2]) != 16 || len(partList[3]) != 2 {
+		return "", false
+	}
+	for _, eachPart := range partList[:4] {
+		if _, err := hex.DecodeString(eachPart); err != nil || strings.ToLower(eachPart) != eachPart {
+			return "", false
+		}
+	}
+	if strings.Trim(partList[1], "0") == "" || strings.Trim(partList[2], "0") == "" {
+		return "", false
+	}
+	return partList[1], true
+}
+
+// #region http
+
+type transport struct {
+	base http.RoundTripper
+}
+
+// set the correlation of request context to the header of outbound requests,
+// http.DefaultTransport is used if base is nil
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{
+		base: base,
+	}
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	correlation := FromContext(r.Context())
+	if correlation == nil || len(r.Header.Get(HeaderRequestId)) > 0 {
+		return t.base.RoundTrip(r)
+	}
+	// the request must not be modified by RoundTripper
+	r = r.Clone(r.Context())
+	correlation.SetHeader(r.Header)
+	return t.base.RoundTrip(r)
+}
+
+// #endregion
@@ -0,0 +1,48 @@
+package correlation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromHeader(t *testing.T) {
+	header := http.Header{}
+	correlation := FromHeader(header)
+	assert.Len(t, correlation.RequestId, 32)
+
+	header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	correlation = FromHeader(header)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", correlation.RequestId)
+
+	header.Set(HeaderRequestId, "req-1")
+	correlation = FromHeader(header)
+	assert.Equal(t, "req-1", correlation.RequestId)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", correlation.Traceparent)
+
+	header.Set(HeaderRequestId, "bad id")
+	header.Set(HeaderTraceparent, "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	correlation = FromHeader(header)
+	assert.NotEqual(t, "bad id", correlation.RequestId)
+	assert.Empty(t, correlation.Traceparent)
+}
+
+func TestTransport(t *testing.T) {
+	var requestId string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId = r.Header.Get(HeaderRequestId)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	ctx := ContextWithCorrelation(context.Background(), &Correlation{RequestId: "req-1"})
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	res, err := client.Do(r)
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.Equal(t, "req-1", requestId)
+	assert.Empty(t, r.Header.Get(HeaderRequestId))
+}
@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// aggregated status of the checks
+type Status string
+
+const (
+	StatusUp Status = "up"
+	// non critical checks fail,the application still works
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+const (
+	// the check is run by the liveness probe,a failure means the process should be restarted
+	TagLiveness = "liveness"
+	// the check is run by the readiness probe,a failure means the process should not receive traffic
+	TagReadiness = "readiness"
+)
+
+const (
+	DefaultTimeout       = 3 * time.Second
+	DefaultCacheDuration = 5 * time.Second
+)
+
+// check one component that the application depends on,
+// a nil error means the component is up
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type checkerFunc struct {
+	name      string
+	checkFunc func(ctx context.Context) error
+}
+
+// use a function as HealthChecker
+func NewCheckerFunc(name string, checkFunc func(ctx context.Context) error) HealthChecker {
+	return &checkerFunc{
+		name:      name,
+		checkFunc: checkFunc,
+	}
+}
+
+func (c *checkerFunc) Name() string {
+	return c.name
+}
+
+func (c *checkerFunc) Check(ctx context.Context) error {
+	if c.checkFunc == nil {
+		return nil
+	}
+	return c.checkFunc(ctx)
+}
+
+// options of a registered checker
+type CheckOptions struct {
+	// tags of the check,a check without tags is only run by the full report
+	Tags []string
+	// the check fails if it does not finish in the timeout
+	Timeout time.Duration
+	// the result is reused in the duration,zero means the registry default
+	CacheDuration time.Duration
+	// a failure of non critical check makes the status degraded instead of down
+	Critical bool
+}
+
+type CheckOption func(*CheckOptions)
+
+func WithTags(tags ...string) CheckOption {
+	return func(o *CheckOptions) {
+		o.Tags = append(o.Tags, tags...)
+	}
+}
+
+func WithTimeout(timeout time.Duration) CheckOption {
+	return func(o *CheckOptions) {
+		o.Timeout = timeout
+	}
+}
+
+func WithCacheDuration(cacheDuration time.Duration) CheckOption {
+	return func(o *CheckOptions) {
+		o.CacheDuration = cacheDuration
+	}
+}
+
+// set the check as non critical
+func WithNonCritical() CheckOption {
+	return func(o *CheckOptions) {
+		o.Critical = false
+	}
+}
+
+func (o *CheckOptions) hasTag(tag string) bool {
+	for _, eachTag := range o.Tags {
+		if eachTag == tag {
+			return true
+		}
+	}
+	return false
+}
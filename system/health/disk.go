@@ -0,0 +1,27 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	CheckerNameDisk = "disk"
+
+	// default minimum free space of NewDiskSpaceChecker
+	DefaultMinFreeBytes uint64 = 100 * 1024 * 1024
+)
+
+// check the free space of the file system that contains path
+func NewDiskSpaceChecker(path string, minFreeBytes uint64) HealthChecker {
+	return NewCheckerFunc(CheckerNameDisk, func(ctx context.Context) error {
+		free, total, err := diskUsage(path)
+		if err != nil {
+			return fmt.Errorf("get disk usage of %s fail,err:%s", path, err.Error())
+		}
+		if free < minFreeBytes {
+			return fmt.Errorf("free space of %s is too low,free:%d bytes,total:%d bytes,min free:%d bytes", path, free, total, minFreeBytes)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package health
+
+import "syscall"
+
+// free space for unprivileged users and total space of the file system
+func diskUsage(path string) (free uint64, total uint64, err error) {
+	stat := syscall.Statfs_t{}
+	if err = syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), stat.Blocks * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,28 @@
+//go:build windows
+// +build windows
+
+package health
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// free space for current user and total space of the volume
+func diskUsage(path string) (free uint64, total uint64, err error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var totalFree uint64
+	r, _, callErr := procGetDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(pathPtr)),
+		uintptr(unsafe.Pointer(&free)),
+		uintptr(unsafe.Pointer(&total)),
+		uintptr(unsafe.Pointer(&totalFree)))
+	if r == 0 {
+		return 0, 0, callErr
+	}
+	return free, total, nil
+}
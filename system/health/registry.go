@@ -0,0 +1,236 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// result of one check
+type ComponentResult struct {
+	Name      string    `json:"name"`
+	Status    Status    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Tags      []string  `json:"tags,omitempty"`
+	LatencyMs int64     `json:"latencyMs"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+	// the result is reused from a previous run
+	Cached bool `json:"cached"`
+}
+
+// aggregated result of the checks,
+// the status is down if a critical check fails,degraded if only non critical checks fail
+type Report struct {
+	Status     Status             `json:"status"`
+	Components []*ComponentResult `json:"components"`
+}
+
+type registration struct {
+	checker HealthChecker
+	options CheckOptions
+
+	// concurrent runs of the check wait for the same result
+	lock      sync.Mutex
+	result    *ComponentResult
+	expiredAt time.Time
+}
+
+// the registered checkers of the application
+type Registry struct {
+	now func() time.Time
+
+	timeout       time.Duration
+	cacheDuration time.Duration
+
+	lock             sync.RWMutex
+	registrationList []*registration
+}
+
+type RegistryOption func(*Registry)
+
+// timeout of the checks that do not set their own
+func WithDefaultTimeout(timeout time.Duration) RegistryOption {
+	return func(r *Registry) {
+		r.timeout = timeout
+	}
+}
+
+// cache duration of the checks that do not set their own,a negative value disables the cache
+func WithDefaultCacheDuration(cacheDuration time.Duration) RegistryOption {
+	return func(r *Registry) {
+		r.cacheDuration = cacheDuration
+	}
+}
+
+func NewRegistry(opts ...RegistryOption) *Registry {
+	r := &Registry{
+		now:           time.Now,
+		timeout:       DefaultTimeout,
+		cacheDuration: DefaultCacheDuration,
+	}
+	for _, eachOpt := range opts {
+		eachOpt(r)
+	}
+	return r
+}
+
+// register a checker,the checker with the same name is replaced.
+// the check is critical unless WithNonCritical is set
+func (r *Registry) Register(checker HealthChecker, opts ...CheckOption) {
+	if checker == nil {
+		return
+	}
+	options := CheckOptions{
+		Critical: true,
+	}
+	for _, eachOpt := range opts {
+		eachOpt(&options)
+	}
+	current := &registration{
+		checker: checker,
+		options: options,
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for index, eachRegistration := range r.registrationList {
+		if eachRegistration.checker.Name() == checker.Name() {
+			r.registrationList[index] = current
+			return
+		}
+	}
+	r.registrationList = append(r.registrationList, current)
+}
+
+func (r *Registry) Unregister(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for index, eachRegistration := range r.registrationList {
+		if eachRegistration.checker.Name() == name {
+			r.registrationList = append(r.registrationList[:index], r.registrationList[index+1:]...)
+			return
+		}
+	}
+}
+
+// run the checks with the tag concurrently,all of the checks are run if tag is empty.
+// the status is up if no check is registered
+func (r *Registry) Check(ctx context.Context, tag string) *Report {
+	r.lock.RLock()
+	registrationList := make([]*registration, 0, len(r.registrationList))
+	for _, eachRegistration := range r.registrationList {
+		if len(tag) > 0 && !eachRegistration.options.hasTag(tag) {
+			continue
+		}
+		registrationList = append(registrationList, eachRegistration)
+	}
+	r.lock.RUnlock()
+
+	report := &Report{
+		Status:     StatusUp,
+		Components: make([]*ComponentResult, len(registrationList)),
+	}
+	wg := sync.WaitGroup{}
+	for index, eachRegistration := range registrationList {
+		wg.Add(1)
+		go func(index int, current *registration) {
+			defer wg.Done()
+			report.Components[index] = r.run(ctx, current)
+		}(index, eachRegistration)
+	}
+	wg.Wait()
+
+	for _, eachResult := range report.Components {
+		if eachResult.Status == StatusUp {
+			continue
+		}
+		if eachResult.Critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, current *registration) *ComponentResult {
+	current.lock.Lock()
+	defer current.lock.Unlock()
+
+	now := r.now()
+	if current.result != nil && now.Before(current.expiredAt) {
+		result := *current.result
+		result.Cached = true
+		return &result
+	}
+
+	timeout := current.options.Timeout
+	if timeout <= 0 {
+		timeout = r.timeout
+	}
+	startTime := time.Now()
+	err := runWithTimeout(ctx, current.checker, timeout)
+	result := &ComponentResult{
+		Name:      current.checker.Name(),
+		Status:    StatusUp,
+		Critical:  current.options.Critical,
+		Tags:      current.options.Tags,
+		LatencyMs: time.Since(startTime).Milliseconds(),
+		CheckedAt: now,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	cacheDuration := current.options.CacheDuration
+	if cacheDuration == 0 {
+		cacheDuration = r.cacheDuration
+	}
+	if cacheDuration > 0 {
+		current.result = result
+		current.expiredAt = now.Add(cacheDuration)
+	}
+	return result
+}
+
+// the check is not canceled by the caller,its result is shared by other callers
+func runWithTimeout(ctx context.Context, checker HealthChecker, timeout time.Duration) error {
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	errChan := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				errChan <- fmt.Errorf("check panic:%v", p)
+			}
+		}()
+		errChan <- checker.Check(checkCtx)
+	}()
+	select {
+	case err := <-errChan:
+		return err
+	case <-checkCtx.Done():
+		return fmt.Errorf("check timeout after %s", timeout)
+	}
+}
+
+// #region default registry
+
+// registry of the application,the starters of the modules register their checkers into it
+var DefaultRegistry = NewRegistry()
+
+// register a checker into DefaultRegistry
+func Register(checker HealthChecker, opts ...CheckOption) {
+	DefaultRegistry.Register(checker, opts...)
+}
+
+// run the checks of DefaultRegistry
+func Check(ctx context.Context, tag string) *Report {
+	return DefaultRegistry.Check(ctx, tag)
+}
+
+// #endregion
@@ -0,0 +1,59 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryStatus(t *testing.T) {
+	r := NewRegistry(WithDefaultCacheDuration(-1))
+	assert.Equal(t, StatusUp, r.Check(context.Background(), "").Status)
+
+	r.Register(NewCheckerFunc("mongodb", func(ctx context.Context) error { return nil }), WithTags(TagReadiness))
+	r.Register(NewCheckerFunc("disk", func(ctx context.Context) error { return errors.New("low") }),
+		WithTags(TagLiveness), WithNonCritical())
+	report := r.Check(context.Background(), "")
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, 2, len(report.Components))
+	assert.Equal(t, "low", report.Components[1].Error)
+	assert.Equal(t, StatusUp, r.Check(context.Background(), TagReadiness).Status)
+
+	r.Register(NewCheckerFunc("mongodb", func(ctx context.Context) error { return errors.New("down") }), WithTags(TagReadiness))
+	report = r.Check(context.Background(), TagReadiness)
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, 1, len(report.Components))
+
+	r.Unregister("mongodb")
+	assert.Equal(t, 1, len(r.Check(context.Background(), "").Components))
+}
+
+func TestRegistryTimeoutAndCache(t *testing.T) {
+	r := NewRegistry(WithDefaultTimeout(10*time.Millisecond), WithDefaultCacheDuration(time.Minute))
+	var count int32
+	r.Register(NewCheckerFunc("slow", func(ctx context.Context) error {
+		atomic.AddInt32(&count, 1)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	r.Register(NewCheckerFunc("panic", func(ctx context.Context) error { panic("boom") }), WithNonCritical())
+
+	report := r.Check(context.Background(), "")
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Contains(t, report.Components[0].Error, "timeout")
+	assert.Contains(t, report.Components[1].Error, "boom")
+	assert.False(t, report.Components[0].Cached)
+
+	report = r.Check(context.Background(), "")
+	assert.True(t, report.Components[0].Cached)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestDiskSpaceChecker(t *testing.T) {
+	assert.Nil(t, NewDiskSpaceChecker(".", 1).Check(context.Background()))
+	assert.NotNil(t, NewDiskSpaceChecker(".", ^uint64(0)).Check(context.Background()))
+}
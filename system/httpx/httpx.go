@@ -2,6 +2,7 @@ package httpx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,8 +10,16 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/shanluzhineng/fwpkg/system/correlation"
+	"github.com/shanluzhineng/fwpkg/system/tracing"
 )
 
+// 请求id等通过Transport传递给被调用的服务,每个请求创建一个client span
+var _client = &http.Client{
+	Transport: correlation.NewTransport(tracing.NewTransport(nil)),
+}
+
 type GenReqWithBodyTypeFunc func(pathUrl string, method string) (*http.Request, error)
 
 func GenReqWithFormBody(body map[string]interface{}, headers map[string]string) GenReqWithBodyTypeFunc {
@@ -77,6 +86,11 @@ func GenReqForGet(headers map[string]string) GenReqWithBodyTypeFunc {
  * 封装请求及body解析。json参数用 map 传递，response body 也使用map读取。非200会报错，err中包含异常body。
  */
 func DoHttpRequest(url string, method string, token string, bodyTypeFunc GenReqWithBodyTypeFunc) (map[string]interface{}, error) {
+	return DoHttpRequestWithContext(context.Background(), url, method, token, bodyTypeFunc)
+}
+
+// 与DoHttpRequest相同,ctx中的请求id会通过X-Request-Id及traceparent header传递
+func DoHttpRequestWithContext(ctx context.Context, url string, method string, token string, bodyTypeFunc GenReqWithBodyTypeFunc) (map[string]interface{}, error) {
 	var (
 		err error
 		req *http.Request
@@ -107,11 +121,11 @@ func DoHttpRequest(url string, method string, token string, bodyTypeFunc GenReqW
 	if len(token) > 0 {
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	}
-	client := http.Client{}
-	response, err := client.Do(req)
+	response, err := _client.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
+	defer response.Body.Close()
 	respBody, err := io.ReadAll(response.Body)
 	if err != nil {
 		return nil, fmt.Errorf("readBody failed: %s", err.Error())
@@ -136,6 +150,10 @@ func PostJsonWithHeader(url string, body map[string]interface{}, headerMap map[s
 	return DoHttpRequest(url, http.MethodPost, "", GenReqWithJsonBody(body, headerMap))
 }
 
+func PostJsonWithContext(ctx context.Context, url string, body map[string]interface{}, headerMap map[string]string) (map[string]interface{}, error) {
+	return DoHttpRequestWithContext(ctx, url, http.MethodPost, "", GenReqWithJsonBody(body, headerMap))
+}
+
 func PostForm(url string, body map[string]interface{}) (map[string]interface{}, error) {
 	return DoHttpRequest(url, http.MethodPost, "", GenReqWithFormBody(body, nil))
 }
@@ -152,6 +170,10 @@ func GetUrlWithHeader(url string, headerMap map[string]string) (map[string]inter
 	return DoHttpRequest(url, http.MethodGet, "", GenReqForGet(headerMap))
 }
 
+func GetUrlWithContext(ctx context.Context, url string, headerMap map[string]string) (map[string]interface{}, error) {
+	return DoHttpRequestWithContext(ctx, url, http.MethodGet, "", GenReqForGet(headerMap))
+}
+
 // GetIP returns request real ip.
 func GetIP(r *http.Request) (string, error) {
 	ip := r.Header.Get("X-Real-IP")
@@ -0,0 +1,292 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	LocaleEn = "en"
+	LocaleZh = "zh"
+
+	// locale of the messages if the locale of request is not supported
+	DefaultLocale = LocaleEn
+)
+
+//go:embed locales
+var _defaultLocaleFS embed.FS
+
+// messages of locales,a message is found in the locale,the base language of locale,then the default locale
+type Bundle struct {
+	defaultLocale language.Tag
+
+	lock       sync.RWMutex
+	messageMap map[language.Tag]map[string]string
+	tagList    []language.Tag
+	matcher    language.Matcher
+}
+
+func NewBundle(defaultLocale string) *Bundle {
+	b := &Bundle{
+		defaultLocale: language.Make(defaultLocale),
+		messageMap:    make(map[language.Tag]map[string]string),
+	}
+	b.messageMap[b.defaultLocale] = make(map[string]string)
+	b.rebuildMatcher()
+	return b
+}
+
+// locale of the messages if the locale of request is not supported
+func (b *Bundle) SetDefaultLocale(locale string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.defaultLocale = language.Make(locale)
+	if _, ok := b.messageMap[b.defaultLocale]; !ok {
+		b.messageMap[b.defaultLocale] = make(map[string]string)
+	}
+	b.rebuildMatcher()
+}
+
+// add messages of locale,the messages with the same keys are replaced
+func (b *Bundle) AddMessages(locale string, messages map[string]string) {
+	tag := language.Make(locale)
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	messageMap, ok := b.messageMap[tag]
+	if !ok {
+		messageMap = make(map[string]string)
+		b.messageMap[tag] = messageMap
+	}
+	for eachKey, eachMessage := range messages {
+		messageMap[eachKey] = eachMessage
+	}
+	b.rebuildMatcher()
+}
+
+func (b *Bundle) rebuildMatcher() {
+	// the default locale is the first one,it is used if no locale matches
+	b.tagList = []language.Tag{b.defaultLocale}
+	for eachTag := range b.messageMap {
+		if eachTag != b.defaultLocale {
+			b.tagList = append(b.tagList, eachTag)
+		}
+	}
+	b.matcher = language.NewMatcher(b.tagList)
+}
+
+// load a yaml or json file,the file name is the locale,e.g. zh-CN.yaml.
+// nested keys are joined with dot,e.g. {error: {notFound: ...}} is error.notFound
+func (b *Bundle) LoadBytes(locale string, format string, data []byte) error {
+	raw := make(map[string]interface{})
+	var err error
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "yaml", "yml":
+		var yamlRaw map[interface{}]interface{}
+		if err = yaml.Unmarshal(data, &yamlRaw); err == nil {
+			for eachKey, eachValue := range yamlRaw {
+				raw[fmt.Sprint(eachKey)] = eachValue
+			}
+		}
+	case "json":
+		err = json.Unmarshal(data, &raw)
+	default:
+		return fmt.Errorf("unsupported format of message file,locale:%s,format:%s", locale, format)
+	}
+	if err != nil {
+		return fmt.Errorf("parse message file fail,locale:%s,err:%w", locale, err)
+	}
+	messages := make(map[string]string)
+	flattenMessages("", raw, messages)
+	b.AddMessages(locale, messages)
+	return nil
+}
+
+// load the yaml and json files of dir in fsys,e.g. an embed.FS
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entryList, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, eachEntry := range entryList {
+		if eachEntry.IsDir() {
+			continue
+		}
+		ext := path.Ext(eachEntry.Name())
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, eachEntry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := b.LoadBytes(strings.TrimSuffix(eachEntry.Name(), ext), ext, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load the yaml and json files of the directory
+func (b *Bundle) LoadDir(dir string) error {
+	return b.LoadFS(os.DirFS(filepath.Clean(dir)), ".")
+}
+
+func flattenMessages(prefix string, raw map[string]interface{}, messages map[string]string) {
+	for eachKey, eachValue := range raw {
+		key := eachKey
+		if len(prefix) > 0 {
+			key = prefix + "." + eachKey
+		}
+		switch v := eachValue.(type) {
+		case map[string]interface{}:
+			flattenMessages(key, v, messages)
+		case map[interface{}]interface{}:
+			child := make(map[string]interface{}, len(v))
+			for eachChildKey, eachChildValue := range v {
+				child[fmt.Sprint(eachChildKey)] = eachChildValue
+			}
+			flattenMessages(key, child, messages)
+		default:
+			messages[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// the supported locale that best matches the locales,e.g. the value of Accept-Language header.
+// the default locale is returned if no locale matches
+func (b *Bundle) Match(localeList ...string) string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	tagList := make([]language.Tag, 0)
+	for _, eachLocale := range localeList {
+		if len(eachLocale) <= 0 {
+			continue
+		}
+		acceptList, _, err := language.ParseAcceptLanguage(eachLocale)
+		if err != nil {
+			continue
+		}
+		tagList = append(tagList, acceptList...)
+	}
+	if len(tagList) <= 0 {
+		return b.defaultLocale.String()
+	}
+	_, index, confidence := b.matcher.Match(tagList...)
+	if confidence == language.No {
+		return b.defaultLocale.String()
+	}
+	return b.tagList[index].String()
+}
+
+// message of key in locale,args are formatted by Format
+func (b *Bundle) Localize(locale string, key string, args ...interface{}) (string, bool) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for _, eachTag := range b.fallbackTagList(locale) {
+		if message, ok := b.messageMap[eachTag][key]; ok {
+			return Format(message, args...), true
+		}
+	}
+	return "", false
+}
+
+// message of key in locale,key is returned if the message is not found
+func (b *Bundle) T(locale string, key string, args ...interface{}) string {
+	if message, ok := b.Localize(locale, key, args...); ok {
+		return message
+	}
+	return key
+}
+
+func (b *Bundle) fallbackTagList(locale string) []language.Tag {
+	tagList := make([]language.Tag, 0, 3)
+	if tag, err := language.Parse(locale); err == nil {
+		tagList = append(tagList, tag)
+		if base, confidence := tag.Base(); confidence != language.No {
+			if baseTag := language.Make(base.String()); baseTag != tag {
+				tagList = append(tagList, baseTag)
+			}
+		}
+	}
+	return append(tagList, b.defaultLocale)
+}
+
+func (b *Bundle) GetDefaultLocale() string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.defaultLocale.String()
+}
+
+// replace {name} of message with the value of a map argument,
+// or format the message with fmt.Sprintf if the arguments are not a map
+func Format(message string, args ...interface{}) string {
+	if len(args) <= 0 {
+		return message
+	}
+	if len(args) == 1 {
+		if namedArgs, ok := args[0].(map[string]interface{}); ok {
+			replaceList := make([]string, 0, len(namedArgs)*2)
+			for eachName, eachValue := range namedArgs {
+				replaceList = append(replaceList, "{"+eachName+"}", fmt.Sprint(eachValue))
+			}
+			return strings.NewReplacer(replaceList...).Replace(message)
+		}
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// #region default bundle
+
+var (
+	_defaultBundle     *Bundle
+	_defaultBundleOnce sync.Once
+)
+
+// bundle with the embedded messages of fwpkg,messages of application can be added to it
+func Default() *Bundle {
+	_defaultBundleOnce.Do(func() {
+		_defaultBundle = NewBundle(DefaultLocale)
+		if err := _defaultBundle.LoadFS(_defaultLocaleFS, "locales"); err != nil {
+			panic(err)
+		}
+	})
+	return _defaultBundle
+}
+
+// message of key in locale of the default bundle
+func T(locale string, key string, args ...interface{}) string {
+	return Default().T(locale, key, args...)
+}
+
+// #endregion
+
+// #region context
+
+type localeContextKey struct{}
+
+func ContextWithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// locale of ctx,DefaultLocale if it is not set
+func LocaleFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if locale, ok := ctx.Value(localeContextKey{}).(string); ok && len(locale) > 0 {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// #endregion
@@ -0,0 +1,32 @@
+package i18n
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundle(t *testing.T) {
+	b := Default()
+	assert.Equal(t, "Not found", b.T(LocaleEn, "error.notFound"))
+	assert.Equal(t, "数据不存在", b.T("zh-CN", "error.notFound"))
+	assert.Equal(t, "Not found", b.T("fr", "error.notFound"))
+	assert.Equal(t, "unknown.key", b.T(LocaleZh, "unknown.key"))
+	assert.Equal(t, "name为必填字段", b.T(LocaleZh, "validation.required", map[string]interface{}{"field": "name"}))
+
+	assert.Equal(t, LocaleZh, b.Match("zh-CN,zh;q=0.9,en;q=0.8"))
+	assert.Equal(t, LocaleEn, b.Match("en-US"))
+	assert.Equal(t, LocaleEn, b.Match("fr-FR"))
+	assert.Equal(t, LocaleEn, b.Match(""))
+
+	app := NewBundle(LocaleZh)
+	err := app.LoadFS(fstest.MapFS{
+		"locales/zh.json": {Data: []byte(`{"order":{"closed":"订单%s已关闭"}}`)},
+		"locales/en.yml":  {Data: []byte("order:\n  closed: Order %s is closed\n")},
+	}, "locales")
+	assert.Nil(t, err)
+	assert.Equal(t, "订单o1已关闭", app.T("zh-Hans-CN", "order.closed", "o1"))
+	assert.Equal(t, "Order o1 is closed", app.T(LocaleEn, "order.closed", "o1"))
+	assert.Equal(t, LocaleZh, app.Match("de"))
+}
@@ -0,0 +1,27 @@
+package log
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type loggerContextKey struct{}
+
+// 将请求级别的logger保存到context中
+func ContextWithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// 获取context中的logger,如果没有则返回默认的Logger
+func FromContext(ctx context.Context) *zap.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerContextKey{}).(*zap.Logger); ok && logger != nil {
+			return logger
+		}
+	}
+	if Logger == nil {
+		BuildDefaultLogger()
+	}
+	return Logger
+}
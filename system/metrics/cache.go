@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shanluzhineng/fwpkg/system/cache"
+)
+
+// source of cache.Metrics,*cache.Cache implements it
+type CacheMetricsSource interface {
+	Metrics() cache.Metrics
+}
+
+var (
+	cacheInsertionsDesc = prometheus.NewDesc("cache_insertions_total", "Total number of items inserted into the cache.", []string{"cache"}, nil)
+	cacheHitsDesc       = prometheus.NewDesc("cache_hits_total", "Total number of cache hits.", []string{"cache"}, nil)
+	cacheMissesDesc     = prometheus.NewDesc("cache_misses_total", "Total number of cache misses.", []string{"cache"}, nil)
+	cacheEvictionsDesc  = prometheus.NewDesc("cache_evictions_total", "Total number of items evicted from the cache.", []string{"cache"}, nil)
+
+	defaultCacheCollector = &cacheCollector{
+		sourceMap: make(map[string]CacheMetricsSource),
+	}
+)
+
+// read the metrics of the caches when they are scraped
+type cacheCollector struct {
+	lock      sync.RWMutex
+	sourceMap map[string]CacheMetricsSource
+}
+
+// export the metrics of the cache with label cache=name,the cache with the same name is replaced
+func RegisterCache(name string, source CacheMetricsSource) {
+	if source == nil {
+		return
+	}
+	defaultCacheCollector.lock.Lock()
+	defer defaultCacheCollector.lock.Unlock()
+	defaultCacheCollector.sourceMap[name] = source
+}
+
+func UnregisterCache(name string) {
+	defaultCacheCollector.lock.Lock()
+	defer defaultCacheCollector.lock.Unlock()
+	delete(defaultCacheCollector.sourceMap, name)
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheInsertionsDesc
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheEvictionsDesc
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for eachName, eachSource := range c.sourceMap {
+		cacheMetrics := eachSource.Metrics()
+		ch <- prometheus.MustNewConstMetric(cacheInsertionsDesc, prometheus.CounterValue, float64(cacheMetrics.Insertions), eachName)
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(cacheMetrics.Hits), eachName)
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(cacheMetrics.Misses), eachName)
+		ch <- prometheus.MustNewConstMetric(cacheEvictionsDesc, prometheus.CounterValue, float64(cacheMetrics.Evictions), eachName)
+	}
+}
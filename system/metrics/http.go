@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// route label of the requests that do not match a route
+const UnmatchedRoute = "unmatched"
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of http requests by method,route and status.",
+	}, []string{"method", "route", "status"})
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of http requests by method,route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	httpRequestSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_size_bytes",
+		Help:    "Size of http request bodies by method and route.",
+		Buckets: prometheus.ExponentialBuckets(100, 10, 6),
+	}, []string{"method", "route"})
+	httpResponseSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_response_size_bytes",
+		Help:    "Size of http response bodies by method and route.",
+		Buckets: prometheus.ExponentialBuckets(100, 10, 6),
+	}, []string{"method", "route"})
+)
+
+func init() {
+	MustRegister(httpRequestsTotal, httpRequestDuration, httpRequestSize, httpResponseSize)
+}
+
+// record a finished http request,route is the template of the route such as /api/users/{id}
+// to keep the cardinality low. negative sizes are not recorded
+func ObserveHttpRequest(method string, route string, status int, duration time.Duration, requestSize int64, responseSize int64) {
+	if len(route) <= 0 {
+		route = UnmatchedRoute
+	}
+	statusValue := strconv.Itoa(status)
+	httpRequestsTotal.WithLabelValues(method, route, statusValue).Inc()
+	httpRequestDuration.WithLabelValues(method, route, statusValue).Observe(duration.Seconds())
+	if requestSize >= 0 {
+		httpRequestSize.WithLabelValues(method, route).Observe(float64(requestSize))
+	}
+	if responseSize >= 0 {
+		httpResponseSize.WithLabelValues(method, route).Observe(float64(responseSize))
+	}
+}
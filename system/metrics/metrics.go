@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// path of the metrics endpoint
+	Path = "/metrics"
+)
+
+// registry of the application,the collectors of web,data stores,cache and queues are registered into it.
+// go runtime and process metrics are included
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	MustRegister(collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		defaultCacheCollector)
+}
+
+// register the collectors into Registry,the collectors that are already registered are skipped
+func Register(collectorList ...prometheus.Collector) error {
+	for _, eachCollector := range collectorList {
+		if eachCollector == nil {
+			continue
+		}
+		err := Registry.Register(eachCollector)
+		var alreadyRegisteredError prometheus.AlreadyRegisteredError
+		if err != nil && !errors.As(err, &alreadyRegisteredError) {
+			return err
+		}
+	}
+	return nil
+}
+
+// register the collectors into Registry,panic if one of them is invalid
+func MustRegister(collectorList ...prometheus.Collector) {
+	if err := Register(collectorList...); err != nil {
+		panic(err)
+	}
+}
+
+// handler of the metrics endpoint
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		Registry: Registry,
+	})
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shanluzhineng/fwpkg/system/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCacheSource struct {
+	metrics cache.Metrics
+}
+
+func (s *fakeCacheSource) Metrics() cache.Metrics {
+	return s.metrics
+}
+
+func TestObserveHttpRequest(t *testing.T) {
+	ObserveHttpRequest("GET", "/api/users/{id}", 200, 10*time.Millisecond, -1, 128)
+	ObserveHttpRequest("GET", "", 404, time.Millisecond, -1, -1)
+	assert.Equal(t, float64(1), testutil.ToFloat64(httpRequestsTotal.WithLabelValues("GET", "/api/users/{id}", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(httpRequestsTotal.WithLabelValues("GET", UnmatchedRoute, "404")))
+}
+
+func TestCacheCollector(t *testing.T) {
+	RegisterCache("test", &fakeCacheSource{metrics: cache.Metrics{Hits: 3, Misses: 1}})
+	defer UnregisterCache("test")
+
+	expected := `
+# HELP cache_hits_total Total number of cache hits.
+# TYPE cache_hits_total counter
+cache_hits_total{cache="test"} 3
+`
+	assert.Nil(t, testutil.CollectAndCompare(defaultCacheCollector, strings.NewReader(expected), "cache_hits_total"))
+}
+
+func TestHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", Path, nil))
+	assert.Equal(t, 200, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "go_goroutines")
+}
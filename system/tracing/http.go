@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type transport struct {
+	base http.RoundTripper
+}
+
+// create a client span for each outbound request and set its context to the traceparent header,
+// http.DefaultTransport is used if base is nil
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{
+		base: base,
+	}
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, span := Tracer().Start(r.Context(), "HTTP "+r.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.full", r.URL.Redacted()),
+			attribute.String("server.address", r.URL.Host),
+		))
+	defer span.End()
+
+	// the request must not be modified by RoundTripper
+	r = r.Clone(ctx)
+	Inject(ctx, r.Header)
+	response, err := t.base.RoundTrip(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return response, err
+	}
+	SetHttpStatus(span, response.StatusCode)
+	return response, nil
+}
+
+// set the status code of the response to span,5xx is an error
+func SetHttpStatus(span trace.Span, statusCode int) {
+	span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	if statusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, fmt.Sprintf("http status %d", statusCode))
+	}
+}
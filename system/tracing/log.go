@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// field names of the ids of span in logs
+	LogFieldTraceId = "traceId"
+	LogFieldSpanId  = "spanId"
+)
+
+// zap fields of the span of ctx,nil if ctx has no valid span
+func LogFields(ctx context.Context) []zap.Field {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String(LogFieldTraceId, spanContext.TraceID().String()),
+		zap.String(LogFieldSpanId, spanContext.SpanID().String()),
+	}
+}
+
+// context with the logger that has the trace id and span id of ctx,
+// log.FromContext of the returned context writes them in each line
+func ContextWithTraceLogger(ctx context.Context) context.Context {
+	fieldList := LogFields(ctx)
+	if len(fieldList) <= 0 {
+		return ctx
+	}
+	return log.ContextWithLogger(ctx, log.FromContext(ctx).With(fieldList...))
+}
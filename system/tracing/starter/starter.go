@@ -0,0 +1,64 @@
+package starter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shanluzhineng/configurationx"
+	"github.com/shanluzhineng/fwpkg/app"
+	"github.com/shanluzhineng/fwpkg/app/host"
+	"github.com/shanluzhineng/fwpkg/app/web"
+	"github.com/shanluzhineng/fwpkg/system/log"
+	"github.com/shanluzhineng/fwpkg/system/tracing"
+	"go.uber.org/zap"
+)
+
+const (
+	shutdownTimeout = 5 * time.Second
+)
+
+var (
+	_shutdown func(ctx context.Context) error
+)
+
+func init() {
+	web.ConfigureService(serviceConfigurator)
+	app.RegisterOneShutdown(tracingShutdown)
+}
+
+// 根据tracing配置初始化TracerProvider,没有配置或enabled为false时不记录span
+func serviceConfigurator(wa web.WebApplication) {
+	options := &tracing.TracingOptions{}
+	if ok := configurationx.GetInstance().UnmarshalPropertiesTo(tracing.ConfigurationKey, options); !ok {
+		log.Logger.Warn(fmt.Sprintf("tracing配置解析失败,key:%s", tracing.ConfigurationKey))
+		return
+	}
+	if !options.Enabled {
+		return
+	}
+	if len(options.ServiceName) <= 0 {
+		options.ServiceName = host.GetHostEnvironment().GetEnvString(host.ENV_AppName)
+	}
+	shutdown, err := tracing.Setup(options)
+	if err != nil {
+		log.Logger.Error("初始化tracing时出现异常", zap.Error(err))
+		return
+	}
+	_shutdown = shutdown
+	log.Logger.Info(fmt.Sprintf(">>> tracing init DONE,exporter:%s,endpoint:%s", options.Exporter, options.Endpoint))
+}
+
+// 关闭时将未导出的span全部导出
+func tracingShutdown() app.IShutdownAction {
+	return app.NewShutdownAction(func() {
+		if _shutdown == nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := _shutdown(ctx); err != nil {
+			log.Logger.Warn("关闭tracing时出现异常", zap.Error(err))
+		}
+	})
+}
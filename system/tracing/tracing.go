@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// configuration key of tracing,see TracingOptions
+	ConfigurationKey = "tracing"
+
+	// name of the tracer of the instrumentations
+	InstrumentationName = "github.com/shanluzhineng/fwpkg"
+
+	// otlp over http,e.g. the collector of jaeger or tempo
+	ExporterOtlpHttp = "otlphttp"
+	// pretty printed spans of stdout,it is used in development
+	ExporterStdout = "stdout"
+)
+
+// options of tracing,e.g.
+//
+//	tracing:
+//	  enabled: true
+//	  serviceName: order-service
+//	  exporter: otlphttp
+//	  endpoint: otel-collector:4318
+//	  insecure: true
+//	  sampleRatio: 0.1
+type TracingOptions struct {
+	Enabled bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	// app.name of host environment is used if it is not set
+	ServiceName string `mapstructure:"serviceName" json:"serviceName" yaml:"serviceName"`
+	// otlphttp or stdout,otlphttp is used if it is not set
+	Exporter string `mapstructure:"exporter" json:"exporter" yaml:"exporter"`
+	// host:port of otlp http receiver,OTEL_EXPORTER_OTLP_ENDPOINT is used if it is not set
+	Endpoint string `mapstructure:"endpoint" json:"endpoint" yaml:"endpoint"`
+	// /v1/traces is used if it is not set
+	UrlPath string `mapstructure:"urlPath" json:"urlPath" yaml:"urlPath"`
+	// use http instead of https
+	Insecure bool              `mapstructure:"insecure" json:"insecure" yaml:"insecure"`
+	Headers  map[string]string `mapstructure:"headers" json:"headers" yaml:"headers"`
+	// ratio of the new traces that are sampled,0 to 1,all traces are sampled if it is not set.
+	// the sampling decision of the parent span is always respected
+	SampleRatio *float64 `mapstructure:"sampleRatio" json:"sampleRatio" yaml:"sampleRatio"`
+}
+
+// tracer of the instrumentations,the spans are not recorded until Setup is called
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// create the tracer provider of the options and set it as the global provider of otel,
+// w3c trace context and baggage are used to propagate the context.
+// the returned function flushes and stops the exporter
+func Setup(options *TracingOptions) (func(ctx context.Context) error, error) {
+	if options == nil || !options.Enabled {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+	exporter, err := newExporter(options)
+	if err != nil {
+		return nil, err
+	}
+	sampleRatio := 1.0
+	if options.SampleRatio != nil {
+		sampleRatio = *options.SampleRatio
+	}
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(newResource(options.ServiceName)),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tracerProvider.Shutdown, nil
+}
+
+func newExporter(options *TracingOptions) (sdktrace.SpanExporter, error) {
+	switch options.Exporter {
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case "", ExporterOtlpHttp:
+		opts := make([]otlptracehttp.Option, 0)
+		if len(options.Endpoint) > 0 {
+			opts = append(opts, otlptracehttp.WithEndpoint(options.Endpoint))
+		}
+		if len(options.UrlPath) > 0 {
+			opts = append(opts, otlptracehttp.WithURLPath(options.UrlPath))
+		}
+		if options.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(options.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(options.Headers))
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+	}
+	return nil, fmt.Errorf("unknown tracing exporter:%s", options.Exporter)
+}
+
+func newResource(serviceName string) *resource.Resource {
+	if len(serviceName) <= 0 {
+		return resource.Default()
+	}
+	// service.name of semantic conventions
+	serviceResource := resource.NewSchemaless(attribute.String("service.name", serviceName))
+	merged, err := resource.Merge(resource.Default(), serviceResource)
+	if err != nil {
+		return serviceResource
+	}
+	return merged
+}
@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupRecorder() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func TestTransport(t *testing.T) {
+	recorder := setupRecorder()
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	response, err := (&http.Client{Transport: NewTransport(nil)}).Do(request)
+	assert.Nil(t, err)
+	response.Body.Close()
+	parent.End()
+
+	spanList := recorder.Ended()
+	assert.Equal(t, 2, len(spanList))
+	clientSpan := spanList[0]
+	assert.Equal(t, "HTTP GET", clientSpan.Name())
+	assert.Equal(t, trace.SpanKindClient, clientSpan.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), clientSpan.Parent().SpanID())
+	assert.Contains(t, traceparent, clientSpan.SpanContext().SpanID().String())
+	assert.Equal(t, "Error", clientSpan.Status().Code.String())
+}
+
+func TestLogFields(t *testing.T) {
+	setupRecorder()
+	assert.Nil(t, LogFields(context.Background()))
+
+	ctx, span := Tracer().Start(context.Background(), "test")
+	defer span.End()
+	fieldList := LogFields(ctx)
+	assert.Equal(t, 2, len(fieldList))
+	assert.Equal(t, span.SpanContext().TraceID().String(), fieldList[0].String)
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(&TracingOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+
+	_, err = Setup(&TracingOptions{Enabled: true, Exporter: "unknown"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,78 @@
+package rsa
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+
+	"github.com/shanluzhineng/fwpkg/utils/crypto"
+)
+
+// ParsePublicKey parse a pem encoded PKIX or PKCS1 public key,a certificate is also accepted
+func ParsePublicKey(pemBytes []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, crypto.ErrInvalidPublicKey
+	}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if pub, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			return pub, nil
+		}
+		return nil, crypto.ErrInvalidPublicKey
+	}
+	pubInterface, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := pubInterface.(*rsa.PublicKey)
+	if !ok {
+		return nil, crypto.ErrInvalidPublicKey
+	}
+	return pub, nil
+}
+
+// ParsePrivateKey parse a pem encoded PKCS1 or PKCS8 private key
+func ParsePrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, crypto.ErrInvalidPrivateKey
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	pkInterface, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pk, ok := pkInterface.(*rsa.PrivateKey)
+	if !ok {
+		return nil, crypto.ErrInvalidPrivateKey
+	}
+	return pk, nil
+}
+
+// LoadPublicKey load a pem public key file
+func LoadPublicKey(fileName string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(data)
+}
+
+// LoadPrivateKey load a pem private key file
+func LoadPrivateKey(fileName string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(data)
+}
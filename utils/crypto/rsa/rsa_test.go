@@ -75,3 +75,13 @@ func TestExeptions(t *testing.T) {
 		assert.Equal(t, crypto.ErrInvalidInput, err)
 	})
 }
+
+func TestParseKey(t *testing.T) {
+	pub, err := ParsePublicKey(defaultPublicKey)
+	assert.Nil(t, err)
+	pk, err := ParsePrivateKey(defaultPrivateKey)
+	assert.Nil(t, err)
+	assert.True(t, pub.Equal(&pk.PublicKey))
+	_, err = ParsePublicKey([]byte("x"))
+	assert.Equal(t, crypto.ErrInvalidPublicKey, err)
+}
@@ -0,0 +1,114 @@
+package parquetx
+
+import (
+	"encoding/binary"
+)
+
+// thrift compact protocol types
+const (
+	thriftTypeBoolTrue  = 1
+	thriftTypeBoolFalse = 2
+	thriftTypeI32       = 5
+	thriftTypeI64       = 6
+	thriftTypeBinary    = 8
+	thriftTypeList      = 9
+	thriftTypeStruct    = 12
+)
+
+// minimal thrift compact protocol encoder,only the types used by parquet metadata are supported
+type thriftWriter struct {
+	buf         []byte
+	lastFieldId []int16
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{lastFieldId: []int16{0}}
+}
+
+func (w *thriftWriter) bytes() []byte {
+	return w.buf
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *thriftWriter) zigzag(v int64) {
+	w.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *thriftWriter) fieldHeader(id int16, fieldType byte) {
+	last := w.lastFieldId[len(w.lastFieldId)-1]
+	delta := id - last
+	if delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|fieldType)
+	} else {
+		w.buf = append(w.buf, fieldType)
+		w.zigzag(int64(id))
+	}
+	w.lastFieldId[len(w.lastFieldId)-1] = id
+}
+
+func (w *thriftWriter) structBegin() {
+	w.lastFieldId = append(w.lastFieldId, 0)
+}
+
+func (w *thriftWriter) structEnd() {
+	w.buf = append(w.buf, 0)
+	w.lastFieldId = w.lastFieldId[:len(w.lastFieldId)-1]
+}
+
+func (w *thriftWriter) i32Field(id int16, v int32) {
+	w.fieldHeader(id, thriftTypeI32)
+	w.zigzag(int64(v))
+}
+
+func (w *thriftWriter) i64Field(id int16, v int64) {
+	w.fieldHeader(id, thriftTypeI64)
+	w.zigzag(v)
+}
+
+func (w *thriftWriter) boolField(id int16, v bool) {
+	if v {
+		w.fieldHeader(id, thriftTypeBoolTrue)
+		return
+	}
+	w.fieldHeader(id, thriftTypeBoolFalse)
+}
+
+func (w *thriftWriter) stringValue(v string) {
+	w.varint(uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+func (w *thriftWriter) stringField(id int16, v string) {
+	w.fieldHeader(id, thriftTypeBinary)
+	w.stringValue(v)
+}
+
+func (w *thriftWriter) structField(id int16, writeFields func()) {
+	w.fieldHeader(id, thriftTypeStruct)
+	w.structBegin()
+	writeFields()
+	w.structEnd()
+}
+
+func (w *thriftWriter) listField(id int16, elemType byte, size int, writeElem func(index int)) {
+	w.fieldHeader(id, thriftTypeList)
+	if size < 15 {
+		w.buf = append(w.buf, byte(size)<<4|elemType)
+	} else {
+		w.buf = append(w.buf, 0xF0|elemType)
+		w.varint(uint64(size))
+	}
+	for index := 0; index < size; index++ {
+		writeElem(index)
+	}
+}
+
+// a struct element of list
+func (w *thriftWriter) structElem(writeFields func()) {
+	w.structBegin()
+	writeFields()
+	w.structEnd()
+}
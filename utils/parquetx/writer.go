@@ -0,0 +1,375 @@
+// package parquetx is a minimal parquet writer for flat schema.
+// all columns are optional,values are PLAIN encoded and pages are not compressed
+package parquetx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// physical type of column
+type Type int32
+
+const (
+	TypeBoolean   Type = 0
+	TypeInt64     Type = 2
+	TypeDouble    Type = 5
+	TypeByteArray Type = 6
+)
+
+// logical annotation of column
+type ConvertedType int
+
+const (
+	ConvertedTypeNone ConvertedType = iota
+	ConvertedTypeUTF8
+	ConvertedTypeTimestampMillis
+)
+
+// value of ConvertedType in parquet metadata
+var _convertedTypeMapping = map[ConvertedType]int32{
+	ConvertedTypeUTF8:            0,
+	ConvertedTypeTimestampMillis: 9,
+}
+
+const (
+	magic = "PAR1"
+
+	repetitionOptional = 1
+	encodingPlain      = 0
+	encodingRle        = 3
+	pageTypeData       = 0
+	codecUncompressed  = 0
+
+	DefaultRowGroupSize = 100000
+)
+
+type Column struct {
+	Name          string
+	Type          Type
+	ConvertedType ConvertedType
+}
+
+type Writer struct {
+	w       io.Writer
+	offset  int64
+	columns []Column
+
+	rowGroupSize int
+	bufferList   []*columnBuffer
+	rowCount     int
+	numRows      int64
+	rowGroupList []*rowGroupMeta
+	closed       bool
+}
+
+type WriterOption func(*Writer)
+
+// rows of a row group,rows are buffered in memory until the row group is full
+func WriterWithRowGroupSize(v int) WriterOption {
+	return func(w *Writer) {
+		if v > 0 {
+			w.rowGroupSize = v
+		}
+	}
+}
+
+type columnBuffer struct {
+	defLevels []byte
+	values    bytes.Buffer
+	// boolean values are bit packed
+	bitCount int
+}
+
+type columnChunkMeta struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+type rowGroupMeta struct {
+	columns []columnChunkMeta
+	size    int64
+	numRows int64
+}
+
+func NewWriter(w io.Writer, columns []Column, opts ...WriterOption) (*Writer, error) {
+	if len(columns) <= 0 {
+		return nil, errors.New("columns must not be empty")
+	}
+	writer := &Writer{
+		w:            w,
+		columns:      columns,
+		rowGroupSize: DefaultRowGroupSize,
+	}
+	for _, eachOpt := range opts {
+		eachOpt(writer)
+	}
+	writer.resetBuffers()
+	if err := writer.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// values are in the same order as columns,nil is null.
+// boolean column accepts bool,int64 column accepts int,int64 and time.Time for timestamp,
+// double column accepts float64,byte array column accepts string and []byte
+func (w *Writer) Write(values []interface{}) error {
+	if w.closed {
+		return errors.New("writer is closed")
+	}
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("value count %d is not matched with column count %d", len(values), len(w.columns))
+	}
+	// check the whole row first,so a row is never partially written
+	for index, eachValue := range values {
+		if err := checkValue(w.columns[index], eachValue); err != nil {
+			return err
+		}
+	}
+	for index, eachValue := range values {
+		w.bufferList[index].append(w.columns[index], eachValue)
+	}
+	w.rowCount++
+	if w.rowCount >= w.rowGroupSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// write the buffered rows as a row group
+func (w *Writer) Flush() error {
+	if w.rowCount <= 0 {
+		return nil
+	}
+	rowGroup := &rowGroupMeta{numRows: int64(w.rowCount)}
+	for index, eachBuffer := range w.bufferList {
+		chunk, err := w.writeColumnChunk(w.columns[index], eachBuffer)
+		if err != nil {
+			return err
+		}
+		rowGroup.columns = append(rowGroup.columns, chunk)
+		rowGroup.size += chunk.size
+	}
+	w.rowGroupList = append(w.rowGroupList, rowGroup)
+	w.numRows += int64(w.rowCount)
+	w.resetBuffers()
+	return nil
+}
+
+// write the footer,the underlying writer is not closed
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	w.closed = true
+	footer := w.fileMetadata()
+	if err := w.write(footer); err != nil {
+		return err
+	}
+	length := make([]byte, 4)
+	binary.LittleEndian.PutUint32(length, uint32(len(footer)))
+	if err := w.write(length); err != nil {
+		return err
+	}
+	return w.write([]byte(magic))
+}
+
+func (w *Writer) write(data []byte) error {
+	n, err := w.w.Write(data)
+	w.offset += int64(n)
+	return err
+}
+
+func (w *Writer) resetBuffers() {
+	w.bufferList = make([]*columnBuffer, len(w.columns))
+	for index := range w.bufferList {
+		w.bufferList[index] = &columnBuffer{}
+	}
+	w.rowCount = 0
+}
+
+// a column chunk has only one data page
+func (w *Writer) writeColumnChunk(column Column, buffer *columnBuffer) (columnChunkMeta, error) {
+	levels := encodeDefinitionLevels(buffer.defLevels)
+	page := make([]byte, 4, 4+len(levels)+buffer.values.Len())
+	binary.LittleEndian.PutUint32(page, uint32(len(levels)))
+	page = append(page, levels...)
+	page = append(page, buffer.values.Bytes()...)
+
+	header := newThriftWriter()
+	header.structBegin()
+	header.i32Field(1, pageTypeData)
+	header.i32Field(2, int32(len(page)))
+	header.i32Field(3, int32(len(page)))
+	header.structField(5, func() {
+		header.i32Field(1, int32(len(buffer.defLevels)))
+		header.i32Field(2, encodingPlain)
+		header.i32Field(3, encodingRle)
+		header.i32Field(4, encodingRle)
+	})
+	header.structEnd()
+
+	chunk := columnChunkMeta{
+		offset:    w.offset,
+		size:      int64(len(header.bytes()) + len(page)),
+		numValues: int64(len(buffer.defLevels)),
+	}
+	if err := w.write(header.bytes()); err != nil {
+		return chunk, err
+	}
+	if err := w.write(page); err != nil {
+		return chunk, err
+	}
+	return chunk, nil
+}
+
+func (w *Writer) fileMetadata() []byte {
+	t := newThriftWriter()
+	t.structBegin()
+	t.i32Field(1, 1)
+	t.listField(2, thriftTypeStruct, len(w.columns)+1, func(index int) {
+		t.structElem(func() {
+			if index == 0 {
+				t.stringField(4, "schema")
+				t.i32Field(5, int32(len(w.columns)))
+				return
+			}
+			column := w.columns[index-1]
+			t.i32Field(1, int32(column.Type))
+			t.i32Field(3, repetitionOptional)
+			t.stringField(4, column.Name)
+			if v, ok := _convertedTypeMapping[column.ConvertedType]; ok {
+				t.i32Field(6, v)
+			}
+		})
+	})
+	t.i64Field(3, w.numRows)
+	t.listField(4, thriftTypeStruct, len(w.rowGroupList), func(index int) {
+		rowGroup := w.rowGroupList[index]
+		t.structElem(func() {
+			t.listField(1, thriftTypeStruct, len(rowGroup.columns), func(columnIndex int) {
+				chunk := rowGroup.columns[columnIndex]
+				column := w.columns[columnIndex]
+				t.structElem(func() {
+					t.i64Field(2, chunk.offset)
+					t.structField(3, func() {
+						t.i32Field(1, int32(column.Type))
+						t.listField(2, thriftTypeI32, 2, func(encodingIndex int) {
+							t.zigzag(int64([]int32{encodingPlain, encodingRle}[encodingIndex]))
+						})
+						t.listField(3, thriftTypeBinary, 1, func(int) {
+							t.stringValue(column.Name)
+						})
+						t.i32Field(4, codecUncompressed)
+						t.i64Field(5, chunk.numValues)
+						t.i64Field(6, chunk.size)
+						t.i64Field(7, chunk.size)
+						t.i64Field(9, chunk.offset)
+					})
+				})
+			})
+			t.i64Field(2, rowGroup.size)
+			t.i64Field(3, rowGroup.numRows)
+		})
+	})
+	t.stringField(6, "fwpkg parquetx")
+	t.structEnd()
+	return t.bytes()
+}
+
+// #region column buffer
+
+func checkValue(column Column, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	valid := false
+	switch column.Type {
+	case TypeBoolean:
+		_, valid = v.(bool)
+	case TypeInt64:
+		switch v.(type) {
+		case int64, int, time.Time:
+			valid = true
+		}
+	case TypeDouble:
+		_, valid = v.(float64)
+	case TypeByteArray:
+		switch v.(type) {
+		case string, []byte:
+			valid = true
+		}
+	default:
+		return fmt.Errorf("unsupported column type %d,column:%s", column.Type, column.Name)
+	}
+	if !valid {
+		return fmt.Errorf("invalid value type %T,column:%s", v, column.Name)
+	}
+	return nil
+}
+
+// v must be checked by checkValue
+func (b *columnBuffer) append(column Column, v interface{}) {
+	if v == nil {
+		b.defLevels = append(b.defLevels, 0)
+		return
+	}
+	switch x := v.(type) {
+	case bool:
+		b.appendBool(x)
+	case int64:
+		_ = binary.Write(&b.values, binary.LittleEndian, x)
+	case int:
+		_ = binary.Write(&b.values, binary.LittleEndian, int64(x))
+	case time.Time:
+		_ = binary.Write(&b.values, binary.LittleEndian, x.UnixMilli())
+	case float64:
+		_ = binary.Write(&b.values, binary.LittleEndian, math.Float64bits(x))
+	case string:
+		_ = binary.Write(&b.values, binary.LittleEndian, uint32(len(x)))
+		b.values.WriteString(x)
+	case []byte:
+		_ = binary.Write(&b.values, binary.LittleEndian, uint32(len(x)))
+		b.values.Write(x)
+	}
+	b.defLevels = append(b.defLevels, 1)
+}
+
+// bit packed,the first value is the least significant bit
+func (b *columnBuffer) appendBool(v bool) {
+	if b.bitCount%8 == 0 {
+		b.values.WriteByte(0)
+	}
+	if v {
+		data := b.values.Bytes()
+		data[len(data)-1] |= 1 << (b.bitCount % 8)
+	}
+	b.bitCount++
+}
+
+// #endregion
+
+// RLE runs of bit width 1
+func encodeDefinitionLevels(levels []byte) []byte {
+	result := make([]byte, 0)
+	for start := 0; start < len(levels); {
+		end := start + 1
+		for end < len(levels) && levels[end] == levels[start] {
+			end++
+		}
+		result = binary.AppendUvarint(result, uint64(end-start)<<1)
+		result = append(result, levels[start])
+		start = end
+	}
+	return result
+}
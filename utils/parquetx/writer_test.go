@@ -0,0 +1,154 @@
+package parquetx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, []Column{
+		{Name: "name", Type: TypeByteArray, ConvertedType: ConvertedTypeUTF8},
+		{Name: "age", Type: TypeInt64},
+		{Name: "enabled", Type: TypeBoolean},
+		{Name: "address.city", Type: TypeByteArray, ConvertedType: ConvertedTypeUTF8},
+		{Name: "creationTime", Type: TypeInt64, ConvertedType: ConvertedTypeTimestampMillis},
+	}, WriterWithRowGroupSize(2))
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write([]interface{}{"a", int64(1), true, "x", time.Now()}))
+	assert.Nil(t, w.Write([]interface{}{"b", nil, false, nil, nil}))
+	assert.Nil(t, w.Write([]interface{}{"c", 3, true, "y", nil}))
+	assert.NotNil(t, w.Write([]interface{}{"d", 3, "true", "y", nil}))
+	assert.Nil(t, w.Close())
+
+	data := buf.Bytes()
+	assert.Equal(t, magic, string(data[:4]))
+	assert.Equal(t, magic, string(data[len(data)-4:]))
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := data[len(data)-8-footerLength : len(data)-8]
+
+	r := &thriftReader{buf: footer}
+	metadata := r.readStruct()
+	assert.Equal(t, int64(1), metadata[1])
+	assert.Equal(t, int64(3), metadata[3])
+	schema := metadata[2].([]interface{})
+	assert.Equal(t, 6, len(schema))
+	assert.Equal(t, "address.city", string(schema[4].(map[int16]interface{})[4].([]byte)))
+	rowGroupList := metadata[4].([]interface{})
+	assert.Equal(t, 2, len(rowGroupList))
+	assert.Equal(t, int64(2), rowGroupList[0].(map[int16]interface{})[3])
+	assert.Equal(t, int64(1), rowGroupList[1].(map[int16]interface{})[3])
+	assert.Equal(t, len(footer), r.pos)
+
+	// the first page header is located at the data page offset of first column
+	chunk := rowGroupList[0].(map[int16]interface{})[1].([]interface{})[0].(map[int16]interface{})
+	offset := chunk[3].(map[int16]interface{})[9].(int64)
+	page := (&thriftReader{buf: data[offset:]}).readStruct()
+	assert.Equal(t, int64(2), page[5].(map[int16]interface{})[1])
+}
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// the output is compared with testdata/writer.parquet byte by byte.
+// run the test with -update to write the file again after the format is changed on purpose,
+// then check the file with another parquet implementation,e.g.
+//
+//	python -c "import pyarrow.parquet as pq;print(pq.read_table('testdata/writer.parquet'))"
+func TestWriterGolden(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, []Column{
+		{Name: "name", Type: TypeByteArray, ConvertedType: ConvertedTypeUTF8},
+		{Name: "age", Type: TypeInt64},
+		{Name: "enabled", Type: TypeBoolean},
+		{Name: "score", Type: TypeDouble},
+		{Name: "address.city", Type: TypeByteArray, ConvertedType: ConvertedTypeUTF8},
+		{Name: "creationTime", Type: TypeInt64, ConvertedType: ConvertedTypeTimestampMillis},
+	}, WriterWithRowGroupSize(2))
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write([]interface{}{"a", int64(1), true, 1.5, "x", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}))
+	assert.Nil(t, w.Write([]interface{}{"b", nil, false, nil, nil, nil}))
+	assert.Nil(t, w.Write([]interface{}{"c", 3, true, -2.25, "y", nil}))
+	assert.Nil(t, w.Close())
+
+	fileName := filepath.Join("testdata", "writer.parquet")
+	if *update {
+		assert.Nil(t, os.MkdirAll("testdata", 0755))
+		assert.Nil(t, os.WriteFile(fileName, buf.Bytes(), 0644))
+	}
+	golden, err := os.ReadFile(fileName)
+	assert.Nil(t, err)
+	assert.Equal(t, golden, buf.Bytes())
+}
+
+// decode thrift compact protocol for test
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) readValue(fieldType byte) interface{} {
+	switch fieldType {
+	case thriftTypeBoolTrue:
+		return true
+	case thriftTypeBoolFalse:
+		return false
+	case thriftTypeI32, thriftTypeI64:
+		return r.zigzag()
+	case thriftTypeBinary:
+		length := int(r.varint())
+		v := r.buf[r.pos : r.pos+length]
+		r.pos += length
+		return v
+	case thriftTypeList:
+		header := r.buf[r.pos]
+		r.pos++
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := make([]interface{}, 0, size)
+		for i := 0; i < size; i++ {
+			list = append(list, r.readValue(header&0x0F))
+		}
+		return list
+	case thriftTypeStruct:
+		return r.readStruct()
+	}
+	panic("unsupported type")
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	result := make(map[int16]interface{})
+	var lastId int16
+	for {
+		header := r.buf[r.pos]
+		r.pos++
+		if header == 0 {
+			return result
+		}
+		id := lastId + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		lastId = id
+		result[id] = r.readValue(header & 0x0F)
+	}
+}